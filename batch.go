@@ -0,0 +1,109 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// BatchResult holds the outcome of a batch operation for a single key.
+type BatchResult struct {
+	Key string // key the operation was performed on
+	URL string // resulting URL, empty for deletions or on error
+	Err error  // nil when the operation succeeded for this key
+}
+
+// BatchResults is the per-key report returned by batch operations.
+// Results are always in the same order as the keys passed in.
+type BatchResults []BatchResult
+
+// Failed returns only the results whose operation returned an error.
+func (r BatchResults) Failed() BatchResults {
+	var failed BatchResults
+	for _, res := range r {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// Succeeded returns only the results whose operation completed without error.
+func (r BatchResults) Succeeded() BatchResults {
+	var succeeded BatchResults
+	for _, res := range r {
+		if res.Err == nil {
+			succeeded = append(succeeded, res)
+		}
+	}
+
+	return succeeded
+}
+
+// Keys returns the keys of all results, in order.
+func (r BatchResults) Keys() []string {
+	keys := make([]string, len(r))
+	for i, res := range r {
+		keys[i] = res.Key
+	}
+
+	return keys
+}
+
+// URLs returns the URLs of all results, in order.
+// Failed entries produce an empty string so indexes still match the input keys.
+func (r BatchResults) URLs() []string {
+	urls := make([]string, len(r))
+	for i, res := range r {
+		urls[i] = res.URL
+	}
+
+	return urls
+}
+
+// Err joins the errors of every failed key into a single error, or returns nil if all succeeded.
+// Each joined error is prefixed with its key and still matches the original error with errors.Is.
+func (r BatchResults) Err() error {
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Key, res.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runBatch calls fn for every key with at most limit calls in flight.
+// A failing key never cancels the others; every outcome is recorded in the returned results.
+func runBatch(ctx context.Context, limit int, keys []string, fn func(ctx context.Context, key string) (string, error)) BatchResults {
+	results := make(BatchResults, len(keys))
+
+	var g errgroup.Group
+	g.SetLimit(limit)
+
+	for i, key := range keys {
+		g.Go(func() error {
+			url, err := fn(ctx, key)
+			results[i] = BatchResult{Key: key, URL: url, Err: err}
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return results
+}
+
+// failBatch reports the same error for every key, e.g. when the storage itself is unavailable.
+func failBatch(keys []string, err error) BatchResults {
+	results := make(BatchResults, len(keys))
+	for i, key := range keys {
+		results[i] = BatchResult{Key: key, Err: err}
+	}
+
+	return results
+}
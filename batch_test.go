@@ -0,0 +1,30 @@
+package gostorage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchResults(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	results := BatchResults{
+		{Key: "a", URL: "http://example.com/a"},
+		{Key: "b", Err: errFailed},
+		{Key: "c", URL: "http://example.com/c"},
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, results.Keys(), "expected keys in order")
+	assert.Equal(t, []string{"http://example.com/a", "", "http://example.com/c"}, results.URLs(), "expected URLs in order")
+	assert.Equal(t, []string{"b"}, results.Failed().Keys(), "expected only failed keys")
+	assert.Equal(t, []string{"a", "c"}, results.Succeeded().Keys(), "expected only succeeded keys")
+
+	err := results.Err()
+	assert.ErrorIs(t, err, errFailed, "expected joined error to wrap the key error")
+	assert.Contains(t, err.Error(), "b: failed", "expected joined error to name the key")
+
+	assert.NoError(t, results.Succeeded().Err(), "expected no error when every key succeeded")
+	assert.NoError(t, BatchResults{}.Err(), "expected no error for empty results")
+}
@@ -0,0 +1,40 @@
+package gostorage
+
+// Capabilities describes which optional features a storage driver supports.
+// Drivers report them by implementing CapabilityReporter; for other drivers
+// StorageManager.Capabilities infers what it can from the interfaces they implement.
+type Capabilities struct {
+	SignedURL   bool // GetSignedURL returns working time-limited URLs
+	PublicURL   bool // GetURL returns working direct URLs
+	List        bool // files can be enumerated (Lister)
+	BatchDelete bool // many files can be removed in one request (BatchDeleter)
+	Copy        bool // files can be copied server-side without downloading them
+	RangeRead   bool // byte ranges of a file can be read without fetching all of it
+	Versioning  bool // previous versions of overwritten files are kept
+	Metadata    bool // content type and custom metadata are stored with files
+	Multipart   bool // large files can be uploaded in independently sent parts (MultipartUploader)
+}
+
+// CapabilitiesOf returns the features of driver: its own report if it implements CapabilityReporter,
+// otherwise what can be inferred from the interfaces it implements. URL support cannot be detected,
+// so it is assumed; the manager still reports ErrNotSupported if such a driver returns an empty URL.
+// Usage: Wrapper drivers use it to derive their capabilities from the drivers they wrap.
+func CapabilitiesOf(driver StorageDriver) Capabilities {
+	if reporter, ok := driver.(CapabilityReporter); ok {
+		return reporter.Capabilities()
+	}
+
+	_, canList := driver.(Lister)
+	_, canBatchDelete := driver.(BatchDeleter)
+	_, canRangeRead := driver.(RangeReader)
+	_, canMultipart := driver.(MultipartUploader)
+
+	return Capabilities{
+		SignedURL:   true,
+		PublicURL:   true,
+		List:        canList,
+		BatchDelete: canBatchDelete,
+		RangeRead:   canRangeRead,
+		Multipart:   canMultipart,
+	}
+}
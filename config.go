@@ -0,0 +1,429 @@
+package gostorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvPrefix is the environment variable prefix used by LoadEnvConfig when none is given.
+const DefaultEnvPrefix = "STORAGE"
+
+// Config describes a StorageManager and all of its storages ("disks").
+// It can be written by hand or loaded from YAML, JSON or environment variables:
+//
+//	default: media
+//	disks:
+//	  media:
+//	    driver: s3
+//	    bucket: media
+//	    region: us-east-1
+type Config struct {
+	Default     string                `json:"default" yaml:"default"`         // alias of the default disk
+	Concurrency int                   `json:"concurrency" yaml:"concurrency"` // optional batch concurrency limit
+	Disks       map[string]DiskConfig `json:"disks" yaml:"disks"`             // disks by alias
+}
+
+// DiskConfig configures a single storage: the registered driver name plus driver specific options.
+// Options are flat key/value pairs, e.g. "bucket" or "use_ssl", always stored as strings.
+// The special "dsn" option configures the disk from a single URL (see ParseDSN); the driver is
+// then taken from the URL scheme and other options override the values parsed from it.
+type DiskConfig struct {
+	Driver  string
+	Options map[string]string
+}
+
+// ConfigError reports an invalid configuration value.
+// It matches ErrInvalidConfig with errors.Is.
+type ConfigError struct {
+	Alias  string // disk alias, empty for top-level fields
+	Field  string // offending field, e.g. "driver" or "bucket"
+	Reason string // why the value is invalid
+}
+
+func (e *ConfigError) Error() string {
+	if e.Alias == "" {
+		return fmt.Sprintf("storage: invalid configuration: %s %s", e.Field, e.Reason)
+	}
+
+	return fmt.Sprintf("storage: invalid configuration for disk %q: %s %s", e.Alias, e.Field, e.Reason)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// String returns the option value for key, or an empty string if it is not set.
+func (d DiskConfig) String(key string) string {
+	return d.Options[key]
+}
+
+// Require returns the option value for key, or a *ConfigError if it is missing or empty.
+func (d DiskConfig) Require(key string) (string, error) {
+	value := d.Options[key]
+	if value == "" {
+		return "", &ConfigError{Field: key, Reason: "is required"}
+	}
+
+	return value, nil
+}
+
+// Bool parses the option value for key as a boolean, returning def if it is not set.
+func (d DiskConfig) Bool(key string, def bool) (bool, error) {
+	value, ok := d.Options[key]
+	if !ok || value == "" {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ConfigError{Field: key, Reason: fmt.Sprintf("must be a boolean, got %q", value)}
+	}
+
+	return b, nil
+}
+
+// Int parses the option value for key as an integer, returning def if it is not set.
+func (d DiskConfig) Int(key string, def int) (int, error) {
+	value, ok := d.Options[key]
+	if !ok || value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ConfigError{Field: key, Reason: fmt.Sprintf("must be an integer, got %q", value)}
+	}
+
+	return n, nil
+}
+
+// Duration parses the option value for key as a time.Duration (e.g. "15m"), returning def if it is not set.
+func (d DiskConfig) Duration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := d.Options[key]
+	if !ok || value == "" {
+		return def, nil
+	}
+
+	dur, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, &ConfigError{Field: key, Reason: fmt.Sprintf("must be a duration, got %q", value)}
+	}
+
+	return dur, nil
+}
+
+// UnmarshalJSON decodes a flat disk object such as {"driver": "s3", "bucket": "media", "use_ssl": true}.
+func (d *DiskConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	return d.fromMap(raw)
+}
+
+// UnmarshalYAML decodes a flat disk mapping such as `driver: s3` followed by driver options.
+func (d *DiskConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]any
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	return d.fromMap(raw)
+}
+
+// fromMap fills the disk from decoded key/value pairs, converting scalar values to strings.
+func (d *DiskConfig) fromMap(raw map[string]any) error {
+	d.Options = make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		var s string
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			s = v
+		case bool, int, int64, float64, uint64:
+			s = fmt.Sprint(v)
+		default:
+			return &ConfigError{Field: key, Reason: "must be a scalar value"}
+		}
+
+		if key == "driver" {
+			d.Driver = s
+			continue
+		}
+
+		d.Options[key] = s
+	}
+
+	return nil
+}
+
+// Validate checks the configuration without opening any storage.
+// Every problem is reported as a *ConfigError; multiple problems are joined.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Default == "" {
+		errs = append(errs, &ConfigError{Field: "default", Reason: "is required"})
+	} else if _, ok := c.Disks[c.Default]; !ok {
+		errs = append(errs, &ConfigError{Field: "default", Reason: fmt.Sprintf("refers to unknown disk %q", c.Default)})
+	}
+
+	if c.Concurrency < 0 {
+		errs = append(errs, &ConfigError{Field: "concurrency", Reason: "must not be negative"})
+	}
+
+	for _, alias := range sortedKeys(c.Disks) {
+		disk, err := c.Disks[alias].expand()
+		if err != nil {
+			errs = append(errs, annotateDiskError(err, alias))
+			continue
+		}
+
+		switch {
+		case disk.Driver == "":
+			errs = append(errs, &ConfigError{Alias: alias, Field: "driver", Reason: "is required"})
+		default:
+			if _, ok := lookupDriver(disk.Driver); !ok {
+				errs = append(errs, &ConfigError{
+					Alias:  alias,
+					Field:  "driver",
+					Reason: fmt.Sprintf("refers to unregistered driver %q (registered: %s)", disk.Driver, strings.Join(Drivers(), ", ")),
+				})
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewFromConfig validates cfg, opens every disk with its registered driver and returns a StorageManager
+// using cfg.Default as the default storage. Driver errors are returned as *ConfigError naming the disk alias.
+// Drivers must be registered first, usually by importing their package:
+//
+//	import _ "github.com/shoraid/go-storage/drivers/s3"
+func NewFromConfig(cfg Config, opts ...ManagerOption) (StorageManager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	storage := make(map[string]StorageDriver, len(cfg.Disks))
+
+	for _, alias := range sortedKeys(cfg.Disks) {
+		disk, _ := cfg.Disks[alias].expand() // already validated
+		opener, _ := lookupDriver(disk.Driver)
+
+		driver, err := opener(ctx, disk)
+		if err != nil {
+			closeDrivers(storage)
+			return nil, annotateDiskError(err, alias)
+		}
+
+		storage[alias] = driver
+	}
+
+	if cfg.Concurrency > 0 {
+		opts = append([]ManagerOption{WithConcurrency(cfg.Concurrency)}, opts...)
+	}
+
+	mgr, err := NewStorageManager(cfg.Default, storage, opts...)
+	if err != nil {
+		closeDrivers(storage)
+		return nil, err
+	}
+
+	return mgr, nil
+}
+
+// closeDrivers releases the connections of drivers that hold any, such as SFTP or FTP pools,
+// when NewFromConfig gives up after some disks were already opened.
+func closeDrivers(storage map[string]StorageDriver) {
+	for _, alias := range sortedKeys(storage) {
+		closer, ok := storage[alias].(io.Closer)
+		if !ok {
+			continue
+		}
+
+		if err := closer.Close(); err != nil {
+			log.Warn().Err(err).Str("alias", alias).Msg("storage: failed to close disk after configuration error")
+		}
+	}
+}
+
+// annotateDiskError fills in the disk alias of a *ConfigError returned by a driver, or wraps any other error
+// so the message still names the disk that failed to open.
+func annotateDiskError(err error, alias string) error {
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) {
+		if cfgErr.Alias == "" {
+			cfgErr.Alias = alias
+		}
+		return err
+	}
+
+	return fmt.Errorf("storage: failed to open disk %q: %w", alias, err)
+}
+
+// ParseJSONConfig decodes a Config from JSON.
+func ParseJSONConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	return cfg, nil
+}
+
+// ParseYAMLConfig decodes a Config from YAML.
+func ParseYAMLConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigFile reads a Config from a .json, .yaml or .yml file.
+func LoadConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseJSONConfig(data)
+	case ".yaml", ".yml":
+		return ParseYAMLConfig(data)
+	default:
+		return Config{}, fmt.Errorf("%w: unsupported config file extension %q", ErrInvalidConfig, filepath.Ext(path))
+	}
+}
+
+// LoadEnvConfig builds a Config from environment variables using prefix (DefaultEnvPrefix if empty):
+//
+//	STORAGE_DEFAULT=media
+//	STORAGE_CONCURRENCY=8
+//	STORAGE_DISKS_MEDIA_DRIVER=s3
+//	STORAGE_DISKS_MEDIA_BUCKET=media
+//	STORAGE_DISKS_MEDIA_USE_SSL=true
+//	STORAGE_DISKS_BACKUPS_DSN=file:///var/backups
+//
+// A disk is declared by its _DRIVER or _DSN variable; aliases and option names are lower-cased.
+func LoadEnvConfig(prefix string) (Config, error) {
+	return parseEnvConfig(prefix, os.Environ())
+}
+
+// parseEnvConfig implements LoadEnvConfig for an explicit list of KEY=VALUE pairs.
+// Aliases may contain underscores, so disks are discovered from their _DRIVER variables first
+// and every other variable is matched against the longest known alias.
+func parseEnvConfig(prefix string, environ []string) (Config, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.ToUpper(prefix) + "_"
+	disksPrefix := prefix + "DISKS_"
+
+	cfg := Config{Disks: make(map[string]DiskConfig)}
+	vars := make(map[string]string)
+
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		vars[name] = value
+
+		rest, ok := strings.CutPrefix(name, disksPrefix)
+		if !ok {
+			continue
+		}
+
+		if alias, ok := strings.CutSuffix(rest, "_DRIVER"); ok && alias != "" {
+			disk := cfg.disk(strings.ToLower(alias))
+			disk.Driver = value
+			cfg.Disks[strings.ToLower(alias)] = disk
+		}
+
+		if alias, ok := strings.CutSuffix(rest, "_DSN"); ok && alias != "" {
+			cfg.disk(strings.ToLower(alias)).Options["dsn"] = value
+		}
+	}
+
+	// Longest aliases first so "USER_MEDIA" wins over "USER" for STORAGE_DISKS_USER_MEDIA_BUCKET.
+	aliases := sortedKeys(cfg.Disks)
+	sort.SliceStable(aliases, func(i, j int) bool { return len(aliases[i]) > len(aliases[j]) })
+
+	for name, value := range vars {
+		switch name {
+		case prefix + "DEFAULT":
+			cfg.Default = strings.ToLower(value)
+			continue
+		case prefix + "CONCURRENCY":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return Config{}, &ConfigError{Field: "concurrency", Reason: fmt.Sprintf("must be an integer, got %q", value)}
+			}
+			cfg.Concurrency = n
+			continue
+		}
+
+		rest, ok := strings.CutPrefix(name, disksPrefix)
+		if !ok {
+			continue
+		}
+
+		for _, alias := range aliases {
+			option, ok := strings.CutPrefix(rest, strings.ToUpper(alias)+"_")
+			if !ok {
+				continue
+			}
+
+			// The longest matching alias owns the variable, even when it is its _DRIVER variable.
+			if option != "" && option != "DRIVER" {
+				cfg.Disks[alias].Options[strings.ToLower(option)] = value
+			}
+			break
+		}
+	}
+
+	return cfg, nil
+}
+
+// disk returns the disk registered under alias, adding an empty one if needed.
+func (c *Config) disk(alias string) DiskConfig {
+	disk, ok := c.Disks[alias]
+	if !ok {
+		disk = DiskConfig{Options: make(map[string]string)}
+		c.Disks[alias] = disk
+	}
+
+	return disk
+}
+
+// sortedKeys returns the keys of m in alphabetical order, for deterministic iteration.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,310 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDriverName is registered once for all config tests.
+const testDriverName = "config-test"
+
+func init() {
+	RegisterDriver(testDriverName, func(ctx context.Context, cfg DiskConfig) (StorageDriver, error) {
+		if _, err := cfg.Require("bucket"); err != nil {
+			return nil, err
+		}
+		if cfg.String("fail") != "" {
+			return nil, errors.New("connection refused")
+		}
+		if cfg.String("closer") != "" {
+			return &closingDriver{closed: openedClosers[cfg.String("closer")]}, nil
+		}
+		return new(MockStorageDriver), nil
+	})
+}
+
+// openedClosers records, by the "closer" option, whether a closingDriver was closed.
+var openedClosers = map[string]*bool{}
+
+// closingDriver is a StorageDriver that records whether it was closed.
+type closingDriver struct {
+	MockStorageDriver
+	closed *bool
+}
+
+func (d *closingDriver) Close() error {
+	*d.closed = true
+	return nil
+}
+
+func TestParseYAMLConfig(t *testing.T) {
+	data := []byte(`
+default: media
+concurrency: 8
+disks:
+  media:
+    driver: s3
+    bucket: media
+    use_ssl: false
+    default_expiry: 15m
+  backups:
+    driver: local
+    root: /var/backups
+`)
+
+	cfg, err := ParseYAMLConfig(data)
+	assert.NoError(t, err, "expected no error parsing YAML")
+	assert.Equal(t, Config{
+		Default:     "media",
+		Concurrency: 8,
+		Disks: map[string]DiskConfig{
+			"media": {
+				Driver:  "s3",
+				Options: map[string]string{"bucket": "media", "use_ssl": "false", "default_expiry": "15m"},
+			},
+			"backups": {
+				Driver:  "local",
+				Options: map[string]string{"root": "/var/backups"},
+			},
+		},
+	}, cfg, "expected config to match")
+
+	_, err = ParseYAMLConfig([]byte("disks:\n  media:\n    driver: s3\n    nested: {a: b}\n"))
+	assert.ErrorIs(t, err, ErrInvalidConfig, "expected error for nested option")
+}
+
+func TestParseJSONConfig(t *testing.T) {
+	data := []byte(`{
+		"default": "media",
+		"disks": {
+			"media": {"driver": "s3", "bucket": "media", "use_ssl": true, "port": 9000}
+		}
+	}`)
+
+	cfg, err := ParseJSONConfig(data)
+	assert.NoError(t, err, "expected no error parsing JSON")
+	assert.Equal(t, "media", cfg.Default, "expected default alias")
+	assert.Equal(t, DiskConfig{
+		Driver:  "s3",
+		Options: map[string]string{"bucket": "media", "use_ssl": "true", "port": "9000"},
+	}, cfg.Disks["media"], "expected disk to match")
+
+	_, err = ParseJSONConfig([]byte(`{"disks": `))
+	assert.ErrorIs(t, err, ErrInvalidConfig, "expected error for malformed JSON")
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "storage.yml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("default: media\n"), 0o600))
+	txtPath := filepath.Join(dir, "storage.txt")
+	require.NoError(t, os.WriteFile(txtPath, []byte("default: media\n"), 0o600))
+
+	cfg, err := LoadConfigFile(yamlPath)
+	assert.NoError(t, err, "expected no error loading YAML file")
+	assert.Equal(t, "media", cfg.Default, "expected default alias")
+
+	_, err = LoadConfigFile(txtPath)
+	assert.ErrorIs(t, err, ErrInvalidConfig, "expected error for unsupported extension")
+}
+
+func TestParseEnvConfig(t *testing.T) {
+	environ := []string{
+		"STORAGE_DEFAULT=MEDIA",
+		"STORAGE_CONCURRENCY=4",
+		"STORAGE_DISKS_MEDIA_DRIVER=s3",
+		"STORAGE_DISKS_MEDIA_BUCKET=media",
+		"STORAGE_DISKS_MEDIA_USE_SSL=false",
+		"STORAGE_DISKS_USER_MEDIA_DRIVER=local",
+		"STORAGE_DISKS_USER_MEDIA_ROOT=/data/users",
+		"STORAGE_DISKS_USER_DRIVER=local",
+		"STORAGE_DISKS_UNKNOWN_ROOT=/ignored",
+		"OTHER_DISKS_MEDIA_DRIVER=ignored",
+	}
+
+	cfg, err := parseEnvConfig("", environ)
+	assert.NoError(t, err, "expected no error parsing environment")
+	assert.Equal(t, Config{
+		Default:     "media",
+		Concurrency: 4,
+		Disks: map[string]DiskConfig{
+			"media": {
+				Driver:  "s3",
+				Options: map[string]string{"bucket": "media", "use_ssl": "false"},
+			},
+			"user_media": {
+				Driver:  "local",
+				Options: map[string]string{"root": "/data/users"},
+			},
+			"user": {
+				Driver:  "local",
+				Options: map[string]string{},
+			},
+		},
+	}, cfg, "expected config to match")
+
+	_, err = parseEnvConfig("STORAGE", []string{"STORAGE_CONCURRENCY=many"})
+	assert.ErrorIs(t, err, ErrInvalidConfig, "expected error for invalid concurrency")
+}
+
+func TestParseEnvConfig_SQLBlobOptions(t *testing.T) {
+	environ := []string{
+		"STORAGE_DEFAULT=db",
+		"STORAGE_DISKS_DB_DRIVER=sqlblob",
+		"STORAGE_DISKS_DB_DIALECT=sqlite",
+		"STORAGE_DISKS_DB_CONNECTION=file:/tmp/files.db",
+		"STORAGE_DISKS_DB_CHUNK_SIZE=65536",
+	}
+
+	cfg, err := parseEnvConfig("STORAGE", environ)
+	assert.NoError(t, err, "expected no error parsing environment")
+	assert.Equal(t, Config{
+		Default: "db",
+		Disks: map[string]DiskConfig{
+			"db": {
+				Driver:  "sqlblob",
+				Options: map[string]string{"dialect": "sqlite", "connection": "file:/tmp/files.db", "chunk_size": "65536"},
+			},
+		},
+	}, cfg, "expected every option on the sqlblob disk and no other disk")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		expectFields []string
+	}{
+		{
+			name: "should accept valid config",
+			cfg: Config{
+				Default: "media",
+				Disks:   map[string]DiskConfig{"media": {Driver: testDriverName}},
+			},
+			expectFields: nil,
+		},
+		{
+			name:         "should require default alias",
+			cfg:          Config{},
+			expectFields: []string{`default`},
+		},
+		{
+			name: "should report unknown default and every invalid disk",
+			cfg: Config{
+				Default: "missing",
+				Disks: map[string]DiskConfig{
+					"a": {},
+					"b": {Driver: "ftp-unknown"},
+				},
+			},
+			expectFields: []string{`default`, `disk "a": driver`, `disk "b": driver`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+
+			if tt.expectFields == nil {
+				assert.NoError(t, err, "expected config to be valid")
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrInvalidConfig, "expected invalid config error")
+			for _, field := range tt.expectFields {
+				assert.Contains(t, err.Error(), field, "expected error to name the offending field")
+			}
+
+			var cfgErr *ConfigError
+			assert.True(t, errors.As(err, &cfgErr), "expected a ConfigError")
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		expectErr string
+	}{
+		{
+			name: "should open every disk",
+			cfg: Config{
+				Default:     "media",
+				Concurrency: 3,
+				Disks: map[string]DiskConfig{
+					"media":   {Driver: testDriverName, Options: map[string]string{"bucket": "media"}},
+					"avatars": {Driver: testDriverName, Options: map[string]string{"bucket": "avatars"}},
+				},
+			},
+		},
+		{
+			name: "should name alias and field when driver rejects an option",
+			cfg: Config{
+				Default: "media",
+				Disks: map[string]DiskConfig{
+					"media": {Driver: testDriverName},
+				},
+			},
+			expectErr: `storage: invalid configuration for disk "media": bucket is required`,
+		},
+		{
+			name: "should name alias when driver fails to open",
+			cfg: Config{
+				Default: "media",
+				Disks: map[string]DiskConfig{
+					"media": {Driver: testDriverName, Options: map[string]string{"bucket": "media", "fail": "1"}},
+				},
+			},
+			expectErr: `storage: failed to open disk "media": connection refused`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, err := NewFromConfig(tt.cfg)
+
+			if tt.expectErr != "" {
+				assert.EqualError(t, err, tt.expectErr, "expected error message to match")
+				assert.Nil(t, mgr, "expected manager to be nil")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, []string{"avatars", "media"}, mgr.Aliases(), "expected every disk to be registered")
+			assert.Equal(t, 3, mgr.(*storageManagerImpl).limit(), "expected concurrency from config")
+		})
+	}
+}
+
+func TestNewFromConfig_ClosesOpenedDisksOnError(t *testing.T) {
+	closed := false
+	openedClosers["avatars"] = &closed
+	t.Cleanup(func() { delete(openedClosers, "avatars") })
+
+	mgr, err := NewFromConfig(Config{
+		Default: "media",
+		Disks: map[string]DiskConfig{
+			"avatars": {Driver: testDriverName, Options: map[string]string{"bucket": "avatars", "closer": "avatars"}},
+			"media":   {Driver: testDriverName, Options: map[string]string{"bucket": "media", "fail": "1"}},
+		},
+	})
+
+	assert.Error(t, err, "expected error when a later disk fails to open")
+	assert.Nil(t, mgr, "expected manager to be nil")
+	assert.True(t, closed, "expected already opened disks to be closed")
+}
+
+func TestRegisterDriver(t *testing.T) {
+	assert.Contains(t, Drivers(), testDriverName, "expected test driver to be registered")
+	assert.Panics(t, func() { RegisterDriver(testDriverName, nil) }, "expected panic for nil opener")
+	assert.Panics(t, func() {
+		RegisterDriver(testDriverName, func(ctx context.Context, cfg DiskConfig) (StorageDriver, error) { return nil, nil })
+	}, "expected panic for duplicate driver")
+}
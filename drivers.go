@@ -0,0 +1,61 @@
+package gostorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// DriverOpener builds a storage driver from its configuration.
+// Drivers register an opener with RegisterDriver so they can be used from Config.
+// Validation problems should be reported as *ConfigError naming the offending field.
+type DriverOpener func(ctx context.Context, cfg DiskConfig) (StorageDriver, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]DriverOpener)
+)
+
+// RegisterDriver makes a driver available by name to NewFromConfig.
+// It is meant to be called from a driver package's init function, like database/sql drivers:
+//
+//	import _ "github.com/shoraid/go-storage/drivers/s3"
+//
+// It panics if opener is nil or a driver with the same name is already registered.
+func RegisterDriver(name string, opener DriverOpener) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if opener == nil {
+		panic("storage: RegisterDriver opener is nil")
+	}
+
+	if _, dup := drivers[name]; dup {
+		panic("storage: RegisterDriver called twice for driver " + name)
+	}
+
+	drivers[name] = opener
+}
+
+// Drivers returns the names of all registered drivers, sorted alphabetically.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// lookupDriver returns the opener registered under name.
+func lookupDriver(name string) (DriverOpener, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	opener, ok := drivers[name]
+	return opener, ok
+}
@@ -0,0 +1,345 @@
+package azblobdriver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+// Visibility is the container's public access level; see gostorage.Visibility.
+type Visibility = gostorage.Visibility
+
+const (
+	VisibilityPrivate = gostorage.VisibilityPrivate // Blobs are private, need a SAS URL to access
+	VisibilityPublic  = gostorage.VisibilityPublic  // Container allows anonymous blob reads via direct URL
+)
+
+// BlobStorageConfig defines the configuration needed to connect to an Azure Blob Storage container.
+// Point Endpoint at the Azurite emulator (http://127.0.0.1:10000/devstoreaccount1) for local development.
+type BlobStorageConfig struct {
+	AccountName   string        // storage account name
+	AccountKey    string        // base64 encoded shared key, used for requests and SAS signing
+	Container     string        // container where files will be stored
+	Endpoint      string        // optional blob service URL; defaults to https://<account>.blob.core.windows.net
+	Visibility    Visibility    // public if the container allows anonymous blob reads
+	DefaultExpiry time.Duration // default expiry duration for SAS URLs
+	BlockSize     int64         // size of the blocks Put stages; 0 uses the SDK default of 1 MiB
+	Concurrency   int           // number of blocks Put uploads in parallel; 0 uploads one at a time
+}
+
+// String returns the config with the account key redacted, so it is safe to print or log.
+func (c BlobStorageConfig) String() string {
+	key := ""
+	if c.AccountKey != "" {
+		key = "xxxxx"
+	}
+
+	return fmt.Sprintf(
+		"{AccountName:%s AccountKey:%s Container:%s Endpoint:%s Visibility:%s DefaultExpiry:%s BlockSize:%d Concurrency:%d}",
+		c.AccountName, key, c.Container, c.Endpoint, c.Visibility, c.DefaultExpiry, c.BlockSize, c.Concurrency,
+	)
+}
+
+// GoString is like String, so %#v does not leak the account key either.
+func (c BlobStorageConfig) GoString() string {
+	return "azblobdriver.BlobStorageConfig" + c.String()
+}
+
+// BlobStorage is the implementation of gostorage.StorageDriver for an Azure Blob Storage container.
+type BlobStorage struct {
+	client *container.Client
+	config BlobStorageConfig
+}
+
+// NewBlobStorage returns a BlobStorage authenticating with the account's shared key.
+// The container must already exist. Returns gostorage.ErrInvalidConfig if the account,
+// key or container is missing, or the key is not valid base64.
+func NewBlobStorage(cfg BlobStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.AccountName == "" || cfg.AccountKey == "" || cfg.Container == "" {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	cred, err := container.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+	if err != nil {
+		log.Error().Err(err).Str("account", cfg.AccountName).Msg("invalid Azure storage account key")
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AccountName)
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	client, err := container.NewClientWithSharedKeyCredential(cfg.Endpoint+"/"+cfg.Container, cred, nil)
+	if err != nil {
+		log.Error().Err(err).Str("endpoint", cfg.Endpoint).Msg("failed to create Azure container client")
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.DefaultExpiry == 0 {
+		cfg.DefaultExpiry = gostorage.DefaultSignedURLExpiry
+	}
+
+	return &BlobStorage{client: client, config: cfg}, nil
+}
+
+// Capabilities reports the features supported by this container.
+// Only public containers serve direct URLs; SAS URLs work on any container.
+func (s *BlobStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		SignedURL: true,
+		PublicURL: s.config.Visibility == VisibilityPublic,
+		List:      true,
+		RangeRead: true,
+	}
+}
+
+// DefaultExpiry returns the configured lifetime of SAS URLs.
+func (s *BlobStorage) DefaultExpiry() time.Duration {
+	return s.config.DefaultExpiry
+}
+
+// DefaultVisibility returns the container's configured visibility.
+func (s *BlobStorage) DefaultVisibility() gostorage.Visibility {
+	return s.config.Visibility
+}
+
+// Delete permanently removes a blob from the container. Deleting a missing blob is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *BlobStorage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.NewBlobClient(key).Delete(ctx, nil)
+	if err != nil && !isNotFound(err) {
+		log.Error().Err(err).Str("key", key).Msg("failed to delete blob from Azure")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// Exists checks if a blob exists in the container.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *BlobStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to check if blob exists in Azure")
+		return false, gostorage.ErrInternal
+	}
+
+	return true, nil
+}
+
+// GetRange downloads part of a blob with a ranged Get Blob request.
+// A negative length reads to the end of the blob; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt, e.g. for video seeking or ZIP archives.
+func (s *BlobStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	byteRange := blob.HTTPRange{Offset: offset}
+	if length > 0 {
+		byteRange.Count = length
+	}
+
+	resp, err := s.client.NewBlobClient(key).DownloadStream(ctx, &blob.DownloadStreamOptions{Range: byteRange})
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return nil, gostorage.ErrNotFound
+		case bloberror.HasCode(err, bloberror.InvalidRange):
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+
+		log.Error().Err(err).Str("key", key).Int64("offset", offset).Int64("length", length).Msg("failed to get blob range from Azure")
+		return nil, gostorage.ErrInternal
+	}
+
+	return resp.Body, nil
+}
+
+// GetSignedURL generates a read-only SAS URL for a blob, signed with the account key.
+// Signing works on public containers too, e.g. to hand out expiring links.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *BlobStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	signed, err := s.client.NewBlobClient(key).GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expiry), nil)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to generate SAS URL")
+		return "", gostorage.ErrInternal
+	}
+
+	return signed, nil
+}
+
+// GetURL returns the direct URL of a blob if the container allows anonymous reads.
+// Usage: Call this when you want to embed or link a public file directly.
+func (s *BlobStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.Visibility != VisibilityPublic {
+		return "", nil
+	}
+
+	return s.client.NewBlobClient(key).URL(), nil
+}
+
+// List calls fn for every blob under opts.Prefix, paging through List Blobs.
+// Non-recursive listings use "/" as delimiter and report blob prefixes as directories.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *BlobStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	if opts.Recursive {
+		pager := s.client.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: &opts.Prefix})
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list blobs in Azure")
+				return gostorage.ErrInternal
+			}
+
+			for _, obj := range mergeListPage(page.Segment.BlobItems, nil) {
+				if err := fn(obj); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	pager := s.client.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{Prefix: &opts.Prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list blobs in Azure")
+			return gostorage.ErrInternal
+		}
+
+		for _, obj := range mergeListPage(page.Segment.BlobItems, page.Segment.BlobPrefixes) {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeListPage converts a List Blobs page into ObjectInfo values in lexical key order,
+// interleaving blob prefixes (directories) with blobs the way the service sorts them.
+func mergeListPage(blobs []*container.BlobItem, prefixes []*container.BlobPrefix) []gostorage.ObjectInfo {
+	infos := make([]gostorage.ObjectInfo, 0, len(blobs)+len(prefixes))
+
+	i, j := 0, 0
+	for i < len(blobs) || j < len(prefixes) {
+		if j == len(prefixes) || (i < len(blobs) && deref(blobs[i].Name) < deref(prefixes[j].Name)) {
+			infos = append(infos, blobInfo(blobs[i]))
+			i++
+			continue
+		}
+
+		infos = append(infos, gostorage.ObjectInfo{
+			Key:   deref(prefixes[j].Name),
+			IsDir: true,
+		})
+		j++
+	}
+
+	return infos
+}
+
+// blobInfo converts a listed blob into an ObjectInfo.
+func blobInfo(item *container.BlobItem) gostorage.ObjectInfo {
+	info := gostorage.ObjectInfo{Key: deref(item.Name)}
+
+	if props := item.Properties; props != nil {
+		info.Size = deref(props.ContentLength)
+		info.LastModified = deref(props.LastModified)
+		if props.ETag != nil {
+			info.ETag = string(*props.ETag)
+		}
+	}
+
+	return info
+}
+
+// Put streams a file into a block blob and returns its URL, staging blocks of BlockSize
+// bytes so the file is never buffered whole. Files smaller than one block are uploaded
+// with a single request.
+// If the container is public, it returns a direct URL.
+// If the container is private, it returns a SAS URL.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *BlobStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	_, err := s.client.NewBlockBlobClient(key).UploadStream(ctx, file, &blockblob.UploadStreamOptions{
+		BlockSize:   s.config.BlockSize,
+		Concurrency: s.config.Concurrency,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to upload blob to Azure")
+		return "", gostorage.ErrInternal
+	}
+
+	// Public container: return direct URL
+	if s.config.Visibility == VisibilityPublic {
+		return s.GetURL(ctx, key)
+	}
+
+	// Private container: return SAS URL
+	return s.GetSignedURL(ctx, key, s.config.DefaultExpiry)
+}
+
+// Stat returns the size, modification time and ETag of a blob using Get Blob Properties.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *BlobStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	props, err := s.client.NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to stat blob in Azure")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	}
+
+	info := gostorage.ObjectInfo{
+		Key:          key,
+		Size:         deref(props.ContentLength),
+		LastModified: deref(props.LastModified),
+	}
+	if props.ETag != nil {
+		info.ETag = string(*props.ETag)
+	}
+
+	return info, nil
+}
+
+// isNotFound reports whether err is the service's response for a missing blob or container.
+func isNotFound(err error) bool {
+	return bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound)
+}
+
+// deref returns the value p points to, or the zero value if p is nil.
+func deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+
+	return *p
+}
@@ -0,0 +1,297 @@
+package azblobdriver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage creates a BlobStorage against the fake service, seeded with the given keys.
+func newTestStorage(t *testing.T, srv *fakeBlobService, visibility Visibility, keys ...string) *BlobStorage {
+	t.Helper()
+
+	driver, err := NewBlobStorage(BlobStorageConfig{
+		AccountName: testAccount,
+		AccountKey:  testAccountKey,
+		Container:   testContainer,
+		Endpoint:    srv.endpoint(),
+		Visibility:  visibility,
+	})
+	require.NoError(t, err, "expected no error creating blob storage")
+
+	storage := driver.(*BlobStorage)
+	for _, key := range keys {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("content of "+key))
+		require.NoError(t, err, "expected no error seeding blob")
+	}
+
+	return storage
+}
+
+// fetch downloads rawURL without credentials.
+func fetch(t *testing.T, rawURL string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(rawURL)
+	require.NoError(t, err, "expected no error fetching URL")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "expected no error reading response")
+
+	return resp.StatusCode, string(body)
+}
+
+func TestNewBlobStorage(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         BlobStorageConfig
+		expectedErr error
+	}{
+		{name: "should create storage", cfg: BlobStorageConfig{AccountName: "media", AccountKey: testAccountKey, Container: "uploads"}},
+		{name: "should reject missing account", cfg: BlobStorageConfig{AccountKey: testAccountKey, Container: "uploads"}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject missing key", cfg: BlobStorageConfig{AccountName: "media", Container: "uploads"}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject missing container", cfg: BlobStorageConfig{AccountName: "media", AccountKey: testAccountKey}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject key that is not base64", cfg: BlobStorageConfig{AccountName: "media", AccountKey: "not a key!", Container: "uploads"}, expectedErr: gostorage.ErrInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, err := NewBlobStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error")
+			assert.Implements(t, (*gostorage.Lister)(nil), driver, "expected driver to list files")
+			assert.Implements(t, (*gostorage.RangeReader)(nil), driver, "expected driver to read ranges")
+			assert.Equal(t, gostorage.DefaultSignedURLExpiry, driver.(*BlobStorage).DefaultExpiry(), "expected default expiry")
+
+			url, err := driver.GetURL(context.Background(), "avatar.png")
+			assert.NoError(t, err, "expected no error building URL")
+			assert.Empty(t, url, "expected no direct URL for private container")
+		})
+	}
+}
+
+func TestBlobStorageConfig_String(t *testing.T) {
+	cfg := BlobStorageConfig{AccountName: "media", AccountKey: testAccountKey, Container: "uploads"}
+
+	assert.NotContains(t, cfg.String(), testAccountKey, "expected String to redact the account key")
+	assert.NotContains(t, cfg.GoString(), testAccountKey, "expected GoString to redact the account key")
+}
+
+func TestBlobStorage_PutAndDelete(t *testing.T) {
+	srv := newFakeBlobService(t, false)
+	storage := newTestStorage(t, srv, VisibilityPrivate)
+	ctx := context.Background()
+
+	signed, err := storage.Put(ctx, "invoices/2024/march.csv", strings.NewReader("id,total\n1,42\n"))
+	require.NoError(t, err, "expected no error uploading")
+	assert.Contains(t, signed, "sig=", "expected SAS URL for private container")
+
+	data, ok := srv.blob("invoices/2024/march.csv")
+	require.True(t, ok, "expected blob in container")
+	assert.Equal(t, "id,total\n1,42\n", string(data), "expected uploaded content")
+	assert.Zero(t, srv.stagedBlocks(), "expected small file to be uploaded in one request")
+
+	_, err = storage.Put(ctx, "invoices/2024/march.csv", strings.NewReader("replaced"))
+	require.NoError(t, err, "expected no error overwriting blob")
+
+	data, _ = srv.blob("invoices/2024/march.csv")
+	assert.Equal(t, "replaced", string(data), "expected overwritten content")
+
+	require.NoError(t, storage.Delete(ctx, "invoices/2024/march.csv"), "expected no error deleting blob")
+	assert.NoError(t, storage.Delete(ctx, "invoices/2024/march.csv"), "expected no error deleting missing blob")
+
+	exists, err := storage.Exists(ctx, "invoices/2024/march.csv")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.False(t, exists, "expected blob to be deleted")
+
+	_, err = storage.Put(ctx, "../escape.txt", strings.NewReader("x"))
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid key to be rejected")
+}
+
+func TestBlobStorage_PutBlocks(t *testing.T) {
+	srv := newFakeBlobService(t, false)
+	storage := newTestStorage(t, srv, VisibilityPrivate)
+	storage.config.Concurrency = 2
+
+	video := bytes.Repeat([]byte("0123456789abcdef"), 160*1024) // 2.5 MiB
+
+	// MultiReader hides the size, like a request body would
+	_, err := storage.Put(context.Background(), "videos/intro.mp4", io.MultiReader(bytes.NewReader(video)))
+	require.NoError(t, err, "expected no error uploading in blocks")
+
+	data, ok := srv.blob("videos/intro.mp4")
+	require.True(t, ok, "expected blob in container")
+	assert.Equal(t, video, data, "expected blocks to be committed in order")
+	assert.Equal(t, 3, srv.stagedBlocks(), "expected one block per MiB")
+}
+
+func TestBlobStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every blob recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with prefixes collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a"},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, newFakeBlobService(t, false), VisibilityPrivate, keys...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				if obj.IsDir {
+					assert.True(t, strings.HasSuffix(obj.Key, "/"), "expected directory key to end with a slash")
+				} else {
+					assert.Equal(t, int64(len("content of "+obj.Key)), obj.Size, "expected size to match")
+				}
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing blobs")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestBlobStorage_GetRange(t *testing.T) {
+	storage := newTestStorage(t, newFakeBlobService(t, false), VisibilityPrivate, "file.txt")
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read whole blob", key: "file.txt", offset: 0, length: -1, expected: "content of file.txt"},
+		{name: "should read range", key: "file.txt", offset: 11, length: 4, expected: "file"},
+		{name: "should read to end from offset", key: "file.txt", offset: 16, length: -1, expected: "txt"},
+		{name: "should read nothing for zero length", key: "file.txt", offset: 3, length: 0, expected: ""},
+		{name: "should read nothing past the end", key: "file.txt", offset: 100, length: 4, expected: ""},
+		{name: "should not find missing blob", key: "missing.txt", length: -1, expectedErr: gostorage.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(context.Background(), tt.key, tt.offset, tt.length)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+
+			data, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(data), "expected range content to match")
+			assert.NoError(t, body.Close(), "expected no error closing body")
+		})
+	}
+}
+
+func TestBlobStorage_Stat(t *testing.T) {
+	storage := newTestStorage(t, newFakeBlobService(t, false), VisibilityPrivate, "dir/file.txt")
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, "dir/file.txt")
+	require.NoError(t, err, "expected no error stating blob")
+	assert.Equal(t, "dir/file.txt", info.Key, "expected key to match")
+	assert.Equal(t, int64(len("content of dir/file.txt")), info.Size, "expected size to match")
+	assert.NotEmpty(t, info.ETag, "expected ETag")
+	assert.False(t, info.LastModified.IsZero(), "expected modification time")
+
+	_, err = storage.Stat(ctx, "missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected error for missing blob")
+}
+
+func TestBlobStorage_SignedURL(t *testing.T) {
+	storage := newTestStorage(t, newFakeBlobService(t, false), VisibilityPrivate, "reports/q1.pdf")
+	ctx := context.Background()
+
+	signed, err := storage.GetSignedURL(ctx, "reports/q1.pdf", time.Hour)
+	require.NoError(t, err, "expected no error signing URL")
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err, "expected valid URL")
+	assert.Equal(t, "r", u.Query().Get("sp"), "expected read-only permission")
+	assert.Equal(t, "b", u.Query().Get("sr"), "expected blob scoped signature")
+
+	expiry, err := time.Parse(time.RFC3339, u.Query().Get("se"))
+	require.NoError(t, err, "expected expiry time")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Minute, "expected expiry to match")
+
+	status, body := fetch(t, signed)
+	assert.Equal(t, http.StatusOK, status, "expected SAS URL to grant access")
+	assert.Equal(t, "content of reports/q1.pdf", body, "expected blob content")
+
+	expired, err := storage.GetSignedURL(ctx, "reports/q1.pdf", -time.Minute)
+	require.NoError(t, err, "expected no error signing URL")
+	status, _ = fetch(t, expired)
+	assert.Equal(t, http.StatusForbidden, status, "expected expired SAS URL to be rejected")
+
+	status, _ = fetch(t, strings.Split(signed, "?")[0])
+	assert.Equal(t, http.StatusForbidden, status, "expected private blob to need a signature")
+}
+
+func TestBlobStorage_PublicURL(t *testing.T) {
+	srv := newFakeBlobService(t, true)
+	storage := newTestStorage(t, srv, VisibilityPublic)
+	ctx := context.Background()
+
+	direct, err := storage.Put(ctx, "avatars/jane.png", strings.NewReader("PNG"))
+	require.NoError(t, err, "expected no error uploading")
+	assert.Equal(t, srv.endpoint()+"/uploads/avatars%2Fjane.png", direct, "expected direct URL")
+
+	got, err := storage.GetURL(ctx, "avatars/jane.png")
+	assert.NoError(t, err, "expected no error building URL")
+	assert.Equal(t, direct, got, "expected GetURL to match URL returned by Put")
+	assert.True(t, storage.Capabilities().PublicURL, "expected public URLs to be supported")
+
+	status, body := fetch(t, direct)
+	assert.Equal(t, http.StatusOK, status, "expected anonymous read")
+	assert.Equal(t, "PNG", body, "expected blob content")
+}
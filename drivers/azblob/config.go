@@ -0,0 +1,115 @@
+package azblobdriver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DriverName is the name the Azure Blob Storage driver is registered under for gostorage.NewFromConfig.
+const DriverName = "azblob"
+
+func init() {
+	gostorage.RegisterDriver(DriverName, openFromConfig)
+	gostorage.RegisterURLScheme(DriverName, parseURL)
+}
+
+// parseURL converts an Azure Blob Storage DSN into disk options:
+//
+//	azblob://ACCOUNT:ACCOUNT_KEY@[host]/container?visibility=private&expiry=15m
+//	azblob://devstoreaccount1:ACCOUNT_KEY@/uploads?endpoint=http://127.0.0.1:10000/devstoreaccount1
+//
+// The host, if given, is the blob service host served over HTTPS (omit it for the account's default
+// endpoint); the endpoint parameter takes a full service URL instead, e.g. for Azurite. The account
+// key must be URL-escaped. Any other query parameter is passed through as an option, e.g. block_size.
+func parseURL(u *url.URL) (gostorage.DiskConfig, error) {
+	options := make(map[string]string)
+
+	for key, values := range u.Query() {
+		if key == "expiry" {
+			key = "default_expiry"
+		}
+		options[key] = values[len(values)-1]
+	}
+
+	if u.User != nil {
+		options["account_name"] = u.User.Username()
+		if key, ok := u.User.Password(); ok {
+			options["account_key"] = key
+		}
+	}
+
+	if _, ok := options["endpoint"]; !ok && u.Host != "" {
+		options["endpoint"] = "https://" + u.Host
+	}
+
+	name, rest, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if name == "" || rest != "" {
+		return gostorage.DiskConfig{}, &gostorage.ConfigError{Field: "dsn", Reason: "must contain exactly one path segment naming the container"}
+	}
+	options["container"] = name
+
+	return gostorage.DiskConfig{Driver: DriverName, Options: options}, nil
+}
+
+// ConfigFromDisk converts generic disk options into a BlobStorageConfig.
+// Recognized options: account_name, account_key, container, endpoint, visibility ("public" or
+// "private"), default_expiry (e.g. "15m"), block_size (bytes) and concurrency.
+// Returns a *gostorage.ConfigError naming the first invalid option.
+func ConfigFromDisk(disk gostorage.DiskConfig) (BlobStorageConfig, error) {
+	var cfg BlobStorageConfig
+	var err error
+
+	if cfg.AccountName, err = disk.Require("account_name"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.AccountKey, err = disk.Require("account_key"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Container, err = disk.Require("container"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.DefaultExpiry, err = disk.Duration("default_expiry", 15*time.Minute); err != nil {
+		return cfg, err
+	}
+
+	blockSize, err := disk.Int("block_size", 0)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.BlockSize = int64(blockSize)
+
+	if cfg.Concurrency, err = disk.Int("concurrency", 0); err != nil {
+		return cfg, err
+	}
+
+	cfg.Endpoint = disk.String("endpoint")
+
+	switch v := Visibility(disk.String("visibility")); v {
+	case "":
+		cfg.Visibility = VisibilityPrivate
+	case VisibilityPrivate, VisibilityPublic:
+		cfg.Visibility = v
+	default:
+		return cfg, &gostorage.ConfigError{Field: "visibility", Reason: fmt.Sprintf("must be %q or %q, got %q", VisibilityPublic, VisibilityPrivate, v)}
+	}
+
+	return cfg, nil
+}
+
+// openFromConfig is the gostorage.DriverOpener for the Azure Blob Storage driver.
+func openFromConfig(ctx context.Context, disk gostorage.DiskConfig) (gostorage.StorageDriver, error) {
+	cfg, err := ConfigFromDisk(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlobStorage(cfg)
+}
@@ -0,0 +1,311 @@
+package azblobdriver
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccount   = "devstoreaccount1"
+	testContainer = "uploads"
+	// testAccountKey is Azurite's well-known development key.
+	testAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	// listPageSize is small so listings exercise paging.
+	listPageSize = 2
+)
+
+type fakeBlob struct {
+	data     []byte
+	modified time.Time
+	etag     string
+}
+
+// fakeBlobService is an in-memory subset of the Blob service REST API for a single
+// container, addressed path-style like Azurite: /devstoreaccount1/uploads/<blob>.
+// Requests need a SharedKey authorization or an unexpired SAS; reads are anonymous
+// when the container is public.
+type fakeBlobService struct {
+	*httptest.Server
+	public bool
+
+	mu      sync.Mutex
+	blobs   map[string]*fakeBlob
+	blocks  map[string]map[string][]byte // uncommitted blocks by blob and block ID
+	staged  int
+	version int
+}
+
+func newFakeBlobService(t *testing.T, public bool) *fakeBlobService {
+	t.Helper()
+
+	f := &fakeBlobService{
+		public: public,
+		blobs:  make(map[string]*fakeBlob),
+		blocks: make(map[string]map[string][]byte),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// endpoint returns the blob service URL of the fake account.
+func (f *fakeBlobService) endpoint() string {
+	return f.URL + "/" + testAccount
+}
+
+func (f *fakeBlobService) blob(name string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.blobs[name]
+	if !ok {
+		return nil, false
+	}
+
+	return b.data, true
+}
+
+func (f *fakeBlobService) stagedBlocks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.staged
+}
+
+func (f *fakeBlobService) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutPrefix(r.URL.Path, "/"+testAccount+"/"+testContainer)
+	if !ok {
+		writeError(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	name := strings.TrimPrefix(path, "/")
+	query := r.URL.Query()
+
+	anonymousRead := f.public && name != "" && (r.Method == http.MethodGet || r.Method == http.MethodHead)
+	if !anonymousRead && !f.authorized(r) {
+		writeError(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case name == "" && r.Method == http.MethodGet && query.Get("comp") == "list":
+		f.list(w, query)
+	case name == "":
+		writeError(w, http.StatusBadRequest, "UnsupportedHttpVerb")
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		data, _ := io.ReadAll(r.Body)
+		if f.blocks[name] == nil {
+			f.blocks[name] = make(map[string][]byte)
+		}
+		f.blocks[name][query.Get("blockid")] = data
+		f.staged++
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&list); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidXmlDocument")
+			return
+		}
+
+		var data []byte
+		for _, id := range list.Latest {
+			block, ok := f.blocks[name][id]
+			if !ok {
+				writeError(w, http.StatusBadRequest, "InvalidBlockList")
+				return
+			}
+			data = append(data, block...)
+		}
+		delete(f.blocks, name)
+		f.store(w, name, data)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.store(w, name, data)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		f.get(w, r, name)
+	case r.Method == http.MethodDelete:
+		if _, ok := f.blobs[name]; !ok {
+			writeError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeError(w, http.StatusBadRequest, "UnsupportedHttpVerb")
+	}
+}
+
+// authorized accepts SharedKey requests for the test account and SAS URLs that have not expired.
+// Signatures are not verified.
+func (f *fakeBlobService) authorized(r *http.Request) bool {
+	if strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+testAccount+":") {
+		return true
+	}
+
+	query := r.URL.Query()
+	if query.Get("sig") == "" || query.Get("sp") != "r" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	expiry, err := time.Parse(time.RFC3339, query.Get("se"))
+	return err == nil && time.Now().Before(expiry)
+}
+
+func (f *fakeBlobService) store(w http.ResponseWriter, name string, data []byte) {
+	f.version++
+	b := &fakeBlob{
+		data:     data,
+		modified: time.Now().UTC().Truncate(time.Second),
+		etag:     fmt.Sprintf("0x8D%012X", f.version),
+	}
+	f.blobs[name] = b
+
+	w.Header().Set("ETag", `"`+b.etag+`"`)
+	w.Header().Set("Last-Modified", b.modified.Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeBlobService) get(w http.ResponseWriter, r *http.Request, name string) {
+	b, ok := f.blobs[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "BlobNotFound")
+		return
+	}
+
+	size := int64(len(b.data))
+	start, end := int64(0), size-1
+	status := http.StatusOK
+
+	if spec, ok := strings.CutPrefix(r.Header.Get("x-ms-range"), "bytes="); ok {
+		first, last, _ := strings.Cut(spec, "-")
+		start, _ = strconv.ParseInt(first, 10, 64)
+		if last != "" {
+			end, _ = strconv.ParseInt(last, 10, 64)
+		}
+		if start >= size {
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+			return
+		}
+		end = min(end, size-1)
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.Header().Set("ETag", `"`+b.etag+`"`)
+	w.Header().Set("Last-Modified", b.modified.Format(http.TimeFormat))
+	w.Header().Set("x-ms-blob-type", "BlockBlob")
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodGet {
+		w.Write(b.data[start : end+1])
+	}
+}
+
+type listBlob struct {
+	XMLName    xml.Name       `xml:"Blob"`
+	Name       string         `xml:"Name"`
+	Properties listProperties `xml:"Properties"`
+}
+
+type listProperties struct {
+	LastModified  string `xml:"Last-Modified"`
+	Etag          string `xml:"Etag"`
+	ContentLength int64  `xml:"Content-Length"`
+	BlobType      string `xml:"BlobType"`
+}
+
+type listPrefix struct {
+	XMLName xml.Name `xml:"BlobPrefix"`
+	Name    string   `xml:"Name"`
+}
+
+// list serves List Blobs, collapsing names at the delimiter into prefixes and
+// returning listPageSize entries per page.
+func (f *fakeBlobService) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+
+	var entries []string
+	isPrefix := make(map[string]bool)
+	for name := range f.blobs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				dir := name[:len(prefix)+i+len(delimiter)]
+				if !isPrefix[dir] {
+					isPrefix[dir] = true
+					entries = append(entries, dir)
+				}
+				continue
+			}
+		}
+		entries = append(entries, name)
+	}
+	slices.Sort(entries)
+
+	if marker != "" {
+		i, _ := slices.BinarySearch(entries, marker)
+		entries = entries[i:]
+	}
+
+	next := ""
+	if len(entries) > listPageSize {
+		next = entries[listPageSize]
+		entries = entries[:listPageSize]
+	}
+
+	var blobs []any
+	for _, name := range entries {
+		if isPrefix[name] {
+			blobs = append(blobs, listPrefix{Name: name})
+			continue
+		}
+
+		b := f.blobs[name]
+		blobs = append(blobs, listBlob{Name: name, Properties: listProperties{
+			LastModified:  b.modified.Format(http.TimeFormat),
+			Etag:          b.etag,
+			ContentLength: int64(len(b.data)),
+			BlobType:      "BlockBlob",
+		}})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName       xml.Name `xml:"EnumerationResults"`
+		ContainerName string   `xml:"ContainerName,attr"`
+		Prefix        string   `xml:"Prefix"`
+		Marker        string   `xml:"Marker"`
+		Delimiter     string   `xml:"Delimiter,omitempty"`
+		Blobs         []any    `xml:"Blobs>x"`
+		NextMarker    string   `xml:"NextMarker"`
+	}{ContainerName: testContainer, Prefix: prefix, Marker: marker, Delimiter: delimiter, Blobs: blobs, NextMarker: next})
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, code)
+}
@@ -0,0 +1,397 @@
+package casdriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// blobPrefix is the directory blobs are stored below, named after the hash algorithm.
+const blobPrefix = "sha256"
+
+// CASStorageConfig defines the configuration of a content-addressable storage.
+type CASStorageConfig struct {
+	Blobs   gostorage.StorageDriver // storage the deduplicated blobs are written to
+	Index   Index                   // key to hash index; it must outlive the process, or the blobs become unreachable
+	TempDir string                  // optional directory uploads are buffered in while hashing, defaults to os.TempDir()
+}
+
+// CASStorage is a gostorage.StorageDriver that stores every distinct content once.
+// Put hashes the file with SHA-256 and writes it to Blobs under "sha256/ab/cd/<hash>" unless
+// that blob already exists; the key itself only becomes an entry of the Index. A blob is
+// deleted when the last key referencing it is deleted or overwritten.
+type CASStorage struct {
+	blobs  gostorage.StorageDriver
+	index  Index
+	config CASStorageConfig
+
+	mu    sync.Mutex
+	locks map[string]*hashLock // serializes blob creation and removal per hash
+}
+
+// hashLock is a mutex for a single hash, removed from CASStorage.locks once unused.
+type hashLock struct {
+	sync.Mutex
+	users int
+}
+
+// NewCASStorage initializes a CASStorage writing blobs to cfg.Blobs.
+// Returns gostorage.ErrInvalidConfig if Blobs or Index is nil. There is no default Index:
+// a MemoryIndex only survives a restart when it is saved and loaded again, so callers choose it explicitly.
+func NewCASStorage(cfg CASStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.Blobs == nil || cfg.Index == nil {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	return &CASStorage{
+		blobs:  cfg.Blobs,
+		index:  cfg.Index,
+		config: cfg,
+		locks:  make(map[string]*hashLock),
+	}, nil
+}
+
+// BlobKey returns the key a blob with the given hex-encoded SHA-256 hash is stored under in Blobs,
+// e.g. "sha256/ab/cd/abcd...".
+// Returns gostorage.ErrInvalidKey if hash is not 64 lowercase hex digits.
+func BlobKey(hash string) (string, error) {
+	if !validHash(hash) {
+		return "", gostorage.ErrInvalidKey
+	}
+
+	return blobKey(hash), nil
+}
+
+// blobKey returns the key of the blob of hash, which must be valid.
+func blobKey(hash string) string {
+	return blobPrefix + "/" + hash[:2] + "/" + hash[2:4] + "/" + hash
+}
+
+// validHash reports whether hash is a hex-encoded SHA-256 hash as computed by Put.
+func validHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lock acquires the lock of hash and returns the function releasing it.
+func (s *CASStorage) lock(hash string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[hash]
+	if !ok {
+		l = &hashLock{}
+		s.locks[hash] = l
+	}
+	l.users++
+	s.mu.Unlock()
+
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+
+		s.mu.Lock()
+		if l.users--; l.users == 0 {
+			delete(s.locks, hash)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// lookup returns the hash referenced by key, failing with gostorage.ErrInternal if the index
+// holds something else than a valid hash for it.
+func (s *CASStorage) lookup(ctx context.Context, key string) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	hash, err := s.index.Lookup(ctx, key)
+	if err != nil {
+		if errors.Is(err, gostorage.ErrNotFound) {
+			return "", gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to look up content hash")
+		return "", gostorage.ErrInternal
+	}
+
+	if !validHash(hash) {
+		log.Error().Str("key", key).Str("hash", hash).Msg("invalid content hash in index")
+		return "", gostorage.ErrInternal
+	}
+
+	return hash, nil
+}
+
+// release deletes the blob of hash if no key references it anymore.
+func (s *CASStorage) release(ctx context.Context, hash string) error {
+	unlock := s.lock(hash)
+	defer unlock()
+
+	refs, err := s.index.Refs(ctx, hash)
+	if err != nil {
+		log.Error().Err(err).Str("hash", hash).Msg("failed to count content references")
+		return gostorage.ErrInternal
+	}
+
+	if refs > 0 {
+		return nil
+	}
+
+	if !validHash(hash) {
+		log.Error().Str("hash", hash).Msg("invalid content hash in index")
+		return gostorage.ErrInternal
+	}
+
+	return s.blobs.Delete(ctx, blobKey(hash))
+}
+
+// Capabilities reports the URL and range read support of Blobs.
+// Listing always works through the index; batch deletes, copies and multipart uploads are not supported.
+func (s *CASStorage) Capabilities() gostorage.Capabilities {
+	blobs := gostorage.CapabilitiesOf(s.blobs)
+
+	return gostorage.Capabilities{
+		SignedURL: blobs.SignedURL,
+		PublicURL: blobs.PublicURL,
+		List:      true,
+		RangeRead: blobs.RangeRead,
+	}
+}
+
+// Delete removes the key, and its blob once no other key references it.
+// Deleting a file that does not exist is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *CASStorage) Delete(ctx context.Context, key string) error {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	previous, err := s.index.Unlink(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to remove content reference")
+		return gostorage.ErrInternal
+	}
+
+	if previous != "" {
+		// The key is gone either way; a blob left behind is only wasted space.
+		if err := s.release(ctx, previous); err != nil {
+			log.Error().Err(err).Str("key", key).Str("hash", previous).Msg("failed to delete unreferenced blob")
+		}
+	}
+
+	return nil
+}
+
+// Exists checks if the key is in the index.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *CASStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := s.lookup(ctx, key); err != nil {
+		if errors.Is(err, gostorage.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetRange reads a byte range of the blob referenced by key.
+// Returns gostorage.ErrNotSupported if Blobs does not implement gostorage.RangeReader.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *CASStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	reader, ok := s.blobs.(gostorage.RangeReader)
+	if !ok {
+		return nil, gostorage.ErrNotSupported
+	}
+
+	hash, err := s.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader.GetRange(ctx, blobKey(hash), offset, length)
+}
+
+// GetSignedURL returns a signed URL of the blob referenced by key.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *CASStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	hash, err := s.lookup(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return s.blobs.GetSignedURL(ctx, blobKey(hash), expiry)
+}
+
+// GetURL returns the public URL of the blob referenced by key.
+// Usage: Call this to display or embed media that anyone can access.
+func (s *CASStorage) GetURL(ctx context.Context, key string) (string, error) {
+	hash, err := s.lookup(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return s.blobs.GetURL(ctx, blobKey(hash))
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the file at key.
+// Usage: Compare files or build cache keys without downloading content.
+func (s *CASStorage) Hash(ctx context.Context, key string) (string, error) {
+	return s.lookup(ctx, key)
+}
+
+// List calls fn for every key of the index under opts.Prefix in lexical key order.
+// Files are reported with their hash as ETag; sizes and times are not tracked by the index.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *CASStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	lastDir := ""
+
+	return s.index.List(ctx, opts.Prefix, func(key, hash string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Collapse everything below the next "/" into a single directory entry.
+		if i := strings.Index(key[len(opts.Prefix):], "/"); i >= 0 && !opts.Recursive {
+			dir := key[:len(opts.Prefix)+i+1]
+			if dir == lastDir {
+				return nil
+			}
+
+			lastDir = dir
+			return fn(gostorage.ObjectInfo{Key: dir, IsDir: true})
+		}
+
+		return fn(gostorage.ObjectInfo{Key: key, ETag: `"` + hash + `"`})
+	})
+}
+
+// Stat returns the size and modification time of the blob referenced by key,
+// with the key's hash as ETag. The modification time is when the content was first stored.
+// Returns gostorage.ErrNotSupported if Blobs does not implement gostorage.RangeReader.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *CASStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	reader, ok := s.blobs.(gostorage.RangeReader)
+	if !ok {
+		return gostorage.ObjectInfo{}, gostorage.ErrNotSupported
+	}
+
+	hash, err := s.lookup(ctx, key)
+	if err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	info, err := reader.Stat(ctx, blobKey(hash))
+	if err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	info.Key = key
+	info.ETag = `"` + hash + `"`
+
+	return info, nil
+}
+
+// Put buffers the file in TempDir while hashing it, uploads it to Blobs unless a blob with the
+// same hash exists, and points key at it. The blob previously referenced by key is deleted
+// if no other key references it.
+// Returns the URL of the file if Blobs supports public URLs, otherwise an empty string.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *CASStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(s.config.TempDir, "gostorage-cas-*")
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to create temporary file for hashing")
+		return "", gostorage.ErrInternal
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hasher), file); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to buffer file for hashing")
+		return "", gostorage.ErrInternal
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to rewind buffered file")
+		return "", gostorage.ErrInternal
+	}
+
+	previous, err := s.store(ctx, key, hash, tmp)
+	if err != nil {
+		return "", err
+	}
+
+	if previous != "" && previous != hash {
+		if err := s.release(ctx, previous); err != nil {
+			log.Error().Err(err).Str("key", key).Str("hash", previous).Msg("failed to delete unreferenced blob")
+		}
+	}
+
+	if !gostorage.CapabilitiesOf(s.blobs).PublicURL {
+		return "", nil
+	}
+
+	return s.GetURL(ctx, key)
+}
+
+// store uploads content as the blob of hash if it does not exist yet and links key to it,
+// holding the lock of hash so a concurrent release cannot delete the blob in between.
+// It returns the hash key referenced before.
+func (s *CASStorage) store(ctx context.Context, key, hash string, content io.Reader) (string, error) {
+	unlock := s.lock(hash)
+	defer unlock()
+
+	blobKey := blobKey(hash)
+	exists, err := s.blobs.Exists(ctx, blobKey)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		if _, err := s.blobs.Put(ctx, blobKey, content); err != nil {
+			return "", err
+		}
+	}
+
+	previous, err := s.index.Link(ctx, key, hash)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("hash", hash).Msg("failed to add content reference")
+
+		if !exists {
+			if err := s.blobs.Delete(ctx, blobKey); err != nil {
+				log.Error().Err(err).Str("hash", hash).Msg("failed to delete unreferenced blob")
+			}
+		}
+		return "", gostorage.ErrInternal
+	}
+
+	return previous, nil
+}
@@ -0,0 +1,295 @@
+package casdriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage creates a CASStorage over a local disk in a temporary directory.
+func newTestStorage(t *testing.T, baseURL string) (*CASStorage, *localdriver.DiskStorage) {
+	t.Helper()
+
+	blobs, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir(), BaseURL: baseURL})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	driver, err := NewCASStorage(CASStorageConfig{Blobs: blobs, Index: NewMemoryIndex(), TempDir: t.TempDir()})
+	require.NoError(t, err, "expected no error creating CAS storage")
+
+	return driver.(*CASStorage), blobs.(*localdriver.DiskStorage)
+}
+
+// blobKeys returns the keys of every blob in the disk.
+func blobKeys(t *testing.T, blobs *localdriver.DiskStorage) []string {
+	t.Helper()
+
+	var keys []string
+	err := blobs.List(context.Background(), gostorage.ListOptions{Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	require.NoError(t, err, "expected no error listing blobs")
+
+	return keys
+}
+
+// sha256Hex returns the hex-encoded SHA-256 hash of content.
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewCASStorage(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  CASStorageConfig
+	}{
+		{
+			name: "should fail without blob storage",
+			cfg:  CASStorageConfig{Index: NewMemoryIndex()},
+		},
+		{
+			name: "should fail without index",
+			cfg:  CASStorageConfig{Blobs: new(gostorage.MockStorageDriver)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewCASStorage(tt.cfg)
+
+			assert.ErrorIs(t, err, gostorage.ErrInvalidConfig, "expected ErrInvalidConfig")
+			assert.Nil(t, storage, "expected storage to be nil on error")
+		})
+	}
+}
+
+func TestBlobKey(t *testing.T) {
+	hash := sha256Hex("hello")
+
+	tests := []struct {
+		name        string
+		hash        string
+		expected    string
+		expectedErr error
+	}{
+		{name: "should shard by the first hash bytes", hash: hash, expected: "sha256/2c/f2/" + hash},
+		{name: "should reject a short hash", hash: "ab", expectedErr: gostorage.ErrInvalidKey},
+		{name: "should reject an empty hash", hash: "", expectedErr: gostorage.ErrInvalidKey},
+		{name: "should reject non-hex characters", hash: strings.Repeat("z", 64), expectedErr: gostorage.ErrInvalidKey},
+		{name: "should reject uppercase hex", hash: strings.ToUpper(hash), expectedErr: gostorage.ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := BlobKey(tt.hash)
+
+			assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			assert.Equal(t, tt.expected, key, "expected blob key to match")
+		})
+	}
+}
+
+func TestCASStorage_InvalidHashInIndex(t *testing.T) {
+	index := NewMemoryIndex()
+	_, err := index.Link(context.Background(), "a.txt", "abc")
+	require.NoError(t, err, "expected no error linking key")
+
+	storage, err := NewCASStorage(CASStorageConfig{Blobs: new(gostorage.MockStorageDriver), Index: index})
+	require.NoError(t, err, "expected no error creating CAS storage")
+
+	_, err = storage.GetURL(context.Background(), "a.txt")
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected ErrInternal instead of a panic for a corrupt index")
+}
+
+func TestCASStorage_PutDeduplicates(t *testing.T) {
+	ctx := context.Background()
+	storage, blobs := newTestStorage(t, "")
+
+	for _, key := range []string{"users/1/invoice.pdf", "users/2/invoice.pdf"} {
+		location, err := storage.Put(ctx, key, strings.NewReader("same invoice"))
+		assert.NoError(t, err, "expected no error putting file")
+		assert.Empty(t, location, "expected no URL without public URLs")
+	}
+
+	hash := sha256Hex("same invoice")
+	assert.Equal(t, []string{blobKey(hash)}, blobKeys(t, blobs), "expected the content to be stored once")
+
+	got, err := storage.Hash(ctx, "users/2/invoice.pdf")
+	assert.NoError(t, err, "expected no error getting hash")
+	assert.Equal(t, hash, got, "expected SHA-256 of the content")
+
+	_, err = storage.Put(ctx, "../invoice.pdf", strings.NewReader("x"))
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid keys to be rejected")
+}
+
+func TestCASStorage_DeleteReleasesLastReference(t *testing.T) {
+	ctx := context.Background()
+	storage, blobs := newTestStorage(t, "")
+
+	for _, key := range []string{"a.pdf", "b.pdf"} {
+		_, err := storage.Put(ctx, key, strings.NewReader("shared"))
+		require.NoError(t, err, "expected no error putting file")
+	}
+
+	assert.NoError(t, storage.Delete(ctx, "a.pdf"), "expected no error deleting first reference")
+	assert.Len(t, blobKeys(t, blobs), 1, "expected the blob to be kept while referenced")
+
+	exists, err := storage.Exists(ctx, "a.pdf")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.False(t, exists, "expected deleted key to be gone")
+
+	assert.NoError(t, storage.Delete(ctx, "b.pdf"), "expected no error deleting last reference")
+	assert.Empty(t, blobKeys(t, blobs), "expected the blob to be deleted with its last reference")
+
+	assert.NoError(t, storage.Delete(ctx, "b.pdf"), "expected deleting a missing key not to fail")
+}
+
+func TestCASStorage_OverwriteReleasesPreviousContent(t *testing.T) {
+	ctx := context.Background()
+	storage, blobs := newTestStorage(t, "")
+
+	_, err := storage.Put(ctx, "avatar.png", strings.NewReader("v1"))
+	require.NoError(t, err, "expected no error putting first version")
+	_, err = storage.Put(ctx, "avatar.png", strings.NewReader("v2"))
+	require.NoError(t, err, "expected no error putting second version")
+
+	assert.Equal(t, []string{blobKey(sha256Hex("v2"))}, blobKeys(t, blobs), "expected the first version to be released")
+
+	_, err = storage.Put(ctx, "avatar.png", strings.NewReader("v2"))
+	require.NoError(t, err, "expected no error putting identical content")
+	assert.Len(t, blobKeys(t, blobs), 1, "expected rewriting identical content to keep the blob")
+}
+
+func TestCASStorage_GetRangeAndStat(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := newTestStorage(t, "")
+
+	_, err := storage.Put(ctx, "docs/report.txt", strings.NewReader("quarterly report"))
+	require.NoError(t, err, "expected no error putting file")
+
+	body, err := storage.GetRange(ctx, "docs/report.txt", 10, 6)
+	require.NoError(t, err, "expected no error reading range")
+	got, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "report", string(got), "expected range content to match")
+
+	info, err := storage.Stat(ctx, "docs/report.txt")
+	assert.NoError(t, err, "expected no error stating file")
+	assert.Equal(t, "docs/report.txt", info.Key, "expected the key instead of the blob key")
+	assert.Equal(t, int64(len("quarterly report")), info.Size, "expected size to match")
+	assert.Equal(t, `"`+sha256Hex("quarterly report")+`"`, info.ETag, "expected the content hash as ETag")
+
+	_, err = storage.Stat(ctx, "docs/missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound for missing key")
+}
+
+func TestCASStorage_WithoutRangeReader(t *testing.T) {
+	storage, err := NewCASStorage(CASStorageConfig{Blobs: new(gostorage.MockStorageDriver), Index: NewMemoryIndex()})
+	require.NoError(t, err, "expected no error creating CAS storage")
+
+	_, err = storage.(*CASStorage).GetRange(context.Background(), "a.txt", 0, -1)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected ErrNotSupported without range reads")
+	assert.False(t, storage.(*CASStorage).Capabilities().RangeRead, "expected no range read capability")
+}
+
+func TestCASStorage_URLs(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := newTestStorage(t, "https://cdn.example.com")
+
+	location, err := storage.Put(ctx, "img/logo.png", strings.NewReader("logo"))
+	assert.NoError(t, err, "expected no error putting file")
+	assert.Equal(t, "https://cdn.example.com/"+blobKey(sha256Hex("logo")), location, "expected URL of the blob")
+
+	_, err = storage.GetURL(ctx, "img/missing.png")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound for missing key")
+
+	_, err = storage.GetSignedURL(ctx, "img/logo.png", 0)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected signed URL support of the blob storage")
+}
+
+func TestCASStorage_List(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := newTestStorage(t, "")
+	for _, key := range []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"} {
+		_, err := storage.Put(ctx, key, strings.NewReader("content"))
+		require.NoError(t, err, "expected no error putting file")
+	}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every key recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should collapse nested directories under a prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/"},
+			expected: []string{"a/b.txt", "a/c/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(ctx, tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing files")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestCASStorage_ConcurrentPutAndDelete(t *testing.T) {
+	ctx := context.Background()
+	storage, blobs := newTestStorage(t, "")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			key := fmt.Sprintf("copies/%d.txt", i)
+			_, err := storage.Put(ctx, key, strings.NewReader("popular"))
+			assert.NoError(t, err, "expected no error putting file")
+
+			if i%2 == 0 {
+				assert.NoError(t, storage.Delete(ctx, key), "expected no error deleting file")
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{blobKey(sha256Hex("popular"))}, blobKeys(t, blobs), "expected the shared blob to survive")
+
+	for i := 1; i < 20; i += 2 {
+		body, err := storage.GetRange(ctx, fmt.Sprintf("copies/%d.txt", i), 0, -1)
+		require.NoError(t, err, "expected remaining keys to be readable")
+		got, _ := io.ReadAll(body)
+		body.Close()
+		assert.Equal(t, "popular", string(got), "expected shared content")
+	}
+}
@@ -0,0 +1,157 @@
+package casdriver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// Index maps keys to the SHA-256 hashes of their content and counts the references to every hash.
+// Implementations must be safe for concurrent use. MemoryIndex is the built-in implementation;
+// deployments with several processes sharing one blob storage should implement Index on a database.
+type Index interface {
+	// Lookup returns the hash referenced by key.
+	// Returns gostorage.ErrNotFound if the key is not in the index.
+	Lookup(ctx context.Context, key string) (hash string, err error)
+
+	// Link points key at hash and returns the hash it referenced before, or "" if it was new.
+	Link(ctx context.Context, key, hash string) (previous string, err error)
+
+	// Unlink removes key and returns the hash it referenced, or "" if it was not in the index.
+	Unlink(ctx context.Context, key string) (previous string, err error)
+
+	// Refs returns the number of keys referencing hash.
+	Refs(ctx context.Context, hash string) (int, error)
+
+	// List calls fn for every key starting with prefix, in lexical key order.
+	// If fn returns an error, listing stops and that error is returned.
+	List(ctx context.Context, prefix string, fn func(key, hash string) error) error
+}
+
+// MemoryIndex is an Index held in memory. Use Save and LoadMemoryIndex to persist it between runs.
+type MemoryIndex struct {
+	mu     sync.RWMutex
+	hashes map[string]string // key -> hash
+	refs   map[string]int    // hash -> number of keys
+}
+
+// NewMemoryIndex returns an empty MemoryIndex.
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		hashes: make(map[string]string),
+		refs:   make(map[string]int),
+	}
+}
+
+// LoadMemoryIndex reads an index written by MemoryIndex.Save. Reference counts are rebuilt from the keys.
+func LoadMemoryIndex(r io.Reader) (*MemoryIndex, error) {
+	idx := NewMemoryIndex()
+	if err := json.NewDecoder(r).Decode(&idx.hashes); err != nil {
+		return nil, err
+	}
+
+	for _, hash := range idx.hashes {
+		idx.refs[hash]++
+	}
+
+	return idx, nil
+}
+
+// Save writes the index as a JSON object mapping keys to hashes.
+// Usage: Persist the index on shutdown, or periodically, and restore it with LoadMemoryIndex.
+func (idx *MemoryIndex) Save(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return json.NewEncoder(w).Encode(idx.hashes)
+}
+
+// Lookup returns the hash referenced by key, or gostorage.ErrNotFound if the key is not in the index.
+func (idx *MemoryIndex) Lookup(ctx context.Context, key string) (string, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	hash, ok := idx.hashes[key]
+	if !ok {
+		return "", gostorage.ErrNotFound
+	}
+
+	return hash, nil
+}
+
+// Link points key at hash and returns the hash it referenced before, or "" if it was new.
+func (idx *MemoryIndex) Link(ctx context.Context, key, hash string) (string, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	previous := idx.hashes[key]
+	if previous != "" {
+		idx.release(previous)
+	}
+
+	idx.hashes[key] = hash
+	idx.refs[hash]++
+
+	return previous, nil
+}
+
+// Unlink removes key and returns the hash it referenced, or "" if it was not in the index.
+func (idx *MemoryIndex) Unlink(ctx context.Context, key string) (string, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	previous, ok := idx.hashes[key]
+	if !ok {
+		return "", nil
+	}
+
+	delete(idx.hashes, key)
+	idx.release(previous)
+
+	return previous, nil
+}
+
+// release drops one reference to hash. The caller must hold the write lock.
+func (idx *MemoryIndex) release(hash string) {
+	if idx.refs[hash]--; idx.refs[hash] <= 0 {
+		delete(idx.refs, hash)
+	}
+}
+
+// Refs returns the number of keys referencing hash.
+func (idx *MemoryIndex) Refs(ctx context.Context, hash string) (int, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.refs[hash], nil
+}
+
+// List calls fn for every key starting with prefix, in lexical key order.
+// fn runs without the lock held, so it may modify the index.
+func (idx *MemoryIndex) List(ctx context.Context, prefix string, fn func(key, hash string) error) error {
+	idx.mu.RLock()
+	keys := make([]string, 0, len(idx.hashes))
+	hashes := make(map[string]string)
+	for key, hash := range idx.hashes {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			hashes[key] = hash
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := fn(key, hashes[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package casdriver
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIndex_LinkAndUnlink(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+
+	previous, err := idx.Link(ctx, "a.pdf", "h1")
+	assert.NoError(t, err, "expected no error linking key")
+	assert.Empty(t, previous, "expected no previous hash for a new key")
+
+	_, err = idx.Link(ctx, "b.pdf", "h1")
+	assert.NoError(t, err, "expected no error linking second key")
+
+	refs, _ := idx.Refs(ctx, "h1")
+	assert.Equal(t, 2, refs, "expected both keys to reference the hash")
+
+	previous, err = idx.Link(ctx, "a.pdf", "h2")
+	assert.NoError(t, err, "expected no error relinking key")
+	assert.Equal(t, "h1", previous, "expected the replaced hash")
+
+	refs, _ = idx.Refs(ctx, "h1")
+	assert.Equal(t, 1, refs, "expected one reference to be dropped")
+
+	previous, err = idx.Unlink(ctx, "b.pdf")
+	assert.NoError(t, err, "expected no error unlinking key")
+	assert.Equal(t, "h1", previous, "expected the unlinked hash")
+
+	refs, _ = idx.Refs(ctx, "h1")
+	assert.Zero(t, refs, "expected no references left")
+
+	previous, err = idx.Unlink(ctx, "b.pdf")
+	assert.NoError(t, err, "expected unlinking a missing key not to fail")
+	assert.Empty(t, previous, "expected no hash for a missing key")
+
+	_, err = idx.Lookup(ctx, "b.pdf")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound for a missing key")
+}
+
+func TestMemoryIndex_List(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	for _, key := range []string{"b/c.txt", "a.txt", "a/b.txt", "c.txt"} {
+		_, err := idx.Link(ctx, key, "hash-of-"+key)
+		require.NoError(t, err, "expected no error linking key")
+	}
+
+	var keys []string
+	err := idx.List(ctx, "a", func(key, hash string) error {
+		assert.Equal(t, "hash-of-"+key, hash, "expected hash of key")
+		keys = append(keys, key)
+		return nil
+	})
+
+	assert.NoError(t, err, "expected no error listing")
+	assert.Equal(t, []string{"a.txt", "a/b.txt"}, keys, "expected matching keys in lexical order")
+}
+
+func TestMemoryIndex_SaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	for _, key := range []string{"a.pdf", "b.pdf"} {
+		_, err := idx.Link(ctx, key, "h1")
+		require.NoError(t, err, "expected no error linking key")
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, idx.Save(&buf), "expected no error saving index")
+
+	loaded, err := LoadMemoryIndex(&buf)
+	require.NoError(t, err, "expected no error loading index")
+
+	hash, err := loaded.Lookup(ctx, "b.pdf")
+	assert.NoError(t, err, "expected key to be restored")
+	assert.Equal(t, "h1", hash, "expected hash to be restored")
+
+	refs, _ := loaded.Refs(ctx, "h1")
+	assert.Equal(t, 2, refs, "expected reference counts to be rebuilt")
+
+	_, err = LoadMemoryIndex(strings.NewReader("not json"))
+	assert.Error(t, err, "expected error for invalid data")
+}
@@ -0,0 +1,265 @@
+package failoverdriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+const (
+	// DefaultCheckInterval is the time between health checks when FailoverStorageConfig.CheckInterval is zero.
+	DefaultCheckInterval = 10 * time.Second
+
+	// DefaultCheckTimeout bounds a single health check when FailoverStorageConfig.CheckTimeout is zero.
+	DefaultCheckTimeout = 5 * time.Second
+
+	// DefaultProbeKey is the key probed with Exists for backends that do not implement gostorage.Pinger.
+	DefaultProbeKey = ".gostorage-health"
+)
+
+// Backend is one of the storages a FailoverStorage routes to.
+type Backend struct {
+	Name   string                  // unique name reported in health state and logs, e.g. "minio" or "s3"
+	Driver gostorage.StorageDriver // storage serving operations while healthy
+}
+
+// FailoverStorageConfig defines the configuration for failing over between storages.
+type FailoverStorageConfig struct {
+	Backends         []Backend     // storages in order of preference
+	CheckInterval    time.Duration // optional time between health checks, defaults to DefaultCheckInterval
+	CheckTimeout     time.Duration // optional timeout of a single health check, defaults to DefaultCheckTimeout
+	ProbeKey         string        // optional key probed with Exists, defaults to DefaultProbeKey; it need not exist
+	FailureThreshold int           // optional consecutive failures before a backend is unhealthy, defaults to 1
+}
+
+// FailoverStorage is a gostorage.StorageDriver that routes every operation to the first healthy backend.
+// Backends are checked by Run, or on demand with Check: drivers implementing gostorage.Pinger are
+// pinged, others are probed with Exists on ProbeKey. Operations failing with an error other than
+// a gostorage.ErrNotFound, ErrInvalidKey or ErrNotSupported count as failed checks too, so a
+// degraded backend is left before the next scheduled check, and reads fall over to the next
+// healthy backend at once. Operations whose context was cancelled or timed out count as neither.
+// A backend becomes healthy again with its next successful check. If no backend is healthy,
+// operations go to the first one.
+type FailoverStorage struct {
+	backends []Backend
+	config   FailoverStorageConfig
+
+	mu     sync.RWMutex
+	states []BackendHealth // health of every backend, in backend order
+}
+
+// NewFailoverStorage initializes a FailoverStorage with every backend assumed healthy.
+// Call Run to check the backends periodically.
+// Returns gostorage.ErrInvalidConfig if there are no backends, a backend has no driver or a
+// missing or duplicate name, or a duration or the threshold is negative.
+func NewFailoverStorage(cfg FailoverStorageConfig) (gostorage.StorageDriver, error) {
+	if len(cfg.Backends) == 0 || cfg.CheckInterval < 0 || cfg.CheckTimeout < 0 || cfg.FailureThreshold < 0 {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	names := make(map[string]bool, len(cfg.Backends))
+	states := make([]BackendHealth, len(cfg.Backends))
+	for i, b := range cfg.Backends {
+		if b.Driver == nil || b.Name == "" || names[b.Name] {
+			return nil, gostorage.ErrInvalidConfig
+		}
+		names[b.Name] = true
+		states[i] = BackendHealth{Name: b.Name, Healthy: true}
+	}
+
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = DefaultCheckInterval
+	}
+	if cfg.CheckTimeout == 0 {
+		cfg.CheckTimeout = DefaultCheckTimeout
+	}
+	if cfg.ProbeKey == "" {
+		cfg.ProbeKey = DefaultProbeKey
+	}
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = 1
+	}
+
+	return &FailoverStorage{
+		backends: cfg.Backends,
+		config:   cfg,
+		states:   states,
+	}, nil
+}
+
+// candidates returns the indexes of the healthy backends in order of preference,
+// or only the first backend if none is healthy.
+func (s *FailoverStorage) candidates() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var healthy []int
+	for i, state := range s.states {
+		if state.Healthy {
+			healthy = append(healthy, i)
+		}
+	}
+	if len(healthy) == 0 {
+		return []int{0}
+	}
+
+	return healthy
+}
+
+// route calls op on the first healthy backend and records its failure, if any, against the
+// backend's health. With retry, backend failures fall over to the next healthy backend; writes
+// do not retry since the upload may already have been consumed. Once ctx is cancelled or past its
+// deadline, failures are returned as they are: drivers report them as gostorage.ErrInternal,
+// but they are the caller's doing and say nothing about the backend.
+func route[T any](ctx context.Context, s *FailoverStorage, retry bool, op func(gostorage.StorageDriver) (T, error)) (T, error) {
+	var (
+		v   T
+		err error
+	)
+	for _, i := range s.candidates() {
+		v, err = op(s.backends[i].Driver)
+		if !isBackendFailure(err) || ctx.Err() != nil {
+			return v, err
+		}
+
+		s.record(i, err)
+		if !retry {
+			break
+		}
+	}
+
+	return v, err
+}
+
+// isBackendFailure reports whether err points at a problem with the backend rather than the request.
+func isBackendFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, gostorage.ErrNotFound),
+		errors.Is(err, gostorage.ErrInvalidKey),
+		errors.Is(err, gostorage.ErrNotSupported):
+		return false
+	default:
+		return true
+	}
+}
+
+// Capabilities reports the features every backend supports, so they do not change on failover.
+// Batch deletes, copies and multipart uploads are not supported.
+func (s *FailoverStorage) Capabilities() gostorage.Capabilities {
+	caps := gostorage.Capabilities{SignedURL: true, PublicURL: true, List: true, RangeRead: true}
+	for _, b := range s.backends {
+		c := gostorage.CapabilitiesOf(b.Driver)
+		caps.SignedURL = caps.SignedURL && c.SignedURL
+		caps.PublicURL = caps.PublicURL && c.PublicURL
+		caps.List = caps.List && c.List
+		caps.RangeRead = caps.RangeRead && c.RangeRead
+	}
+
+	return caps
+}
+
+// Delete removes a file from the first healthy backend.
+// Usage: Call when you want to delete a file by its key.
+func (s *FailoverStorage) Delete(ctx context.Context, key string) error {
+	_, err := route(ctx, s, false, func(driver gostorage.StorageDriver) (struct{}, error) {
+		return struct{}{}, driver.Delete(ctx, key)
+	})
+
+	return err
+}
+
+// Exists checks if a file exists on the first healthy backend, falling over to the next one if it fails.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *FailoverStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return route(ctx, s, true, func(driver gostorage.StorageDriver) (bool, error) {
+		return driver.Exists(ctx, key)
+	})
+}
+
+// GetRange reads a byte range from the first healthy backend, falling over to the next one if it fails.
+// Returns gostorage.ErrNotSupported if the backend does not implement gostorage.RangeReader.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *FailoverStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return route(ctx, s, true, func(driver gostorage.StorageDriver) (io.ReadCloser, error) {
+		reader, ok := driver.(gostorage.RangeReader)
+		if !ok {
+			return nil, gostorage.ErrNotSupported
+		}
+
+		return reader.GetRange(ctx, key, offset, length)
+	})
+}
+
+// GetSignedURL returns a signed URL from the first healthy backend, falling over to the next one if it fails.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *FailoverStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return route(ctx, s, true, func(driver gostorage.StorageDriver) (string, error) {
+		return driver.GetSignedURL(ctx, key, expiry)
+	})
+}
+
+// GetURL returns a public URL from the first healthy backend, falling over to the next one if it fails.
+// Usage: Call this to display or embed media that anyone can access.
+func (s *FailoverStorage) GetURL(ctx context.Context, key string) (string, error) {
+	return route(ctx, s, true, func(driver gostorage.StorageDriver) (string, error) {
+		return driver.GetURL(ctx, key)
+	})
+}
+
+// List lists files of the first healthy backend, falling over to the next one only if listing
+// fails before any file was reported and ctx is not done.
+// Returns gostorage.ErrNotSupported if the backend does not implement gostorage.Lister.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *FailoverStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	var err error
+	for _, i := range s.candidates() {
+		lister, ok := s.backends[i].Driver.(gostorage.Lister)
+		if !ok {
+			return gostorage.ErrNotSupported
+		}
+
+		reported := false
+		err = lister.List(ctx, opts, func(obj gostorage.ObjectInfo) error {
+			reported = true
+			return fn(obj)
+		})
+		if !isBackendFailure(err) || ctx.Err() != nil {
+			return err
+		}
+
+		s.record(i, err)
+		if reported {
+			break
+		}
+	}
+
+	return err
+}
+
+// Stat returns file information from the first healthy backend, falling over to the next one if it fails.
+// Returns gostorage.ErrNotSupported if the backend does not implement gostorage.RangeReader.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *FailoverStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	return route(ctx, s, true, func(driver gostorage.StorageDriver) (gostorage.ObjectInfo, error) {
+		reader, ok := driver.(gostorage.RangeReader)
+		if !ok {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotSupported
+		}
+
+		return reader.Stat(ctx, key)
+	})
+}
+
+// Put uploads a file to the first healthy backend. Files written during an outage of the preferred
+// backend stay on the backend that received them; use the mirror driver to keep backends in sync.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *FailoverStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	return route(ctx, s, false, func(driver gostorage.StorageDriver) (string, error) {
+		return driver.Put(ctx, key, file)
+	})
+}
@@ -0,0 +1,275 @@
+package failoverdriver
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFailover fails over from a flaky "minio" disk to a flaky "s3" disk.
+func newTestFailover(t *testing.T, threshold int) (*FailoverStorage, *storagetest.FlakyDisk, *storagetest.FlakyDisk) {
+	t.Helper()
+
+	minio, s3 := storagetest.NewFlakyDisk(t, "https://minio.example.com"), storagetest.NewFlakyDisk(t, "https://s3.example.com")
+	driver, err := NewFailoverStorage(FailoverStorageConfig{
+		Backends:         []Backend{{Name: "minio", Driver: minio}, {Name: "s3", Driver: s3}},
+		FailureThreshold: threshold,
+	})
+	require.NoError(t, err, "expected no error creating failover storage")
+
+	return driver.(*FailoverStorage), minio, s3
+}
+
+// healthy returns the Healthy flag of every backend.
+func healthy(storage *FailoverStorage) []bool {
+	var flags []bool
+	for _, state := range storage.Health() {
+		flags = append(flags, state.Healthy)
+	}
+
+	return flags
+}
+
+func TestNewFailoverStorage(t *testing.T) {
+	disk := new(gostorage.MockStorageDriver)
+
+	tests := []struct {
+		name        string
+		cfg         FailoverStorageConfig
+		expectedErr error
+	}{
+		{
+			name:        "should create failover storage successfully",
+			cfg:         FailoverStorageConfig{Backends: []Backend{{Name: "a", Driver: disk}, {Name: "b", Driver: disk}}},
+			expectedErr: nil,
+		},
+		{
+			name:        "should return error without backends",
+			cfg:         FailoverStorageConfig{},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error for duplicate backend names",
+			cfg:         FailoverStorageConfig{Backends: []Backend{{Name: "a", Driver: disk}, {Name: "a", Driver: disk}}},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error for backend without driver",
+			cfg:         FailoverStorageConfig{Backends: []Backend{{Name: "a"}}},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error for negative threshold",
+			cfg:         FailoverStorageConfig{Backends: []Backend{{Name: "a", Driver: disk}}, FailureThreshold: -1},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewFailoverStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error when config is invalid")
+				assert.Nil(t, storage, "expected storage to be nil on error")
+			} else {
+				assert.NoError(t, err, "expected no error creating storage")
+			}
+		})
+	}
+}
+
+func TestFailoverStorage_RoutesToFirstHealthyBackend(t *testing.T) {
+	ctx := context.Background()
+	storage, minio, s3 := newTestFailover(t, 1)
+
+	location, err := storage.Put(ctx, "a.txt", strings.NewReader("hello"))
+	assert.NoError(t, err, "expected no error putting file")
+	assert.Equal(t, "https://minio.example.com/a.txt", location, "expected the preferred backend")
+	assert.Equal(t, "minio", storage.Active(), "expected the preferred backend to be active")
+
+	minio.Down.Store(true)
+	storage.Check(ctx)
+	assert.Equal(t, []bool{false, true}, healthy(storage), "expected the failed backend to be unhealthy")
+	assert.Equal(t, "s3", storage.Active(), "expected the secondary backend to be active")
+
+	location, err = storage.Put(ctx, "b.txt", strings.NewReader("world"))
+	assert.NoError(t, err, "expected no error putting file")
+	assert.Equal(t, "https://s3.example.com/b.txt", location, "expected the secondary backend")
+
+	exists, _ := s3.Exists(ctx, "b.txt")
+	assert.True(t, exists, "expected file on the secondary backend")
+
+	minio.Down.Store(false)
+	storage.Check(ctx)
+	assert.Equal(t, []bool{true, true}, healthy(storage), "expected the backend to recover")
+	assert.Equal(t, "minio", storage.Active(), "expected the preferred backend to be active again")
+}
+
+func TestFailoverStorage_ReadFallsOverOnFailure(t *testing.T) {
+	ctx := context.Background()
+	storage, minio, s3 := newTestFailover(t, 1)
+
+	for _, disk := range []*storagetest.FlakyDisk{minio, s3} {
+		_, err := disk.Put(ctx, "videos/clip.txt", strings.NewReader("clip content"))
+		require.NoError(t, err, "expected no error putting file")
+	}
+
+	minio.Down.Store(true)
+
+	body, err := storage.GetRange(ctx, "videos/clip.txt", 5, 7)
+	require.NoError(t, err, "expected read to fall over to the secondary backend")
+	got, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "content", string(got), "expected range from the secondary backend")
+
+	health := storage.Health()
+	assert.False(t, health[0].Healthy, "expected the failed read to mark the backend unhealthy")
+	assert.Equal(t, gostorage.ErrInternal.Error(), health[0].LastError, "expected the failure to be recorded")
+	assert.Equal(t, 1, health[0].ConsecutiveFailures, "expected one failure")
+
+	location, err := storage.GetURL(ctx, "videos/clip.txt")
+	assert.NoError(t, err, "expected no error getting URL")
+	assert.Equal(t, "https://s3.example.com/videos/clip.txt", location, "expected URL of the secondary backend")
+}
+
+func TestFailoverStorage_RequestErrorsKeepBackendHealthy(t *testing.T) {
+	ctx := context.Background()
+	storage, _, _ := newTestFailover(t, 1)
+
+	_, err := storage.GetRange(ctx, "missing.txt", 0, -1)
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound of the preferred backend")
+
+	_, err = storage.Stat(ctx, "../a.txt")
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected ErrInvalidKey of the preferred backend")
+
+	assert.Equal(t, []bool{true, true}, healthy(storage), "expected request errors not to affect health")
+}
+
+func TestFailoverStorage_CallerContextKeepsBackendHealthy(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name: "should keep backend healthy when the caller cancels",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+		},
+		{
+			name: "should keep backend healthy when the caller's deadline passes",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			// drivers report interrupted requests as ErrInternal
+			minio := new(gostorage.MockStorageDriver)
+			minio.On("Exists", mock.Anything, "a.txt").Return(false, gostorage.ErrInternal)
+			s3 := new(gostorage.MockStorageDriver)
+
+			driver, err := NewFailoverStorage(FailoverStorageConfig{
+				Backends: []Backend{{Name: "minio", Driver: minio}, {Name: "s3", Driver: s3}},
+			})
+			require.NoError(t, err, "expected no error creating failover storage")
+			storage := driver.(*FailoverStorage)
+
+			_, err = storage.Exists(ctx, "a.txt")
+			assert.ErrorIs(t, err, gostorage.ErrInternal, "expected the error of the interrupted request")
+
+			minio.AssertExpectations(t)
+			s3.AssertNotCalled(t, "Exists", mock.Anything, mock.Anything)
+			assert.Equal(t, []bool{true, true}, healthy(storage), "expected the caller's context not to affect health")
+			assert.Zero(t, storage.Health()[0].ConsecutiveFailures, "expected no failure to be recorded")
+		})
+	}
+}
+
+func TestFailoverStorage_FailureThreshold(t *testing.T) {
+	ctx := context.Background()
+	storage, minio, _ := newTestFailover(t, 2)
+	minio.Down.Store(true)
+
+	storage.Check(ctx)
+	assert.Equal(t, []bool{true, true}, healthy(storage), "expected the backend to stay healthy below the threshold")
+
+	storage.Check(ctx)
+	assert.Equal(t, []bool{false, true}, healthy(storage), "expected the backend to be unhealthy at the threshold")
+	assert.Equal(t, 2, storage.Health()[0].ConsecutiveFailures, "expected both failures to be counted")
+}
+
+func TestFailoverStorage_CheckPrefersPing(t *testing.T) {
+	ctx := context.Background()
+
+	pinger := new(gostorage.MockPinger)
+	pinger.On("Ping", mock.Anything).Return(gostorage.ErrInternal)
+
+	prober := new(gostorage.MockStorageDriver)
+	prober.On("Exists", mock.Anything, "health/probe").Return(false, nil)
+
+	driver, err := NewFailoverStorage(FailoverStorageConfig{
+		Backends: []Backend{{Name: "minio", Driver: pinger}, {Name: "local", Driver: prober}},
+		ProbeKey: "health/probe",
+	})
+	require.NoError(t, err, "expected no error creating failover storage")
+	storage := driver.(*FailoverStorage)
+
+	storage.Check(ctx)
+
+	pinger.AssertExpectations(t)
+	pinger.AssertNotCalled(t, "Exists", mock.Anything, mock.Anything)
+	prober.AssertExpectations(t)
+	assert.Equal(t, []bool{false, true}, healthy(storage), "expected health from Ping and Exists")
+	assert.False(t, storage.Health()[1].LastCheck.IsZero(), "expected the check time to be recorded")
+}
+
+func TestFailoverStorage_Ready(t *testing.T) {
+	ctx := context.Background()
+	storage, minio, s3 := newTestFailover(t, 1)
+
+	assert.NoError(t, storage.Ready(), "expected storage to be ready while a backend is healthy")
+
+	minio.Down.Store(true)
+	s3.Down.Store(true)
+	storage.Check(ctx)
+
+	assert.ErrorIs(t, storage.Ready(), ErrNoHealthyBackend, "expected error when every backend is unhealthy")
+	assert.Equal(t, "minio", storage.Active(), "expected the preferred backend without healthy ones")
+
+	_, err := storage.GetURL(ctx, "a.txt")
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected error of the preferred backend")
+}
+
+func TestFailoverStorage_RunChecksUntilDone(t *testing.T) {
+	storage, minio, _ := newTestFailover(t, 1)
+	minio.Down.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, storage.Run(ctx), context.Canceled, "expected Run to stop when the context is done")
+	assert.Equal(t, []bool{true, true}, healthy(storage), "expected checks interrupted by the context to be discarded")
+}
+
+func TestFailoverStorage_Capabilities(t *testing.T) {
+	storage, _, _ := newTestFailover(t, 1)
+
+	assert.Equal(t, gostorage.Capabilities{PublicURL: true, List: true, RangeRead: true}, storage.Capabilities(), "expected capabilities shared by the backends")
+}
@@ -0,0 +1,157 @@
+package failoverdriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// ErrNoHealthyBackend is returned by FailoverStorage.Ready when every backend is unhealthy.
+var ErrNoHealthyBackend = errors.New("storage: no healthy backend")
+
+// BackendHealth is the health state of one backend.
+type BackendHealth struct {
+	Name                string    `json:"name"`                 // name of the backend
+	Healthy             bool      `json:"healthy"`              // whether operations are routed to the backend
+	LastCheck           time.Time `json:"last_check"`           // time of the last check or failed operation, zero before the first one
+	LastError           string    `json:"last_error,omitempty"` // error of the last failure, empty once the backend recovered
+	ConsecutiveFailures int       `json:"consecutive_failures"` // failures since the last successful check
+}
+
+// record updates the health of backend i with the outcome of a check or an operation.
+func (s *FailoverStorage) record(i int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := &s.states[i]
+	state.LastCheck = time.Now()
+
+	if err == nil {
+		if !state.Healthy {
+			log.Info().Str("backend", state.Name).Msg("storage backend recovered")
+		}
+		state.Healthy = true
+		state.LastError = ""
+		state.ConsecutiveFailures = 0
+		return
+	}
+
+	state.LastError = err.Error()
+	state.ConsecutiveFailures++
+	if state.Healthy && state.ConsecutiveFailures >= s.config.FailureThreshold {
+		log.Error().Err(err).Str("backend", state.Name).Int("failures", state.ConsecutiveFailures).Msg("storage backend is unhealthy")
+		state.Healthy = false
+	}
+}
+
+// probe checks one backend with Ping, or with Exists on the probe key if it is not a gostorage.Pinger.
+func (s *FailoverStorage) probe(ctx context.Context, driver gostorage.StorageDriver) error {
+	ctx, cancel := context.WithTimeout(ctx, s.config.CheckTimeout)
+	defer cancel()
+
+	if pinger, ok := driver.(gostorage.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	_, err := driver.Exists(ctx, s.config.ProbeKey)
+	return err
+}
+
+// Check probes every backend concurrently, each within CheckTimeout, and updates their health.
+// Results of checks interrupted by ctx being done are discarded.
+// Usage: Call from your own scheduler, or use Run.
+func (s *FailoverStorage) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, b := range s.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.probe(ctx, b.Driver)
+			if ctx.Err() != nil {
+				return
+			}
+			s.record(i, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// Run checks the backends immediately and then every CheckInterval until ctx is done.
+// It returns ctx.Err().
+// Usage: Start in its own goroutine after creating the storage, e.g. go storage.Run(ctx).
+func (s *FailoverStorage) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Health returns the health state of every backend, in order of preference.
+// Usage: Expose in status pages or metrics.
+func (s *FailoverStorage) Health() []BackendHealth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	health := make([]BackendHealth, len(s.states))
+	copy(health, s.states)
+
+	return health
+}
+
+// Active returns the name of the backend operations are currently routed to.
+func (s *FailoverStorage) Active() string {
+	return s.backends[s.candidates()[0]].Name
+}
+
+// Ready returns ErrNoHealthyBackend if every backend is unhealthy, nil otherwise.
+// Usage: Call from readiness probes.
+func (s *FailoverStorage) Ready() error {
+	for _, state := range s.Health() {
+		if state.Healthy {
+			return nil
+		}
+	}
+
+	return ErrNoHealthyBackend
+}
+
+// healthResponse is the body written by HealthHandler.
+type healthResponse struct {
+	Status   string          `json:"status"`
+	Active   string          `json:"active"`
+	Backends []BackendHealth `json:"backends"`
+}
+
+// HealthHandler returns an http.Handler reporting the health of every backend as JSON,
+// with status 200 OK while any backend is healthy and 503 Service Unavailable otherwise.
+// Usage: Mount as a readiness endpoint, e.g. http.Handle("/readyz", storage.HealthHandler()).
+func (s *FailoverStorage) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := healthResponse{Status: "ok", Active: s.Active(), Backends: s.Health()}
+		status := http.StatusOK
+		if s.Ready() != nil {
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
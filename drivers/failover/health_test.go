@@ -0,0 +1,67 @@
+package failoverdriver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverStorage_HealthHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		down           []bool
+		expectedStatus int
+		expectedBody   string
+		expectedActive string
+	}{
+		{
+			name:           "should report ok while the preferred backend is healthy",
+			down:           []bool{false, false},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+			expectedActive: "minio",
+		},
+		{
+			name:           "should report ok while the secondary backend is healthy",
+			down:           []bool{true, false},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+			expectedActive: "s3",
+		},
+		{
+			name:           "should report unavailable when every backend is unhealthy",
+			down:           []bool{true, true},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "unavailable",
+			expectedActive: "minio",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, minio, s3 := newTestFailover(t, 1)
+			minio.Down.Store(tt.down[0])
+			s3.Down.Store(tt.down[1])
+			storage.Check(context.Background())
+
+			rec := httptest.NewRecorder()
+			storage.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, "expected status code to match")
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "expected JSON response")
+
+			var resp healthResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), "expected valid JSON")
+			assert.Equal(t, tt.expectedBody, resp.Status, "expected status to match")
+			assert.Equal(t, tt.expectedActive, resp.Active, "expected active backend to match")
+			require.Len(t, resp.Backends, 2, "expected every backend to be reported")
+			assert.Equal(t, !tt.down[0], resp.Backends[0].Healthy, "expected health of the preferred backend")
+			assert.Equal(t, !tt.down[1], resp.Backends[1].Healthy, "expected health of the secondary backend")
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package fsdriver
+
+import (
+	"context"
+	"os"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DriverName is the name the fs driver is registered under for gostorage.NewFromConfig.
+const DriverName = "fs"
+
+func init() {
+	gostorage.RegisterDriver(DriverName, openFromConfig)
+}
+
+// ConfigFromDisk converts generic disk options into an FSStorageConfig mounting a local
+// directory read-only. Recognized options: root (required) and base_url.
+// An embed.FS cannot be described by options; pass it to NewFSStorage and register the driver instead.
+// Returns a *gostorage.ConfigError naming the first invalid option.
+func ConfigFromDisk(disk gostorage.DiskConfig) (FSStorageConfig, error) {
+	root, err := disk.Require("root")
+	if err != nil {
+		return FSStorageConfig{}, err
+	}
+
+	return FSStorageConfig{
+		FS:      os.DirFS(root),
+		BaseURL: disk.String("base_url"),
+	}, nil
+}
+
+// openFromConfig is the gostorage.DriverOpener for the fs driver.
+func openFromConfig(ctx context.Context, disk gostorage.DiskConfig) (gostorage.StorageDriver, error) {
+	cfg, err := ConfigFromDisk(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFSStorage(cfg)
+}
@@ -0,0 +1,42 @@
+package fsdriver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromDisk(t *testing.T) {
+	_, err := ConfigFromDisk(gostorage.DiskConfig{Driver: DriverName})
+	assert.ErrorIs(t, err, gostorage.ErrInvalidConfig, "expected error for missing root")
+
+	cfg, err := ConfigFromDisk(gostorage.DiskConfig{Driver: DriverName, Options: map[string]string{
+		"root":     "./public",
+		"base_url": "https://cdn.example.com/public",
+	}})
+	require.NoError(t, err, "expected no error converting disk")
+	assert.Equal(t, os.DirFS("./public"), cfg.FS, "expected root to be mounted")
+	assert.Equal(t, "https://cdn.example.com/public", cfg.BaseURL, "expected base URL to match")
+}
+
+func TestNewFromConfig(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "logo.svg"), []byte("<svg/>"), 0o644), "expected no error seeding file")
+
+	manager, err := gostorage.NewFromConfig(gostorage.Config{
+		Default: "assets",
+		Disks: map[string]gostorage.DiskConfig{
+			"assets": {Driver: DriverName, Options: map[string]string{"root": root}},
+		},
+	})
+	require.NoError(t, err, "expected no error creating manager")
+
+	exists, err := manager.Exists(context.Background(), "logo.svg")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.True(t, exists, "expected file from mounted directory")
+}
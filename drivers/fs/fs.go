@@ -0,0 +1,186 @@
+package fsdriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/internal/filetree"
+)
+
+// FSStorageConfig defines a read-only storage backed by an fs.FS.
+type FSStorageConfig struct {
+	FS      fs.FS  // files to serve, e.g. an embed.FS or os.DirFS("assets"); use fs.Sub to serve a subdirectory
+	BaseURL string // optional public URL the files are served from, e.g. "https://cdn.example.com/defaults"
+}
+
+// FSStorage is a read-only gostorage.StorageDriver over an fs.FS, for built-in assets that should
+// be addressed like uploaded files. Keys are paths in the FS. Put and Delete return
+// gostorage.ErrNotSupported.
+type FSStorage struct {
+	fsys   fs.FS
+	config FSStorageConfig
+}
+
+// NewFSStorage initializes an FSStorage over cfg.FS.
+// Returns gostorage.ErrInvalidConfig if FS is nil.
+func NewFSStorage(cfg FSStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.FS == nil {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	return &FSStorage{fsys: cfg.FS, config: cfg}, nil
+}
+
+// Capabilities reports the features supported by an fs.FS.
+// Public URLs are only available when a BaseURL is configured.
+func (s *FSStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		PublicURL: s.config.BaseURL != "",
+		List:      true,
+		RangeRead: true,
+	}
+}
+
+// Delete always fails because the storage is read-only.
+// Usage: Returns gostorage.ErrNotSupported.
+func (s *FSStorage) Delete(ctx context.Context, key string) error {
+	return gostorage.ErrNotSupported
+}
+
+// Exists checks if a regular file exists for the key.
+// Usage: Call to check whether a built-in asset is available.
+func (s *FSStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := s.Stat(ctx, key); err != nil {
+		if errors.Is(err, gostorage.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetRange returns a reader limited to the requested byte range of a file.
+// A negative length reads to the end of the file; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *FSStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.fsys.Open(key)
+	if err != nil {
+		return nil, s.openError(key, err)
+	}
+
+	offset = min(offset, info.Size)
+	if length < 0 {
+		length = info.Size - offset
+	}
+
+	if r, ok := f.(io.ReaderAt); ok {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.NewSectionReader(r, offset, length), f}, nil
+	}
+
+	// files without random access are read up to the offset
+	if _, err := io.CopyN(io.Discard, f, offset); err != nil && !errors.Is(err, io.EOF) {
+		f.Close()
+		log.Error().Err(err).Str("key", key).Msg("failed to seek file in fs")
+		return nil, gostorage.ErrInternal
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+// GetSignedURL is not supported: files of an fs.FS are meant to be public.
+// Usage: Returns gostorage.ErrNotSupported.
+func (s *FSStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", gostorage.ErrNotSupported
+}
+
+// GetURL returns BaseURL joined with the key.
+// Returns gostorage.ErrNotSupported if no BaseURL is configured.
+// Usage: Call this to link a built-in asset served by a web server or CDN.
+func (s *FSStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.BaseURL == "" {
+		return "", gostorage.ErrNotSupported
+	}
+
+	if err := gostorage.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(s.config.BaseURL, "/") + "/" + key, nil
+}
+
+// List calls fn for every file under opts.Prefix in lexical key order.
+// Usage: Used by StorageManager.List.
+func (s *FSStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	return filetree.List(ctx, opts, func(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+		name := dir
+		if name == "" {
+			name = "."
+		}
+
+		entries, err := fs.ReadDir(s.fsys, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list files in fs")
+			return nil, gostorage.ErrInternal
+		}
+
+		return entries, nil
+	}, fn)
+}
+
+// Put always fails because the storage is read-only.
+// Usage: Returns gostorage.ErrNotSupported.
+func (s *FSStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	return "", gostorage.ErrNotSupported
+}
+
+// Stat returns the size, modification time and ETag of a regular file.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *FSStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	info, err := fs.Stat(s.fsys, key)
+	if err != nil {
+		return gostorage.ObjectInfo{}, s.openError(key, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+	}
+
+	return filetree.ObjectInfo(key, info), nil
+}
+
+// openError maps an fs error to gostorage.ErrNotFound or, after logging it, gostorage.ErrInternal.
+func (s *FSStorage) openError(key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return gostorage.ErrNotFound
+	}
+
+	log.Error().Err(err).Str("key", key).Msg("failed to open file in fs")
+	return gostorage.ErrInternal
+}
@@ -0,0 +1,241 @@
+package fsdriver
+
+import (
+	"context"
+	"embed"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/defaults
+var defaults embed.FS
+
+// newTestStorage creates an FSStorage over an in-memory FS holding the given keys.
+func newTestStorage(t *testing.T, baseURL string, keys ...string) *FSStorage {
+	t.Helper()
+
+	fsys := fstest.MapFS{}
+	for _, key := range keys {
+		fsys[key] = &fstest.MapFile{Data: []byte("content of " + key), ModTime: time.Unix(1700000000, 0)}
+	}
+
+	driver, err := NewFSStorage(FSStorageConfig{FS: fsys, BaseURL: baseURL})
+	require.NoError(t, err, "expected no error creating fs storage")
+
+	return driver.(*FSStorage)
+}
+
+func TestNewFSStorage(t *testing.T) {
+	_, err := NewFSStorage(FSStorageConfig{})
+	assert.ErrorIs(t, err, gostorage.ErrInvalidConfig, "expected error for missing FS")
+
+	driver, err := NewFSStorage(FSStorageConfig{FS: fstest.MapFS{}})
+	assert.NoError(t, err, "expected no error creating fs storage")
+	assert.Implements(t, (*gostorage.Lister)(nil), driver, "expected driver to list files")
+	assert.Implements(t, (*gostorage.RangeReader)(nil), driver, "expected driver to read ranges")
+}
+
+func TestFSStorage_ReadOnly(t *testing.T) {
+	storage := newTestStorage(t, "", "avatar.png")
+	ctx := context.Background()
+
+	_, err := storage.Put(ctx, "avatar.png", nil)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected put to be rejected")
+
+	err = storage.Delete(ctx, "avatar.png")
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected delete to be rejected")
+
+	exists, err := storage.Exists(ctx, "avatar.png")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.True(t, exists, "expected file to be kept")
+}
+
+func TestFSStorage_Exists(t *testing.T) {
+	storage := newTestStorage(t, "", "images/avatar.png")
+
+	tests := []struct {
+		name        string
+		key         string
+		expected    bool
+		expectedErr error
+	}{
+		{name: "should find file", key: "images/avatar.png", expected: true},
+		{name: "should not find missing file", key: "images/cover.png"},
+		{name: "should not treat directory as file", key: "images"},
+		{name: "should reject invalid key", key: "../secret", expectedErr: gostorage.ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists, err := storage.Exists(context.Background(), tt.key)
+
+			assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			assert.Equal(t, tt.expected, exists, "expected existence to match")
+		})
+	}
+}
+
+func TestFSStorage_URLs(t *testing.T) {
+	ctx := context.Background()
+
+	storage := newTestStorage(t, "https://cdn.example.com/defaults/", "avatar.png")
+	assert.True(t, storage.Capabilities().PublicURL, "expected public URLs with base URL")
+
+	url, err := storage.GetURL(ctx, "img/avatar.png")
+	assert.NoError(t, err, "expected no error building URL")
+	assert.Equal(t, "https://cdn.example.com/defaults/img/avatar.png", url, "expected URL to match")
+
+	_, err = storage.GetSignedURL(ctx, "avatar.png", time.Minute)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected signed URLs to be unsupported")
+
+	storage = newTestStorage(t, "", "avatar.png")
+	assert.False(t, storage.Capabilities().PublicURL, "expected no public URLs without base URL")
+
+	_, err = storage.GetURL(ctx, "avatar.png")
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected URLs to be unsupported without base URL")
+}
+
+func TestFSStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every file recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt"},
+		},
+		{
+			name:     "should list direct children of a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/"},
+			expected: []string{"a/b.txt", "a/c/"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a"},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, "", keys...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing files")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestFSStorage_GetRange(t *testing.T) {
+	storage := newTestStorage(t, "", "file.txt")
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read whole file", key: "file.txt", offset: 0, length: -1, expected: "content of file.txt"},
+		{name: "should read range", key: "file.txt", offset: 11, length: 4, expected: "file"},
+		{name: "should read to end from offset", key: "file.txt", offset: 16, length: -1, expected: "txt"},
+		{name: "should read nothing past the end", key: "file.txt", offset: 100, length: 4, expected: ""},
+		{name: "should not find missing file", key: "missing.txt", length: -1, expectedErr: gostorage.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(context.Background(), tt.key, tt.offset, tt.length)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+			defer body.Close()
+
+			data, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(data), "expected range content to match")
+		})
+	}
+}
+
+func TestFSStorage_Stat(t *testing.T) {
+	storage := newTestStorage(t, "", "file.txt")
+
+	info, err := storage.Stat(context.Background(), "file.txt")
+	require.NoError(t, err, "expected no error stating file")
+	assert.Equal(t, int64(len("content of file.txt")), info.Size, "expected size to match")
+	assert.True(t, info.LastModified.Equal(time.Unix(1700000000, 0)), "expected modification time to match")
+	assert.NotEmpty(t, info.ETag, "expected ETag for file with modification time")
+
+	_, err = storage.Stat(context.Background(), "missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected error for missing file")
+}
+
+func TestFSStorage_EmbedFS(t *testing.T) {
+	sub, err := fs.Sub(defaults, "testdata/defaults")
+	require.NoError(t, err, "expected no error creating sub FS")
+
+	driver, err := NewFSStorage(FSStorageConfig{FS: sub, BaseURL: "https://example.com/static"})
+	require.NoError(t, err, "expected no error creating fs storage")
+
+	manager, err := gostorage.NewStorageManager("defaults", map[string]gostorage.StorageDriver{"defaults": driver})
+	require.NoError(t, err, "expected no error creating manager")
+
+	ctx := context.Background()
+
+	info, err := manager.Stat(ctx, "avatar.png")
+	require.NoError(t, err, "expected no error stating embedded file")
+	assert.Equal(t, int64(3), info.Size, "expected size to match")
+	assert.Empty(t, info.ETag, "expected no ETag without modification time")
+
+	body, err := manager.GetRange(ctx, "cover.jpg", 6, -1)
+	require.NoError(t, err, "expected no error reading embedded file")
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err, "expected no error reading body")
+	assert.Equal(t, "image", string(data), "expected range content to match")
+
+	url, err := manager.URL(ctx, "avatar.png", gostorage.URLOptions{})
+	assert.NoError(t, err, "expected no error building URL")
+	assert.Equal(t, "https://example.com/static/avatar.png", url, "expected URL to match")
+
+	_, err = manager.Put(ctx, "avatar.png", nil)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected embedded files to be read-only")
+}
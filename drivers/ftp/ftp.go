@@ -0,0 +1,531 @@
+package ftpdriver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"net"
+	"net/textproto"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/internal/connpool"
+	"github.com/shoraid/go-storage/internal/filetree"
+)
+
+// DefaultPoolSize is the number of connections kept open when FTPStorageConfig.PoolSize is zero.
+const DefaultPoolSize = 4
+
+// TLSMode selects how the connection to the server is encrypted.
+type TLSMode string
+
+const (
+	TLSNone     TLSMode = ""         // plain FTP
+	TLSExplicit TLSMode = "explicit" // FTPES: the connection is upgraded with AUTH TLS, usually on port 21
+	TLSImplicit TLSMode = "implicit" // FTPS: TLS from the first byte, usually on port 990
+)
+
+// FTPStorageConfig defines the configuration for storing files on an FTP server.
+// Transfers always use passive mode (EPSV, falling back to PASV).
+type FTPStorageConfig struct {
+	Addr     string // server address, e.g. "ftp.example.com:21"
+	User     string // login user
+	Password string // login password
+
+	TLS       TLSMode     // optional encryption of the control and data connections
+	TLSConfig *tls.Config // optional TLS settings, e.g. RootCAs for a private CA; ServerName defaults to the host of Addr
+
+	Root        string        // optional directory keys are stored below, relative to the login directory unless absolute
+	BaseURL     string        // optional public URL the Root directory is served from, e.g. "https://files.example.com"
+	PoolSize    int           // optional maximum number of open connections, defaults to DefaultPoolSize
+	DialTimeout time.Duration // optional timeout for connecting and logging in, defaults to 30s
+	DisableEPSV bool          // optional, use PASV only for servers behind NAT that mishandle EPSV
+}
+
+// FTPStorage is the concrete implementation of gostorage.StorageDriver for FTP servers.
+// Keys map to paths below Root, with "/" separated segments becoming directories.
+// Connections are pooled and replaced transparently when they break.
+type FTPStorage struct {
+	root   string
+	config FTPStorageConfig
+	pool   *connpool.Pool[*conn]
+}
+
+// NewFTPStorage initializes an FTPStorage. No connection is made until the first operation.
+// Returns gostorage.ErrInvalidConfig if Addr or User is missing, or TLS is not a known mode.
+func NewFTPStorage(cfg FTPStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.Addr == "" || cfg.User == "" {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	var tlsConfig *tls.Config
+	switch cfg.TLS {
+	case TLSNone:
+	case TLSExplicit, TLSImplicit:
+		tlsConfig = &tls.Config{}
+		if cfg.TLSConfig != nil {
+			tlsConfig = cfg.TLSConfig.Clone()
+		}
+		if tlsConfig.ServerName == "" {
+			host, _, err := net.SplitHostPort(cfg.Addr)
+			if err != nil {
+				log.Error().Err(err).Str("addr", cfg.Addr).Msg("invalid FTP server address")
+				return nil, gostorage.ErrInvalidConfig
+			}
+			tlsConfig.ServerName = host
+		}
+	default:
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = DefaultPoolSize
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 30 * time.Second
+	}
+
+	s := &FTPStorage{
+		root:   strings.TrimRight(cfg.Root, "/"),
+		config: cfg,
+	}
+	s.pool = newPool(cfg.PoolSize, func(ctx context.Context) (*ftp.ServerConn, error) {
+		return s.dial(ctx, tlsConfig)
+	})
+
+	return s, nil
+}
+
+// dial connects and logs in.
+func (s *FTPStorage) dial(ctx context.Context, tlsConfig *tls.Config) (*ftp.ServerConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.DialTimeout)
+	defer cancel()
+
+	opts := []ftp.DialOption{
+		ftp.DialWithContext(ctx),
+		ftp.DialWithDisabledEPSV(s.config.DisableEPSV),
+	}
+	switch s.config.TLS {
+	case TLSExplicit:
+		opts = append(opts, ftp.DialWithExplicitTLS(tlsConfig))
+	case TLSImplicit:
+		opts = append(opts, ftp.DialWithTLS(tlsConfig))
+	}
+
+	c, err := ftp.Dial(s.config.Addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Login(s.config.User, s.config.Password); err != nil {
+		c.Quit()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close closes the pooled connections. Connections in use are closed once their operation ends.
+func (s *FTPStorage) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// filePath converts a validated key into a remote path below the storage root.
+func (s *FTPStorage) filePath(key string) string {
+	if s.root == "" {
+		return key
+	}
+
+	return s.root + "/" + key
+}
+
+// do runs fn on a pooled connection. Operations without side effects pass retry to run again
+// on a new connection when the connection broke mid-call.
+func (s *FTPStorage) do(ctx context.Context, retry bool, fn func(*ftp.ServerConn) error) error {
+	for {
+		c, err := s.pool.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn(c.ServerConn)
+		release(s.pool, c, err)
+		if !retry || !lostConnection(err) {
+			return err
+		}
+		retry = false
+	}
+}
+
+// notFound reports whether the server rejected a command because the path does not exist.
+func notFound(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply) && (reply.Code == ftp.StatusFileUnavailable || reply.Code == ftp.StatusFileActionIgnored)
+}
+
+// Capabilities reports the features supported by FTP servers.
+// Public URLs are only available when a BaseURL is configured.
+func (s *FTPStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		PublicURL: s.config.BaseURL != "",
+		List:      true,
+		RangeRead: true,
+	}
+}
+
+// Delete removes a file and any parent directories left empty by its removal.
+// Deleting a file that does not exist is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *FTPStorage) Delete(ctx context.Context, key string) error {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	err := s.do(ctx, true, func(c *ftp.ServerConn) error {
+		if err := c.Delete(s.filePath(key)); err != nil && !notFound(err) {
+			return err
+		}
+
+		filetree.RemoveEmptyParents(key, func(dir string) error {
+			return c.RemoveDir(s.filePath(dir))
+		})
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to delete file from FTP server")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// Exists checks if a regular file exists for the key.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *FTPStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := s.Stat(ctx, key); err != nil {
+		if errors.Is(err, gostorage.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetRange downloads the requested byte range of a file, resuming the transfer at offset.
+// The connection stays reserved until the reader is closed.
+// A negative length reads to the end of the file; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *FTPStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset >= info.Size {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if length < 0 {
+		length = math.MaxInt64
+	}
+
+	for retry := true; ; retry = false {
+		c, err := s.pool.Get(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("key", key).Msg("failed to connect to FTP server")
+			return nil, gostorage.ErrInternal
+		}
+
+		resp, err := c.RetrFrom(s.filePath(key), uint64(offset))
+		if err != nil {
+			release(s.pool, c, err)
+			if retry && lostConnection(err) {
+				continue
+			}
+
+			if notFound(err) {
+				return nil, gostorage.ErrNotFound
+			}
+
+			log.Error().Err(err).Str("key", key).Msg("failed to download file from FTP server")
+			return nil, gostorage.ErrInternal
+		}
+
+		return &rangeReader{Reader: io.LimitReader(resp, length), resp: resp, conn: c, pool: s.pool}, nil
+	}
+}
+
+// rangeReader reads a transfer and returns its connection to the pool when closed.
+type rangeReader struct {
+	io.Reader
+	resp *ftp.Response
+	conn *conn
+	pool *connpool.Pool[*conn]
+}
+
+// Close ends the transfer. Servers may reply with an error to a transfer aborted early,
+// which leaves the connection usable and is not reported.
+func (r *rangeReader) Close() error {
+	err := r.resp.Close()
+	release(r.pool, r.conn, err)
+	if lostConnection(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetSignedURL is not supported by FTP servers.
+// Usage: Returns gostorage.ErrNotSupported.
+func (s *FTPStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", gostorage.ErrNotSupported
+}
+
+// GetURL returns BaseURL joined with the key.
+// Returns gostorage.ErrNotSupported if no BaseURL is configured.
+// Usage: Call this when Root is served by a web server.
+func (s *FTPStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.BaseURL == "" {
+		return "", gostorage.ErrNotSupported
+	}
+
+	return strings.TrimRight(s.config.BaseURL, "/") + "/" + key, nil
+}
+
+// List calls fn for every file under opts.Prefix in lexical key order.
+// Each directory is read on its own pooled connection, so fn may use the storage itself.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *FTPStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	return filetree.List(ctx, opts, func(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+		list, err := s.readDir(ctx, dir)
+		if err != nil {
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list files on FTP server")
+			return nil, gostorage.ErrInternal
+		}
+
+		entries := make([]fs.DirEntry, 0, len(list))
+		for _, e := range list {
+			if e.Name != "." && e.Name != ".." {
+				entries = append(entries, fs.FileInfoToDirEntry(entryInfo{e}))
+			}
+		}
+
+		return entries, nil
+	}, fn)
+}
+
+// readDir lists a remote directory, returning nil if it does not exist.
+func (s *FTPStorage) readDir(ctx context.Context, dir string) ([]*ftp.Entry, error) {
+	remote := s.filePath(dir)
+	if dir == "" {
+		remote = s.root
+	}
+
+	var entries []*ftp.Entry
+	err := s.do(ctx, true, func(c *ftp.ServerConn) error {
+		var err error
+		entries, err = c.List(remote)
+		return err
+	})
+	if notFound(err) {
+		return nil, nil
+	}
+
+	return entries, err
+}
+
+// Stat returns the size, modification time and ETag of a regular file.
+// It uses MLST where the server supports it and otherwise looks the file up in its directory listing.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *FTPStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	var entry *ftp.Entry
+	err := s.do(ctx, true, func(c *ftp.ServerConn) error {
+		var err error
+		entry, err = c.GetEntry(s.filePath(key))
+		return err
+	})
+
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code == ftp.StatusNotImplemented {
+		entry, err = s.lookup(ctx, key)
+	}
+
+	switch {
+	case notFound(err):
+		return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+	case err != nil:
+		log.Error().Err(err).Str("key", key).Msg("failed to stat file on FTP server")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	case entry == nil || entry.Type != ftp.EntryTypeFile:
+		return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+	}
+
+	return filetree.ObjectInfo(key, entryInfo{entry}), nil
+}
+
+// lookup finds the entry of key in the listing of its directory, returning nil if it is missing.
+func (s *FTPStorage) lookup(ctx context.Context, key string) (*ftp.Entry, error) {
+	dir, name := path.Split(key)
+
+	entries, err := s.readDir(ctx, strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// entryInfo adapts a listing entry to fs.FileInfo.
+type entryInfo struct {
+	entry *ftp.Entry
+}
+
+func (i entryInfo) Name() string       { return i.entry.Name }
+func (i entryInfo) Size() int64        { return int64(i.entry.Size) }
+func (i entryInfo) ModTime() time.Time { return i.entry.Time }
+func (i entryInfo) IsDir() bool        { return i.entry.Type == ftp.EntryTypeFolder }
+func (i entryInfo) Sys() any           { return i.entry }
+
+func (i entryInfo) Mode() fs.FileMode {
+	switch i.entry.Type {
+	case ftp.EntryTypeFolder:
+		return fs.ModeDir | 0o755
+	case ftp.EntryTypeFile:
+		return 0o644
+	default:
+		return fs.ModeSymlink | 0o777
+	}
+}
+
+// Put uploads a file atomically: the content goes to a temporary file that is renamed into place,
+// so concurrent readers never observe a partially written file. Missing parent directories
+// are created first.
+// Returns the public URL if BaseURL is configured, otherwise an empty string.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *FTPStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	p := s.filePath(key)
+	tmp := path.Join(path.Dir(p), filetree.TempName())
+
+	body := &countingReader{r: file}
+	upload := func(c *ftp.ServerConn) error {
+		if err := s.mkdirAll(c, path.Dir(key)); err != nil {
+			return err
+		}
+
+		if err := c.Stor(tmp, body); err != nil {
+			if !lostConnection(err) {
+				c.Delete(tmp)
+			}
+			return err
+		}
+
+		// some servers refuse to rename over an existing file
+		if err := c.Rename(tmp, p); err != nil {
+			if lostConnection(err) {
+				return err
+			}
+
+			c.Delete(p)
+			if err := c.Rename(tmp, p); err != nil {
+				c.Delete(tmp)
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// The body can only be read once: a broken connection is retried only if the server
+	// dropped it before the transfer started, e.g. after an idle timeout.
+	err := s.do(ctx, false, upload)
+	if lostConnection(err) && body.n == 0 && body.err == nil {
+		err = s.do(ctx, false, upload)
+	}
+	if err != nil {
+		// A broken connection, or a failed read of the body which aborts the transfer the
+		// same way, leaves the temporary file behind; remove it on a fresh connection.
+		if lostConnection(err) {
+			s.removeTemp(ctx, tmp)
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to upload file to FTP server")
+		return "", gostorage.ErrInternal
+	}
+
+	if s.config.BaseURL == "" {
+		return "", nil
+	}
+
+	return s.GetURL(ctx, key)
+}
+
+// removeTemp deletes the temporary file of a failed upload, even if ctx is already cancelled.
+func (s *FTPStorage) removeTemp(ctx context.Context, tmp string) {
+	err := s.do(context.WithoutCancel(ctx), true, func(c *ftp.ServerConn) error {
+		return c.Delete(tmp)
+	})
+	if err != nil && !notFound(err) {
+		log.Warn().Err(err).Str("file", tmp).Msg("failed to remove temporary file from FTP server")
+	}
+}
+
+// mkdirAll creates dir (a key prefix, "." for the root) and its missing parents below Root.
+// Errors creating a directory are ignored as it usually exists already; a real failure
+// surfaces when the file is stored.
+func (s *FTPStorage) mkdirAll(c *ftp.ServerConn, dir string) error {
+	if dir == "." {
+		return nil
+	}
+
+	var current string
+	for _, segment := range strings.Split(dir, "/") {
+		current = path.Join(current, segment)
+		if err := c.MakeDir(s.filePath(current)); lostConnection(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// countingReader counts the bytes read from r and records its first read error.
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,474 @@
+package ftpdriver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goftp.io/server/v2"
+	"goftp.io/server/v2/driver/file"
+	"golang.org/x/sync/errgroup"
+)
+
+// testServer is an in-process FTP server for user "partner" with password "secret".
+type testServer struct {
+	addr string
+	root string
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	accepted int
+}
+
+// trackingListener records accepted connections so tests can drop them.
+type trackingListener struct {
+	net.Listener
+	srv *testServer
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	nc, err := l.Listener.Accept()
+	if err == nil {
+		l.srv.mu.Lock()
+		l.srv.conns = append(l.srv.conns, nc)
+		l.srv.accepted++
+		l.srv.mu.Unlock()
+	}
+
+	return nc, err
+}
+
+func newServerOptions(t *testing.T, root string) *server.Options {
+	t.Helper()
+
+	driver, err := file.NewDriver(root)
+	require.NoError(t, err, "expected no error creating FTP file driver")
+
+	return &server.Options{
+		Driver:   driver,
+		Auth:     &server.SimpleAuth{Name: "partner", Password: "secret"},
+		Perm:     server.NewSimplePerm("partner", "partner"),
+		Hostname: "127.0.0.1",
+		PublicIP: "127.0.0.1",
+		Logger:   &server.DiscardLogger{},
+	}
+}
+
+// newTestServer starts a plain FTP server on a random port.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	srv := &testServer{root: t.TempDir()}
+
+	ftpServer, err := server.NewServer(newServerOptions(t, srv.root))
+	require.NoError(t, err, "expected no error creating FTP server")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "expected no error listening")
+	srv.addr = ln.Addr().String()
+
+	go ftpServer.Serve(&trackingListener{Listener: ln, srv: srv})
+	t.Cleanup(func() {
+		ftpServer.Shutdown()
+		srv.drop()
+	})
+
+	return srv
+}
+
+// drop closes every open control connection, as a server restart would.
+func (s *testServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, nc := range s.conns {
+		nc.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) acceptedConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accepted
+}
+
+// newTestStorage creates an FTPStorage against srv, seeded with the given keys.
+func newTestStorage(t *testing.T, srv *testServer, keys ...string) *FTPStorage {
+	t.Helper()
+
+	driver, err := NewFTPStorage(FTPStorageConfig{
+		Addr:     srv.addr,
+		User:     "partner",
+		Password: "secret",
+		PoolSize: 2,
+	})
+	require.NoError(t, err, "expected no error creating FTP storage")
+
+	storage := driver.(*FTPStorage)
+	t.Cleanup(func() { storage.Close() })
+
+	for _, key := range keys {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("content of "+key))
+		require.NoError(t, err, "expected no error seeding file")
+	}
+
+	return storage
+}
+
+// newCertificate returns a self-signed certificate for 127.0.0.1 and a pool trusting it.
+func newCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "expected no error generating key")
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ftp test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err, "expected no error creating certificate")
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err, "expected no error parsing certificate")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestNewFTPStorage(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         FTPStorageConfig
+		expectedErr error
+	}{
+		{name: "should create plain storage", cfg: FTPStorageConfig{Addr: "localhost:21", User: "partner"}},
+		{name: "should create explicit TLS storage", cfg: FTPStorageConfig{Addr: "localhost:21", User: "partner", TLS: TLSExplicit}},
+		{name: "should reject missing address", cfg: FTPStorageConfig{User: "partner"}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject missing user", cfg: FTPStorageConfig{Addr: "localhost:21"}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject unknown TLS mode", cfg: FTPStorageConfig{Addr: "localhost:21", User: "partner", TLS: "always"}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject TLS without host", cfg: FTPStorageConfig{Addr: "localhost", User: "partner", TLS: TLSImplicit}, expectedErr: gostorage.ErrInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, err := NewFTPStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Implements(t, (*gostorage.Lister)(nil), driver, "expected driver to list files")
+			assert.Implements(t, (*gostorage.RangeReader)(nil), driver, "expected driver to read ranges")
+		})
+	}
+}
+
+func TestFTPStorage_PutAndDelete(t *testing.T) {
+	srv := newTestServer(t)
+	storage := newTestStorage(t, srv)
+	ctx := context.Background()
+
+	_, err := storage.Put(ctx, "partners/acme/invoice.csv", strings.NewReader("id,total\n1,42\n"))
+	require.NoError(t, err, "expected no error uploading into nested directories")
+
+	data, err := os.ReadFile(filepath.Join(srv.root, "partners", "acme", "invoice.csv"))
+	require.NoError(t, err, "expected file on server")
+	assert.Equal(t, "id,total\n1,42\n", string(data), "expected uploaded content")
+
+	_, err = storage.Put(ctx, "partners/acme/invoice.csv", strings.NewReader("replaced"))
+	require.NoError(t, err, "expected no error overwriting file")
+
+	data, err = os.ReadFile(filepath.Join(srv.root, "partners", "acme", "invoice.csv"))
+	require.NoError(t, err, "expected file on server")
+	assert.Equal(t, "replaced", string(data), "expected overwritten content")
+
+	require.NoError(t, storage.Delete(ctx, "partners/acme/invoice.csv"), "expected no error deleting file")
+	assert.NoError(t, storage.Delete(ctx, "partners/acme/invoice.csv"), "expected no error deleting missing file")
+	assert.NoDirExists(t, filepath.Join(srv.root, "partners"), "expected empty directories to be removed")
+
+	_, err = storage.Put(ctx, "../escape.txt", strings.NewReader("x"))
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid key to be rejected")
+}
+
+// failingReader returns some data and then fails, like a client disconnecting mid-upload.
+type failingReader struct {
+	sent bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("client disconnected")
+	}
+
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestFTPStorage_PutBodyError(t *testing.T) {
+	srv := newTestServer(t)
+	storage := newTestStorage(t, srv)
+	ctx := context.Background()
+
+	_, err := storage.Put(ctx, "uploads/broken.bin", &failingReader{})
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected a failed body read to fail the upload")
+
+	entries, err := os.ReadDir(filepath.Join(srv.root, "uploads"))
+	require.NoError(t, err, "expected no error reading the upload directory")
+	assert.Empty(t, entries, "expected the temporary file to be removed")
+
+	_, err = storage.Put(ctx, "uploads/ok.txt", strings.NewReader("ok"))
+	assert.NoError(t, err, "expected the storage to keep working")
+}
+
+func TestFTPStorage_Root(t *testing.T) {
+	srv := newTestServer(t)
+	require.NoError(t, os.Mkdir(filepath.Join(srv.root, "outbound"), 0o755), "expected no error creating root")
+
+	driver, err := NewFTPStorage(FTPStorageConfig{Addr: srv.addr, User: "partner", Password: "secret", Root: "/outbound/"})
+	require.NoError(t, err, "expected no error creating FTP storage")
+	storage := driver.(*FTPStorage)
+	defer storage.Close()
+
+	_, err = storage.Put(context.Background(), "daily/report.csv", strings.NewReader("ok"))
+	require.NoError(t, err, "expected no error uploading")
+	assert.FileExists(t, filepath.Join(srv.root, "outbound", "daily", "report.csv"), "expected file below root")
+
+	var keys []string
+	err = storage.List(context.Background(), gostorage.ListOptions{Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	assert.NoError(t, err, "expected no error listing")
+	assert.Equal(t, []string{"daily/report.csv"}, keys, "expected keys relative to root")
+}
+
+func TestFTPStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every file recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a"},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, newTestServer(t), keys...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing files")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestFTPStorage_GetRange(t *testing.T) {
+	storage := newTestStorage(t, newTestServer(t), "file.txt")
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read whole file", key: "file.txt", offset: 0, length: -1, expected: "content of file.txt"},
+		{name: "should read range", key: "file.txt", offset: 11, length: 4, expected: "file"},
+		{name: "should read to end from offset", key: "file.txt", offset: 16, length: -1, expected: "txt"},
+		{name: "should read nothing past the end", key: "file.txt", offset: 100, length: 4, expected: ""},
+		{name: "should not find missing file", key: "missing.txt", length: -1, expectedErr: gostorage.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(context.Background(), tt.key, tt.offset, tt.length)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+
+			data, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(data), "expected range content to match")
+			assert.NoError(t, body.Close(), "expected no error closing body")
+		})
+	}
+
+	exists, err := storage.Exists(context.Background(), "file.txt")
+	assert.NoError(t, err, "expected connections to stay usable after partial reads")
+	assert.True(t, exists, "expected file to exist")
+}
+
+func TestFTPStorage_Stat(t *testing.T) {
+	storage := newTestStorage(t, newTestServer(t), "dir/file.txt")
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, "dir/file.txt")
+	require.NoError(t, err, "expected no error stating file")
+	assert.Equal(t, int64(len("content of dir/file.txt")), info.Size, "expected size to match")
+	assert.NotEmpty(t, info.ETag, "expected ETag")
+	assert.False(t, info.LastModified.IsZero(), "expected modification time")
+
+	_, err = storage.Stat(ctx, "dir")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected directory not to be a file")
+
+	_, err = storage.Stat(ctx, "missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected error for missing file")
+}
+
+func TestFTPStorage_ExplicitTLS(t *testing.T) {
+	cert, roots := newCertificate(t)
+	root := t.TempDir()
+
+	// The server only enables TLS when it opens its own listener, so reserve a free port first.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "expected no error reserving port")
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	opts := newServerOptions(t, root)
+	opts.Port = port
+	opts.TLS = true
+	opts.ExplicitFTPS = true
+	opts.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	ftpServer, err := server.NewServer(opts)
+	require.NoError(t, err, "expected no error creating FTP server")
+	go ftpServer.ListenAndServe()
+	t.Cleanup(func() { ftpServer.Shutdown() })
+
+	addr := (&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}).String()
+	require.Eventually(t, func() bool {
+		nc, err := net.Dial("tcp", addr)
+		if err == nil {
+			nc.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "expected server to listen")
+
+	t.Run("should transfer over TLS", func(t *testing.T) {
+		driver, err := NewFTPStorage(FTPStorageConfig{
+			Addr:      addr,
+			User:      "partner",
+			Password:  "secret",
+			TLS:       TLSExplicit,
+			TLSConfig: &tls.Config{RootCAs: roots},
+		})
+		require.NoError(t, err, "expected no error creating FTP storage")
+		defer driver.(*FTPStorage).Close()
+
+		_, err = driver.Put(context.Background(), "secure/report.csv", strings.NewReader("encrypted"))
+		require.NoError(t, err, "expected no error uploading over TLS")
+
+		body, err := driver.(*FTPStorage).GetRange(context.Background(), "secure/report.csv", 0, -1)
+		require.NoError(t, err, "expected no error downloading over TLS")
+		data, _ := io.ReadAll(body)
+		body.Close()
+		assert.Equal(t, "encrypted", string(data), "expected downloaded content")
+	})
+
+	t.Run("should reject untrusted certificate", func(t *testing.T) {
+		driver, err := NewFTPStorage(FTPStorageConfig{Addr: addr, User: "partner", Password: "secret", TLS: TLSExplicit})
+		require.NoError(t, err, "expected no error creating FTP storage")
+		defer driver.(*FTPStorage).Close()
+
+		_, err = driver.Put(context.Background(), "leak.csv", strings.NewReader("plain"))
+		assert.ErrorIs(t, err, gostorage.ErrInternal, "expected certificate verification to fail")
+		assert.NoFileExists(t, filepath.Join(root, "leak.csv"), "expected nothing uploaded")
+	})
+}
+
+func TestFTPStorage_Pool(t *testing.T) {
+	srv := newTestServer(t)
+	storage := newTestStorage(t, srv, "file.txt")
+
+	var g errgroup.Group
+	for range 20 {
+		g.Go(func() error {
+			_, err := storage.Stat(context.Background(), "file.txt")
+			return err
+		})
+	}
+
+	require.NoError(t, g.Wait(), "expected no error from concurrent operations")
+	assert.LessOrEqual(t, srv.acceptedConns(), 2, "expected connections to be reused up to the pool size")
+}
+
+func TestFTPStorage_Reconnect(t *testing.T) {
+	srv := newTestServer(t)
+	storage := newTestStorage(t, srv, "file.txt")
+	ctx := context.Background()
+
+	srv.drop()
+
+	exists, err := storage.Exists(ctx, "file.txt")
+	assert.NoError(t, err, "expected no error after the server dropped the connection")
+	assert.True(t, exists, "expected file to exist")
+
+	_, err = storage.Put(ctx, "after.txt", strings.NewReader("reconnected"))
+	assert.NoError(t, err, "expected no error uploading after reconnecting")
+	assert.Greater(t, srv.acceptedConns(), 1, "expected a new connection")
+}
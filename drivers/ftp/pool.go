@@ -0,0 +1,56 @@
+package ftpdriver
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/shoraid/go-storage/internal/connpool"
+)
+
+// idleCheck is how long a connection may sit idle before it is probed with NOOP on reuse;
+// servers commonly drop idle control connections after a few minutes.
+const idleCheck = 15 * time.Second
+
+// conn is a pooled FTP control connection.
+type conn struct {
+	*ftp.ServerConn
+	lastUsed time.Time
+}
+
+// newPool returns a pool of up to size logged in connections opened by dial.
+// Connections idle for longer than idleCheck are probed with NOOP before they are reused.
+func newPool(size int, dial func(ctx context.Context) (*ftp.ServerConn, error)) *connpool.Pool[*conn] {
+	return connpool.New(size,
+		func(ctx context.Context) (*conn, error) {
+			sc, err := dial(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return &conn{ServerConn: sc}, nil
+		},
+		func(c *conn) bool { return time.Since(c.lastUsed) < idleCheck || c.NoOp() == nil },
+		func(c *conn) { c.Quit() },
+	)
+}
+
+// release returns a connection to the pool after an operation that ended with err,
+// closing it instead if err shows the connection broke.
+func release(p *connpool.Pool[*conn], c *conn, err error) {
+	if lostConnection(err) {
+		p.Discard(c)
+		return
+	}
+
+	c.lastUsed = time.Now()
+	p.Put(c)
+}
+
+// lostConnection reports whether err means the connection broke rather than the server
+// rejecting a command: every server reply arrives as a *textproto.Error.
+func lostConnection(err error) bool {
+	var reply *textproto.Error
+	return err != nil && !errors.As(err, &reply)
+}
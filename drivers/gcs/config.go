@@ -0,0 +1,113 @@
+package gcsdriver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DriverName is the name the Google Cloud Storage driver is registered under for gostorage.NewFromConfig.
+const DriverName = "gcs"
+
+func init() {
+	gostorage.RegisterDriver(DriverName, openFromConfig)
+	gostorage.RegisterURLScheme("gs", parseURL)
+	gostorage.RegisterURLScheme("gcs", parseURL)
+}
+
+// parseURL converts a Google Cloud Storage DSN into disk options:
+//
+//	gs://media-bucket?credentials_file=/etc/gcs/key.json&visibility=private&expiry=15m
+//	gs://media-bucket?endpoint=http://localhost:4443&without_authentication=true
+//
+// The host is the bucket. Any query parameter is passed through as an option, e.g. chunk_size.
+func parseURL(u *url.URL) (gostorage.DiskConfig, error) {
+	options := make(map[string]string)
+
+	for key, values := range u.Query() {
+		if key == "expiry" {
+			key = "default_expiry"
+		}
+		options[key] = values[len(values)-1]
+	}
+
+	if u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return gostorage.DiskConfig{}, &gostorage.ConfigError{Field: "dsn", Reason: "must name the bucket as host and have no path"}
+	}
+	options["bucket"] = u.Host
+
+	return gostorage.DiskConfig{Driver: DriverName, Options: options}, nil
+}
+
+// ConfigFromDisk converts generic disk options into a GCSStorageConfig.
+// Recognized options: bucket, credentials (service account key JSON), credentials_file, endpoint,
+// visibility ("public" or "private"), default_expiry (e.g. "15m"), chunk_size (bytes) and
+// without_authentication. Credentials are required unless without_authentication is true.
+// Returns a *gostorage.ConfigError naming the first invalid option.
+func ConfigFromDisk(disk gostorage.DiskConfig) (GCSStorageConfig, error) {
+	var cfg GCSStorageConfig
+	var err error
+
+	if cfg.Bucket, err = disk.Require("bucket"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.WithoutAuthentication, err = disk.Bool("without_authentication", false); err != nil {
+		return cfg, err
+	}
+
+	cfg.CredentialsJSON = []byte(disk.String("credentials"))
+	if file := disk.String("credentials_file"); file != "" {
+		if cfg.CredentialsJSON, err = os.ReadFile(file); err != nil {
+			log.Error().Err(err).Str("file", file).Msg("failed to read GCS credentials")
+			return cfg, &gostorage.ConfigError{Field: "credentials_file", Reason: "cannot be read"}
+		}
+	}
+
+	if len(cfg.CredentialsJSON) == 0 {
+		cfg.CredentialsJSON = nil
+		if !cfg.WithoutAuthentication {
+			return cfg, &gostorage.ConfigError{Field: "credentials", Reason: "or credentials_file is required unless without_authentication is true"}
+		}
+	}
+
+	if cfg.DefaultExpiry, err = disk.Duration("default_expiry", 15*time.Minute); err != nil {
+		return cfg, err
+	}
+
+	if cfg.ChunkSize, err = disk.Int("chunk_size", DefaultChunkSize); err != nil {
+		return cfg, err
+	}
+
+	if cfg.ChunkSize <= 0 || cfg.ChunkSize%chunkAlign != 0 {
+		return cfg, &gostorage.ConfigError{Field: "chunk_size", Reason: "must be a positive multiple of 262144 (256 KiB)"}
+	}
+
+	cfg.Endpoint = disk.String("endpoint")
+
+	switch v := Visibility(disk.String("visibility")); v {
+	case "":
+		cfg.Visibility = VisibilityPrivate
+	case VisibilityPrivate, VisibilityPublic:
+		cfg.Visibility = v
+	default:
+		return cfg, &gostorage.ConfigError{Field: "visibility", Reason: fmt.Sprintf("must be %q or %q, got %q", VisibilityPublic, VisibilityPrivate, v)}
+	}
+
+	return cfg, nil
+}
+
+// openFromConfig is the gostorage.DriverOpener for the Google Cloud Storage driver.
+func openFromConfig(ctx context.Context, disk gostorage.DiskConfig) (gostorage.StorageDriver, error) {
+	cfg, err := ConfigFromDisk(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGCSStorage(cfg)
+}
@@ -0,0 +1,158 @@
+package gcsdriver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		dsn           string
+		expected      map[string]string
+		expectedField string
+	}{
+		{
+			name: "should parse bucket and options",
+			dsn:  "gs://media?credentials_file=/etc/gcs/key.json&visibility=public&expiry=1h",
+			expected: map[string]string{
+				"bucket":           "media",
+				"credentials_file": "/etc/gcs/key.json",
+				"visibility":       "public",
+				"default_expiry":   "1h",
+			},
+		},
+		{
+			name: "should accept gcs scheme for the emulator",
+			dsn:  "gcs://media?endpoint=http://localhost:4443&without_authentication=true",
+			expected: map[string]string{
+				"bucket":                 "media",
+				"endpoint":               "http://localhost:4443",
+				"without_authentication": "true",
+			},
+		},
+		{
+			name:          "should require bucket",
+			dsn:           "gs:///media",
+			expectedField: "dsn",
+		},
+		{
+			name:          "should reject object path",
+			dsn:           "gs://media/avatars",
+			expectedField: "dsn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := gostorage.ParseDSN(tt.dsn)
+
+			if tt.expectedField != "" {
+				var cfgErr *gostorage.ConfigError
+				require.ErrorAs(t, err, &cfgErr, "expected config error")
+				assert.Equal(t, tt.expectedField, cfgErr.Field, "expected field to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error parsing DSN")
+			assert.Equal(t, DriverName, disk.Driver, "expected driver to match")
+			assert.Equal(t, tt.expected, disk.Options, "expected options to match")
+		})
+	}
+}
+
+func TestConfigFromDisk(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(keyFile, []byte(`{"type":"service_account"}`), 0o600), "expected no error writing key file")
+
+	tests := []struct {
+		name          string
+		options       map[string]string
+		expected      GCSStorageConfig
+		expectedField string
+	}{
+		{
+			name:    "should apply defaults",
+			options: map[string]string{"bucket": "media", "credentials": `{"type":"service_account"}`},
+			expected: GCSStorageConfig{
+				Bucket:          "media",
+				CredentialsJSON: []byte(`{"type":"service_account"}`),
+				Visibility:      VisibilityPrivate,
+				DefaultExpiry:   15 * time.Minute,
+				ChunkSize:       DefaultChunkSize,
+			},
+		},
+		{
+			name: "should parse every option",
+			options: map[string]string{
+				"bucket":           "media",
+				"credentials_file": keyFile,
+				"endpoint":         "http://localhost:4443",
+				"visibility":       "public",
+				"default_expiry":   "1h",
+				"chunk_size":       "524288",
+			},
+			expected: GCSStorageConfig{
+				Bucket:          "media",
+				CredentialsJSON: []byte(`{"type":"service_account"}`),
+				Endpoint:        "http://localhost:4443",
+				Visibility:      VisibilityPublic,
+				DefaultExpiry:   time.Hour,
+				ChunkSize:       512 << 10,
+			},
+		},
+		{
+			name:    "should allow missing credentials without authentication",
+			options: map[string]string{"bucket": "media", "without_authentication": "true"},
+			expected: GCSStorageConfig{
+				Bucket:                "media",
+				Visibility:            VisibilityPrivate,
+				DefaultExpiry:         15 * time.Minute,
+				ChunkSize:             DefaultChunkSize,
+				WithoutAuthentication: true,
+			},
+		},
+		{
+			name:          "should require credentials",
+			options:       map[string]string{"bucket": "media"},
+			expectedField: "credentials",
+		},
+		{
+			name:          "should reject unreadable credentials file",
+			options:       map[string]string{"bucket": "media", "credentials_file": "/missing/key.json"},
+			expectedField: "credentials_file",
+		},
+		{
+			name:          "should reject unaligned chunk size",
+			options:       map[string]string{"bucket": "media", "without_authentication": "true", "chunk_size": "1000000"},
+			expectedField: "chunk_size",
+		},
+		{
+			name:          "should reject unknown visibility",
+			options:       map[string]string{"bucket": "media", "without_authentication": "true", "visibility": "allUsers"},
+			expectedField: "visibility",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ConfigFromDisk(gostorage.DiskConfig{Driver: DriverName, Options: tt.options})
+
+			if tt.expectedField != "" {
+				var cfgErr *gostorage.ConfigError
+				require.ErrorAs(t, err, &cfgErr, "expected config error")
+				assert.Equal(t, tt.expectedField, cfgErr.Field, "expected field to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expected, cfg, "expected config to match")
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package gcsdriver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBucket = "media"
+	testEmail  = "uploader@project.iam.gserviceaccount.com"
+	testToken  = "ya29.test-token"
+	// listPageSize is small so listings exercise paging.
+	listPageSize = 2
+)
+
+// testKey is generated once, RSA key generation is slow.
+var testKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// credentialsFor returns a service account key file whose token endpoint is tokenURI.
+func credentialsFor(t *testing.T, tokenURI string) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(testKey())
+	require.NoError(t, err, "expected no error encoding key")
+
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   testEmail,
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      tokenURI,
+	})
+	require.NoError(t, err, "expected no error encoding credentials")
+
+	return data
+}
+
+type fakeObject struct {
+	object
+	data []byte
+}
+
+type fakeUpload struct {
+	meta object
+	data []byte
+}
+
+// fakeGCS is an in-memory subset of the GCS JSON API for one bucket, plus the OAuth token
+// endpoint and path-style downloads through public or V4 signed URLs.
+type fakeGCS struct {
+	*httptest.Server
+	public    bool // allow anonymous downloads
+	anonymous bool // accept API requests without a token, like fake-gcs-server
+
+	mu            sync.Mutex
+	objects       map[string]*fakeObject
+	uploads       map[string]*fakeUpload
+	tokenRequests int
+	chunkRequests int
+	failChunks    int // chunk requests that persist half their data, then fail with 503
+	generation    int
+	sessions      int
+}
+
+func newFakeGCS(t *testing.T) *fakeGCS {
+	t.Helper()
+
+	f := &fakeGCS{
+		objects: make(map[string]*fakeObject),
+		uploads: make(map[string]*fakeUpload),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeGCS) stored(name string) (*fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[name]
+	return obj, ok
+}
+
+func (f *fakeGCS) counts() (tokens, chunks int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.tokenRequests, f.chunkRequests
+}
+
+func (f *fakeGCS) failNextChunks(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failChunks = n
+}
+
+func (f *fakeGCS) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/token":
+		f.token(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/"+testBucket+"/"):
+		f.download(w, r, strings.TrimPrefix(r.URL.Path, "/"+testBucket+"/"))
+		return
+	}
+
+	if !f.anonymous && r.Header.Get("Authorization") != "Bearer "+testToken {
+		writeError(w, http.StatusUnauthorized, "Invalid Credentials")
+		return
+	}
+
+	objects := "/storage/v1/b/" + testBucket + "/o"
+	uploads := "/upload/storage/v1/b/" + testBucket + "/o"
+
+	switch {
+	case r.URL.Path == objects && r.Method == http.MethodGet:
+		f.list(w, r)
+	case strings.HasPrefix(r.URL.Path, objects+"/"):
+		name := strings.TrimPrefix(r.URL.Path, objects+"/")
+		obj, ok := f.objects[name]
+		switch {
+		case !ok:
+			writeError(w, http.StatusNotFound, "No such object: "+testBucket+"/"+name)
+		case r.Method == http.MethodDelete:
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Query().Get("alt") == "media":
+			serveRange(w, r, obj)
+		case r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, obj.object)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case r.URL.Path == uploads && r.Method == http.MethodPost && r.URL.Query().Get("uploadType") == "resumable":
+		var meta object
+		if err := json.NewDecoder(r.Body).Decode(&meta); err != nil || meta.Name != r.URL.Query().Get("name") {
+			writeError(w, http.StatusBadRequest, "invalid upload metadata")
+			return
+		}
+
+		f.sessions++
+		id := strconv.Itoa(f.sessions)
+		f.uploads[id] = &fakeUpload{meta: meta}
+		w.Header().Set("Location", f.URL+uploads+"?uploadType=resumable&upload_id="+id)
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == uploads && r.Method == http.MethodPut:
+		f.chunk(w, r)
+	case r.URL.Path == uploads && r.Method == http.MethodDelete:
+		delete(f.uploads, r.URL.Query().Get("upload_id"))
+		w.WriteHeader(499)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// token serves the OAuth 2.0 JWT bearer grant, checking the assertion's signature and claims.
+func (f *fakeGCS) token(w http.ResponseWriter, r *http.Request) {
+	f.tokenRequests++
+
+	parts := strings.Split(r.FormValue("assertion"), ".")
+	if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || len(parts) != 3 {
+		writeError(w, http.StatusBadRequest, "invalid grant")
+		return
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(&testKey().PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		writeError(w, http.StatusBadRequest, "invalid JWT signature")
+		return
+	}
+
+	var claims struct {
+		Iss   string `json:"iss"`
+		Scope string `json:"scope"`
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if json.Unmarshal(payload, &claims) != nil || claims.Iss != testEmail || claims.Scope != scopeReadWrite {
+		writeError(w, http.StatusBadRequest, "invalid JWT claims")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"access_token": testToken, "token_type": "Bearer", "expires_in": 3600})
+}
+
+// chunk serves a PUT to a resumable upload session.
+func (f *fakeGCS) chunk(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("upload_id")
+	upload, ok := f.uploads[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such upload")
+		return
+	}
+
+	spec, _ := strings.CutPrefix(r.Header.Get("Content-Range"), "bytes ")
+	rng, totalSpec, _ := strings.Cut(spec, "/")
+	total := int64(-1)
+	if totalSpec != "*" {
+		total, _ = strconv.ParseInt(totalSpec, 10, 64)
+	}
+
+	data, _ := io.ReadAll(r.Body)
+	if rng != "*" {
+		f.chunkRequests++
+
+		first, _, _ := strings.Cut(rng, "-")
+		start, _ := strconv.ParseInt(first, 10, 64)
+		if start != int64(len(upload.data)) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("chunk starts at %d, expected %d", start, len(upload.data)))
+			return
+		}
+
+		if f.failChunks > 0 {
+			f.failChunks--
+			upload.data = append(upload.data, data[:len(data)/2]...)
+			writeError(w, http.StatusServiceUnavailable, "backend error")
+			return
+		}
+		upload.data = append(upload.data, data...)
+	}
+
+	if total >= 0 && int64(len(upload.data)) == total {
+		delete(f.uploads, id)
+		f.generation++
+
+		obj := &fakeObject{object: upload.meta, data: upload.data}
+		obj.Size = total
+		obj.Updated = time.Now().UTC().Truncate(time.Millisecond)
+		obj.Etag = fmt.Sprintf("CL%dEAE=", f.generation)
+		f.objects[obj.Name] = obj
+
+		writeJSON(w, http.StatusOK, obj.object)
+		return
+	}
+
+	if len(upload.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(upload.data)-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+// download serves path-style object URLs, anonymously for public buckets or with a V4 signature.
+func (f *fakeGCS) download(w http.ResponseWriter, r *http.Request, name string) {
+	if !f.public && !validSignature(r) {
+		writeError(w, http.StatusForbidden, "Access denied.")
+		return
+	}
+
+	obj, ok := f.objects[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such object")
+		return
+	}
+
+	serveRange(w, r, obj)
+}
+
+// validSignature checks an unexpired GOOG4-RSA-SHA256 signature of a GET request.
+func validSignature(r *http.Request) bool {
+	query := r.URL.Query()
+	signature, err := hex.DecodeString(query.Get("X-Goog-Signature"))
+	if err != nil || len(signature) == 0 || r.Method != http.MethodGet {
+		return false
+	}
+
+	date, err := time.Parse("20060102T150405Z", query.Get("X-Goog-Date"))
+	expires, _ := strconv.Atoi(query.Get("X-Goog-Expires"))
+	if err != nil || time.Now().After(date.Add(time.Duration(expires)*time.Second)) {
+		return false
+	}
+
+	query.Del("X-Goog-Signature")
+	scope := strings.SplitN(query.Get("X-Goog-Credential"), "/", 2)[1]
+	canonical := strings.Join([]string{"GET", r.URL.EscapedPath(), query.Encode(), "host:" + r.Host + "\n", "host", "UNSIGNED-PAYLOAD"}, "\n")
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := "GOOG4-RSA-SHA256\n" + query.Get("X-Goog-Date") + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	return rsa.VerifyPKCS1v15(&testKey().PublicKey, crypto.SHA256, digest[:], signature) == nil
+}
+
+// list serves objects.list, collapsing names at the delimiter into prefixes and
+// returning listPageSize entries per page.
+func (f *fakeGCS) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+
+	var entries []string
+	isPrefix := make(map[string]bool)
+	for name := range f.objects {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				dir := name[:len(prefix)+i+len(delimiter)]
+				if !isPrefix[dir] {
+					isPrefix[dir] = true
+					entries = append(entries, dir)
+				}
+				continue
+			}
+		}
+		entries = append(entries, name)
+	}
+	slices.Sort(entries)
+
+	if token := query.Get("pageToken"); token != "" {
+		i, _ := slices.BinarySearch(entries, token)
+		entries = entries[i:]
+	}
+
+	page := struct {
+		Items         []object `json:"items,omitempty"`
+		Prefixes      []string `json:"prefixes,omitempty"`
+		NextPageToken string   `json:"nextPageToken,omitempty"`
+	}{}
+	if len(entries) > listPageSize {
+		page.NextPageToken = entries[listPageSize]
+		entries = entries[:listPageSize]
+	}
+
+	for _, name := range entries {
+		if isPrefix[name] {
+			page.Prefixes = append(page.Prefixes, name)
+		} else {
+			page.Items = append(page.Items, f.objects[name].object)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// serveRange writes the object's content, honoring a single "bytes=" Range header.
+func serveRange(w http.ResponseWriter, r *http.Request, obj *fakeObject) {
+	size := int64(len(obj.data))
+	start, end := int64(0), size-1
+	status := http.StatusOK
+
+	if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+		first, last, _ := strings.Cut(spec, "-")
+		start, _ = strconv.ParseInt(first, 10, 64)
+		if last != "" {
+			end, _ = strconv.ParseInt(last, 10, 64)
+		}
+		if start >= size {
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "The requested range cannot be satisfied.")
+			return
+		}
+		end = min(end, size-1)
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.WriteHeader(status)
+	w.Write(obj.data[start : end+1])
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"code": status, "message": message}})
+}
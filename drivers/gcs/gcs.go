@@ -0,0 +1,512 @@
+package gcsdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// DefaultEndpoint serves both the JSON API and path-style object URLs.
+	DefaultEndpoint = "https://storage.googleapis.com"
+
+	// DefaultChunkSize is the size of the chunks resumable uploads send, like the official client.
+	DefaultChunkSize = 16 << 20
+
+	// chunkAlign is the granularity GCS requires for every chunk of a resumable upload but the last.
+	chunkAlign = 256 << 10
+
+	// scopeReadWrite is the OAuth 2.0 scope requested for the service account.
+	scopeReadWrite = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// Visibility is the bucket's default visibility; see gostorage.Visibility.
+type Visibility = gostorage.Visibility
+
+const (
+	VisibilityPrivate = gostorage.VisibilityPrivate // Objects are private, need signed URL to access
+	VisibilityPublic  = gostorage.VisibilityPublic  // Objects are readable by allUsers via direct URL
+)
+
+// GCSStorageConfig defines the configuration needed to connect to a Google Cloud Storage bucket
+// through the native JSON API, authenticating as a service account.
+// Point Endpoint at fake-gcs-server (e.g. http://localhost:4443) for local development.
+type GCSStorageConfig struct {
+	Bucket                string        // bucket name where files will be stored
+	CredentialsJSON       []byte        // service account key file, used for OAuth tokens and URL signing
+	Endpoint              string        // optional API endpoint; defaults to DefaultEndpoint
+	Visibility            Visibility    // public if the bucket grants allUsers read access
+	DefaultExpiry         time.Duration // default expiry duration for signed URLs (at most 7 days)
+	ChunkSize             int           // size of resumable upload chunks, a multiple of 256 KiB; defaults to DefaultChunkSize
+	WithoutAuthentication bool          // send requests without OAuth tokens, e.g. to fake-gcs-server
+}
+
+// String returns the config with the credentials redacted, so it is safe to print or log.
+func (c GCSStorageConfig) String() string {
+	credentials := ""
+	if len(c.CredentialsJSON) > 0 {
+		credentials = "xxxxx"
+	}
+
+	return fmt.Sprintf(
+		"{Bucket:%s CredentialsJSON:%s Endpoint:%s Visibility:%s DefaultExpiry:%s ChunkSize:%d WithoutAuthentication:%t}",
+		c.Bucket, credentials, c.Endpoint, c.Visibility, c.DefaultExpiry, c.ChunkSize, c.WithoutAuthentication,
+	)
+}
+
+// GoString is like String, so %#v does not leak the credentials either.
+func (c GCSStorageConfig) GoString() string {
+	return "gcsdriver.GCSStorageConfig" + c.String()
+}
+
+// Metadata is the content metadata GCS stores with an object.
+type Metadata struct {
+	ContentType        string            // served as Content-Type
+	CacheControl       string            // served as Cache-Control
+	ContentDisposition string            // served as Content-Disposition
+	Custom             map[string]string // served as x-goog-meta-* headers
+}
+
+// object is the JSON API representation of an object, limited to the fields the driver uses.
+type object struct {
+	Name               string            `json:"name,omitempty"`
+	Size               int64             `json:"size,omitempty,string"`
+	Updated            time.Time         `json:"updated,omitzero"`
+	Etag               string            `json:"etag,omitempty"`
+	ContentType        string            `json:"contentType,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// apiError is an error response of the JSON API.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("gcs: status %d: %s", e.Status, e.Message)
+}
+
+// GCSStorage is the implementation of gostorage.StorageDriver for Google Cloud Storage.
+type GCSStorage struct {
+	client  *http.Client
+	account *serviceAccount // nil without authentication
+	config  GCSStorageConfig
+}
+
+// NewGCSStorage returns a GCSStorage for the bucket, authenticating with the service account key.
+// Returns gostorage.ErrInvalidConfig if the bucket is missing, the key cannot be parsed or the
+// chunk size is not a multiple of 256 KiB.
+func NewGCSStorage(cfg GCSStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.Bucket == "" {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+
+	if cfg.ChunkSize < 0 || cfg.ChunkSize%chunkAlign != 0 {
+		log.Error().Int("chunk_size", cfg.ChunkSize).Msg("GCS chunk size must be a multiple of 256 KiB")
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultEndpoint
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	if cfg.DefaultExpiry == 0 {
+		cfg.DefaultExpiry = gostorage.DefaultSignedURLExpiry
+	}
+
+	s := &GCSStorage{client: &http.Client{}, config: cfg}
+
+	if len(cfg.CredentialsJSON) > 0 {
+		account, err := parseServiceAccount(cfg.CredentialsJSON)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid GCS service account credentials")
+			return nil, gostorage.ErrInvalidConfig
+		}
+		s.account = account
+	}
+
+	if cfg.WithoutAuthentication {
+		return s, nil
+	}
+
+	if s.account == nil {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	tokens := &jwt.Config{
+		Email:        s.account.ClientEmail,
+		PrivateKey:   []byte(s.account.PrivateKey),
+		PrivateKeyID: s.account.PrivateKeyID,
+		Scopes:       []string{scopeReadWrite},
+		TokenURL:     s.account.TokenURI,
+	}
+	s.client = tokens.Client(context.Background())
+
+	return s, nil
+}
+
+// Capabilities reports the features supported by this bucket.
+// Signed URLs need service account credentials; only public buckets serve direct URLs.
+func (s *GCSStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		SignedURL: s.account != nil,
+		PublicURL: s.config.Visibility == VisibilityPublic,
+		List:      true,
+		RangeRead: true,
+		Metadata:  true,
+	}
+}
+
+// DefaultExpiry returns the configured lifetime of signed URLs.
+func (s *GCSStorage) DefaultExpiry() time.Duration {
+	return s.config.DefaultExpiry
+}
+
+// DefaultVisibility returns the bucket's configured visibility.
+func (s *GCSStorage) DefaultVisibility() gostorage.Visibility {
+	return s.config.Visibility
+}
+
+// Delete permanently removes an object from the bucket. Deleting a missing object is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *GCSStorage) Delete(ctx context.Context, key string) error {
+	resp, err := s.send(ctx, http.MethodDelete, s.objectURL(key, nil), nil, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to delete object from GCS")
+		return gostorage.ErrInternal
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Exists checks if an object exists in the bucket.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *GCSStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.get(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to check if object exists in GCS")
+		return false, gostorage.ErrInternal
+	}
+
+	return true, nil
+}
+
+// GetRange downloads part of an object with a ranged media request.
+// A negative length reads to the end of the object; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt, e.g. for video seeking or ZIP archives.
+func (s *GCSStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	header := make(http.Header)
+	switch {
+	case length > 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	case offset > 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := s.send(ctx, http.MethodGet, s.objectURL(key, url.Values{"alt": {"media"}}), nil, header)
+	if err != nil {
+		var apiErr *apiError
+		switch {
+		case isNotFound(err):
+			return nil, gostorage.ErrNotFound
+		case errors.As(err, &apiErr) && apiErr.Status == http.StatusRequestedRangeNotSatisfiable:
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+
+		log.Error().Err(err).Str("key", key).Int64("offset", offset).Int64("length", length).Msg("failed to get object range from GCS")
+		return nil, gostorage.ErrInternal
+	}
+
+	return resp.Body, nil
+}
+
+// GetSignedURL generates a V4 signed URL for downloading an object, valid for at most 7 days.
+// Returns gostorage.ErrNotSupported when the storage has no service account key to sign with.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *GCSStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.account == nil {
+		return "", gostorage.ErrNotSupported
+	}
+
+	signed, err := s.account.signURL(http.MethodGet, s.config.Endpoint, s.config.Bucket, key, time.Now(), expiry)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to generate signed URL")
+		return "", gostorage.ErrInternal
+	}
+
+	return signed, nil
+}
+
+// GetURL returns the direct public URL for an object if the bucket is public.
+// Usage: Call this when you want to embed or link a public file directly.
+func (s *GCSStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.Visibility != VisibilityPublic {
+		return "", nil
+	}
+
+	return s.config.Endpoint + "/" + escapePath(s.config.Bucket) + "/" + escapePath(key), nil
+}
+
+// List calls fn for every object under opts.Prefix, paging through the objects.list API.
+// Non-recursive listings use "/" as delimiter and report prefixes as directories.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *GCSStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	query := url.Values{"prefix": {opts.Prefix}}
+	if !opts.Recursive {
+		query.Set("delimiter", "/")
+	}
+
+	for {
+		var page struct {
+			Items         []object `json:"items"`
+			Prefixes      []string `json:"prefixes"`
+			NextPageToken string   `json:"nextPageToken"`
+		}
+
+		listURL := s.config.Endpoint + "/storage/v1/b/" + url.PathEscape(s.config.Bucket) + "/o?" + query.Encode()
+		if err := s.sendJSON(ctx, http.MethodGet, listURL, nil, &page); err != nil {
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list objects in GCS")
+			return gostorage.ErrInternal
+		}
+
+		for _, obj := range mergeListPage(page.Items, page.Prefixes) {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+
+		if page.NextPageToken == "" {
+			return nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// mergeListPage converts an objects.list page into ObjectInfo values in lexical key order,
+// interleaving prefixes (directories) with objects the way GCS sorts them.
+func mergeListPage(items []object, prefixes []string) []gostorage.ObjectInfo {
+	infos := make([]gostorage.ObjectInfo, 0, len(items)+len(prefixes))
+
+	i, j := 0, 0
+	for i < len(items) || j < len(prefixes) {
+		if j == len(prefixes) || (i < len(items) && items[i].Name < prefixes[j]) {
+			infos = append(infos, items[i].info())
+			i++
+			continue
+		}
+
+		infos = append(infos, gostorage.ObjectInfo{Key: prefixes[j], IsDir: true})
+		j++
+	}
+
+	return infos
+}
+
+// Metadata returns the content type, caching headers and custom metadata stored with an object.
+// Usage: Call to serve a file with the headers it was uploaded with.
+func (s *GCSStorage) Metadata(ctx context.Context, key string) (Metadata, error) {
+	obj, err := s.get(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return Metadata{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to get object metadata from GCS")
+		return Metadata{}, gostorage.ErrInternal
+	}
+
+	return Metadata{
+		ContentType:        obj.ContentType,
+		CacheControl:       obj.CacheControl,
+		ContentDisposition: obj.ContentDisposition,
+		Custom:             obj.Metadata,
+	}, nil
+}
+
+// Put uploads a file with a resumable upload and returns its URL. The content type is
+// derived from the key's extension.
+// If the bucket is public, it returns a direct URL.
+// If the bucket is private, it returns a signed URL, or an empty URL without a service account key.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *GCSStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	return s.PutWithMetadata(ctx, key, file, Metadata{ContentType: mime.TypeByExtension(path.Ext(key))})
+}
+
+// PutWithMetadata is like Put, but stores the given content metadata with the object.
+// Usage: Call to set the Content-Type, Cache-Control or custom metadata served with a file.
+func (s *GCSStorage) PutWithMetadata(ctx context.Context, key string, file io.Reader, meta Metadata) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	session, err := s.startUpload(ctx, key, meta)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to start upload to GCS")
+		return "", gostorage.ErrInternal
+	}
+
+	if err := s.upload(ctx, session, file); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to upload object to GCS")
+		s.cancelUpload(session)
+		return "", gostorage.ErrInternal
+	}
+
+	// Public bucket: return direct URL
+	if s.config.Visibility == VisibilityPublic {
+		return s.GetURL(ctx, key)
+	}
+
+	// Private bucket: return signed URL
+	if s.account == nil {
+		return "", nil
+	}
+
+	return s.GetSignedURL(ctx, key, s.config.DefaultExpiry)
+}
+
+// Stat returns the size, modification time and ETag of an object.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *GCSStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	obj, err := s.get(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to stat object in GCS")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	}
+
+	return obj.info(), nil
+}
+
+// info converts the object into an ObjectInfo.
+func (o object) info() gostorage.ObjectInfo {
+	return gostorage.ObjectInfo{
+		Key:          o.Name,
+		Size:         o.Size,
+		LastModified: o.Updated,
+		ETag:         o.Etag,
+	}
+}
+
+// get fetches the metadata of an object.
+func (s *GCSStorage) get(ctx context.Context, key string) (object, error) {
+	var obj object
+	err := s.sendJSON(ctx, http.MethodGet, s.objectURL(key, nil), nil, &obj)
+
+	return obj, err
+}
+
+// objectURL returns the JSON API URL of an object.
+func (s *GCSStorage) objectURL(key string, query url.Values) string {
+	u := s.config.Endpoint + "/storage/v1/b/" + url.PathEscape(s.config.Bucket) + "/o/" + url.PathEscape(key)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	return u
+}
+
+// sendJSON sends a request with body encoded as JSON, if not nil, and decodes the response into out.
+func (s *GCSStorage) sendJSON(ctx context.Context, method, rawURL string, body, out any) error {
+	var reader io.Reader
+	header := make(http.Header)
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	resp, err := s.send(ctx, method, rawURL, reader, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a request and returns the response, or an *apiError for statuses other than
+// 2xx and 308 (which resumable uploads use for an incomplete upload).
+func (s *GCSStorage) send(ctx context.Context, method, rawURL string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 300 || resp.StatusCode == http.StatusPermanentRedirect {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &apiError{Status: resp.StatusCode}
+
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(data, &payload) == nil && payload.Error.Message != "" {
+		apiErr.Message = payload.Error.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+
+	return nil, apiErr
+}
+
+// isNotFound reports whether err is the API's response for a missing object.
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
@@ -0,0 +1,377 @@
+package gcsdriver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage creates a GCSStorage against srv, seeded with the given keys.
+func newTestStorage(t *testing.T, srv *fakeGCS, visibility Visibility, keys ...string) *GCSStorage {
+	t.Helper()
+
+	driver, err := NewGCSStorage(GCSStorageConfig{
+		Bucket:          testBucket,
+		CredentialsJSON: credentialsFor(t, srv.URL+"/token"),
+		Endpoint:        srv.URL,
+		Visibility:      visibility,
+		ChunkSize:       chunkAlign,
+	})
+	require.NoError(t, err, "expected no error creating GCS storage")
+
+	storage := driver.(*GCSStorage)
+	for _, key := range keys {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("content of "+key))
+		require.NoError(t, err, "expected no error seeding object")
+	}
+
+	return storage
+}
+
+// fetch downloads rawURL without credentials.
+func fetch(t *testing.T, rawURL string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(rawURL)
+	require.NoError(t, err, "expected no error fetching URL")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "expected no error reading response")
+
+	return resp.StatusCode, string(body)
+}
+
+func TestNewGCSStorage(t *testing.T) {
+	credentials := credentialsFor(t, defaultTokenURI)
+
+	tests := []struct {
+		name        string
+		cfg         GCSStorageConfig
+		expectedErr error
+	}{
+		{name: "should create storage", cfg: GCSStorageConfig{Bucket: testBucket, CredentialsJSON: credentials}},
+		{name: "should create storage without authentication", cfg: GCSStorageConfig{Bucket: testBucket, WithoutAuthentication: true}},
+		{name: "should reject missing bucket", cfg: GCSStorageConfig{CredentialsJSON: credentials}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject missing credentials", cfg: GCSStorageConfig{Bucket: testBucket}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject malformed credentials", cfg: GCSStorageConfig{Bucket: testBucket, CredentialsJSON: []byte("{")}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject user credentials", cfg: GCSStorageConfig{Bucket: testBucket, CredentialsJSON: []byte(`{"type":"authorized_user"}`)}, expectedErr: gostorage.ErrInvalidConfig},
+		{name: "should reject unaligned chunk size", cfg: GCSStorageConfig{Bucket: testBucket, CredentialsJSON: credentials, ChunkSize: 1000}, expectedErr: gostorage.ErrInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, err := NewGCSStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error")
+			assert.Implements(t, (*gostorage.Lister)(nil), driver, "expected driver to list files")
+			assert.Implements(t, (*gostorage.RangeReader)(nil), driver, "expected driver to read ranges")
+			assert.Equal(t, gostorage.DefaultSignedURLExpiry, driver.(*GCSStorage).DefaultExpiry(), "expected default expiry")
+			assert.Equal(t, DefaultChunkSize, driver.(*GCSStorage).config.ChunkSize, "expected default chunk size")
+		})
+	}
+}
+
+func TestGCSStorageConfig_String(t *testing.T) {
+	cfg := GCSStorageConfig{Bucket: testBucket, CredentialsJSON: credentialsFor(t, defaultTokenURI)}
+
+	assert.NotContains(t, cfg.String(), "PRIVATE KEY", "expected String to redact the credentials")
+	assert.NotContains(t, cfg.GoString(), "PRIVATE KEY", "expected GoString to redact the credentials")
+}
+
+func TestGCSStorage_PutAndDelete(t *testing.T) {
+	srv := newFakeGCS(t)
+	storage := newTestStorage(t, srv, VisibilityPrivate)
+	ctx := context.Background()
+
+	signed, err := storage.Put(ctx, "invoices/2024/march.csv", strings.NewReader("id,total\n1,42\n"))
+	require.NoError(t, err, "expected no error uploading")
+	assert.Contains(t, signed, "X-Goog-Signature=", "expected signed URL for private bucket")
+
+	obj, ok := srv.stored("invoices/2024/march.csv")
+	require.True(t, ok, "expected object in bucket")
+	assert.Equal(t, "id,total\n1,42\n", string(obj.data), "expected uploaded content")
+	assert.Equal(t, "text/csv; charset=utf-8", obj.ContentType, "expected content type from extension")
+
+	_, err = storage.Put(ctx, "invoices/2024/march.csv", strings.NewReader("replaced"))
+	require.NoError(t, err, "expected no error overwriting object")
+
+	obj, _ = srv.stored("invoices/2024/march.csv")
+	assert.Equal(t, "replaced", string(obj.data), "expected overwritten content")
+
+	require.NoError(t, storage.Delete(ctx, "invoices/2024/march.csv"), "expected no error deleting object")
+	assert.NoError(t, storage.Delete(ctx, "invoices/2024/march.csv"), "expected no error deleting missing object")
+
+	exists, err := storage.Exists(ctx, "invoices/2024/march.csv")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.False(t, exists, "expected object to be deleted")
+
+	tokens, _ := srv.counts()
+	assert.Equal(t, 1, tokens, "expected access token to be reused")
+
+	_, err = storage.Put(ctx, "../escape.txt", strings.NewReader("x"))
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid key to be rejected")
+}
+
+func TestGCSStorage_ResumableUpload(t *testing.T) {
+	retryDelay = 0
+	defer func() { retryDelay = time.Second }()
+
+	video := bytes.Repeat([]byte("0123456789abcdef"), 40*1024) // 640 KiB, two full chunks and a partial one
+
+	tests := []struct {
+		name           string
+		size           int
+		failChunks     int
+		expectedChunks int
+	}{
+		{name: "should upload in chunks", size: len(video), expectedChunks: 3},
+		{name: "should finalize after a full last chunk", size: 2 * chunkAlign, expectedChunks: 2},
+		{name: "should upload empty file", size: 0, expectedChunks: 0},
+		{name: "should resume after transient failures", size: len(video), failChunks: 2, expectedChunks: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeGCS(t)
+			storage := newTestStorage(t, srv, VisibilityPrivate)
+			srv.failNextChunks(tt.failChunks)
+
+			// MultiReader hides the size, like a request body would
+			_, err := storage.Put(context.Background(), "videos/intro.mp4", io.MultiReader(bytes.NewReader(video[:tt.size])))
+			require.NoError(t, err, "expected no error uploading")
+
+			obj, ok := srv.stored("videos/intro.mp4")
+			require.True(t, ok, "expected object in bucket")
+			assert.Equal(t, string(video[:tt.size]), string(obj.data), "expected chunks to be assembled in order")
+
+			_, chunks := srv.counts()
+			assert.Equal(t, tt.expectedChunks, chunks, "expected number of chunk requests")
+		})
+	}
+
+	srv := newFakeGCS(t)
+	storage := newTestStorage(t, srv, VisibilityPrivate)
+	srv.failNextChunks(maxChunkRetries + 1)
+
+	_, err := storage.Put(context.Background(), "videos/intro.mp4", bytes.NewReader(video))
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected upload to fail after retries")
+
+	_, ok := srv.stored("videos/intro.mp4")
+	assert.False(t, ok, "expected no object after failed upload")
+	assert.Empty(t, srv.uploads, "expected failed upload session to be cancelled")
+}
+
+func TestGCSStorage_Metadata(t *testing.T) {
+	srv := newFakeGCS(t)
+	storage := newTestStorage(t, srv, VisibilityPrivate)
+	ctx := context.Background()
+
+	meta := Metadata{
+		ContentType:        "application/pdf",
+		CacheControl:       "private, max-age=600",
+		ContentDisposition: `attachment; filename="q1.pdf"`,
+		Custom:             map[string]string{"uploaded-by": "42"},
+	}
+
+	_, err := storage.PutWithMetadata(ctx, "reports/q1", strings.NewReader("%PDF"), meta)
+	require.NoError(t, err, "expected no error uploading with metadata")
+
+	got, err := storage.Metadata(ctx, "reports/q1")
+	assert.NoError(t, err, "expected no error reading metadata")
+	assert.Equal(t, meta, got, "expected metadata to round-trip")
+	assert.True(t, storage.Capabilities().Metadata, "expected metadata to be supported")
+
+	_, err = storage.Metadata(ctx, "missing.pdf")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected error for missing object")
+}
+
+func TestGCSStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every object recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with prefixes collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a"},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, newFakeGCS(t), VisibilityPrivate, keys...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				if !obj.IsDir {
+					assert.Equal(t, int64(len("content of "+obj.Key)), obj.Size, "expected size to match")
+				}
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing objects")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestGCSStorage_GetRange(t *testing.T) {
+	storage := newTestStorage(t, newFakeGCS(t), VisibilityPrivate, "file.txt")
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read whole object", key: "file.txt", offset: 0, length: -1, expected: "content of file.txt"},
+		{name: "should read range", key: "file.txt", offset: 11, length: 4, expected: "file"},
+		{name: "should read to end from offset", key: "file.txt", offset: 16, length: -1, expected: "txt"},
+		{name: "should read nothing for zero length", key: "file.txt", offset: 3, length: 0, expected: ""},
+		{name: "should read nothing past the end", key: "file.txt", offset: 100, length: 4, expected: ""},
+		{name: "should not find missing object", key: "missing.txt", length: -1, expectedErr: gostorage.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(context.Background(), tt.key, tt.offset, tt.length)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+
+			data, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(data), "expected range content to match")
+			assert.NoError(t, body.Close(), "expected no error closing body")
+		})
+	}
+}
+
+func TestGCSStorage_Stat(t *testing.T) {
+	storage := newTestStorage(t, newFakeGCS(t), VisibilityPrivate, "dir/file.txt")
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, "dir/file.txt")
+	require.NoError(t, err, "expected no error stating object")
+	assert.Equal(t, "dir/file.txt", info.Key, "expected key to match")
+	assert.Equal(t, int64(len("content of dir/file.txt")), info.Size, "expected size to match")
+	assert.NotEmpty(t, info.ETag, "expected ETag")
+	assert.False(t, info.LastModified.IsZero(), "expected modification time")
+
+	_, err = storage.Stat(ctx, "missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected error for missing object")
+}
+
+func TestGCSStorage_SignedURL(t *testing.T) {
+	storage := newTestStorage(t, newFakeGCS(t), VisibilityPrivate, "reports/q1_final.pdf")
+	ctx := context.Background()
+
+	signed, err := storage.GetSignedURL(ctx, "reports/q1_final.pdf", time.Hour)
+	require.NoError(t, err, "expected no error signing URL")
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err, "expected valid URL")
+	assert.Equal(t, "/media/reports/q1_final.pdf", u.EscapedPath(), "expected path-style URL")
+	assert.Equal(t, "3600", u.Query().Get("X-Goog-Expires"), "expected expiry in seconds")
+	assert.True(t, strings.HasPrefix(u.Query().Get("X-Goog-Credential"), testEmail+"/"), "expected service account credential")
+
+	status, body := fetch(t, signed)
+	assert.Equal(t, http.StatusOK, status, "expected signed URL to grant access")
+	assert.Equal(t, "content of reports/q1_final.pdf", body, "expected object content")
+
+	status, _ = fetch(t, strings.Replace(signed, "q1_final.pdf", "q2.pdf", 1))
+	assert.Equal(t, http.StatusForbidden, status, "expected signature to cover the object name")
+
+	status, _ = fetch(t, strings.Split(signed, "?")[0])
+	assert.Equal(t, http.StatusForbidden, status, "expected private object to need a signature")
+
+	_, err = storage.GetSignedURL(ctx, "reports/q1_final.pdf", 8*24*time.Hour)
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected expiry beyond 7 days to be rejected")
+}
+
+func TestGCSStorage_PublicURL(t *testing.T) {
+	srv := newFakeGCS(t)
+	srv.public = true
+	storage := newTestStorage(t, srv, VisibilityPublic)
+	ctx := context.Background()
+
+	direct, err := storage.Put(ctx, "avatars/jane.png", strings.NewReader("PNG"))
+	require.NoError(t, err, "expected no error uploading")
+	assert.Equal(t, srv.URL+"/media/avatars/jane.png", direct, "expected direct URL")
+
+	got, err := storage.GetURL(ctx, "avatars/jane.png")
+	assert.NoError(t, err, "expected no error building URL")
+	assert.Equal(t, direct, got, "expected GetURL to match URL returned by Put")
+
+	status, body := fetch(t, direct)
+	assert.Equal(t, http.StatusOK, status, "expected anonymous read")
+	assert.Equal(t, "PNG", body, "expected object content")
+}
+
+func TestGCSStorage_WithoutAuthentication(t *testing.T) {
+	srv := newFakeGCS(t)
+	srv.anonymous = true
+
+	driver, err := NewGCSStorage(GCSStorageConfig{Bucket: testBucket, Endpoint: srv.URL, WithoutAuthentication: true})
+	require.NoError(t, err, "expected no error creating GCS storage")
+	ctx := context.Background()
+
+	publicURL, err := driver.Put(ctx, "notes.txt", strings.NewReader("hello"))
+	assert.NoError(t, err, "expected no error uploading")
+	assert.Empty(t, publicURL, "expected no URL without a key to sign with")
+
+	exists, err := driver.Exists(ctx, "notes.txt")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.True(t, exists, "expected object to exist")
+
+	_, err = driver.GetSignedURL(ctx, "notes.txt", time.Minute)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected signing to be unsupported")
+	assert.False(t, driver.(*GCSStorage).Capabilities().SignedURL, "expected signed URLs to be reported unsupported")
+
+	tokens, _ := srv.counts()
+	assert.Zero(t, tokens, "expected no token requests")
+}
@@ -0,0 +1,156 @@
+package gcsdriver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSignedURLExpiry is the longest lifetime GCS accepts for V4 signed URLs.
+const maxSignedURLExpiry = 7 * 24 * time.Hour
+
+// defaultTokenURI is Google's OAuth 2.0 token endpoint, used when a key file does not name one.
+const defaultTokenURI = "https://oauth2.googleapis.com/token"
+
+// serviceAccount is the subset of a service account key file used by the driver.
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// parseServiceAccount decodes a service account key file and its PEM encoded RSA key.
+func parseServiceAccount(data []byte) (*serviceAccount, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("decode credentials: %w", err)
+	}
+
+	if account.Type != "service_account" {
+		return nil, fmt.Errorf("credentials type %q is not service_account", account.Type)
+	}
+
+	if account.ClientEmail == "" {
+		return nil, errors.New("credentials have no client_email")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("credentials private_key is not PEM encoded")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("parse credentials private_key: %w", err)
+		}
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("credentials private_key is not an RSA key")
+	}
+	account.key = key
+
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+
+	return &account, nil
+}
+
+// signURL builds a V4 signed URL (GOOG4-RSA-SHA256) for a path-style request to
+// endpoint/bucket/object, valid for expiry from now.
+// See https://cloud.google.com/storage/docs/access-control/signing-urls-manually.
+func (a *serviceAccount) signURL(method, endpoint, bucket, object string, now time.Time, expiry time.Duration) (string, error) {
+	if expiry <= 0 || expiry > maxSignedURLExpiry {
+		return "", fmt.Errorf("expiry %s must be between 1s and %s", expiry, maxSignedURLExpiry)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse endpoint: %w", err)
+	}
+
+	now = now.UTC()
+	datetime := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/auto/storage/goog4_request"
+
+	query := url.Values{
+		"X-Goog-Algorithm":     {"GOOG4-RSA-SHA256"},
+		"X-Goog-Credential":    {a.ClientEmail + "/" + scope},
+		"X-Goog-Date":          {datetime},
+		"X-Goog-Expires":       {fmt.Sprint(int64(expiry / time.Second))},
+		"X-Goog-SignedHeaders": {"host"},
+	}
+
+	canonicalURI := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + escapePath(bucket) + "/" + escapePath(object)
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"GOOG4-RSA-SHA256", datetime, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign URL: %w", err)
+	}
+
+	return fmt.Sprintf("%s://%s%s?%s&X-Goog-Signature=%s", u.Scheme, u.Host, canonicalURI, canonicalQuery, hex.EncodeToString(signature)), nil
+}
+
+// canonicalQueryString encodes query sorted by name with RFC 3986 escaping, as V4 signing requires.
+func canonicalQueryString(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, escape(name)+"="+escape(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// escapePath percent-encodes every segment of an object name, keeping the slashes between them.
+func escapePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes everything but RFC 3986 unreserved characters.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,205 @@
+package gcsdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxChunkRetries is how often a chunk is resent after a transient failure before the upload fails.
+const maxChunkRetries = 3
+
+// retryDelay is the pause before resuming an upload after a transient failure, doubled on every retry.
+var retryDelay = time.Second
+
+// startUpload initiates a resumable upload of key with the given metadata and returns its session URI.
+func (s *GCSStorage) startUpload(ctx context.Context, key string, meta Metadata) (string, error) {
+	body, err := json.Marshal(object{
+		Name:               key,
+		ContentType:        meta.ContentType,
+		CacheControl:       meta.CacheControl,
+		ContentDisposition: meta.ContentDisposition,
+		Metadata:           meta.Custom,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json; charset=UTF-8")
+	if meta.ContentType != "" {
+		header.Set("X-Upload-Content-Type", meta.ContentType)
+	}
+
+	query := url.Values{"uploadType": {"resumable"}, "name": {key}}
+	startURL := s.config.Endpoint + "/upload/storage/v1/b/" + url.PathEscape(s.config.Bucket) + "/o?" + query.Encode()
+
+	resp, err := s.send(ctx, http.MethodPost, startURL, bytes.NewReader(body), header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return "", errors.New("gcs: resumable upload response has no session URI")
+	}
+
+	return session, nil
+}
+
+// upload streams file to a resumable upload session in chunks of ChunkSize bytes. Only one
+// chunk is buffered at a time, so the total size does not need to be known in advance.
+func (s *GCSStorage) upload(ctx context.Context, session string, file io.Reader) error {
+	buf := make([]byte, s.config.ChunkSize)
+	var offset int64
+
+	for {
+		n, err := io.ReadFull(file, buf)
+		last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			return fmt.Errorf("read file: %w", err)
+		}
+
+		if err := s.putChunk(ctx, session, buf[:n], offset, last); err != nil {
+			return err
+		}
+
+		offset += int64(n)
+		if last {
+			return nil
+		}
+	}
+}
+
+// putChunk sends chunk, which starts at offset in the object. The last chunk also declares the
+// total size, which finalizes the object. Bytes the server did not persist are resent, and
+// transient failures are retried from the offset the server reports.
+func (s *GCSStorage) putChunk(ctx context.Context, session string, chunk []byte, offset int64, last bool) error {
+	end := offset + int64(len(chunk))
+	total := "*"
+	if last {
+		total = strconv.FormatInt(end, 10)
+	}
+
+	start := offset
+	retries := 0
+	for {
+		header := make(http.Header)
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, end-1, total))
+		if start == end {
+			header.Set("Content-Range", "bytes */"+total)
+		}
+
+		resp, err := s.send(ctx, http.MethodPut, session, bytes.NewReader(chunk[start-offset:]), header)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusPermanentRedirect {
+				return nil // the object is complete
+			}
+
+			persisted, err := persistedBytes(resp.Header.Get("Range"))
+			if err != nil {
+				return err
+			}
+
+			if !last && persisted == end {
+				return nil
+			}
+
+			if persisted < offset || persisted > end {
+				return fmt.Errorf("gcs: server persisted %d bytes, outside chunk %d-%d", persisted, offset, end)
+			}
+
+			start = persisted
+			continue
+		}
+
+		if !transient(err) || ctx.Err() != nil || retries == maxChunkRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(retryDelay << retries):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		retries++
+
+		// resend from wherever the failed request left the upload
+		if start, err = s.uploadStatus(ctx, session, total); err != nil {
+			return err
+		}
+		if start == -1 {
+			return nil // the object is complete
+		}
+		if start < offset || start > end {
+			return fmt.Errorf("gcs: server persisted %d bytes, outside chunk %d-%d", start, offset, end)
+		}
+	}
+}
+
+// uploadStatus asks how many bytes of a resumable upload the server has persisted.
+// It returns -1 if the upload is already complete.
+func (s *GCSStorage) uploadStatus(ctx context.Context, session, total string) (int64, error) {
+	header := make(http.Header)
+	header.Set("Content-Range", "bytes */"+total)
+
+	resp, err := s.send(ctx, http.MethodPut, session, nil, header)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPermanentRedirect {
+		return -1, nil
+	}
+
+	return persistedBytes(resp.Header.Get("Range"))
+}
+
+// cancelUpload discards an unfinished resumable upload, so it does not linger for a week.
+func (s *GCSStorage) cancelUpload(session string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := s.send(ctx, http.MethodDelete, session, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// persistedBytes parses the Range header of a 308 response ("bytes=0-N"), which is absent
+// when nothing was persisted yet.
+func persistedBytes(header string) (int64, error) {
+	if header == "" {
+		return 0, nil
+	}
+
+	_, last, ok := strings.Cut(strings.TrimPrefix(header, "bytes="), "-")
+	n, err := strconv.ParseInt(last, 10, 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("gcs: invalid Range header %q", header)
+	}
+
+	return n + 1, nil
+}
+
+// transient reports whether a failed request may succeed when retried: network errors,
+// rate limiting and server errors.
+func transient(err error) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	return apiErr.Status == http.StatusTooManyRequests || apiErr.Status >= 500
+}
@@ -0,0 +1,62 @@
+package localdriver
+
+import (
+	"context"
+	"net/url"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DriverName is the name the local driver is registered under for gostorage.NewFromConfig.
+const DriverName = "local"
+
+func init() {
+	gostorage.RegisterDriver(DriverName, openFromConfig)
+	gostorage.RegisterURLScheme("file", parseURL)
+}
+
+// parseURL converts a file DSN into disk options:
+//
+//	file:///var/data?base_url=https://cdn.example.com/media
+//	file:///var/data?base_url=https://api.example.com/files&signing_secret=...
+//	file://./data (relative to the working directory)
+func parseURL(u *url.URL) (gostorage.DiskConfig, error) {
+	options := make(map[string]string)
+	for key, values := range u.Query() {
+		options[key] = values[len(values)-1]
+	}
+
+	root := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		root = u.Host + u.Path
+	}
+	options["root"] = root
+
+	return gostorage.DiskConfig{Driver: DriverName, Options: options}, nil
+}
+
+// ConfigFromDisk converts generic disk options into a DiskStorageConfig.
+// Recognized options: root (required), base_url and signing_secret.
+// Returns a *gostorage.ConfigError naming the first invalid option.
+func ConfigFromDisk(disk gostorage.DiskConfig) (DiskStorageConfig, error) {
+	root, err := disk.Require("root")
+	if err != nil {
+		return DiskStorageConfig{}, err
+	}
+
+	return DiskStorageConfig{
+		Root:          root,
+		BaseURL:       disk.String("base_url"),
+		SigningSecret: disk.String("signing_secret"),
+	}, nil
+}
+
+// openFromConfig is the gostorage.DriverOpener for the local driver.
+func openFromConfig(ctx context.Context, disk gostorage.DiskConfig) (gostorage.StorageDriver, error) {
+	cfg, err := ConfigFromDisk(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDiskStorage(cfg)
+}
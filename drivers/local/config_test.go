@@ -0,0 +1,59 @@
+package localdriver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		dsn      string
+		expected DiskStorageConfig
+	}{
+		{
+			name:     "should parse absolute path with base URL",
+			dsn:      "file:///var/data?base_url=https://cdn.example.com/media",
+			expected: DiskStorageConfig{Root: "/var/data", BaseURL: "https://cdn.example.com/media"},
+		},
+		{
+			name:     "should parse signing secret",
+			dsn:      "file:///var/data?base_url=https://api.example.com/files&signing_secret=s3cr3t",
+			expected: DiskStorageConfig{Root: "/var/data", BaseURL: "https://api.example.com/files", SigningSecret: "s3cr3t"},
+		},
+		{
+			name:     "should parse relative path",
+			dsn:      "file://./data",
+			expected: DiskStorageConfig{Root: "./data"},
+		},
+		{
+			name:     "should ignore localhost host",
+			dsn:      "file://localhost/var/data",
+			expected: DiskStorageConfig{Root: "/var/data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := gostorage.ParseDSN(tt.dsn)
+			assert.NoError(t, err, "expected no error parsing DSN")
+
+			cfg, err := ConfigFromDisk(disk)
+			assert.NoError(t, err, "expected no error converting disk")
+			assert.Equal(t, tt.expected, cfg, "expected config to match")
+		})
+	}
+}
+
+func TestOpenURL(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "media")
+
+	driver, err := gostorage.OpenURL(context.Background(), "file://"+root)
+	assert.NoError(t, err, "expected no error opening DSN")
+	assert.IsType(t, &DiskStorage{}, driver, "expected disk storage")
+	assert.DirExists(t, root, "expected root to be created")
+}
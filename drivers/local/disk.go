@@ -0,0 +1,286 @@
+package localdriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/httpserve"
+	"github.com/shoraid/go-storage/internal/filetree"
+)
+
+// maxCreateAttempts bounds how often Put retries creating its temporary file when the
+// directory is pruned by a concurrent Delete or the random name is already taken.
+const maxCreateAttempts = 10
+
+// DiskStorageConfig defines the configuration for storing files on the local filesystem.
+type DiskStorageConfig struct {
+	Root          string // directory where files are stored, created if missing
+	BaseURL       string // optional public URL the Root directory is served from, e.g. "https://cdn.example.com/media"
+	SigningSecret string // optional secret shared with an httpserve.Handler at BaseURL, enables GetSignedURL
+}
+
+// DiskStorage is the concrete implementation of gostorage.StorageDriver for the local filesystem.
+// Keys map to paths below Root, with "/" separated segments becoming directories.
+type DiskStorage struct {
+	root   string
+	config DiskStorageConfig
+	signer *httpserve.Signer // nil unless BaseURL and SigningSecret are set
+}
+
+// NewDiskStorage initializes a DiskStorage rooted at cfg.Root, creating the directory if needed.
+// Returns gostorage.ErrInvalidConfig if Root is empty or cannot be created.
+func NewDiskStorage(cfg DiskStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.Root == "" {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		log.Error().Err(err).Str("root", cfg.Root).Msg("failed to resolve storage root")
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		log.Error().Err(err).Str("root", root).Msg("failed to create storage root")
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	storage := &DiskStorage{
+		root:   root,
+		config: cfg,
+	}
+	if cfg.BaseURL != "" && cfg.SigningSecret != "" {
+		storage.signer = httpserve.NewSigner([]byte(cfg.SigningSecret))
+	}
+
+	return storage, nil
+}
+
+// filePath converts a validated key into an absolute path below the storage root.
+func (s *DiskStorage) filePath(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// Capabilities reports the features supported by the local filesystem.
+// Public URLs are only available when a BaseURL is configured, signed URLs when a SigningSecret is too.
+// There is no batch delete: removing files one by one lets StorageManager run them concurrently.
+func (s *DiskStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		SignedURL: s.signer != nil,
+		PublicURL: s.config.BaseURL != "",
+		List:      true,
+		RangeRead: true,
+	}
+}
+
+// Delete removes a file and any parent directories left empty by its removal.
+// Deleting a file that does not exist is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *DiskStorage) Delete(ctx context.Context, key string) error {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	p := s.filePath(key)
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Error().Err(err).Str("key", key).Msg("failed to delete file from disk")
+		return gostorage.ErrInternal
+	}
+
+	filetree.RemoveEmptyParents(key, func(dir string) error {
+		return os.Remove(s.filePath(dir))
+	})
+
+	return nil
+}
+
+// Exists checks if a regular file exists for the key.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *DiskStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(s.filePath(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to check if file exists on disk")
+		return false, gostorage.ErrInternal
+	}
+
+	return info.Mode().IsRegular(), nil
+}
+
+// GetRange opens the file and returns a reader limited to the requested byte range.
+// A negative length reads to the end of the file; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *DiskStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(s.filePath(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to open file on disk")
+		return nil, gostorage.ErrInternal
+	}
+
+	if length < 0 {
+		length = math.MaxInt64 - offset
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(f, offset, length), f}, nil
+}
+
+// GetSignedURL returns a URL below BaseURL signed with SigningSecret, to be verified by an
+// httpserve.Handler serving this storage with httpserve.WithSigner and the same secret.
+// Returns gostorage.ErrNotSupported if BaseURL or SigningSecret is not configured.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *DiskStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.signer == nil {
+		return "", gostorage.ErrNotSupported
+	}
+
+	if err := gostorage.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	return s.signer.SignURL(s.config.BaseURL, key, expiry), nil
+}
+
+// GetURL returns BaseURL joined with the key.
+// Returns gostorage.ErrNotSupported if no BaseURL is configured.
+// Usage: Call this when Root is served by a web server or CDN.
+func (s *DiskStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.BaseURL == "" {
+		return "", gostorage.ErrNotSupported
+	}
+
+	return strings.TrimRight(s.config.BaseURL, "/") + "/" + key, nil
+}
+
+// List calls fn for every file under opts.Prefix in lexical key order.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *DiskStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	return filetree.List(ctx, opts, func(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+		entries, err := os.ReadDir(s.filePath(dir))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list files on disk")
+			return nil, gostorage.ErrInternal
+		}
+
+		return entries, nil
+	}, fn)
+}
+
+// Stat returns the size, modification time and ETag of a regular file.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *DiskStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	info, err := os.Stat(s.filePath(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to stat file on disk")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	}
+
+	if !info.Mode().IsRegular() {
+		return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+	}
+
+	return filetree.ObjectInfo(key, info), nil
+}
+
+// Put writes a file atomically: the content goes to a temporary file that is renamed into place,
+// so concurrent readers never observe a partially written file.
+// Returns the public URL if BaseURL is configured, otherwise an empty string.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *DiskStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	p := s.filePath(key)
+	tmp, err := s.createTemp(filepath.Dir(p))
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to create temporary file on disk")
+		return "", gostorage.ErrInternal
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	_, err = io.Copy(tmp, file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to write file to disk")
+		return "", gostorage.ErrInternal
+	}
+
+	if s.config.BaseURL == "" {
+		return "", nil
+	}
+
+	return s.GetURL(ctx, key)
+}
+
+// createTemp creates a temporary file in dir, creating dir and its parents first.
+// The file is created with mode 0644 minus the umask, like any other file written by the process,
+// so a web server running as another user can read it once renamed into place.
+// A concurrent Delete may prune dir, or one of its parents, while this runs, in which case both steps are retried.
+func (s *DiskStorage) createTemp(dir string) (*os.File, error) {
+	var err error
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // a parent was pruned while creating dir
+			}
+			return nil, err
+		}
+
+		var f *os.File
+		name := filepath.Join(dir, filetree.TempName())
+		f, err = os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
@@ -0,0 +1,362 @@
+package localdriver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/httpserve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage creates a DiskStorage in a temporary directory seeded with the given keys.
+func newTestStorage(t *testing.T, baseURL string, keys ...string) *DiskStorage {
+	t.Helper()
+
+	driver, err := NewDiskStorage(DiskStorageConfig{Root: t.TempDir(), BaseURL: baseURL})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	storage := driver.(*DiskStorage)
+	for _, key := range keys {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("content of "+key))
+		require.NoError(t, err, "expected no error seeding file")
+	}
+
+	return storage
+}
+
+func TestNewDiskStorage(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         DiskStorageConfig
+		expectedErr error
+	}{
+		{
+			name:        "should create disk storage successfully",
+			cfg:         DiskStorageConfig{Root: filepath.Join(t.TempDir(), "nested", "root")},
+			expectedErr: nil,
+		},
+		{
+			name:        "should return error when root is missing",
+			cfg:         DiskStorageConfig{},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewDiskStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error when config is invalid")
+				assert.Nil(t, storage, "expected storage to be nil on error")
+			} else {
+				assert.NoError(t, err, "expected no error when config is valid")
+				assert.DirExists(t, tt.cfg.Root, "expected root directory to be created")
+			}
+		})
+	}
+}
+
+func TestDiskStorage_Put(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseURL     string
+		key         string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "should write file and return empty URL without base URL",
+			key:      "file.txt",
+			expected: "",
+		},
+		{
+			name:     "should write nested file and return public URL",
+			baseURL:  "https://cdn.example.com/media/",
+			key:      "users/1/avatar.png",
+			expected: "https://cdn.example.com/media/users/1/avatar.png",
+		},
+		{
+			name:        "should reject keys escaping the root",
+			key:         "../outside.txt",
+			expectedErr: gostorage.ErrInvalidKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newTestStorage(t, tt.baseURL)
+
+			got, err := storage.Put(context.Background(), tt.key, strings.NewReader("hello"))
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error for invalid key")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error when Put succeeds")
+			assert.Equal(t, tt.expected, got, "expected returned URL to match")
+
+			content, err := os.ReadFile(storage.filePath(tt.key))
+			assert.NoError(t, err, "expected file to be written")
+			assert.Equal(t, "hello", string(content), "expected file content to match")
+		})
+	}
+}
+
+func TestDiskStorage_Put_FileMode(t *testing.T) {
+	storage := newTestStorage(t, "")
+
+	_, err := storage.Put(context.Background(), "public/file.txt", strings.NewReader("hello"))
+	require.NoError(t, err, "expected no error when Put succeeds")
+
+	info, err := os.Stat(storage.filePath("public/file.txt"))
+	require.NoError(t, err, "expected file to exist")
+	assert.NotZero(t, info.Mode().Perm()&0o044, "expected file to be readable by group or others under a usual umask")
+}
+
+func TestDiskStorage_PutConcurrentWithDelete(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, "")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := storage.Put(ctx, "dir/sub/put.txt", strings.NewReader("hello"))
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- storage.Delete(ctx, "dir/sub/put.txt")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err, "expected Put and Delete to succeed while racing on the same directory")
+	}
+}
+
+func TestDiskStorage_DeleteAndExists(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, "", "users/1/a.txt", "users/2/b.txt")
+
+	exists, err := storage.Exists(ctx, "users/1/a.txt")
+	assert.NoError(t, err, "expected no error")
+	assert.True(t, exists, "expected file to exist")
+
+	assert.NoError(t, storage.Delete(ctx, "users/1/a.txt"), "expected no error deleting file")
+	assert.NoError(t, storage.Delete(ctx, "users/1/a.txt"), "expected no error deleting missing file")
+
+	exists, err = storage.Exists(ctx, "users/1/a.txt")
+	assert.NoError(t, err, "expected no error")
+	assert.False(t, exists, "expected file to be gone")
+
+	assert.NoDirExists(t, filepath.Join(storage.root, "users", "1"), "expected empty directory to be pruned")
+	assert.DirExists(t, filepath.Join(storage.root, "users"), "expected non-empty parent to be kept")
+
+	exists, err = storage.Exists(ctx, "users")
+	assert.NoError(t, err, "expected no error")
+	assert.False(t, exists, "expected directory not to count as a file")
+}
+
+func TestDiskStorage_URLs(t *testing.T) {
+	ctx := context.Background()
+
+	withBaseURL := newTestStorage(t, "https://cdn.example.com/media")
+	withoutBaseURL := newTestStorage(t, "")
+
+	url, err := withBaseURL.GetURL(ctx, "a/b.txt")
+	assert.NoError(t, err, "expected no error with base URL")
+	assert.Equal(t, "https://cdn.example.com/media/a/b.txt", url, "expected public URL")
+	assert.True(t, withBaseURL.Capabilities().PublicURL, "expected public URL capability with base URL")
+
+	_, err = withoutBaseURL.GetURL(ctx, "a/b.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected not supported without base URL")
+	assert.False(t, withoutBaseURL.Capabilities().PublicURL, "expected no public URL capability without base URL")
+
+	_, err = withBaseURL.GetSignedURL(ctx, "a/b.txt", time.Minute)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected signed URLs not to be supported without signing secret")
+}
+
+func TestDiskStorage_GetSignedURL(t *testing.T) {
+	ctx := context.Background()
+
+	driver, err := NewDiskStorage(DiskStorageConfig{
+		Root:          t.TempDir(),
+		BaseURL:       "https://api.example.com/files/",
+		SigningSecret: "test-secret",
+	})
+	require.NoError(t, err, "expected no error creating disk storage")
+	storage := driver.(*DiskStorage)
+
+	signed, err := storage.GetSignedURL(ctx, "a/b.txt", time.Minute)
+	require.NoError(t, err, "expected no error signing URL")
+	assert.True(t, storage.Capabilities().SignedURL, "expected signed URL capability with signing secret")
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err, "expected a valid URL")
+	assert.Equal(t, "/files/a/b.txt", u.Path, "expected key below base URL")
+	assert.NoError(t, httpserve.NewSigner([]byte("test-secret")).Verify("a/b.txt", u.Query()), "expected signature to verify with the same secret")
+
+	_, err = storage.GetSignedURL(ctx, "../b.txt", time.Minute)
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid keys to be rejected")
+}
+
+func TestDiskStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every file recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a", Recursive: false},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, "", keys...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing files")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestDiskStorage_DeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, "", "users/1/a.txt", "users/1/b/c.txt", "users/10/d.txt", "users/2/e.txt")
+
+	manager, err := gostorage.NewStorageManager("local", map[string]gostorage.StorageDriver{"local": storage})
+	require.NoError(t, err, "expected no error creating manager")
+
+	report, err := manager.DeletePrefix(ctx, "users/1/", gostorage.DeletePrefixOptions{})
+	assert.NoError(t, err, "expected no error deleting prefix")
+	assert.Equal(t, 2, report.Deleted, "expected both files under the prefix to be deleted")
+
+	assert.NoDirExists(t, filepath.Join(storage.root, "users", "1"), "expected prefix directory to be removed")
+	assert.FileExists(t, filepath.Join(storage.root, "users", "10", "d.txt"), "expected sibling prefix to be kept")
+	assert.FileExists(t, filepath.Join(storage.root, "users", "2", "e.txt"), "expected other files to be kept")
+}
+
+func TestDiskStorage_DeleteManyConcurrently(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, "")
+
+	var keys []string
+	for i := range 100 {
+		key := fmt.Sprintf("users/%d/files/%d.txt", i%5, i)
+		_, err := storage.Put(ctx, key, strings.NewReader("x"))
+		require.NoError(t, err, "expected no error putting file")
+		keys = append(keys, key)
+	}
+
+	_, isBatchDeleter := any(storage).(gostorage.BatchDeleter)
+	assert.False(t, isBatchDeleter, "expected deletes to run through the manager's concurrency limit")
+
+	manager, err := gostorage.NewStorageManager("local", map[string]gostorage.StorageDriver{"local": storage}, gostorage.WithConcurrency(8))
+	require.NoError(t, err, "expected no error creating manager")
+
+	assert.NoError(t, manager.DeleteMany(ctx, keys...), "expected no error deleting files")
+	assert.NoDirExists(t, filepath.Join(storage.root, "users"), "expected every emptied directory to be removed")
+}
+
+func TestDiskStorage_GetRange(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, "", "videos/clip.txt") // "content of videos/clip.txt"
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read bounded range", key: "videos/clip.txt", offset: 11, length: 6, expected: "videos"},
+		{name: "should read to the end for negative length", key: "videos/clip.txt", offset: 18, length: -1, expected: "clip.txt"},
+		{name: "should return empty body past the end", key: "videos/clip.txt", offset: 100, length: 5, expected: ""},
+		{name: "should return ErrNotFound for missing file", key: "videos/missing.txt", length: 5, expectedErr: gostorage.ErrNotFound},
+		{name: "should return ErrInvalidKey for invalid key", key: "../clip.txt", length: 5, expectedErr: gostorage.ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(ctx, tt.key, tt.offset, tt.length)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+			defer body.Close()
+
+			got, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(got), "expected range content to match")
+		})
+	}
+}
+
+func TestDiskStorage_Stat(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, "", "docs/a.txt")
+
+	info, err := storage.Stat(ctx, "docs/a.txt")
+	assert.NoError(t, err, "expected no error stating file")
+	assert.Equal(t, "docs/a.txt", info.Key, "expected key to match")
+	assert.Equal(t, int64(len("content of docs/a.txt")), info.Size, "expected size to match")
+	assert.NotEmpty(t, info.ETag, "expected an ETag")
+
+	_, err = storage.Stat(ctx, "docs")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected directories to be reported as not found")
+
+	_, err = storage.Stat(ctx, "docs/missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound for missing file")
+}
@@ -0,0 +1,409 @@
+package mirrordriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// Replica is one of the storages a MirrorStorage writes to.
+type Replica struct {
+	Name   string                  // unique name used in repair tasks and logs, e.g. the storage alias "s3" or "r2"
+	Driver gostorage.StorageDriver // storage holding a full copy of the files
+}
+
+// MirrorStorageConfig defines the configuration for mirroring files across several storages.
+type MirrorStorageConfig struct {
+	Replicas    []Replica   // storages to write to; the first one is the primary that reads go to first
+	WriteQuorum int         // optional number of replicas that must accept a write, defaults to all of them
+	RepairQueue RepairQueue // optional queue of failed replica writes, defaults to a new MemoryRepairQueue
+	TempDir     string      // optional directory uploads are buffered in while fanned out, defaults to os.TempDir()
+}
+
+// MirrorStorage is a gostorage.StorageDriver that writes every file to all replicas.
+// Put and Delete run on all replicas concurrently and succeed once WriteQuorum of them did;
+// every replica that failed is recorded in the RepairQueue for Repair to bring it up to date.
+// Reads go to the primary and fail over to the next replica on any error, including
+// gostorage.ErrNotFound, since the primary may have missed a write.
+//
+// A Delete that met the quorum while some replicas failed leaves a tombstone for the key until
+// Repair deleted it from those replicas, so reads report the file missing instead of serving it
+// from a replica that missed the delete, and Repair deletes it rather than copying it back.
+// Tombstones are held in memory; a later successful Put of the key removes its tombstone.
+type MirrorStorage struct {
+	replicas []Replica
+	quorum   int
+	queue    RepairQueue
+	config   MirrorStorageConfig
+
+	mu         sync.Mutex
+	tombstones map[string]map[string]bool // key -> names of the replicas that missed its delete
+}
+
+// NewMirrorStorage initializes a MirrorStorage.
+// Returns gostorage.ErrInvalidConfig if there are no replicas, a replica has no driver or a
+// missing or duplicate name, or WriteQuorum is negative or larger than the number of replicas.
+func NewMirrorStorage(cfg MirrorStorageConfig) (gostorage.StorageDriver, error) {
+	if len(cfg.Replicas) == 0 {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	names := make(map[string]bool, len(cfg.Replicas))
+	for _, r := range cfg.Replicas {
+		if r.Driver == nil || r.Name == "" || names[r.Name] {
+			return nil, gostorage.ErrInvalidConfig
+		}
+		names[r.Name] = true
+	}
+
+	quorum := cfg.WriteQuorum
+	if quorum < 0 || quorum > len(cfg.Replicas) {
+		return nil, gostorage.ErrInvalidConfig
+	}
+	if quorum == 0 {
+		quorum = len(cfg.Replicas)
+	}
+
+	if cfg.RepairQueue == nil {
+		cfg.RepairQueue = NewMemoryRepairQueue()
+	}
+
+	return &MirrorStorage{
+		replicas:   cfg.Replicas,
+		quorum:     quorum,
+		queue:      cfg.RepairQueue,
+		config:     cfg,
+		tombstones: make(map[string]map[string]bool),
+	}, nil
+}
+
+// deleted reports whether key has a tombstone.
+func (s *MirrorStorage) deleted(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tombstones[key] != nil
+}
+
+// bury records that key was deleted while the replicas named in missed still have it.
+// Without such replicas the key is as deleted everywhere as it gets, and any tombstone is removed.
+func (s *MirrorStorage) bury(key string, missed map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(missed) == 0 {
+		delete(s.tombstones, key)
+		return
+	}
+
+	s.tombstones[key] = missed
+}
+
+// unbury removes the tombstone of key once replica no longer has the file, or right away if
+// replica is "" because the key was written again.
+func (s *MirrorStorage) unbury(key, replica string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	missed := s.tombstones[key]
+	delete(missed, replica)
+	if replica == "" || len(missed) == 0 {
+		delete(s.tombstones, key)
+	}
+}
+
+// replicaResult is the outcome of a write on one replica.
+type replicaResult struct {
+	url string
+	err error
+}
+
+// fanOut runs write on every replica concurrently and records a repair task for each failure.
+// It returns the results in replica order and the error of the first failed replica if fewer
+// than WriteQuorum replicas succeeded.
+func (s *MirrorStorage) fanOut(ctx context.Context, op Op, key string, write func(gostorage.StorageDriver) (string, error)) ([]replicaResult, error) {
+	results := make([]replicaResult, len(s.replicas))
+
+	var wg sync.WaitGroup
+	for i, r := range s.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].url, results[i].err = write(r.Driver)
+		}()
+	}
+	wg.Wait()
+
+	var sources []string
+	for i, res := range results {
+		if res.err == nil {
+			sources = append(sources, s.replicas[i].Name)
+		}
+	}
+
+	var firstErr error
+	for i, res := range results {
+		if res.err == nil {
+			continue
+		}
+
+		if firstErr == nil {
+			firstErr = res.err
+		}
+
+		name := s.replicas[i].Name
+		log.Error().Err(res.err).Str("key", key).Str("replica", name).Str("op", string(op)).Msg("failed to write to replica")
+
+		task := RepairTask{Key: key, Replica: name, Op: op, Err: res.err.Error(), FailedAt: time.Now(), Sources: sources}
+		if err := s.queue.Enqueue(context.WithoutCancel(ctx), task); err != nil {
+			log.Error().Err(err).Str("key", key).Str("replica", name).Msg("failed to queue replica repair")
+		}
+	}
+
+	if len(sources) < s.quorum {
+		return results, firstErr
+	}
+
+	return results, nil
+}
+
+// Capabilities reports the features supported by any replica, since reads fail over between them.
+// Batch deletes, copies and multipart uploads are not supported.
+func (s *MirrorStorage) Capabilities() gostorage.Capabilities {
+	var caps gostorage.Capabilities
+	for _, r := range s.replicas {
+		c := gostorage.CapabilitiesOf(r.Driver)
+		caps.SignedURL = caps.SignedURL || c.SignedURL
+		caps.PublicURL = caps.PublicURL || c.PublicURL
+		caps.List = caps.List || c.List
+		caps.RangeRead = caps.RangeRead || c.RangeRead
+	}
+
+	return caps
+}
+
+// Delete removes a file from every replica.
+// Returns the first replica's error if fewer than WriteQuorum replicas succeeded.
+// Usage: Call when you want to delete a file by its key.
+func (s *MirrorStorage) Delete(ctx context.Context, key string) error {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	results, err := s.fanOut(ctx, OpDelete, key, func(driver gostorage.StorageDriver) (string, error) {
+		return "", driver.Delete(ctx, key)
+	})
+	if err != nil {
+		return err
+	}
+
+	missed := make(map[string]bool)
+	for i, res := range results {
+		if res.err != nil {
+			missed[s.replicas[i].Name] = true
+		}
+	}
+	s.bury(key, missed)
+
+	return nil
+}
+
+// Exists reports whether any replica has the file, asking the primary first.
+// Keys with a tombstone are reported missing. An error is only returned if no replica could answer.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *MirrorStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return false, err
+	}
+
+	if s.deleted(key) {
+		return false, nil
+	}
+
+	answered := false
+	var firstErr error
+	for _, r := range s.replicas {
+		exists, err := r.Driver.Exists(ctx, key)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if exists {
+			return true, nil
+		}
+		answered = true
+	}
+
+	if answered {
+		return false, nil
+	}
+
+	return false, firstErr
+}
+
+// failover calls read on every replica in order until one succeeds, and returns
+// gostorage.ErrNotFound right away for keys with a tombstone. If all fail, it returns the most
+// relevant error: any failure beats gostorage.ErrNotFound, which beats gostorage.ErrNotSupported
+// from replicas lacking the feature.
+func failover[T any](s *MirrorStorage, key string, read func(gostorage.StorageDriver) (T, error)) (T, error) {
+	var zero T
+	if err := gostorage.ValidateKey(key); err != nil {
+		return zero, err
+	}
+
+	if s.deleted(key) {
+		return zero, gostorage.ErrNotFound
+	}
+
+	var bestErr error
+	for _, r := range s.replicas {
+		v, err := read(r.Driver)
+		if err == nil {
+			return v, nil
+		}
+
+		if bestErr == nil || errorRank(err) > errorRank(bestErr) {
+			bestErr = err
+		}
+	}
+
+	return zero, bestErr
+}
+
+// errorRank orders read errors by how much they tell the caller.
+func errorRank(err error) int {
+	switch {
+	case errors.Is(err, gostorage.ErrNotSupported):
+		return 0
+	case errors.Is(err, gostorage.ErrNotFound):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// GetRange reads a byte range from the first replica that can serve it.
+// Replicas not implementing gostorage.RangeReader are skipped.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *MirrorStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return failover(s, key, func(driver gostorage.StorageDriver) (io.ReadCloser, error) {
+		reader, ok := driver.(gostorage.RangeReader)
+		if !ok {
+			return nil, gostorage.ErrNotSupported
+		}
+
+		return reader.GetRange(ctx, key, offset, length)
+	})
+}
+
+// GetSignedURL returns a signed URL from the first replica that can create one.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *MirrorStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return failover(s, key, func(driver gostorage.StorageDriver) (string, error) {
+		return driver.GetSignedURL(ctx, key, expiry)
+	})
+}
+
+// GetURL returns a public URL from the first replica that can create one.
+// Usage: Call this to display or embed media that anyone can access.
+func (s *MirrorStorage) GetURL(ctx context.Context, key string) (string, error) {
+	return failover(s, key, func(driver gostorage.StorageDriver) (string, error) {
+		return driver.GetURL(ctx, key)
+	})
+}
+
+// List lists files from the first replica implementing gostorage.Lister, falling over to the
+// next one only if listing fails before any file was reported. Keys with a tombstone are skipped.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *MirrorStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	err := error(gostorage.ErrNotSupported)
+	for _, r := range s.replicas {
+		lister, ok := r.Driver.(gostorage.Lister)
+		if !ok {
+			continue
+		}
+
+		reported := false
+		err = lister.List(ctx, opts, func(obj gostorage.ObjectInfo) error {
+			if !obj.IsDir && s.deleted(obj.Key) {
+				return nil
+			}
+
+			reported = true
+			return fn(obj)
+		})
+		if err == nil || reported {
+			return err
+		}
+
+		log.Error().Err(err).Str("prefix", opts.Prefix).Str("replica", r.Name).Msg("failed to list replica")
+	}
+
+	return err
+}
+
+// Stat returns the file information of the first replica that has the file.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *MirrorStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	return failover(s, key, func(driver gostorage.StorageDriver) (gostorage.ObjectInfo, error) {
+		reader, ok := driver.(gostorage.RangeReader)
+		if !ok {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotSupported
+		}
+
+		return reader.Stat(ctx, key)
+	})
+}
+
+// Put buffers the file in TempDir and uploads it to every replica concurrently.
+// Returns the URL reported by the primary, or by the first replica that succeeded if the
+// primary failed, and the first replica's error if fewer than WriteQuorum replicas succeeded.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *MirrorStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(s.config.TempDir, "gostorage-mirror-*")
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to create temporary file for mirroring")
+		return "", gostorage.ErrInternal
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, file)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to buffer file for mirroring")
+		return "", gostorage.ErrInternal
+	}
+
+	results, err := s.fanOut(ctx, OpPut, key, func(driver gostorage.StorageDriver) (string, error) {
+		return driver.Put(ctx, key, io.NewSectionReader(tmp, 0, size))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.unbury(key, "")
+
+	for _, res := range results {
+		if res.err == nil {
+			return res.url, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,273 @@
+package mirrordriver
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// content reads a whole file from a driver implementing gostorage.RangeReader.
+func content(t *testing.T, driver gostorage.StorageDriver, key string) string {
+	t.Helper()
+
+	body, err := driver.(gostorage.RangeReader).GetRange(context.Background(), key, 0, -1)
+	require.NoError(t, err, "expected no error reading "+key)
+	defer body.Close()
+
+	got, err := io.ReadAll(body)
+	require.NoError(t, err, "expected no error reading body")
+
+	return string(got)
+}
+
+// newTestMirror mirrors two flaky disks, "aws" being the primary.
+func newTestMirror(t *testing.T, quorum int) (*MirrorStorage, *storagetest.FlakyDisk, *storagetest.FlakyDisk) {
+	t.Helper()
+
+	aws, r2 := storagetest.NewFlakyDisk(t, "https://aws.example.com"), storagetest.NewFlakyDisk(t, "https://r2.example.com")
+	driver, err := NewMirrorStorage(MirrorStorageConfig{
+		Replicas:    []Replica{{Name: "aws", Driver: aws}, {Name: "r2", Driver: r2}},
+		WriteQuorum: quorum,
+		TempDir:     t.TempDir(),
+	})
+	require.NoError(t, err, "expected no error creating mirror storage")
+
+	return driver.(*MirrorStorage), aws, r2
+}
+
+func TestNewMirrorStorage(t *testing.T) {
+	disk := new(gostorage.MockStorageDriver)
+
+	tests := []struct {
+		name        string
+		cfg         MirrorStorageConfig
+		expectedErr error
+	}{
+		{
+			name:        "should create mirror storage successfully",
+			cfg:         MirrorStorageConfig{Replicas: []Replica{{Name: "a", Driver: disk}, {Name: "b", Driver: disk}}, WriteQuorum: 1},
+			expectedErr: nil,
+		},
+		{
+			name:        "should return error without replicas",
+			cfg:         MirrorStorageConfig{},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error for duplicate replica names",
+			cfg:         MirrorStorageConfig{Replicas: []Replica{{Name: "a", Driver: disk}, {Name: "a", Driver: disk}}},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error for replica without driver",
+			cfg:         MirrorStorageConfig{Replicas: []Replica{{Name: "a"}}},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error when quorum exceeds replicas",
+			cfg:         MirrorStorageConfig{Replicas: []Replica{{Name: "a", Driver: disk}}, WriteQuorum: 2},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewMirrorStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error when config is invalid")
+				assert.Nil(t, storage, "expected storage to be nil on error")
+			} else {
+				assert.NoError(t, err, "expected no error creating storage")
+			}
+		})
+	}
+}
+
+func TestMirrorStorage_PutWritesEveryReplica(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, r2 := newTestMirror(t, 0)
+
+	location, err := storage.Put(ctx, "backups/db.sql", strings.NewReader("dump"))
+	assert.NoError(t, err, "expected no error putting file")
+	assert.Equal(t, "https://aws.example.com/backups/db.sql", location, "expected URL of the primary")
+
+	assert.Equal(t, "dump", content(t, aws, "backups/db.sql"), "expected file on the primary")
+	assert.Equal(t, "dump", content(t, r2, "backups/db.sql"), "expected file on the replica")
+
+	pending, _ := storage.queue.Len(ctx)
+	assert.Zero(t, pending, "expected no repairs after a complete write")
+}
+
+func TestMirrorStorage_WriteQuorum(t *testing.T) {
+	tests := []struct {
+		name        string
+		quorum      int
+		expectedErr error
+	}{
+		{name: "should succeed when the quorum is met", quorum: 1, expectedErr: nil},
+		{name: "should fail when every replica is required", quorum: 0, expectedErr: gostorage.ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage, aws, r2 := newTestMirror(t, tt.quorum)
+			aws.Down.Store(true)
+
+			location, err := storage.Put(ctx, "a.txt", strings.NewReader("hello"))
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error of the failed replica")
+			} else {
+				assert.NoError(t, err, "expected no error with quorum met")
+				assert.Equal(t, "https://r2.example.com/a.txt", location, "expected URL of the replica that succeeded")
+			}
+
+			assert.Equal(t, "hello", content(t, r2, "a.txt"), "expected file on the healthy replica")
+
+			task, ok, err := storage.queue.Dequeue(ctx)
+			require.NoError(t, err, "expected no error reading queue")
+			require.True(t, ok, "expected the failed write to be queued")
+			assert.Equal(t, "aws", task.Replica, "expected the failed replica")
+			assert.Equal(t, OpPut, task.Op, "expected the failed operation")
+			assert.Equal(t, "a.txt", task.Key, "expected the written key")
+		})
+	}
+}
+
+func TestMirrorStorage_DeleteWritesEveryReplica(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, r2 := newTestMirror(t, 1)
+
+	_, err := storage.Put(ctx, "a.txt", strings.NewReader("hello"))
+	require.NoError(t, err, "expected no error putting file")
+
+	r2.Down.Store(true)
+	assert.NoError(t, storage.Delete(ctx, "a.txt"), "expected no error with quorum met")
+
+	exists, _ := aws.Exists(ctx, "a.txt")
+	assert.False(t, exists, "expected file to be deleted from the primary")
+
+	task, ok, _ := storage.queue.Dequeue(ctx)
+	require.True(t, ok, "expected the failed delete to be queued")
+	assert.Equal(t, RepairTask{Key: "a.txt", Replica: "r2", Op: OpDelete, Err: gostorage.ErrInternal.Error(), FailedAt: task.FailedAt, Sources: []string{"aws"}}, task, "expected repair task to match")
+
+	assert.ErrorIs(t, storage.Delete(ctx, "../a.txt"), gostorage.ErrInvalidKey, "expected invalid keys to be rejected")
+}
+
+func TestMirrorStorage_ReadFailover(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, _ := newTestMirror(t, 1)
+
+	_, err := storage.Put(ctx, "videos/clip.txt", strings.NewReader("clip content"))
+	require.NoError(t, err, "expected no error putting file")
+
+	aws.Down.Store(true)
+
+	body, err := storage.GetRange(ctx, "videos/clip.txt", 5, 7)
+	require.NoError(t, err, "expected read to fail over to the replica")
+	got, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "content", string(got), "expected range from the replica")
+
+	exists, err := storage.Exists(ctx, "videos/clip.txt")
+	assert.NoError(t, err, "expected no error while a replica answers")
+	assert.True(t, exists, "expected file to exist on the replica")
+
+	info, err := storage.Stat(ctx, "videos/clip.txt")
+	assert.NoError(t, err, "expected stat to fail over to the replica")
+	assert.Equal(t, int64(len("clip content")), info.Size, "expected size to match")
+
+	location, err := storage.GetURL(ctx, "videos/clip.txt")
+	assert.NoError(t, err, "expected URL from the replica")
+	assert.Equal(t, "https://r2.example.com/videos/clip.txt", location, "expected URL of the replica")
+
+	aws.Down.Store(false)
+	_, err = storage.GetRange(ctx, "videos/missing.txt", 0, -1)
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound when no replica has the file")
+}
+
+func TestMirrorStorage_ReadFallsBackWhenPrimaryMissedWrite(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, _ := newTestMirror(t, 1)
+
+	aws.Down.Store(true)
+	_, err := storage.Put(ctx, "a.txt", strings.NewReader("from r2"))
+	require.NoError(t, err, "expected no error with quorum met")
+	aws.Down.Store(false)
+
+	assert.Equal(t, "from r2", content(t, storage, "a.txt"), "expected the replica to serve the file the primary missed")
+}
+
+func TestMirrorStorage_List(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, r2 := newTestMirror(t, 1)
+	for _, key := range []string{"a/b.txt", "c.txt"} {
+		_, err := storage.Put(ctx, key, strings.NewReader("x"))
+		require.NoError(t, err, "expected no error putting file")
+	}
+	_, err := r2.Put(ctx, "only-on-r2.txt", strings.NewReader("x"))
+	require.NoError(t, err, "expected no error putting file on the replica")
+
+	var keys []string
+	err = storage.List(ctx, gostorage.ListOptions{Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	assert.NoError(t, err, "expected no error listing")
+	assert.Equal(t, []string{"a/b.txt", "c.txt"}, keys, "expected files of the primary")
+
+	aws.Down.Store(true)
+	require.NoError(t, storage.Delete(ctx, "c.txt"), "expected no error with quorum met")
+	aws.Down.Store(false)
+
+	keys = nil
+	err = storage.List(ctx, gostorage.ListOptions{Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	assert.NoError(t, err, "expected no error listing")
+	assert.Equal(t, []string{"a/b.txt"}, keys, "expected files deleted on a quorum to be skipped")
+	assert.True(t, storage.Capabilities().List, "expected list capability")
+}
+
+func TestMirrorStorage_Capabilities(t *testing.T) {
+	storage, _, _ := newTestMirror(t, 0)
+
+	assert.Equal(t, gostorage.Capabilities{PublicURL: true, List: true, RangeRead: true}, storage.Capabilities(), "expected capabilities of the replicas")
+	_, err := storage.GetSignedURL(context.Background(), "a.txt", time.Minute)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected no signed URLs from local disks without secret")
+}
+
+func TestMirrorStorage_ReadsRespectPartialDelete(t *testing.T) {
+	ctx := context.Background()
+	storage, _, r2 := newTestMirror(t, 1)
+
+	_, err := storage.Put(ctx, "a.txt", strings.NewReader("hello"))
+	require.NoError(t, err, "expected no error putting file")
+
+	r2.Down.Store(true)
+	require.NoError(t, storage.Delete(ctx, "a.txt"), "expected no error with quorum met")
+	r2.Down.Store(false)
+
+	exists, err := storage.Exists(ctx, "a.txt")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.False(t, exists, "expected the deleted file to be missing")
+
+	_, err = storage.GetRange(ctx, "a.txt", 0, -1)
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected the replica that missed the delete not to serve the file")
+
+	_, err = storage.GetURL(ctx, "a.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected no URL for the deleted file")
+
+	_, err = storage.Put(ctx, "a.txt", strings.NewReader("again"))
+	require.NoError(t, err, "expected no error writing the file again")
+	assert.Equal(t, "again", content(t, storage, "a.txt"), "expected a new write to replace the tombstone")
+}
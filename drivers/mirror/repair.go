@@ -0,0 +1,207 @@
+package mirrordriver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// Op is the write operation that failed on a replica.
+type Op string
+
+const (
+	OpPut    Op = "put"
+	OpDelete Op = "delete"
+)
+
+// RepairTask records a write that failed on one replica.
+type RepairTask struct {
+	Key      string    // key of the file that was written
+	Replica  string    // name of the replica that missed the write
+	Op       Op        // operation that failed
+	Err      string    // error message of the failure
+	FailedAt time.Time // time of the first failure
+	Attempts int       // number of failed repairs so far
+	Sources  []string  // names of the replicas that accepted the write, the only ones repairs copy from
+}
+
+// RepairQueue holds the replica writes waiting to be repaired.
+// Implementations must be safe for concurrent use. MemoryRepairQueue is the built-in implementation;
+// implement RepairQueue on a database or message queue to keep pending repairs across restarts.
+type RepairQueue interface {
+	// Enqueue adds a task to the end of the queue.
+	Enqueue(ctx context.Context, task RepairTask) error
+
+	// Dequeue removes and returns the oldest task, or false if the queue is empty.
+	Dequeue(ctx context.Context) (RepairTask, bool, error)
+
+	// Len returns the number of tasks in the queue.
+	Len(ctx context.Context) (int, error)
+}
+
+// MemoryRepairQueue is a RepairQueue held in memory.
+type MemoryRepairQueue struct {
+	mu    sync.Mutex
+	tasks []RepairTask
+}
+
+// NewMemoryRepairQueue returns an empty MemoryRepairQueue.
+func NewMemoryRepairQueue() *MemoryRepairQueue {
+	return &MemoryRepairQueue{}
+}
+
+func (q *MemoryRepairQueue) Enqueue(ctx context.Context, task RepairTask) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tasks = append(q.tasks, task)
+	return nil
+}
+
+func (q *MemoryRepairQueue) Dequeue(ctx context.Context) (RepairTask, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tasks) == 0 {
+		return RepairTask{}, false, nil
+	}
+
+	task := q.tasks[0]
+	q.tasks = q.tasks[1:]
+
+	return task, true, nil
+}
+
+func (q *MemoryRepairQueue) Len(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.tasks), nil
+}
+
+// RepairReport is the outcome of a MirrorStorage.Repair call.
+type RepairReport struct {
+	Repaired int // tasks whose replica now matches the others
+	Failed   int // tasks that failed again and were queued for the next run
+}
+
+// Repair replays the tasks queued when Repair was called. For every task, the replica is brought
+// in line with the replicas that accepted the write, regardless of the failed operation: the file
+// is copied from the first of them that has it (the primary if possible), or deleted if none has
+// it, so tasks stay correct even if the key was written again since. Replicas that missed the
+// write too may hold an older version and are never copied from; tasks without Sources consider
+// every other replica. Keys with a tombstone are deleted from the
+// replica instead, since other replicas may still hold the file only because they missed the
+// delete. Tasks that fail again are queued with Attempts incremented. Copying needs a source
+// replica implementing gostorage.RangeReader.
+// Usage: Run periodically from a background job, e.g. every minute.
+func (s *MirrorStorage) Repair(ctx context.Context) (RepairReport, error) {
+	var report RepairReport
+
+	n, err := s.queue.Len(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to read replica repair queue")
+		return report, gostorage.ErrInternal
+	}
+
+	for range n {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		task, ok, err := s.queue.Dequeue(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to read replica repair queue")
+			return report, gostorage.ErrInternal
+		}
+		if !ok {
+			break
+		}
+
+		if err := s.repair(ctx, task); err != nil {
+			log.Error().Err(err).Str("key", task.Key).Str("replica", task.Replica).Msg("failed to repair replica")
+
+			report.Failed++
+			task.Attempts++
+			task.Err = err.Error()
+			if err := s.queue.Enqueue(context.WithoutCancel(ctx), task); err != nil {
+				log.Error().Err(err).Str("key", task.Key).Str("replica", task.Replica).Msg("failed to queue replica repair")
+				return report, gostorage.ErrInternal
+			}
+			continue
+		}
+
+		report.Repaired++
+	}
+
+	return report, nil
+}
+
+// repair copies the file of task from a source replica to the task's replica, or deletes it there.
+func (s *MirrorStorage) repair(ctx context.Context, task RepairTask) error {
+	var target gostorage.StorageDriver
+	for _, r := range s.replicas {
+		if r.Name == task.Replica {
+			target = r.Driver
+		}
+	}
+	if target == nil {
+		// The replica was removed from the configuration; nothing left to repair.
+		s.unbury(task.Key, task.Replica)
+		return nil
+	}
+
+	if s.deleted(task.Key) {
+		if err := target.Delete(ctx, task.Key); err != nil {
+			return err
+		}
+
+		s.unbury(task.Key, task.Replica)
+		return nil
+	}
+
+	found := false
+	for _, r := range s.replicas {
+		if r.Name == task.Replica || (len(task.Sources) > 0 && !slices.Contains(task.Sources, r.Name)) {
+			continue
+		}
+
+		exists, err := r.Driver.Exists(ctx, task.Key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		found = true
+		source, ok := r.Driver.(gostorage.RangeReader)
+		if !ok {
+			continue
+		}
+
+		body, err := source.GetRange(ctx, task.Key, 0, -1)
+		if err != nil {
+			if errors.Is(err, gostorage.ErrNotFound) {
+				continue // deleted meanwhile
+			}
+			return err
+		}
+		defer body.Close()
+
+		_, err = target.Put(ctx, task.Key, body)
+		return err
+	}
+
+	if found {
+		// Only replicas that cannot be read from have the file; keep it until one can.
+		return gostorage.ErrNotSupported
+	}
+
+	return target.Delete(ctx, task.Key)
+}
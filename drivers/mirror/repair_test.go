@@ -0,0 +1,150 @@
+package mirrordriver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepairQueue(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryRepairQueue()
+
+	_, ok, err := queue.Dequeue(ctx)
+	assert.NoError(t, err, "expected no error reading empty queue")
+	assert.False(t, ok, "expected empty queue")
+
+	for _, key := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, queue.Enqueue(ctx, RepairTask{Key: key}), "expected no error queueing task")
+	}
+
+	n, _ := queue.Len(ctx)
+	assert.Equal(t, 2, n, "expected both tasks to be queued")
+
+	task, ok, _ := queue.Dequeue(ctx)
+	assert.True(t, ok, "expected a task")
+	assert.Equal(t, "a.txt", task.Key, "expected tasks in insertion order")
+}
+
+func TestMirrorStorage_RepairCopiesMissedPut(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, r2 := newTestMirror(t, 1)
+
+	r2.Down.Store(true)
+	_, err := storage.Put(ctx, "a.txt", strings.NewReader("v1"))
+	require.NoError(t, err, "expected no error with quorum met")
+
+	report, err := storage.Repair(ctx)
+	assert.NoError(t, err, "expected no error while the replica is down")
+	assert.Equal(t, RepairReport{Failed: 1}, report, "expected the repair to fail while the replica is down")
+
+	task, ok, _ := storage.queue.Dequeue(ctx)
+	require.True(t, ok, "expected the task to be queued again")
+	assert.Equal(t, 1, task.Attempts, "expected the failed attempt to be counted")
+	require.NoError(t, storage.queue.Enqueue(ctx, task), "expected no error queueing task")
+
+	r2.Down.Store(false)
+	_, err = aws.Put(ctx, "a.txt", strings.NewReader("v2")) // written again since the failure
+	require.NoError(t, err, "expected no error overwriting the primary")
+
+	report, err = storage.Repair(ctx)
+	assert.NoError(t, err, "expected no error repairing")
+	assert.Equal(t, RepairReport{Repaired: 1}, report, "expected the task to be repaired")
+	assert.Equal(t, "v2", content(t, r2, "a.txt"), "expected the replica to receive the current content")
+
+	n, _ := storage.queue.Len(ctx)
+	assert.Zero(t, n, "expected the queue to be drained")
+}
+
+func TestMirrorStorage_RepairAppliesMissedDelete(t *testing.T) {
+	ctx := context.Background()
+	storage, _, r2 := newTestMirror(t, 1)
+
+	_, err := storage.Put(ctx, "a.txt", strings.NewReader("v1"))
+	require.NoError(t, err, "expected no error putting file")
+
+	r2.Down.Store(true)
+	require.NoError(t, storage.Delete(ctx, "a.txt"), "expected no error with quorum met")
+	r2.Down.Store(false)
+
+	exists, _ := r2.Exists(ctx, "a.txt")
+	require.True(t, exists, "expected the replica to still have the file")
+
+	report, err := storage.Repair(ctx)
+	assert.NoError(t, err, "expected no error repairing")
+	assert.Equal(t, RepairReport{Repaired: 1}, report, "expected the task to be repaired")
+
+	exists, _ = r2.Exists(ctx, "a.txt")
+	assert.False(t, exists, "expected the file to be deleted from the replica")
+}
+
+func TestMirrorStorage_RepairSkipsRemovedReplica(t *testing.T) {
+	ctx := context.Background()
+	storage, _, _ := newTestMirror(t, 0)
+	require.NoError(t, storage.queue.Enqueue(ctx, RepairTask{Key: "a.txt", Replica: "gcs", Op: OpPut}), "expected no error queueing task")
+
+	report, err := storage.Repair(ctx)
+	assert.NoError(t, err, "expected no error repairing")
+	assert.Equal(t, RepairReport{Repaired: 1}, report, "expected tasks of unknown replicas to be dropped")
+
+	_, err = storage.GetRange(ctx, "a.txt", 0, -1)
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected nothing to be written")
+}
+
+func TestMirrorStorage_RepairDoesNotResurrectDeletes(t *testing.T) {
+	ctx := context.Background()
+	storage, aws, r2 := newTestMirror(t, 1)
+
+	aws.Down.Store(true)
+	_, err := storage.Put(ctx, "a.txt", strings.NewReader("v1"))
+	require.NoError(t, err, "expected no error with quorum met")
+	aws.Down.Store(false)
+
+	r2.Down.Store(true)
+	require.NoError(t, storage.Delete(ctx, "a.txt"), "expected no error with quorum met")
+	r2.Down.Store(false)
+
+	report, err := storage.Repair(ctx)
+	assert.NoError(t, err, "expected no error repairing")
+	assert.Equal(t, RepairReport{Repaired: 2}, report, "expected both tasks to be repaired")
+
+	for _, disk := range []*storagetest.FlakyDisk{aws, r2} {
+		exists, _ := disk.Exists(ctx, "a.txt")
+		assert.False(t, exists, "expected the deleted file not to be copied back")
+	}
+	assert.False(t, storage.deleted("a.txt"), "expected the tombstone to be removed once repaired")
+}
+
+func TestMirrorStorage_RepairCopiesFromReplicasThatAcceptedTheWrite(t *testing.T) {
+	ctx := context.Background()
+	aws, r2, gcs := storagetest.NewFlakyDisk(t, ""), storagetest.NewFlakyDisk(t, ""), storagetest.NewFlakyDisk(t, "")
+	driver, err := NewMirrorStorage(MirrorStorageConfig{
+		Replicas:    []Replica{{Name: "aws", Driver: aws}, {Name: "r2", Driver: r2}, {Name: "gcs", Driver: gcs}},
+		WriteQuorum: 1,
+		TempDir:     t.TempDir(),
+	})
+	require.NoError(t, err, "expected no error creating mirror storage")
+	storage := driver.(*MirrorStorage)
+
+	_, err = storage.Put(ctx, "a.txt", strings.NewReader("v1"))
+	require.NoError(t, err, "expected no error putting file")
+
+	aws.Down.Store(true)
+	r2.Down.Store(true)
+	_, err = storage.Put(ctx, "a.txt", strings.NewReader("v2"))
+	require.NoError(t, err, "expected no error with quorum met")
+	aws.Down.Store(false)
+	r2.Down.Store(false)
+
+	report, err := storage.Repair(ctx)
+	assert.NoError(t, err, "expected no error repairing")
+	assert.Equal(t, RepairReport{Repaired: 2}, report, "expected both missed writes to be repaired")
+
+	assert.Equal(t, "v2", content(t, aws, "a.txt"), "expected the primary to receive the accepted version")
+	assert.Equal(t, "v2", content(t, r2, "a.txt"), "expected the replica not to be copied the stale version of the primary")
+}
@@ -1,14 +1,67 @@
 package s3driver
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 // mockS3Client simulates s3.Client's PutObject behavior
 type mockS3Client struct {
 	err error
+
+	failedDeleteKeys   map[string]bool // keys reported as failed by DeleteObjects
+	deleteObjectsCalls [][]string      // keys received by each DeleteObjects call
+
+	listPages      []*s3.ListObjectsV2Output // pages returned by ListObjectsV2, in order
+	listObjectsErr error                     // error returned by ListObjectsV2
+	listInputs     []*s3.ListObjectsV2Input  // inputs received by ListObjectsV2
+
+	objectData []byte               // content served by GetObject, honoring the requested range
+	headOutput *s3.HeadObjectOutput // output returned by HeadObject
+	getErr     error                // error returned by GetObject
+	getInputs  []*s3.GetObjectInput // inputs received by GetObject
+
+	grants       []types.Grant           // grants returned by GetObjectAcl
+	aclErr       error                   // error returned by GetObjectAcl and PutObjectAcl
+	putACLInputs []*s3.PutObjectAclInput // inputs received by PutObjectAcl
+
+	tags         []types.Tag                 // tags returned by GetObjectTagging
+	tagErr       error                       // error returned by GetObjectTagging and PutObjectTagging
+	putTagInputs []*s3.PutObjectTaggingInput // inputs received by PutObjectTagging
+
+	multipartErr   error                              // error returned by the multipart calls
+	uploadParts    map[int32][]byte                   // parts received by UploadPart
+	completeInputs []*s3.CompleteMultipartUploadInput // inputs received by CompleteMultipartUpload
+	abortedUploads []string                           // upload IDs received by AbortMultipartUpload
+}
+
+func (m *mockS3Client) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	m.abortedUploads = append(m.abortedUploads, aws.ToString(params.UploadId))
+	if m.multipartErr != nil {
+		return nil, m.multipartErr
+	}
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3Client) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.completeInputs = append(m.completeInputs, params)
+	if m.multipartErr != nil {
+		return nil, m.multipartErr
+	}
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3Client) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	if m.multipartErr != nil {
+		return nil, m.multipartErr
+	}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
 }
 
 func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
@@ -18,16 +71,138 @@ func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObject
 	return &s3.DeleteObjectOutput{}, nil
 }
 
+func (m *mockS3Client) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	keys := make([]string, len(params.Delete.Objects))
+	for i, obj := range params.Delete.Objects {
+		keys[i] = aws.ToString(obj.Key)
+	}
+	m.deleteObjectsCalls = append(m.deleteObjectsCalls, keys)
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	out := &s3.DeleteObjectsOutput{}
+	for _, key := range keys {
+		if m.failedDeleteKeys[key] {
+			out.Errors = append(out.Errors, types.Error{
+				Key:     aws.String(key),
+				Code:    aws.String("AccessDenied"),
+				Message: aws.String("Access Denied"),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (m *mockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.getInputs = append(m.getInputs, params)
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+
+	data := m.objectData
+	if params.Range != nil {
+		var start, end int64
+		if n, _ := fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-%d", &start, &end); n == 2 {
+			data = data[start:min(end+1, int64(len(data)))]
+		} else {
+			data = data[start:]
+		}
+	}
+
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+	}, nil
+}
+
+func (m *mockS3Client) GetObjectAcl(ctx context.Context, params *s3.GetObjectAclInput, optFns ...func(*s3.Options)) (*s3.GetObjectAclOutput, error) {
+	if m.aclErr != nil {
+		return nil, m.aclErr
+	}
+	return &s3.GetObjectAclOutput{Grants: m.grants}, nil
+}
+
+func (m *mockS3Client) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	if m.tagErr != nil {
+		return nil, m.tagErr
+	}
+	return &s3.GetObjectTaggingOutput{TagSet: m.tags}, nil
+}
+
+func (m *mockS3Client) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
 func (m *mockS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	if m.headOutput != nil {
+		return m.headOutput, nil
+	}
 	return &s3.HeadObjectOutput{}, nil
 }
 
+func (m *mockS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.listInputs = append(m.listInputs, params)
+	if m.listObjectsErr != nil {
+		return nil, m.listObjectsErr
+	}
+
+	page := len(m.listInputs) - 1
+	if page >= len(m.listPages) {
+		return &s3.ListObjectsV2Output{}, nil
+	}
+
+	out := *m.listPages[page]
+	if page < len(m.listPages)-1 {
+		out.IsTruncated = aws.Bool(true)
+		out.NextContinuationToken = aws.String(fmt.Sprintf("page-%d", page+1))
+	}
+	return &out, nil
+}
+
 func (m *mockS3Client) PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	return &s3.PutObjectOutput{}, nil
 }
+
+func (m *mockS3Client) PutObjectAcl(ctx context.Context, params *s3.PutObjectAclInput, optFns ...func(*s3.Options)) (*s3.PutObjectAclOutput, error) {
+	m.putACLInputs = append(m.putACLInputs, params)
+	if m.aclErr != nil {
+		return nil, m.aclErr
+	}
+	return &s3.PutObjectAclOutput{}, nil
+}
+
+func (m *mockS3Client) PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error) {
+	m.putTagInputs = append(m.putTagInputs, params)
+	if m.tagErr != nil {
+		return nil, m.tagErr
+	}
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+func (m *mockS3Client) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if m.multipartErr != nil {
+		return nil, m.multipartErr
+	}
+
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	if m.uploadParts == nil {
+		m.uploadParts = make(map[int32][]byte)
+	}
+	m.uploadParts[aws.ToInt32(params.PartNumber)] = data
+
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf(`"etag-%d"`, aws.ToInt32(params.PartNumber)))}, nil
+}
@@ -0,0 +1,111 @@
+package s3driver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DriverName is the name the S3 driver is registered under for gostorage.NewFromConfig.
+const DriverName = "s3"
+
+func init() {
+	gostorage.RegisterDriver(DriverName, openFromConfig)
+	gostorage.RegisterURLScheme(DriverName, parseURL)
+}
+
+// parseURL converts an S3 DSN into disk options:
+//
+//	s3://ACCESS_KEY:SECRET_KEY@[endpoint]/bucket?region=us-east-1&visibility=private&ssl=false&expiry=15m
+//
+// The host is the custom endpoint (omit it for AWS: s3://key:secret@/bucket). Credentials must be
+// URL-escaped. Any other query parameter is passed through as an option, e.g. default_expiry.
+func parseURL(u *url.URL) (gostorage.DiskConfig, error) {
+	options := make(map[string]string)
+
+	for key, values := range u.Query() {
+		switch key {
+		case "ssl":
+			key = "use_ssl"
+		case "expiry":
+			key = "default_expiry"
+		}
+		options[key] = values[len(values)-1]
+	}
+
+	if u.User != nil {
+		options["access_key"] = u.User.Username()
+		if secret, ok := u.User.Password(); ok {
+			options["secret_key"] = secret
+		}
+	}
+
+	if u.Host != "" {
+		options["endpoint"] = u.Host
+	}
+
+	bucket, rest, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if bucket == "" || rest != "" {
+		return gostorage.DiskConfig{}, &gostorage.ConfigError{Field: "dsn", Reason: "must contain exactly one path segment naming the bucket"}
+	}
+	options["bucket"] = bucket
+
+	return gostorage.DiskConfig{Driver: DriverName, Options: options}, nil
+}
+
+// ConfigFromDisk converts generic disk options into an ObjectStorageConfig.
+// Recognized options: bucket, region, access_key, secret_key, endpoint, use_ssl,
+// visibility ("public" or "private") and default_expiry (e.g. "15m").
+// Returns a *gostorage.ConfigError naming the first invalid option.
+func ConfigFromDisk(disk gostorage.DiskConfig) (ObjectStorageConfig, error) {
+	var cfg ObjectStorageConfig
+	var err error
+
+	if cfg.Bucket, err = disk.Require("bucket"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.AccessKey, err = disk.Require("access_key"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.SecretKey, err = disk.Require("secret_key"); err != nil {
+		return cfg, err
+	}
+
+	if cfg.UseSSL, err = disk.Bool("use_ssl", true); err != nil {
+		return cfg, err
+	}
+
+	if cfg.DefaultExpiry, err = disk.Duration("default_expiry", 15*time.Minute); err != nil {
+		return cfg, err
+	}
+
+	cfg.Region = disk.String("region")
+	cfg.Endpoint = disk.String("endpoint")
+
+	switch v := Visibility(disk.String("visibility")); v {
+	case "":
+		cfg.Visibility = VisibilityPrivate
+	case VisibilityPrivate, VisibilityPublic:
+		cfg.Visibility = v
+	default:
+		return cfg, &gostorage.ConfigError{Field: "visibility", Reason: fmt.Sprintf("must be %q or %q, got %q", VisibilityPublic, VisibilityPrivate, v)}
+	}
+
+	return cfg, nil
+}
+
+// openFromConfig is the gostorage.DriverOpener for the S3 driver.
+func openFromConfig(ctx context.Context, disk gostorage.DiskConfig) (gostorage.StorageDriver, error) {
+	cfg, err := ConfigFromDisk(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewObjectStorage(cfg)
+}
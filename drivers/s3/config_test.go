@@ -0,0 +1,200 @@
+package s3driver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromDisk(t *testing.T) {
+	valid := map[string]string{
+		"bucket":     "media",
+		"access_key": "key",
+		"secret_key": "secret",
+	}
+
+	with := func(overrides map[string]string) gostorage.DiskConfig {
+		options := make(map[string]string)
+		for k, v := range valid {
+			options[k] = v
+		}
+		for k, v := range overrides {
+			options[k] = v
+		}
+		return gostorage.DiskConfig{Driver: DriverName, Options: options}
+	}
+
+	tests := []struct {
+		name        string
+		disk        gostorage.DiskConfig
+		expected    ObjectStorageConfig
+		expectField string
+	}{
+		{
+			name: "should apply defaults",
+			disk: with(nil),
+			expected: ObjectStorageConfig{
+				Bucket:        "media",
+				AccessKey:     "key",
+				SecretKey:     "secret",
+				UseSSL:        true,
+				Visibility:    VisibilityPrivate,
+				DefaultExpiry: 15 * time.Minute,
+			},
+		},
+		{
+			name: "should parse every option",
+			disk: with(map[string]string{
+				"region":         "eu-west-1",
+				"endpoint":       "minio:9000",
+				"use_ssl":        "false",
+				"visibility":     "public",
+				"default_expiry": "1h",
+			}),
+			expected: ObjectStorageConfig{
+				Bucket:        "media",
+				Region:        "eu-west-1",
+				AccessKey:     "key",
+				SecretKey:     "secret",
+				Endpoint:      "minio:9000",
+				UseSSL:        false,
+				Visibility:    VisibilityPublic,
+				DefaultExpiry: time.Hour,
+			},
+		},
+		{
+			name:        "should require bucket",
+			disk:        with(map[string]string{"bucket": ""}),
+			expectField: "bucket",
+		},
+		{
+			name:        "should reject invalid boolean",
+			disk:        with(map[string]string{"use_ssl": "sometimes"}),
+			expectField: "use_ssl",
+		},
+		{
+			name:        "should reject unknown visibility",
+			disk:        with(map[string]string{"visibility": "internal"}),
+			expectField: "visibility",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ConfigFromDisk(tt.disk)
+
+			if tt.expectField != "" {
+				var cfgErr *gostorage.ConfigError
+				assert.ErrorAs(t, err, &cfgErr, "expected a config error")
+				assert.Equal(t, tt.expectField, cfgErr.Field, "expected error to name the field")
+				assert.ErrorIs(t, err, gostorage.ErrInvalidConfig, "expected invalid config error")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expected, cfg, "expected config to match")
+		})
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsn         string
+		expected    ObjectStorageConfig
+		expectField string
+	}{
+		{
+			name: "should parse MinIO DSN",
+			dsn:  "s3://key:se%2Fcret@minio:9000/media?region=us-east-1&visibility=public&ssl=false&expiry=1h",
+			expected: ObjectStorageConfig{
+				Bucket:        "media",
+				Region:        "us-east-1",
+				AccessKey:     "key",
+				SecretKey:     "se/cret",
+				Endpoint:      "minio:9000",
+				UseSSL:        false,
+				Visibility:    VisibilityPublic,
+				DefaultExpiry: time.Hour,
+			},
+		},
+		{
+			name: "should parse AWS DSN without endpoint",
+			dsn:  "s3://key:secret@/media?region=eu-west-1",
+			expected: ObjectStorageConfig{
+				Bucket:        "media",
+				Region:        "eu-west-1",
+				AccessKey:     "key",
+				SecretKey:     "secret",
+				UseSSL:        true,
+				Visibility:    VisibilityPrivate,
+				DefaultExpiry: 15 * time.Minute,
+			},
+		},
+		{
+			name:        "should require bucket in path",
+			dsn:         "s3://key:secret@minio:9000",
+			expectField: "dsn",
+		},
+		{
+			name:        "should require credentials",
+			dsn:         "s3://minio:9000/media",
+			expectField: "access_key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := gostorage.ParseDSN(tt.dsn)
+			if err == nil {
+				var cfg ObjectStorageConfig
+				cfg, err = ConfigFromDisk(disk)
+				if tt.expectField == "" {
+					assert.NoError(t, err, "expected no error")
+					assert.Equal(t, tt.expected, cfg, "expected config to match")
+					return
+				}
+			}
+
+			var cfgErr *gostorage.ConfigError
+			assert.ErrorAs(t, err, &cfgErr, "expected a config error")
+			assert.Equal(t, tt.expectField, cfgErr.Field, "expected error to name the field")
+		})
+	}
+}
+
+func TestOpenURL_CustomEndpoint(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.Method + " " + r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	driver, err := gostorage.OpenURL(context.Background(), "s3://key:secret@"+host+"/media?region=us-east-1&ssl=false")
+	require.NoError(t, err, "expected no error opening DSN with a host-only endpoint")
+
+	exists, err := driver.Exists(context.Background(), "file.txt")
+	require.NoError(t, err, "expected request against the endpoint to succeed")
+	assert.True(t, exists, "expected object to exist")
+	assert.Equal(t, "HEAD /media/file.txt", requested, "expected a path-style request to the custom endpoint")
+}
+
+func TestObjectStorageConfig_String(t *testing.T) {
+	cfg := ObjectStorageConfig{Bucket: "media", AccessKey: "key", SecretKey: "super-secret"}
+
+	for _, verb := range []string{"%v", "%+v", "%s", "%#v"} {
+		printed := fmt.Sprintf(verb, cfg)
+		assert.NotContains(t, printed, "super-secret", "expected secret key to be redacted with "+verb)
+		assert.Contains(t, printed, "Bucket:media", "expected other fields to be printed with "+verb)
+	}
+}
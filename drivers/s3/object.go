@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"regexp"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -16,27 +16,52 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// maxDeleteObjects is the maximum number of keys S3 accepts in a single DeleteObjects request.
+const maxDeleteObjects = 1000
+
 type s3Client interface {
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
 	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	GetObjectAcl(ctx context.Context, params *s3.GetObjectAclInput, optFns ...func(*s3.Options)) (*s3.GetObjectAclOutput, error)
+	GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error)
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
 	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
 	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	PutObjectAcl(ctx context.Context, params *s3.PutObjectAclInput, optFns ...func(*s3.Options)) (*s3.PutObjectAclOutput, error)
+	PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
 }
 
 type presignClient interface {
 	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
 }
 
-type Visibility string
+// allUsersGroup is the grantee URI S3 uses for anonymous (public) access in object ACLs.
+const allUsersGroup = "http://acs.amazonaws.com/groups/global/AllUsers"
+
+// visibilityTag is the object tag SetVisibility uses to mark files hidden in a public bucket,
+// whose objects carry no read grant of their own when the bucket is opened by a policy.
+const visibilityTag = "gostorage-visibility"
+
+// Visibility is the bucket's default visibility; see gostorage.Visibility.
+type Visibility = gostorage.Visibility
 
 const (
-	VisibilityPrivate Visibility = "private" // Files are private, need signed URL to access
-	VisibilityPublic  Visibility = "public"  // Files are publicly accessible via direct URL
+	VisibilityPrivate = gostorage.VisibilityPrivate // Files are private, need signed URL to access
+	VisibilityPublic  = gostorage.VisibilityPublic  // Files are publicly accessible via direct URL
 )
 
 // ObjectStorageConfig defines the configuration needed to connect to an S3-compatible storage.
-// You can use this with AWS S3, Cloudflare R2, MinIO, GCS (S3 API), etc.
+// You can use this with AWS S3, Cloudflare R2, MinIO, GCS (S3 API with HMAC keys), etc.
+// For service account signed URLs and resumable uploads on GCS, use the native gcsdriver.
 type ObjectStorageConfig struct {
 	Bucket        string        // bucket name where files will be stored
 	Region        string        // AWS region or equivalent
@@ -48,6 +73,52 @@ type ObjectStorageConfig struct {
 	DefaultExpiry time.Duration // default expiry duration for signed URLs
 }
 
+// String returns the config with the secret key redacted, so it is safe to print or log.
+func (c ObjectStorageConfig) String() string {
+	secret := ""
+	if c.SecretKey != "" {
+		secret = "xxxxx"
+	}
+
+	return fmt.Sprintf(
+		"{Bucket:%s Region:%s AccessKey:%s SecretKey:%s Endpoint:%s UseSSL:%t Visibility:%s DefaultExpiry:%s}",
+		c.Bucket, c.Region, c.AccessKey, secret, c.Endpoint, c.UseSSL, c.Visibility, c.DefaultExpiry,
+	)
+}
+
+// GoString is like String, so %#v does not leak the secret key either.
+func (c ObjectStorageConfig) GoString() string {
+	return "s3driver.ObjectStorageConfig" + c.String()
+}
+
+// endpointURL returns Endpoint as the full URL the SDK needs, adding http:// or https://
+// according to UseSSL when it is a bare host such as "minio:9000".
+func (c ObjectStorageConfig) endpointURL() string {
+	if strings.Contains(c.Endpoint, "://") {
+		return c.Endpoint
+	}
+
+	return c.scheme() + "://" + c.Endpoint
+}
+
+// endpointHost returns Endpoint without its scheme, for building public object URLs.
+func (c ObjectStorageConfig) endpointHost() string {
+	if _, host, ok := strings.Cut(c.Endpoint, "://"); ok {
+		return strings.TrimRight(host, "/")
+	}
+
+	return c.Endpoint
+}
+
+// scheme returns "https", or "http" when UseSSL is off.
+func (c ObjectStorageConfig) scheme() string {
+	if !c.UseSSL {
+		return "http"
+	}
+
+	return "https"
+}
+
 // ObjectStorage is the concrete implementation of gostorage.StorageDriver for S3-compatible storages.
 type ObjectStorage struct {
 	client        s3Client
@@ -81,14 +152,13 @@ func NewObjectStorage(cfg ObjectStorageConfig) (gostorage.StorageDriver, error)
 
 	client := s3.NewFromConfig(storageCfg, func(o *s3.Options) {
 		if cfg.Endpoint != "" {
-			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.BaseEndpoint = aws.String(cfg.endpointURL())
 			o.UsePathStyle = true // needed for MinIO / R2
 		}
 	})
 
-	defaultExpiry := cfg.DefaultExpiry
-	if defaultExpiry == 0 {
-		defaultExpiry = 15 * time.Minute
+	if cfg.DefaultExpiry == 0 {
+		cfg.DefaultExpiry = gostorage.DefaultSignedURLExpiry
 	}
 
 	return &ObjectStorage{
@@ -99,6 +169,84 @@ func NewObjectStorage(cfg ObjectStorageConfig) (gostorage.StorageDriver, error)
 	}, nil
 }
 
+// AbortMultipartUpload discards a multipart upload and the parts uploaded so far.
+// Usage: Call when an upload is cancelled, so its parts stop incurring storage costs.
+func (s *ObjectStorage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		if errorCode(err) == "NoSuchUpload" {
+			return gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to abort multipart upload in S3")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// Capabilities reports the features supported by this bucket.
+// Only public buckets serve direct URLs; signed URLs work on any bucket.
+func (s *ObjectStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		SignedURL:   true,
+		PublicURL:   s.config.Visibility == VisibilityPublic,
+		List:        true,
+		BatchDelete: true,
+		RangeRead:   true,
+		Multipart:   true,
+	}
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the object at key.
+// Usage: Call once every part is uploaded; the object only becomes visible now.
+func (s *ObjectStorage) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []gostorage.Part) error {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = types.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int32(int32(part.Number)),
+		}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		if errorCode(err) == "NoSuchUpload" {
+			return gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Int("parts", len(parts)).Msg("failed to complete multipart upload in S3")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// CreateMultipartUpload starts a multipart upload to key, encrypted like objects written by Put.
+// Usage: Upload large files in parts of at least 5 MiB (except the last) with UploadPart.
+func (s *ObjectStorage) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		ServerSideEncryption: "AES256",
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to create multipart upload in S3")
+		return "", gostorage.ErrInternal
+	}
+
+	return aws.ToString(out.UploadId), nil
+}
+
 // Delete permanently removes a file from the bucket.
 // Usage: Call when you want to delete a file by its key.
 func (s *ObjectStorage) Delete(ctx context.Context, key string) error {
@@ -114,6 +262,57 @@ func (s *ObjectStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// DeleteBatch removes many files using DeleteObjects, sending at most 1000 keys per request.
+// Keys rejected by S3, or belonging to a request that failed entirely, are reported with gostorage.ErrInternal.
+// Usage: Used automatically by StorageManager.DeleteMany and StorageManager.BatchDelete.
+func (s *ObjectStorage) DeleteBatch(ctx context.Context, keys []string) gostorage.BatchResults {
+	results := make(gostorage.BatchResults, len(keys))
+
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		end := min(start+maxDeleteObjects, len(keys))
+		chunk := keys[start:end]
+
+		objects := make([]types.ObjectIdentifier, len(chunk))
+		for i, key := range chunk {
+			results[start+i] = gostorage.BatchResult{Key: key}
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		out, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true), // only report failures
+			},
+		})
+		if err != nil {
+			log.Error().Err(err).Int("keys", len(chunk)).Msg("failed to delete files from S3")
+			for i := range chunk {
+				results[start+i].Err = gostorage.ErrInternal
+			}
+			continue
+		}
+
+		failed := make(map[string]types.Error, len(out.Errors))
+		for _, e := range out.Errors {
+			failed[aws.ToString(e.Key)] = e
+		}
+
+		for i, key := range chunk {
+			if e, ok := failed[key]; ok {
+				log.Error().
+					Str("key", key).
+					Str("code", aws.ToString(e.Code)).
+					Str("message", aws.ToString(e.Message)).
+					Msg("failed to delete file from S3")
+				results[start+i].Err = gostorage.ErrInternal
+			}
+		}
+	}
+
+	return results
+}
+
 // Exists checks if a file exists in the bucket.
 // Usage: Call before uploading or deleting to verify the file's presence.
 func (s *ObjectStorage) Exists(ctx context.Context, key string) (bool, error) {
@@ -134,13 +333,53 @@ func (s *ObjectStorage) Exists(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
-// GetSignedURL generates a temporary signed URL for downloading a file from a private bucket.
-// Usage: Call this when you need to share temporary access to a private file.
-func (s *ObjectStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
-	if s.config.Visibility != VisibilityPrivate {
-		return "", nil
+// DefaultExpiry returns the configured lifetime of signed URLs.
+func (s *ObjectStorage) DefaultExpiry() time.Duration {
+	return s.config.DefaultExpiry
+}
+
+// DefaultVisibility returns the bucket's configured visibility.
+func (s *ObjectStorage) DefaultVisibility() gostorage.Visibility {
+	return s.config.Visibility
+}
+
+// GetRange downloads part of a file with a ranged GetObject request.
+// A negative length reads to the end of the file; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt, e.g. for video seeking or ZIP archives.
+func (s *ObjectStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
 	}
 
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return nil, gostorage.ErrNotFound
+		case errorCode(err) == "InvalidRange":
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+
+		log.Error().Err(err).Str("key", key).Str("range", byteRange).Msg("failed to get file range from S3")
+		return nil, gostorage.ErrInternal
+	}
+
+	return out.Body, nil
+}
+
+// GetSignedURL generates a temporary signed URL for downloading a file.
+// Signing works on public buckets too, so private objects in them can still be shared.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *ObjectStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
 	req, err := s.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
@@ -160,38 +399,290 @@ func (s *ObjectStorage) GetURL(ctx context.Context, key string) (string, error)
 		return "", nil
 	}
 
-	scheme := "https"
-	if !s.config.UseSSL {
-		scheme = "http"
+	return fmt.Sprintf("%s://%s/%s/%s", s.config.scheme(), s.config.endpointHost(), s.bucket, key), nil
+}
+
+// List calls fn for every object under opts.Prefix, paging through ListObjectsV2.
+// Non-recursive listings use "/" as delimiter and report common prefixes as directories.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *ObjectStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(opts.Prefix),
+	}
+	if !opts.Recursive {
+		input.Delimiter = aws.String("/")
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list files in S3")
+			return gostorage.ErrInternal
+		}
+
+		for _, obj := range mergeListPage(page) {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeListPage converts a ListObjectsV2 page into ObjectInfo values in lexical key order,
+// interleaving common prefixes (directories) with objects the way S3 sorts them.
+func mergeListPage(page *s3.ListObjectsV2Output) []gostorage.ObjectInfo {
+	infos := make([]gostorage.ObjectInfo, 0, len(page.Contents)+len(page.CommonPrefixes))
+
+	i, j := 0, 0
+	for i < len(page.Contents) || j < len(page.CommonPrefixes) {
+		if j == len(page.CommonPrefixes) ||
+			(i < len(page.Contents) && aws.ToString(page.Contents[i].Key) < aws.ToString(page.CommonPrefixes[j].Prefix)) {
+			obj := page.Contents[i]
+			infos = append(infos, gostorage.ObjectInfo{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+				ETag:         aws.ToString(obj.ETag),
+			})
+			i++
+			continue
+		}
+
+		infos = append(infos, gostorage.ObjectInfo{
+			Key:   aws.ToString(page.CommonPrefixes[j].Prefix),
+			IsDir: true,
+		})
+		j++
+	}
+
+	return infos
+}
+
+// Ping checks that the bucket can be reached with the configured credentials, using a HEAD request on the bucket.
+// Usage: Used by health checks, e.g. of the failover driver.
+func (s *ObjectStorage) Ping(ctx context.Context) error {
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(s.bucket),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("bucket", s.bucket).Msg("failed to reach S3 bucket")
+		return gostorage.ErrInternal
 	}
 
-	return fmt.Sprintf("%s://%s/%s/%s", scheme, s.config.Endpoint, s.bucket, key), nil
+	return nil
 }
 
-// validateKey ensures that the provided key is valid (not empty, no invalid characters).
-// Usage: Called internally by Put to prevent uploading bad file names.
-var fileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+// SetVisibility makes a single object public or private by replacing its canned ACL.
+// On public buckets it also tags files made private with visibilityTag, so Visibility can tell
+// them from files only public through the bucket policy. Buckets with ACLs disabled (Object
+// Ownership "bucket owner enforced") reject this with gostorage.ErrInternal.
+// Usage: Call to publish one file from a private bucket, or hide one in a public bucket.
+func (s *ObjectStorage) SetVisibility(ctx context.Context, key string, visibility gostorage.Visibility) error {
+	var acl types.ObjectCannedACL
+	switch visibility {
+	case gostorage.VisibilityPublic:
+		acl = types.ObjectCannedACLPublicRead
+	case gostorage.VisibilityPrivate:
+		acl = types.ObjectCannedACLPrivate
+	default:
+		return gostorage.ErrInvalidConfig
+	}
 
-func validateKey(name string) error {
-	switch {
-	case len(name) == 0:
-		return errors.New("key cannot be empty")
-	case !fileNameRegex.MatchString(name):
-		return errors.New("key contains invalid characters")
-	case name == "." || name == "..":
-		return errors.New("invalid key")
+	_, err := s.client.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		ACL:    acl,
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to set file visibility in S3")
+		return gostorage.ErrInternal
 	}
+
+	if s.config.Visibility == VisibilityPublic {
+		return s.tagVisibility(ctx, key, visibility)
+	}
+
 	return nil
 }
 
+// tagVisibility sets visibilityTag on a private file and removes it from a public one,
+// keeping the object's other tags.
+func (s *ObjectStorage) tagVisibility(ctx context.Context, key string, visibility gostorage.Visibility) error {
+	out, err := s.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		tags := make([]types.Tag, 0, len(out.TagSet)+1)
+		for _, tag := range out.TagSet {
+			if aws.ToString(tag.Key) != visibilityTag {
+				tags = append(tags, tag)
+			}
+		}
+		if visibility == gostorage.VisibilityPrivate {
+			tags = append(tags, types.Tag{Key: aws.String(visibilityTag), Value: aws.String(string(visibility))})
+		}
+
+		_, err = s.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+			Bucket:  aws.String(s.bucket),
+			Key:     aws.String(key),
+			Tagging: &types.Tagging{TagSet: tags},
+		})
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to tag file visibility in S3")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// Visibility reports a file public if its ACL grants read access to anonymous users. Otherwise
+// files of private buckets are private, while files of public buckets are public unless
+// SetVisibility made them private: public buckets are usually opened with a bucket policy rather
+// than per-object grants, so a missing grant alone cannot prove a file private. Storages without
+// object ACLs (many S3-compatible servers, buckets with ACLs disabled) report the bucket's visibility.
+// Usage: Used by StorageManager.URL to choose between a direct and a signed URL.
+func (s *ObjectStorage) Visibility(ctx context.Context, key string) (gostorage.Visibility, error) {
+	out, err := s.client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		switch code := errorCode(err); {
+		case isNotFound(err):
+			return "", gostorage.ErrNotFound
+		case code == "NotImplemented" || code == "AccessControlListNotSupported":
+			return s.config.Visibility, nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to get file visibility from S3")
+		return "", gostorage.ErrInternal
+	}
+
+	for _, grant := range out.Grants {
+		if grant.Grantee == nil || aws.ToString(grant.Grantee.URI) != allUsersGroup {
+			continue
+		}
+
+		if grant.Permission == types.PermissionRead || grant.Permission == types.PermissionFullControl {
+			return gostorage.VisibilityPublic, nil
+		}
+	}
+
+	if s.config.Visibility != VisibilityPublic {
+		return gostorage.VisibilityPrivate, nil
+	}
+
+	tagging, err := s.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		switch {
+		case isNotFound(err):
+			return "", gostorage.ErrNotFound
+		case errorCode(err) == "NotImplemented":
+			return s.config.Visibility, nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to get file tags from S3")
+		return "", gostorage.ErrInternal
+	}
+
+	for _, tag := range tagging.TagSet {
+		if aws.ToString(tag.Key) == visibilityTag && aws.ToString(tag.Value) == string(gostorage.VisibilityPrivate) {
+			return gostorage.VisibilityPrivate, nil
+		}
+	}
+
+	return gostorage.VisibilityPublic, nil
+}
+
+// errorCode returns the S3 API error code of err, or an empty string for other errors.
+func errorCode(err error) string {
+	var apiError interface{ ErrorCode() string }
+	if !errors.As(err, &apiError) {
+		return ""
+	}
+
+	return apiError.ErrorCode()
+}
+
+// isNotFound reports whether err is S3's response for a missing object.
+// HeadObject reports "NotFound" while GetObject and the ACL calls report "NoSuchKey".
+func isNotFound(err error) bool {
+	code := errorCode(err)
+	return code == "NotFound" || code == "NoSuchKey"
+}
+
+// Stat returns the size, modification time and ETag of a file using HeadObject.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *ObjectStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to stat file in S3")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	}
+
+	return gostorage.ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		LastModified: aws.ToTime(out.LastModified),
+		ETag:         aws.ToString(out.ETag),
+	}, nil
+}
+
+// UploadPart uploads size bytes of body as part number of a multipart upload.
+// Usage: Used by resumable upload servers to store chunks directly in the final object.
+func (s *ObjectStorage) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (gostorage.Part, error) {
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(int32(number)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		if errorCode(err) == "NoSuchUpload" {
+			return gostorage.Part{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Int("part", number).Msg("failed to upload part to S3")
+		return gostorage.Part{}, gostorage.ErrInternal
+	}
+
+	return gostorage.Part{Number: number, ETag: aws.ToString(out.ETag), Size: size}, nil
+}
+
 // Put uploads a file to the bucket and returns its URL.
 // If the bucket is public, it returns a direct URL.
 // If the bucket is private, it returns a signed URL.
 // Usage: Call this to save a new file or overwrite an existing file.
 func (s *ObjectStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
-	if err := validateKey(key); err != nil {
+	if err := gostorage.ValidateKey(key); err != nil {
 		log.Error().Err(err).Str("key", key).Msg("invalid key")
-		return "", gostorage.ErrInvalidKey
+		return "", err
 	}
 
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
@@ -207,11 +698,7 @@ func (s *ObjectStorage) Put(ctx context.Context, key string, file io.Reader) (st
 
 	// Public bucket: return direct URL
 	if s.config.Visibility == VisibilityPublic {
-		scheme := "https"
-		if !s.config.UseSSL {
-			scheme = "http"
-		}
-		return fmt.Sprintf("%s://%s/%s/%s", scheme, s.config.Endpoint, s.bucket, key), nil
+		return fmt.Sprintf("%s://%s/%s/%s", s.config.scheme(), s.config.endpointHost(), s.bucket, key), nil
 	}
 
 	// Private bucket: return signed URL
@@ -1,15 +1,22 @@
 package s3driver
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	gostorage "github.com/shoraid/go-storage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewObjectStorage(t *testing.T) {
@@ -74,6 +81,32 @@ func TestNewObjectStorage(t *testing.T) {
 	}
 }
 
+func TestObjectStorage_Capabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility Visibility
+		expected   gostorage.Capabilities
+	}{
+		{
+			name:       "should only support signed URLs on private bucket",
+			visibility: VisibilityPrivate,
+			expected:   gostorage.Capabilities{SignedURL: true, List: true, BatchDelete: true, RangeRead: true, Multipart: true},
+		},
+		{
+			name:       "should support public and signed URLs on public bucket",
+			visibility: VisibilityPublic,
+			expected:   gostorage.Capabilities{SignedURL: true, PublicURL: true, List: true, BatchDelete: true, RangeRead: true, Multipart: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &ObjectStorage{config: ObjectStorageConfig{Visibility: tt.visibility}}
+			assert.Equal(t, tt.expected, storage.Capabilities(), "expected capabilities to match")
+		})
+	}
+}
+
 func TestObjectStorage_Delete(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -112,6 +145,85 @@ func TestObjectStorage_Delete(t *testing.T) {
 	}
 }
 
+func TestObjectStorage_DeleteBatch(t *testing.T) {
+	manyKeys := make([]string, 2500)
+	for i := range manyKeys {
+		manyKeys[i] = fmt.Sprintf("file-%d.txt", i)
+	}
+
+	tests := []struct {
+		name         string
+		keys         []string
+		mockErr      error
+		failedKeys   map[string]bool
+		expectCalls  []int
+		expectFailed []string
+	}{
+		{
+			name:         "should delete all files in a single request",
+			keys:         []string{"a.txt", "b.txt", "c.txt"},
+			expectCalls:  []int{3},
+			expectFailed: nil,
+		},
+		{
+			name:         "should split keys into chunks of 1000",
+			keys:         manyKeys,
+			expectCalls:  []int{1000, 1000, 500},
+			expectFailed: nil,
+		},
+		{
+			name:         "should report keys rejected by S3",
+			keys:         []string{"a.txt", "b.txt", "c.txt"},
+			failedKeys:   map[string]bool{"b.txt": true},
+			expectCalls:  []int{3},
+			expectFailed: []string{"b.txt"},
+		},
+		{
+			name:         "should report every key when the request fails",
+			keys:         []string{"a.txt", "b.txt"},
+			mockErr:      errors.New("delete objects error"),
+			expectCalls:  []int{2},
+			expectFailed: []string{"a.txt", "b.txt"},
+		},
+		{
+			name:         "should not send a request when there are no keys",
+			keys:         []string{},
+			expectCalls:  nil,
+			expectFailed: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockS3Client{
+				err:              tt.mockErr,
+				failedDeleteKeys: tt.failedKeys,
+			}
+			storage := &ObjectStorage{
+				bucket: "test-bucket",
+				client: client,
+			}
+
+			results := storage.DeleteBatch(context.Background(), tt.keys)
+
+			assert.Equal(t, tt.keys, results.Keys(), "expected one result per key in input order")
+
+			var calls []int
+			for _, call := range client.deleteObjectsCalls {
+				calls = append(calls, len(call))
+			}
+			assert.Equal(t, tt.expectCalls, calls, "expected DeleteObjects chunk sizes to match")
+
+			if tt.expectFailed != nil {
+				assert.Equal(t, tt.expectFailed, results.Failed().Keys(), "expected failed keys to match")
+				assert.ErrorIs(t, results.Err(), gostorage.ErrInternal, "expected internal error for failed keys")
+			} else {
+				assert.NoError(t, results.Err(), "expected no error when DeleteObjects succeeds")
+			}
+		})
+	}
+}
+
 func TestObjectStorage_Exists(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -159,6 +271,43 @@ func TestObjectStorage_Exists(t *testing.T) {
 	}
 }
 
+func TestObjectStorage_Ping(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockErr     error
+		expectedErr error
+	}{
+		{
+			name:        "should succeed when bucket is reachable",
+			mockErr:     nil,
+			expectedErr: nil,
+		},
+		{
+			name:        "should return internal error when bucket is unreachable",
+			mockErr:     errors.New("connection refused"),
+			expectedErr: gostorage.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &ObjectStorage{
+				bucket: "test-bucket",
+				client: &mockS3Client{
+					err: tt.mockErr,
+				},
+			}
+
+			err := storage.Ping(context.Background())
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error")
+			} else {
+				assert.NoError(t, err, "expected no error")
+			}
+		})
+	}
+}
+
 func TestObjectStorage_GetSignedURL(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -169,9 +318,10 @@ func TestObjectStorage_GetSignedURL(t *testing.T) {
 		expectedErr error
 	}{
 		{
-			name:        "should return empty string when bucket is public",
+			name:        "should return signed URL when bucket is public",
 			visibility:  VisibilityPublic,
-			expected:    "",
+			mockURL:     "https://example.com/signed",
+			expected:    "https://example.com/signed",
 			expectedErr: nil,
 		},
 		{
@@ -219,6 +369,7 @@ func TestObjectStorage_GetSignedURL(t *testing.T) {
 func TestObjectStorage_GetURL(t *testing.T) {
 	tests := []struct {
 		name       string
+		endpoint   string
 		visibility Visibility
 		useSSL     bool
 		key        string
@@ -252,14 +403,27 @@ func TestObjectStorage_GetURL(t *testing.T) {
 			key:        "file.txt",
 			expected:   "http://endpoint/test-bucket/file.txt",
 		},
+		{
+			name:       "should use the host of an endpoint given as full URL",
+			endpoint:   "https://minio.example.com:9000/",
+			visibility: VisibilityPublic,
+			useSSL:     true,
+			key:        "file.txt",
+			expected:   "https://minio.example.com:9000/test-bucket/file.txt",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			if endpoint == "" {
+				endpoint = "endpoint"
+			}
+
 			storage := &ObjectStorage{
 				bucket: "test-bucket",
 				config: ObjectStorageConfig{
-					Endpoint:   "endpoint",
+					Endpoint:   endpoint,
 					UseSSL:     tt.useSSL,
 					Visibility: tt.visibility,
 				},
@@ -272,6 +436,80 @@ func TestObjectStorage_GetURL(t *testing.T) {
 	}
 }
 
+func TestObjectStorage_List(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        gostorage.ListOptions
+		pages       []*s3.ListObjectsV2Output
+		mockErr     error
+		expected    []gostorage.ObjectInfo
+		expectedErr error
+	}{
+		{
+			name: "should list objects across pages",
+			opts: gostorage.ListOptions{Prefix: "users/", Recursive: true},
+			pages: []*s3.ListObjectsV2Output{
+				{Contents: []types.Object{{Key: aws.String("users/1/a.txt"), Size: aws.Int64(3)}}},
+				{Contents: []types.Object{{Key: aws.String("users/2/b.txt"), Size: aws.Int64(5)}}},
+			},
+			expected: []gostorage.ObjectInfo{
+				{Key: "users/1/a.txt", Size: 3},
+				{Key: "users/2/b.txt", Size: 5},
+			},
+		},
+		{
+			name: "should interleave common prefixes for non-recursive listing",
+			opts: gostorage.ListOptions{Prefix: "a"},
+			pages: []*s3.ListObjectsV2Output{
+				{
+					Contents:       []types.Object{{Key: aws.String("a.txt")}, {Key: aws.String("ab.txt")}},
+					CommonPrefixes: []types.CommonPrefix{{Prefix: aws.String("a/")}},
+				},
+			},
+			expected: []gostorage.ObjectInfo{
+				{Key: "a.txt"},
+				{Key: "a/", IsDir: true},
+				{Key: "ab.txt"},
+			},
+		},
+		{
+			name:        "should return internal error when listing fails",
+			opts:        gostorage.ListOptions{Recursive: true},
+			mockErr:     errors.New("list error"),
+			expected:    nil,
+			expectedErr: gostorage.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockS3Client{listPages: tt.pages, listObjectsErr: tt.mockErr}
+			storage := &ObjectStorage{
+				bucket: "test-bucket",
+				client: client,
+			}
+
+			var got []gostorage.ObjectInfo
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj)
+				return nil
+			})
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error when listing fails")
+			} else {
+				assert.NoError(t, err, "expected no error when listing succeeds")
+			}
+			assert.Equal(t, tt.expected, got, "expected listed objects to match")
+
+			if len(client.listInputs) > 0 {
+				assert.Equal(t, tt.opts.Prefix, aws.ToString(client.listInputs[0].Prefix), "expected prefix to be passed to S3")
+				assert.Equal(t, !tt.opts.Recursive, client.listInputs[0].Delimiter != nil, "expected delimiter only for non-recursive listing")
+			}
+		})
+	}
+}
+
 func TestObjectStorage_Put(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -292,7 +530,14 @@ func TestObjectStorage_Put(t *testing.T) {
 		},
 		{
 			name:        "should return error when key contains invalid characters",
-			key:         "bad/key.txt",
+			key:         "bad key.txt",
+			visibility:  VisibilityPrivate,
+			expected:    "",
+			expectedErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name:        "should return error when key contains parent segment",
+			key:         "../key.txt",
 			visibility:  VisibilityPrivate,
 			expected:    "",
 			expectedErr: gostorage.ErrInvalidKey,
@@ -319,6 +564,14 @@ func TestObjectStorage_Put(t *testing.T) {
 			expected:    "http://endpoint/test-bucket/file.txt",
 			expectedErr: nil,
 		},
+		{
+			name:        "should return signed URL for nested key",
+			key:         "users/1/file.txt",
+			visibility:  VisibilityPrivate,
+			mockSignURL: "https://signed-url",
+			expected:    "https://signed-url",
+			expectedErr: nil,
+		},
 		{
 			name:        "should return signed URL when bucket is private and presign succeeds",
 			key:         "file.txt",
@@ -366,3 +619,412 @@ func TestObjectStorage_Put(t *testing.T) {
 		})
 	}
 }
+
+func TestObjectStorage_SetVisibility(t *testing.T) {
+	tests := []struct {
+		name        string
+		visibility  gostorage.Visibility
+		aclErr      error
+		expectedACL types.ObjectCannedACL
+		expectedErr error
+	}{
+		{
+			name:        "should set public-read ACL when making file public",
+			visibility:  gostorage.VisibilityPublic,
+			expectedACL: types.ObjectCannedACLPublicRead,
+		},
+		{
+			name:        "should set private ACL when making file private",
+			visibility:  gostorage.VisibilityPrivate,
+			expectedACL: types.ObjectCannedACLPrivate,
+		},
+		{
+			name:        "should return ErrInvalidConfig for unknown visibility",
+			visibility:  "internal",
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return ErrNotFound when object does not exist",
+			visibility:  gostorage.VisibilityPublic,
+			aclErr:      &smithy.GenericAPIError{Code: "NoSuchKey"},
+			expectedACL: types.ObjectCannedACLPublicRead,
+			expectedErr: gostorage.ErrNotFound,
+		},
+		{
+			name:        "should return ErrInternal when ACL update fails",
+			visibility:  gostorage.VisibilityPublic,
+			aclErr:      &smithy.GenericAPIError{Code: "AccessControlListNotSupported"},
+			expectedACL: types.ObjectCannedACLPublicRead,
+			expectedErr: gostorage.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockS3Client{aclErr: tt.aclErr}
+			s := &ObjectStorage{client: client, bucket: "test-bucket"}
+
+			err := s.SetVisibility(context.Background(), "file.txt", tt.visibility)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected no error when ACL is updated")
+			}
+
+			if tt.expectedACL == "" {
+				assert.Empty(t, client.putACLInputs, "expected no PutObjectAcl call")
+				return
+			}
+
+			if assert.Len(t, client.putACLInputs, 1, "expected a single PutObjectAcl call") {
+				assert.Equal(t, tt.expectedACL, client.putACLInputs[0].ACL, "expected canned ACL to match")
+				assert.Equal(t, "file.txt", aws.ToString(client.putACLInputs[0].Key), "expected key to match")
+			}
+		})
+	}
+}
+
+func TestObjectStorage_SetVisibilityOnPublicBucket(t *testing.T) {
+	ctx := context.Background()
+	client := &mockS3Client{tags: []types.Tag{{Key: aws.String("team"), Value: aws.String("media")}}}
+	s := &ObjectStorage{client: client, bucket: "test-bucket", config: ObjectStorageConfig{Visibility: VisibilityPublic}}
+
+	require.NoError(t, s.SetVisibility(ctx, "file.txt", gostorage.VisibilityPrivate), "expected no error making file private")
+	require.Len(t, client.putTagInputs, 1, "expected the file to be tagged")
+	client.tags = client.putTagInputs[0].Tagging.TagSet
+	assert.Equal(t, []types.Tag{
+		{Key: aws.String("team"), Value: aws.String("media")},
+		{Key: aws.String(visibilityTag), Value: aws.String("private")},
+	}, client.tags, "expected the visibility tag next to the existing tags")
+
+	visibility, err := s.Visibility(ctx, "file.txt")
+	assert.NoError(t, err, "expected no error reading visibility")
+	assert.Equal(t, gostorage.VisibilityPrivate, visibility, "expected the private file of a public bucket to be private")
+
+	require.NoError(t, s.SetVisibility(ctx, "file.txt", gostorage.VisibilityPublic), "expected no error making file public")
+	assert.Equal(t, []types.Tag{{Key: aws.String("team"), Value: aws.String("media")}}, client.putTagInputs[1].Tagging.TagSet, "expected the visibility tag to be removed")
+}
+
+func TestObjectStorage_Visibility(t *testing.T) {
+	allUsers := &types.Grantee{Type: types.TypeGroup, URI: aws.String(allUsersGroup)}
+	owner := &types.Grantee{Type: types.TypeCanonicalUser, ID: aws.String("owner")}
+
+	tests := []struct {
+		name             string
+		bucketVisibility Visibility
+		grants           []types.Grant
+		aclErr           error
+		tags             []types.Tag
+		expected         gostorage.Visibility
+		expectedErr      error
+	}{
+		{
+			name:             "should return public when all users can read",
+			bucketVisibility: VisibilityPrivate,
+			grants: []types.Grant{
+				{Grantee: owner, Permission: types.PermissionFullControl},
+				{Grantee: allUsers, Permission: types.PermissionRead},
+			},
+			expected: gostorage.VisibilityPublic,
+		},
+		{
+			name:             "should return private when only the owner has access on a private bucket",
+			bucketVisibility: VisibilityPrivate,
+			grants:           []types.Grant{{Grantee: owner, Permission: types.PermissionFullControl}},
+			expected:         gostorage.VisibilityPrivate,
+		},
+		{
+			name:             "should return public on a public bucket without per-object grant",
+			bucketVisibility: VisibilityPublic,
+			grants:           []types.Grant{{Grantee: owner, Permission: types.PermissionFullControl}},
+			tags:             []types.Tag{{Key: aws.String("team"), Value: aws.String("media")}},
+			expected:         gostorage.VisibilityPublic,
+		},
+		{
+			name:             "should return private on a public bucket for a file made private",
+			bucketVisibility: VisibilityPublic,
+			grants:           []types.Grant{{Grantee: owner, Permission: types.PermissionFullControl}},
+			tags:             []types.Tag{{Key: aws.String(visibilityTag), Value: aws.String("private")}},
+			expected:         gostorage.VisibilityPrivate,
+		},
+		{
+			name:             "should fall back to public bucket visibility when ACLs are disabled",
+			bucketVisibility: VisibilityPublic,
+			aclErr:           &smithy.GenericAPIError{Code: "AccessControlListNotSupported"},
+			tags:             []types.Tag{{Key: aws.String(visibilityTag), Value: aws.String("private")}},
+			expected:         gostorage.VisibilityPublic,
+		},
+		{
+			name:             "should return private when all users can only read the ACL",
+			bucketVisibility: VisibilityPrivate,
+			grants:           []types.Grant{{Grantee: allUsers, Permission: types.PermissionReadAcp}},
+			expected:         gostorage.VisibilityPrivate,
+		},
+		{
+			name:             "should fall back to bucket visibility when ACLs are not implemented",
+			bucketVisibility: VisibilityPrivate,
+			aclErr:           &smithy.GenericAPIError{Code: "NotImplemented"},
+			expected:         gostorage.VisibilityPrivate,
+		},
+		{
+			name:             "should fall back to bucket visibility when ACLs are disabled",
+			bucketVisibility: VisibilityPrivate,
+			aclErr:           &smithy.GenericAPIError{Code: "AccessControlListNotSupported"},
+			expected:         gostorage.VisibilityPrivate,
+		},
+		{
+			name:             "should return ErrNotFound when object does not exist",
+			bucketVisibility: VisibilityPrivate,
+			aclErr:           &smithy.GenericAPIError{Code: "NoSuchKey"},
+			expectedErr:      gostorage.ErrNotFound,
+		},
+		{
+			name:             "should return ErrInternal when ACL lookup fails",
+			bucketVisibility: VisibilityPrivate,
+			aclErr:           errors.New("connection reset"),
+			expectedErr:      gostorage.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ObjectStorage{
+				client: &mockS3Client{grants: tt.grants, aclErr: tt.aclErr, tags: tt.tags},
+				bucket: "test-bucket",
+				config: ObjectStorageConfig{Visibility: tt.bucketVisibility},
+			}
+
+			got, err := s.Visibility(context.Background(), "file.txt")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected no error when ACL is readable")
+			}
+
+			assert.Equal(t, tt.expected, got, "expected visibility to match")
+		})
+	}
+}
+
+func TestObjectStorage_GetRange(t *testing.T) {
+	data := []byte("0123456789")
+
+	tests := []struct {
+		name          string
+		offset        int64
+		length        int64
+		getErr        error
+		expectedRange string
+		expected      string
+		expectedErr   error
+	}{
+		{
+			name:          "should request bounded range",
+			offset:        2,
+			length:        3,
+			expectedRange: "bytes=2-4",
+			expected:      "234",
+		},
+		{
+			name:          "should request open-ended range for negative length",
+			offset:        7,
+			length:        -1,
+			expectedRange: "bytes=7-",
+			expected:      "789",
+		},
+		{
+			name:     "should return empty body without request for zero length",
+			offset:   2,
+			length:   0,
+			expected: "",
+		},
+		{
+			name:          "should return empty body when offset is past the end",
+			offset:        20,
+			length:        5,
+			getErr:        &smithy.GenericAPIError{Code: "InvalidRange"},
+			expectedRange: "bytes=20-24",
+			expected:      "",
+		},
+		{
+			name:          "should return ErrNotFound when object does not exist",
+			offset:        0,
+			length:        5,
+			getErr:        &smithy.GenericAPIError{Code: "NoSuchKey"},
+			expectedRange: "bytes=0-4",
+			expectedErr:   gostorage.ErrNotFound,
+		},
+		{
+			name:          "should return ErrInternal when request fails",
+			offset:        0,
+			length:        5,
+			getErr:        errors.New("connection reset"),
+			expectedRange: "bytes=0-4",
+			expectedErr:   gostorage.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockS3Client{objectData: data, getErr: tt.getErr}
+			s := &ObjectStorage{client: client, bucket: "test-bucket"}
+
+			body, err := s.GetRange(context.Background(), "file.txt", tt.offset, tt.length)
+
+			if tt.expectedRange == "" {
+				assert.Empty(t, client.getInputs, "expected no GetObject call")
+			} else if assert.Len(t, client.getInputs, 1, "expected a single GetObject call") {
+				assert.Equal(t, tt.expectedRange, aws.ToString(client.getInputs[0].Range), "expected range header to match")
+			}
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error reading range")
+			got, _ := io.ReadAll(body)
+			assert.Equal(t, tt.expected, string(got), "expected body to match")
+		})
+	}
+}
+
+func TestObjectStorage_Stat(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		client      *mockS3Client
+		expected    gostorage.ObjectInfo
+		expectedErr error
+	}{
+		{
+			name: "should return object info from HeadObject",
+			client: &mockS3Client{headOutput: &s3.HeadObjectOutput{
+				ContentLength: aws.Int64(42),
+				LastModified:  aws.Time(modified),
+				ETag:          aws.String(`"abc"`),
+			}},
+			expected: gostorage.ObjectInfo{Key: "file.txt", Size: 42, LastModified: modified, ETag: `"abc"`},
+		},
+		{
+			name:        "should return ErrNotFound when object does not exist",
+			client:      &mockS3Client{err: &smithy.GenericAPIError{Code: "NotFound"}},
+			expectedErr: gostorage.ErrNotFound,
+		},
+		{
+			name:        "should return ErrInternal when request fails",
+			client:      &mockS3Client{err: errors.New("connection reset")},
+			expectedErr: gostorage.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ObjectStorage{client: tt.client, bucket: "test-bucket"}
+
+			got, err := s.Stat(context.Background(), "file.txt")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected no error stating object")
+			}
+
+			assert.Equal(t, tt.expected, got, "expected object info to match")
+		})
+	}
+}
+
+func TestObjectStorage_OpenZipInPlace(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, name := range []string{"a.txt", "docs/b.txt"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err, "expected no error creating zip entry")
+		_, err = w.Write(bytes.Repeat([]byte(name), 1000))
+		require.NoError(t, err, "expected no error writing zip entry")
+	}
+	require.NoError(t, zw.Close(), "expected no error closing zip")
+
+	client := &mockS3Client{
+		objectData: archive.Bytes(),
+		headOutput: &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(archive.Len()))},
+	}
+	s := &ObjectStorage{client: client, bucket: "test-bucket"}
+
+	r, err := gostorage.NewObjectReaderAt(context.Background(), s, "archive.zip")
+	require.NoError(t, err, "expected no error opening reader")
+
+	zr, err := zip.NewReader(r, r.Size())
+	require.NoError(t, err, "expected archive to be readable in place")
+	require.Len(t, zr.File, 2, "expected both entries")
+
+	f, err := zr.File[1].Open()
+	require.NoError(t, err, "expected no error opening entry")
+	content, err := io.ReadAll(f)
+	require.NoError(t, err, "expected no error reading entry")
+
+	assert.Equal(t, bytes.Repeat([]byte("docs/b.txt"), 1000), content, "expected entry content to match")
+	assert.Len(t, client.getInputs, 1, "expected the small archive to be served by one read-ahead request")
+}
+
+func TestObjectStorage_MultipartUpload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should upload parts and complete them in order", func(t *testing.T) {
+		client := &mockS3Client{}
+		s := &ObjectStorage{client: client, bucket: "test-bucket"}
+
+		uploadID, err := s.CreateMultipartUpload(ctx, "big.bin")
+		require.NoError(t, err, "expected no error creating upload")
+		assert.Equal(t, "upload-1", uploadID, "expected upload ID from S3")
+
+		first, err := s.UploadPart(ctx, "big.bin", uploadID, 1, bytes.NewReader([]byte("hello ")), 6)
+		require.NoError(t, err, "expected no error uploading first part")
+		second, err := s.UploadPart(ctx, "big.bin", uploadID, 2, bytes.NewReader([]byte("world")), 5)
+		require.NoError(t, err, "expected no error uploading second part")
+
+		assert.Equal(t, gostorage.Part{Number: 1, ETag: `"etag-1"`, Size: 6}, first, "expected first part")
+		assert.Equal(t, []byte("world"), client.uploadParts[2], "expected second part content")
+
+		err = s.CompleteMultipartUpload(ctx, "big.bin", uploadID, []gostorage.Part{first, second})
+		require.NoError(t, err, "expected no error completing upload")
+
+		require.Len(t, client.completeInputs, 1, "expected a single CompleteMultipartUpload call")
+		completed := client.completeInputs[0].MultipartUpload.Parts
+		require.Len(t, completed, 2, "expected both parts to be completed")
+		assert.Equal(t, int32(2), aws.ToInt32(completed[1].PartNumber), "expected part numbers to be kept")
+		assert.Equal(t, `"etag-2"`, aws.ToString(completed[1].ETag), "expected part ETags to be kept")
+	})
+
+	t.Run("should abort upload", func(t *testing.T) {
+		client := &mockS3Client{}
+		s := &ObjectStorage{client: client, bucket: "test-bucket"}
+
+		assert.NoError(t, s.AbortMultipartUpload(ctx, "big.bin", "upload-1"), "expected no error aborting upload")
+		assert.Equal(t, []string{"upload-1"}, client.abortedUploads, "expected upload to be aborted")
+	})
+
+	t.Run("should return ErrNotFound for unknown upload", func(t *testing.T) {
+		s := &ObjectStorage{client: &mockS3Client{multipartErr: &smithy.GenericAPIError{Code: "NoSuchUpload"}}, bucket: "test-bucket"}
+
+		_, err := s.UploadPart(ctx, "big.bin", "gone", 1, bytes.NewReader(nil), 0)
+		assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound from UploadPart")
+
+		err = s.CompleteMultipartUpload(ctx, "big.bin", "gone", nil)
+		assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound from CompleteMultipartUpload")
+
+		err = s.AbortMultipartUpload(ctx, "big.bin", "gone")
+		assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected ErrNotFound from AbortMultipartUpload")
+	})
+
+	t.Run("should return ErrInternal when S3 fails", func(t *testing.T) {
+		s := &ObjectStorage{client: &mockS3Client{multipartErr: errors.New("connection reset")}, bucket: "test-bucket"}
+
+		_, err := s.CreateMultipartUpload(ctx, "big.bin")
+		assert.ErrorIs(t, err, gostorage.ErrInternal, "expected ErrInternal from CreateMultipartUpload")
+	})
+}
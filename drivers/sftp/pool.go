@@ -0,0 +1,78 @@
+package sftpdriver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/shoraid/go-storage/internal/connpool"
+	"golang.org/x/crypto/ssh"
+)
+
+// conn is a pooled SSH connection with its SFTP session.
+type conn struct {
+	ssh  *ssh.Client
+	sftp *sftp.Client
+	done chan struct{} // closed once the SSH connection is gone
+}
+
+// alive reports whether the SSH connection is still open.
+func (c *conn) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *conn) close() {
+	c.sftp.Close()
+	c.ssh.Close()
+}
+
+// newPool returns a pool of up to size SFTP connections to addr.
+func newPool(addr string, config *ssh.ClientConfig, size int) *connpool.Pool[*conn] {
+	return connpool.New(size, func(ctx context.Context) (*conn, error) {
+		return dial(ctx, addr, config)
+	}, (*conn).alive, (*conn).close)
+}
+
+// dial opens an SSH connection and starts an SFTP session on it.
+func dial(ctx context.Context, addr string, config *ssh.ClientConfig) (*conn, error) {
+	dialer := net.Dialer{Timeout: config.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// bound the handshake, which otherwise blocks on an unresponsive server
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	} else if config.Timeout > 0 {
+		netConn.SetDeadline(time.Now().Add(config.Timeout))
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	c := &conn{ssh: client, sftp: sftpClient, done: make(chan struct{})}
+	go func() {
+		client.Wait()
+		close(c.done)
+	}()
+
+	return c, nil
+}
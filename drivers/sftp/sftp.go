@@ -0,0 +1,406 @@
+package sftpdriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/internal/connpool"
+	"github.com/shoraid/go-storage/internal/filetree"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultPoolSize is the number of connections kept open when SFTPStorageConfig.PoolSize is zero.
+const DefaultPoolSize = 4
+
+// SFTPStorageConfig defines the configuration for storing files on an SFTP server.
+type SFTPStorageConfig struct {
+	Addr       string // server address, e.g. "sftp.example.com:22"
+	User       string // login user
+	Password   string // optional password
+	PrivateKey []byte // optional PEM encoded private key, tried before the password
+	Passphrase string // optional passphrase of PrivateKey
+
+	// HostKeyCallback verifies the server's host key, e.g. knownhosts.New("~/.ssh/known_hosts")
+	// or ssh.FixedHostKey(key). Required: use ssh.InsecureIgnoreHostKey() only for testing.
+	HostKeyCallback ssh.HostKeyCallback
+
+	Root        string        // optional directory keys are stored below, relative to the login directory unless absolute
+	BaseURL     string        // optional public URL the Root directory is served from, e.g. "https://files.example.com"
+	PoolSize    int           // optional maximum number of open connections, defaults to DefaultPoolSize
+	DialTimeout time.Duration // optional timeout for connecting and the SSH handshake, defaults to 30s
+}
+
+// SFTPStorage is the concrete implementation of gostorage.StorageDriver for SFTP servers.
+// Keys map to paths below Root, with "/" separated segments becoming directories.
+// Connections are pooled and replaced transparently when the server drops them.
+type SFTPStorage struct {
+	root   string
+	config SFTPStorageConfig
+	pool   *connpool.Pool[*conn]
+}
+
+// NewSFTPStorage initializes an SFTPStorage. No connection is made until the first operation.
+// Returns gostorage.ErrInvalidConfig if Addr, User, HostKeyCallback or both credentials are
+// missing, or if PrivateKey cannot be parsed.
+func NewSFTPStorage(cfg SFTPStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.Addr == "" || cfg.User == "" || cfg.HostKeyCallback == nil {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	var auth []ssh.AuthMethod
+	if len(cfg.PrivateKey) > 0 {
+		var signer ssh.Signer
+		var err error
+		if cfg.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(cfg.PrivateKey, []byte(cfg.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(cfg.PrivateKey)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("addr", cfg.Addr).Msg("failed to parse SFTP private key")
+			return nil, gostorage.ErrInvalidConfig
+		}
+
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(auth) == 0 {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = DefaultPoolSize
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 30 * time.Second
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: cfg.HostKeyCallback,
+		Timeout:         cfg.DialTimeout,
+	}
+
+	return &SFTPStorage{
+		root:   strings.TrimRight(cfg.Root, "/"),
+		config: cfg,
+		pool:   newPool(cfg.Addr, sshConfig, cfg.PoolSize),
+	}, nil
+}
+
+// Close closes the pooled connections. Connections in use are closed once their operation ends.
+func (s *SFTPStorage) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// filePath converts a validated key into a remote path below the storage root.
+func (s *SFTPStorage) filePath(key string) string {
+	if s.root == "" {
+		return key
+	}
+
+	return s.root + "/" + key
+}
+
+// do runs fn on a pooled connection. Operations without side effects pass retry to run again
+// on a new connection when the server dropped the connection mid-call.
+func (s *SFTPStorage) do(ctx context.Context, retry bool, fn func(*sftp.Client) error) error {
+	for {
+		c, err := s.pool.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn(c.sftp)
+		if !lostConnection(err) {
+			s.pool.Put(c)
+			return err
+		}
+
+		s.pool.Discard(c)
+		if !retry {
+			return err
+		}
+		retry = false
+	}
+}
+
+// lostConnection reports whether err means the connection broke rather than the operation failed.
+func lostConnection(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// Capabilities reports the features supported by SFTP servers.
+// Public URLs are only available when a BaseURL is configured.
+func (s *SFTPStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		PublicURL: s.config.BaseURL != "",
+		List:      true,
+		RangeRead: true,
+	}
+}
+
+// Delete removes a file and any parent directories left empty by its removal.
+// Deleting a file that does not exist is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *SFTPStorage) Delete(ctx context.Context, key string) error {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	err := s.do(ctx, true, func(client *sftp.Client) error {
+		p := s.filePath(key)
+		if err := client.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		filetree.RemoveEmptyParents(key, func(dir string) error {
+			return client.RemoveDirectory(s.filePath(dir))
+		})
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to delete file from SFTP server")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// Exists checks if a regular file exists for the key.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *SFTPStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := s.Stat(ctx, key); err != nil {
+		if errors.Is(err, gostorage.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetRange opens the file and returns a reader limited to the requested byte range.
+// The connection stays reserved until the reader is closed.
+// A negative length reads to the end of the file; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt.
+func (s *SFTPStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	for retry := true; ; retry = false {
+		c, err := s.pool.Get(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("key", key).Msg("failed to connect to SFTP server")
+			return nil, gostorage.ErrInternal
+		}
+
+		f, err := c.sftp.Open(s.filePath(key))
+		if err != nil {
+			if lostConnection(err) {
+				s.pool.Discard(c)
+				if retry {
+					continue
+				}
+			} else {
+				s.pool.Put(c)
+			}
+
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, gostorage.ErrNotFound
+			}
+
+			log.Error().Err(err).Str("key", key).Msg("failed to open file on SFTP server")
+			return nil, gostorage.ErrInternal
+		}
+
+		if length < 0 {
+			length = math.MaxInt64 - offset
+		}
+
+		return &rangeReader{
+			Reader: io.NewSectionReader(f, offset, length),
+			file:   f,
+			conn:   c,
+			pool:   s.pool,
+		}, nil
+	}
+}
+
+// rangeReader reads a remote file and returns its connection to the pool when closed.
+type rangeReader struct {
+	io.Reader
+	file *sftp.File
+	conn *conn
+	pool *connpool.Pool[*conn]
+}
+
+func (r *rangeReader) Close() error {
+	err := r.file.Close()
+	if lostConnection(err) {
+		r.pool.Discard(r.conn)
+	} else {
+		r.pool.Put(r.conn)
+	}
+
+	return err
+}
+
+// GetSignedURL is not supported by SFTP servers.
+// Usage: Returns gostorage.ErrNotSupported.
+func (s *SFTPStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", gostorage.ErrNotSupported
+}
+
+// GetURL returns BaseURL joined with the key.
+// Returns gostorage.ErrNotSupported if no BaseURL is configured.
+// Usage: Call this when Root is served by a web server.
+func (s *SFTPStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.BaseURL == "" {
+		return "", gostorage.ErrNotSupported
+	}
+
+	return strings.TrimRight(s.config.BaseURL, "/") + "/" + key, nil
+}
+
+// List calls fn for every file under opts.Prefix in lexical key order.
+// Each directory is read on its own pooled connection, so fn may use the storage itself.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *SFTPStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	return filetree.List(ctx, opts, func(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+		remote := s.filePath(dir)
+		if dir == "" {
+			remote = s.root
+			if remote == "" {
+				remote = "."
+			}
+		}
+
+		var infos []fs.FileInfo
+		err := s.do(ctx, true, func(client *sftp.Client) error {
+			var err error
+			infos, err = client.ReadDir(remote)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list files on SFTP server")
+			return nil, gostorage.ErrInternal
+		}
+
+		entries := make([]fs.DirEntry, len(infos))
+		for i, info := range infos {
+			entries[i] = fs.FileInfoToDirEntry(info)
+		}
+
+		return entries, nil
+	}, fn)
+}
+
+// Stat returns the size, modification time and ETag of a regular file.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *SFTPStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	var info fs.FileInfo
+	err := s.do(ctx, true, func(client *sftp.Client) error {
+		var err error
+		info, err = client.Stat(s.filePath(key))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to stat file on SFTP server")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	}
+
+	if !info.Mode().IsRegular() {
+		return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+	}
+
+	return filetree.ObjectInfo(key, info), nil
+}
+
+// Put uploads a file atomically: the content goes to a temporary file that is renamed into place,
+// so concurrent readers never observe a partially written file. Servers without the
+// posix-rename extension get a delete followed by a rename instead.
+// Returns the public URL if BaseURL is configured, otherwise an empty string.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *SFTPStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	p := s.filePath(key)
+	tmp := path.Join(path.Dir(p), filetree.TempName())
+
+	// the body can only be read once, so a dropped connection is not retried
+	err := s.do(ctx, false, func(client *sftp.Client) error {
+		if dir := path.Dir(p); dir != "." {
+			if err := client.MkdirAll(dir); err != nil {
+				return err
+			}
+		}
+
+		f, err := client.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		defer client.Remove(tmp) // no-op once renamed
+
+		_, err = f.ReadFrom(file)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := client.PosixRename(tmp, p); err != nil {
+			client.Remove(p)
+			return client.Rename(tmp, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to upload file to SFTP server")
+		return "", gostorage.ErrInternal
+	}
+
+	if s.config.BaseURL == "" {
+		return "", nil
+	}
+
+	return s.GetURL(ctx, key)
+}
@@ -0,0 +1,428 @@
+package sftpdriver
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sync/errgroup"
+)
+
+// testServer is an in-process SSH server exposing the SFTP subsystem.
+type testServer struct {
+	addr    string
+	root    string
+	hostKey ssh.PublicKey
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	accepted int
+}
+
+// newTestServer starts a server accepting user "partner" with password "secret" or clientKey.
+func newTestServer(t *testing.T, clientKey ssh.PublicKey) *testServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "expected no error generating host key")
+	hostSigner, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err, "expected no error creating host signer")
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == "partner" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if clientKey != nil && meta.User() == "partner" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "expected no error listening")
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &testServer{addr: ln.Addr().String(), root: t.TempDir(), hostKey: hostSigner.PublicKey()}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			srv.mu.Lock()
+			srv.conns = append(srv.conns, nc)
+			srv.accepted++
+			srv.mu.Unlock()
+
+			go srv.serve(nc, config)
+		}
+	}()
+	t.Cleanup(srv.drop)
+
+	return srv
+}
+
+func (s *testServer) serve(nc net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(nc, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					server, err := sftp.NewServer(ch)
+					if err == nil {
+						server.Serve()
+					}
+					ch.Close()
+				}
+			}
+		}()
+	}
+}
+
+// drop closes every open connection, as a server restart would.
+func (s *testServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, nc := range s.conns {
+		nc.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) acceptedConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accepted
+}
+
+// newTestStorage creates an SFTPStorage with password auth against srv, seeded with the given keys.
+func newTestStorage(t *testing.T, srv *testServer, keys ...string) *SFTPStorage {
+	t.Helper()
+
+	driver, err := NewSFTPStorage(SFTPStorageConfig{
+		Addr:            srv.addr,
+		User:            "partner",
+		Password:        "secret",
+		HostKeyCallback: ssh.FixedHostKey(srv.hostKey),
+		Root:            srv.root,
+		PoolSize:        2,
+	})
+	require.NoError(t, err, "expected no error creating SFTP storage")
+
+	storage := driver.(*SFTPStorage)
+	t.Cleanup(func() { storage.Close() })
+
+	for _, key := range keys {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("content of "+key))
+		require.NoError(t, err, "expected no error seeding file")
+	}
+
+	return storage
+}
+
+func TestNewSFTPStorage(t *testing.T) {
+	callback := ssh.InsecureIgnoreHostKey()
+
+	tests := []struct {
+		name        string
+		cfg         SFTPStorageConfig
+		expectedErr error
+	}{
+		{
+			name: "should create storage with password",
+			cfg:  SFTPStorageConfig{Addr: "localhost:22", User: "partner", Password: "secret", HostKeyCallback: callback},
+		},
+		{
+			name:        "should reject missing address",
+			cfg:         SFTPStorageConfig{User: "partner", Password: "secret", HostKeyCallback: callback},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should reject missing host key verification",
+			cfg:         SFTPStorageConfig{Addr: "localhost:22", User: "partner", Password: "secret"},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should reject missing credentials",
+			cfg:         SFTPStorageConfig{Addr: "localhost:22", User: "partner", HostKeyCallback: callback},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should reject invalid private key",
+			cfg:         SFTPStorageConfig{Addr: "localhost:22", User: "partner", PrivateKey: []byte("not a key"), HostKeyCallback: callback},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, err := NewSFTPStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Implements(t, (*gostorage.Lister)(nil), driver, "expected driver to list files")
+			assert.Implements(t, (*gostorage.RangeReader)(nil), driver, "expected driver to read ranges")
+		})
+	}
+}
+
+func TestSFTPStorage_PutAndDelete(t *testing.T) {
+	srv := newTestServer(t, nil)
+	storage := newTestStorage(t, srv)
+	ctx := context.Background()
+
+	_, err := storage.Put(ctx, "partners/acme/invoice.csv", strings.NewReader("id,total\n1,42\n"))
+	require.NoError(t, err, "expected no error uploading file")
+
+	data, err := os.ReadFile(filepath.Join(srv.root, "partners", "acme", "invoice.csv"))
+	require.NoError(t, err, "expected file on server")
+	assert.Equal(t, "id,total\n1,42\n", string(data), "expected uploaded content")
+
+	_, err = storage.Put(ctx, "partners/acme/invoice.csv", strings.NewReader("replaced"))
+	require.NoError(t, err, "expected no error overwriting file")
+
+	data, err = os.ReadFile(filepath.Join(srv.root, "partners", "acme", "invoice.csv"))
+	require.NoError(t, err, "expected file on server")
+	assert.Equal(t, "replaced", string(data), "expected overwritten content")
+
+	exists, err := storage.Exists(ctx, "partners/acme/invoice.csv")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.True(t, exists, "expected file to exist")
+
+	require.NoError(t, storage.Delete(ctx, "partners/acme/invoice.csv"), "expected no error deleting file")
+	assert.NoError(t, storage.Delete(ctx, "partners/acme/invoice.csv"), "expected no error deleting missing file")
+
+	exists, err = storage.Exists(ctx, "partners/acme/invoice.csv")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.False(t, exists, "expected file to be deleted")
+	assert.NoDirExists(t, filepath.Join(srv.root, "partners"), "expected empty directories to be removed")
+
+	_, err = storage.Put(ctx, "../escape.txt", strings.NewReader("x"))
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid key to be rejected")
+}
+
+func TestSFTPStorage_PrivateKeyAuth(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "expected no error generating client key")
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err, "expected no error creating client signer")
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err, "expected no error encoding client key")
+
+	srv := newTestServer(t, signer.PublicKey())
+
+	driver, err := NewSFTPStorage(SFTPStorageConfig{
+		Addr:            srv.addr,
+		User:            "partner",
+		PrivateKey:      pem.EncodeToMemory(block),
+		HostKeyCallback: ssh.FixedHostKey(srv.hostKey),
+		Root:            srv.root,
+	})
+	require.NoError(t, err, "expected no error creating SFTP storage")
+	defer driver.(*SFTPStorage).Close()
+
+	_, err = driver.Put(context.Background(), "report.txt", strings.NewReader("ok"))
+	assert.NoError(t, err, "expected key authentication to succeed")
+}
+
+func TestSFTPStorage_HostKeyVerification(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "expected no error generating key")
+	otherKey, err := ssh.NewPublicKey(other)
+	require.NoError(t, err, "expected no error creating public key")
+
+	driver, err := NewSFTPStorage(SFTPStorageConfig{
+		Addr:            srv.addr,
+		User:            "partner",
+		Password:        "secret",
+		HostKeyCallback: ssh.FixedHostKey(otherKey),
+		Root:            srv.root,
+	})
+	require.NoError(t, err, "expected no error creating SFTP storage")
+	defer driver.(*SFTPStorage).Close()
+
+	_, err = driver.Put(context.Background(), "report.txt", strings.NewReader("leak"))
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected unknown host key to be rejected")
+	assert.NoFileExists(t, filepath.Join(srv.root, "report.txt"), "expected nothing uploaded")
+}
+
+func TestSFTPStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every file recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a"},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, newTestServer(t, nil), keys...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing files")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestSFTPStorage_GetRange(t *testing.T) {
+	storage := newTestStorage(t, newTestServer(t, nil), "file.txt")
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read whole file", key: "file.txt", offset: 0, length: -1, expected: "content of file.txt"},
+		{name: "should read range", key: "file.txt", offset: 11, length: 4, expected: "file"},
+		{name: "should read to end from offset", key: "file.txt", offset: 16, length: -1, expected: "txt"},
+		{name: "should read nothing past the end", key: "file.txt", offset: 100, length: 4, expected: ""},
+		{name: "should not find missing file", key: "missing.txt", length: -1, expectedErr: gostorage.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(context.Background(), tt.key, tt.offset, tt.length)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+			defer body.Close()
+
+			data, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(data), "expected range content to match")
+		})
+	}
+}
+
+func TestSFTPStorage_Stat(t *testing.T) {
+	storage := newTestStorage(t, newTestServer(t, nil), "dir/file.txt")
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, "dir/file.txt")
+	require.NoError(t, err, "expected no error stating file")
+	assert.Equal(t, int64(len("content of dir/file.txt")), info.Size, "expected size to match")
+	assert.NotEmpty(t, info.ETag, "expected ETag")
+	assert.False(t, info.LastModified.IsZero(), "expected modification time")
+
+	_, err = storage.Stat(ctx, "dir")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected directory not to be a file")
+
+	_, err = storage.Stat(ctx, "missing.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected error for missing file")
+}
+
+func TestSFTPStorage_Pool(t *testing.T) {
+	srv := newTestServer(t, nil)
+	storage := newTestStorage(t, srv, "file.txt")
+
+	var g errgroup.Group
+	for range 20 {
+		g.Go(func() error {
+			_, err := storage.Stat(context.Background(), "file.txt")
+			return err
+		})
+	}
+
+	require.NoError(t, g.Wait(), "expected no error from concurrent operations")
+	assert.LessOrEqual(t, srv.acceptedConns(), 2, "expected connections to be reused up to the pool size")
+}
+
+func TestSFTPStorage_Reconnect(t *testing.T) {
+	srv := newTestServer(t, nil)
+	storage := newTestStorage(t, srv, "file.txt")
+	ctx := context.Background()
+
+	srv.drop()
+
+	exists, err := storage.Exists(ctx, "file.txt")
+	assert.NoError(t, err, "expected no error after the server dropped the connection")
+	assert.True(t, exists, "expected file to exist")
+
+	_, err = storage.Put(ctx, "after.txt", strings.NewReader("reconnected"))
+	assert.NoError(t, err, "expected no error uploading after reconnecting")
+	assert.Greater(t, srv.acceptedConns(), 1, "expected a new connection")
+}
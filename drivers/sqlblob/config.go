@@ -0,0 +1,143 @@
+package sqlblobdriver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DriverName is the name the SQL blob driver is registered under for gostorage.NewFromConfig.
+const DriverName = "sqlblob"
+
+func init() {
+	gostorage.RegisterDriver(DriverName, openFromConfig)
+	gostorage.RegisterURLScheme("sqlite", parseURL)
+	gostorage.RegisterURLScheme("postgres", parseURL)
+	gostorage.RegisterURLScheme("postgresql", parseURL)
+}
+
+// storageOptions are the query parameters of a DSN read by this driver.
+// All others are left in the database DSN, e.g. sslmode or _pragma.
+var storageOptions = map[string]bool{
+	"chunk_size":     true,
+	"base_url":       true,
+	"signing_secret": true,
+	"sql_driver":     true,
+	"migrate":        true,
+}
+
+// parseURL converts a SQLite or PostgreSQL DSN into disk options:
+//
+//	sqlite:///var/data/files.db?base_url=https://api.example.com/files&signing_secret=...
+//	sqlite://./data/files.db?_pragma=busy_timeout(5000) (relative to the working directory)
+//	postgres://app:secret@db:5432/app?sslmode=disable&sql_driver=pgx&chunk_size=262144
+//
+// Storage options are taken from the query; everything else becomes the connection option.
+func parseURL(u *url.URL) (gostorage.DiskConfig, error) {
+	options := make(map[string]string)
+	rest := url.Values{}
+	for key, values := range u.Query() {
+		if storageOptions[key] {
+			options[key] = values[len(values)-1]
+		} else {
+			rest[key] = values
+		}
+	}
+
+	switch u.Scheme {
+	case "sqlite":
+		file := u.Path
+		if u.Host != "" && u.Host != "localhost" {
+			file = u.Host + u.Path
+		}
+		if file == "" {
+			return gostorage.DiskConfig{}, &gostorage.ConfigError{Field: "dsn", Reason: "must name the database file"}
+		}
+
+		if len(rest) > 0 {
+			file += "?" + rest.Encode()
+		}
+		options["dialect"] = string(DialectSQLite)
+		options["connection"] = file
+	default:
+		db := *u
+		db.RawQuery = rest.Encode()
+		options["dialect"] = string(DialectPostgres)
+		options["connection"] = db.String()
+	}
+
+	return gostorage.DiskConfig{Driver: DriverName, Options: options}, nil
+}
+
+// ConfigFromDisk converts generic disk options into a SQLBlobStorageConfig.
+// Recognized options: dialect ("sqlite" or "postgres"), connection (required), sql_driver
+// (defaults to "sqlite" for SQLite and "pgx" for PostgreSQL), chunk_size (bytes), base_url
+// and signing_secret. Opening the disk also applies migrations unless migrate is false.
+// Returns a *gostorage.ConfigError naming the first invalid option.
+func ConfigFromDisk(disk gostorage.DiskConfig) (SQLBlobStorageConfig, error) {
+	var cfg SQLBlobStorageConfig
+	var err error
+
+	switch d := Dialect(disk.String("dialect")); d {
+	case DialectSQLite:
+		cfg.SQLDriver = "sqlite"
+		cfg.Dialect = d
+	case DialectPostgres:
+		cfg.SQLDriver = "pgx"
+		cfg.Dialect = d
+	default:
+		return cfg, &gostorage.ConfigError{Field: "dialect", Reason: fmt.Sprintf("must be %q or %q, got %q", DialectSQLite, DialectPostgres, d)}
+	}
+
+	if cfg.DSN, err = disk.Require("connection"); err != nil {
+		return cfg, err
+	}
+
+	if driver := disk.String("sql_driver"); driver != "" {
+		cfg.SQLDriver = driver
+	}
+
+	if cfg.ChunkSize, err = disk.Int("chunk_size", DefaultChunkSize); err != nil {
+		return cfg, err
+	}
+
+	if cfg.ChunkSize <= 0 {
+		return cfg, &gostorage.ConfigError{Field: "chunk_size", Reason: "must be positive"}
+	}
+
+	cfg.BaseURL = disk.String("base_url")
+	cfg.SigningSecret = disk.String("signing_secret")
+
+	return cfg, nil
+}
+
+// openFromConfig is the gostorage.DriverOpener for the SQL blob driver.
+// It migrates the schema unless the migrate option is false.
+func openFromConfig(ctx context.Context, disk gostorage.DiskConfig) (gostorage.StorageDriver, error) {
+	cfg, err := ConfigFromDisk(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	migrate, err := disk.Bool("migrate", true)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := NewSQLBlobStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if migrate {
+		storage := driver.(*SQLBlobStorage)
+		if err := storage.Migrate(ctx); err != nil {
+			storage.db.Close()
+			return nil, err
+		}
+	}
+
+	return driver, nil
+}
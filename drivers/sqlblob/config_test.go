@@ -0,0 +1,194 @@
+package sqlblobdriver
+
+import (
+	"context"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		dsn           string
+		expected      map[string]string
+		expectedField string
+	}{
+		{
+			name: "should parse absolute sqlite path and storage options",
+			dsn:  "sqlite:///var/data/files.db?base_url=https://api.example.com/files&signing_secret=s3cret&chunk_size=65536",
+			expected: map[string]string{
+				"dialect":        "sqlite",
+				"connection":     "/var/data/files.db",
+				"base_url":       "https://api.example.com/files",
+				"signing_secret": "s3cret",
+				"chunk_size":     "65536",
+			},
+		},
+		{
+			name: "should keep database parameters in the sqlite DSN",
+			dsn:  "sqlite://./data/files.db?_pragma=busy_timeout(5000)&migrate=false",
+			expected: map[string]string{
+				"dialect":    "sqlite",
+				"connection": "./data/files.db?_pragma=busy_timeout%285000%29",
+				"migrate":    "false",
+			},
+		},
+		{
+			name: "should parse postgres DSN",
+			dsn:  "postgres://app:secret@db:5432/app?sslmode=disable&sql_driver=postgres&base_url=https://api.example.com/files",
+			expected: map[string]string{
+				"dialect":    "postgres",
+				"connection": "postgres://app:secret@db:5432/app?sslmode=disable",
+				"sql_driver": "postgres",
+				"base_url":   "https://api.example.com/files",
+			},
+		},
+		{
+			name: "should accept postgresql scheme",
+			dsn:  "postgresql://app@db/app",
+			expected: map[string]string{
+				"dialect":    "postgres",
+				"connection": "postgresql://app@db/app",
+			},
+		},
+		{
+			name:          "should require sqlite file",
+			dsn:           "sqlite://",
+			expectedField: "dsn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := gostorage.ParseDSN(tt.dsn)
+
+			if tt.expectedField != "" {
+				var cfgErr *gostorage.ConfigError
+				require.ErrorAs(t, err, &cfgErr, "expected config error")
+				assert.Equal(t, tt.expectedField, cfgErr.Field, "expected field to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error parsing DSN")
+			assert.Equal(t, DriverName, disk.Driver, "expected driver to match")
+			assert.Equal(t, tt.expected, disk.Options, "expected options to match")
+		})
+	}
+}
+
+func TestConfigFromDisk(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       map[string]string
+		expected      SQLBlobStorageConfig
+		expectedField string
+	}{
+		{
+			name:    "should apply sqlite defaults",
+			options: map[string]string{"dialect": "sqlite", "connection": "files.db"},
+			expected: SQLBlobStorageConfig{
+				SQLDriver: "sqlite",
+				DSN:       "files.db",
+				Dialect:   DialectSQLite,
+				ChunkSize: DefaultChunkSize,
+			},
+		},
+		{
+			name: "should parse every option",
+			options: map[string]string{
+				"dialect":        "postgres",
+				"connection":     "postgres://app@db/app",
+				"sql_driver":     "postgres",
+				"chunk_size":     "65536",
+				"base_url":       "https://api.example.com/files",
+				"signing_secret": "s3cret",
+			},
+			expected: SQLBlobStorageConfig{
+				SQLDriver:     "postgres",
+				DSN:           "postgres://app@db/app",
+				Dialect:       DialectPostgres,
+				ChunkSize:     64 << 10,
+				BaseURL:       "https://api.example.com/files",
+				SigningSecret: "s3cret",
+			},
+		},
+		{
+			name:     "should default to pgx for postgres",
+			options:  map[string]string{"dialect": "postgres", "connection": "postgres://app@db/app"},
+			expected: SQLBlobStorageConfig{SQLDriver: "pgx", DSN: "postgres://app@db/app", Dialect: DialectPostgres, ChunkSize: DefaultChunkSize},
+		},
+		{
+			name:          "should reject unknown dialect",
+			options:       map[string]string{"dialect": "mysql", "connection": "app"},
+			expectedField: "dialect",
+		},
+		{
+			name:          "should require database DSN",
+			options:       map[string]string{"dialect": "sqlite"},
+			expectedField: "connection",
+		},
+		{
+			name:          "should reject non-positive chunk size",
+			options:       map[string]string{"dialect": "sqlite", "connection": "files.db", "chunk_size": "0"},
+			expectedField: "chunk_size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ConfigFromDisk(gostorage.DiskConfig{Driver: DriverName, Options: tt.options})
+
+			if tt.expectedField != "" {
+				var cfgErr *gostorage.ConfigError
+				require.ErrorAs(t, err, &cfgErr, "expected config error")
+				assert.Equal(t, tt.expectedField, cfgErr.Field, "expected field to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expected, cfg, "expected config to match")
+		})
+	}
+}
+
+func TestOpenURL(t *testing.T) {
+	ctx := context.Background()
+	dsn := "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "files.db")) + "?chunk_size=8"
+
+	driver, err := gostorage.OpenURL(ctx, dsn)
+	require.NoError(t, err, "expected no error opening DSN")
+	t.Cleanup(func() { driver.(*SQLBlobStorage).db.Close() })
+
+	_, err = driver.Put(ctx, "docs/a.txt", strings.NewReader("migrated on open"))
+	require.NoError(t, err, "expected the schema to be migrated on open")
+
+	body, err := driver.(*SQLBlobStorage).GetRange(ctx, "docs/a.txt", 0, -1)
+	require.NoError(t, err, "expected no error reading file")
+	got, _ := io.ReadAll(body)
+	assert.Equal(t, "migrated on open", string(got), "expected content to round-trip")
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "files.db")
+	t.Setenv("STORAGE_DEFAULT", "db")
+	t.Setenv("STORAGE_DISKS_DB_DRIVER", DriverName)
+	t.Setenv("STORAGE_DISKS_DB_DIALECT", "sqlite")
+	t.Setenv("STORAGE_DISKS_DB_CONNECTION", "file:"+file)
+
+	cfg, err := gostorage.LoadEnvConfig("STORAGE")
+	require.NoError(t, err, "expected no error loading environment")
+	require.NoError(t, cfg.Validate(), "expected a sqlblob disk configured from the environment to be valid")
+	assert.Equal(t, []string{"db"}, slices.Sorted(maps.Keys(cfg.Disks)), "expected no disk besides db")
+
+	sqlCfg, err := ConfigFromDisk(cfg.Disks["db"])
+	require.NoError(t, err, "expected no error converting disk options")
+	assert.Equal(t, "file:"+file, sqlCfg.DSN, "expected the connection option to reach the config")
+}
@@ -0,0 +1,143 @@
+package sqlblobdriver
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// migrations holds the schema of every dialect as numbered SQL files, e.g. migrations/sqlite/0001_create_files.sql.
+//
+//go:embed migrations
+var migrations embed.FS
+
+// migration is a single schema change of a dialect.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the migrations of a dialect, ordered by version.
+func loadMigrations(dialect Dialect) ([]migration, error) {
+	dir := path.Join("migrations", string(dialect))
+
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]migration, 0, len(entries))
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || !strings.HasSuffix(e.Name(), ".sql") {
+			return nil, fmt.Errorf("invalid migration file name %q", e.Name())
+		}
+
+		content, err := fs.ReadFile(migrations, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, migration{version: version, name: e.Name(), sql: string(content)})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+
+	return list, nil
+}
+
+// Migrate creates or upgrades the tables of the storage. Applied versions are recorded in
+// gostorage_schema_migrations, so it is safe to call on every start. Every migration runs in
+// its own transaction; when two processes migrate at once, one of them fails and can retry.
+// Usage: Call once after NewSQLBlobStorage, before the first Put. Opening through
+// gostorage.OpenURL or NewFromConfig migrates automatically unless migrate=false.
+func (s *SQLBlobStorage) Migrate(ctx context.Context) error {
+	list, err := loadMigrations(s.config.Dialect)
+	if err != nil {
+		log.Error().Err(err).Str("dialect", string(s.config.Dialect)).Msg("failed to load SQL migrations")
+		return gostorage.ErrInternal
+	}
+
+	_, err = s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS gostorage_schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create SQL migrations table")
+		return gostorage.ErrInternal
+	}
+
+	var current int
+	err = s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM gostorage_schema_migrations").Scan(&current)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to read SQL schema version")
+		return gostorage.ErrInternal
+	}
+
+	for _, m := range list {
+		if m.version <= current {
+			continue
+		}
+
+		if err := s.apply(ctx, m); err != nil {
+			log.Error().Err(err).Str("migration", m.name).Msg("failed to apply SQL migration")
+			return gostorage.ErrInternal
+		}
+	}
+
+	return nil
+}
+
+// apply runs the statements of a migration and records its version in one transaction.
+func (s *SQLBlobStorage) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	for _, stmt := range splitStatements(m.sql) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, s.rebind("INSERT INTO gostorage_schema_migrations (version, applied_at) VALUES (?, ?)"),
+		m.version, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// splitStatements splits a migration file into single statements, dropping comments.
+// Not every database/sql driver accepts several statements in one Exec.
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts
+}
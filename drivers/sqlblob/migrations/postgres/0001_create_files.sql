@@ -0,0 +1,19 @@
+-- Files are stored as a metadata row in gostorage_files and their content split into
+-- gostorage_chunks rows. Uploads in progress have a NULL path until they are committed,
+-- modified_at holds Unix nanoseconds.
+-- Paths use the "C" collation so listings are ordered byte-wise like every other driver.
+CREATE TABLE gostorage_files (
+    id          BIGSERIAL PRIMARY KEY,
+    path        TEXT COLLATE "C" UNIQUE,
+    size        BIGINT NOT NULL DEFAULT 0,
+    chunk_size  INTEGER NOT NULL,
+    etag        TEXT NOT NULL DEFAULT '',
+    modified_at BIGINT NOT NULL
+);
+
+CREATE TABLE gostorage_chunks (
+    file_id BIGINT NOT NULL REFERENCES gostorage_files (id) ON DELETE CASCADE,
+    seq     INTEGER NOT NULL,
+    data    BYTEA NOT NULL,
+    PRIMARY KEY (file_id, seq)
+);
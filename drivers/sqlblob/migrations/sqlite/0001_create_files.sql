@@ -0,0 +1,18 @@
+-- Files are stored as a metadata row in gostorage_files and their content split into
+-- gostorage_chunks rows. Uploads in progress have a NULL path until they are committed,
+-- modified_at holds Unix nanoseconds.
+CREATE TABLE gostorage_files (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    path        TEXT UNIQUE,
+    size        INTEGER NOT NULL DEFAULT 0,
+    chunk_size  INTEGER NOT NULL,
+    etag        TEXT NOT NULL DEFAULT '',
+    modified_at INTEGER NOT NULL
+);
+
+CREATE TABLE gostorage_chunks (
+    file_id INTEGER NOT NULL REFERENCES gostorage_files (id) ON DELETE CASCADE,
+    seq     INTEGER NOT NULL,
+    data    BLOB NOT NULL,
+    PRIMARY KEY (file_id, seq)
+);
@@ -0,0 +1,67 @@
+package sqlblobdriver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// chunkReader streams a byte range of a file, loading one chunk row per query.
+// It holds no connection between reads, so a slow consumer does not tie up the pool.
+type chunkReader struct {
+	ctx       context.Context
+	storage   *SQLBlobStorage
+	key       string
+	fileID    int64
+	seq       int64  // next chunk to load
+	skip      int    // bytes to drop from the start of the next chunk
+	remaining int64  // bytes left in the range
+	buf       []byte // unread part of the current chunk
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if len(r.buf) == 0 {
+		var data []byte
+		err := r.storage.db.QueryRowContext(r.ctx, r.storage.rebind("SELECT data FROM gostorage_chunks WHERE file_id = ? AND seq = ?"), r.fileID, r.seq).
+			Scan(&data)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// The file was overwritten or deleted while reading.
+				return 0, io.ErrUnexpectedEOF
+			}
+
+			log.Error().Err(err).Str("key", r.key).Int64("seq", r.seq).Msg("failed to read file chunk from database")
+			return 0, gostorage.ErrInternal
+		}
+
+		if r.skip > len(data) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.buf = data[r.skip:]
+		r.skip = 0
+		r.seq++
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.remaining -= int64(n)
+
+	return n, nil
+}
+
+func (r *chunkReader) Close() error {
+	r.buf, r.remaining = nil, 0
+	return nil
+}
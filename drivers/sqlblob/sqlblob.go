@@ -0,0 +1,499 @@
+package sqlblobdriver
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/httpserve"
+)
+
+// DefaultChunkSize is the number of bytes stored per blob row when SQLBlobStorageConfig.ChunkSize is zero.
+const DefaultChunkSize = 1 << 20
+
+// listPageSize is the number of rows List reads per query.
+var listPageSize = 1000
+
+// Dialect selects the SQL flavour spoken by the database.
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"   // SQLite 3.35 or later, e.g. through modernc.org/sqlite
+	DialectPostgres Dialect = "postgres" // PostgreSQL 10 or later, e.g. through github.com/jackc/pgx/v5/stdlib
+)
+
+// SQLBlobStorageConfig defines the configuration for storing files in a SQL database.
+// The database/sql driver must be registered by the application, e.g. with a blank import.
+type SQLBlobStorageConfig struct {
+	DB        *sql.DB // optional open database; if nil, one is opened from SQLDriver and DSN
+	SQLDriver string  // database/sql driver name used with DSN, e.g. "sqlite" or "pgx"
+	DSN       string  // data source name used with SQLDriver, e.g. "/var/data/files.db"
+	Dialect   Dialect // SQL flavour of the database
+
+	ChunkSize     int    // optional bytes stored per blob row, defaults to DefaultChunkSize
+	BaseURL       string // optional URL an httpserve.Handler serves this storage from, e.g. "https://api.example.com/files"
+	SigningSecret string // optional secret shared with the httpserve.Handler at BaseURL, enables GetSignedURL
+}
+
+// String returns the config with the DSN password and the signing secret redacted, so it is safe to print or log.
+func (c SQLBlobStorageConfig) String() string {
+	secret := ""
+	if c.SigningSecret != "" {
+		secret = "xxxxx"
+	}
+
+	return fmt.Sprintf(
+		"{DB:%p SQLDriver:%s DSN:%s Dialect:%s ChunkSize:%d BaseURL:%s SigningSecret:%s}",
+		c.DB, c.SQLDriver, gostorage.RedactDSN(c.DSN), c.Dialect, c.ChunkSize, c.BaseURL, secret,
+	)
+}
+
+// GoString is like String, so %#v does not leak the secrets either.
+func (c SQLBlobStorageConfig) GoString() string {
+	return "sqlblobdriver.SQLBlobStorageConfig" + c.String()
+}
+
+// SQLBlobStorage is the concrete implementation of gostorage.StorageDriver for SQL databases.
+// Every file is a row of gostorage_files holding its metadata, and its content is split into
+// gostorage_chunks rows of ChunkSize bytes, so uploads and downloads stream instead of holding
+// whole files in memory. Files are served over HTTP by an httpserve.Handler.
+type SQLBlobStorage struct {
+	db     *sql.DB
+	config SQLBlobStorageConfig
+	signer *httpserve.Signer // nil unless BaseURL and SigningSecret are set
+}
+
+// NewSQLBlobStorage initializes a SQLBlobStorage. The schema must exist; see Migrate.
+// A database opened from SQLDriver and DSN for SQLite is limited to one connection, since
+// SQLite allows a single writer. No connection is made until the first operation.
+// Returns gostorage.ErrInvalidConfig if the dialect is unknown, ChunkSize is negative,
+// or neither DB nor SQLDriver and DSN are set.
+func NewSQLBlobStorage(cfg SQLBlobStorageConfig) (gostorage.StorageDriver, error) {
+	if cfg.Dialect != DialectSQLite && cfg.Dialect != DialectPostgres {
+		return nil, gostorage.ErrInvalidConfig
+	}
+
+	if cfg.ChunkSize < 0 {
+		return nil, gostorage.ErrInvalidConfig
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+
+	db := cfg.DB
+	if db == nil {
+		if cfg.SQLDriver == "" || cfg.DSN == "" {
+			return nil, gostorage.ErrInvalidConfig
+		}
+
+		var err error
+		if db, err = sql.Open(cfg.SQLDriver, cfg.DSN); err != nil {
+			log.Error().Err(err).Str("sql_driver", cfg.SQLDriver).Msg("failed to open SQL database")
+			return nil, gostorage.ErrInvalidConfig
+		}
+
+		if cfg.Dialect == DialectSQLite {
+			db.SetMaxOpenConns(1)
+		}
+	}
+
+	storage := &SQLBlobStorage{
+		db:     db,
+		config: cfg,
+	}
+	if cfg.BaseURL != "" && cfg.SigningSecret != "" {
+		storage.signer = httpserve.NewSigner([]byte(cfg.SigningSecret))
+	}
+
+	return storage, nil
+}
+
+// rebind converts the "?" placeholders of query into the dialect's syntax.
+func (s *SQLBlobStorage) rebind(query string) string {
+	if s.config.Dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Capabilities reports the features supported by the database.
+// Public URLs are only available when a BaseURL is configured, signed URLs when a SigningSecret is too.
+func (s *SQLBlobStorage) Capabilities() gostorage.Capabilities {
+	return gostorage.Capabilities{
+		SignedURL: s.signer != nil,
+		PublicURL: s.config.BaseURL != "",
+		List:      true,
+		RangeRead: true,
+	}
+}
+
+// Delete removes a file and its chunks. Deleting a file that does not exist is not an error.
+// Usage: Call when you want to delete a file by its key.
+func (s *SQLBlobStorage) Delete(ctx context.Context, key string) error {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err == nil {
+		defer tx.Rollback() // no-op once committed
+
+		if err = s.deletePath(ctx, tx, key); err == nil {
+			err = tx.Commit()
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to delete file from database")
+		return gostorage.ErrInternal
+	}
+
+	return nil
+}
+
+// deletePath removes the committed file at key, if any, within tx.
+func (s *SQLBlobStorage) deletePath(ctx context.Context, tx *sql.Tx, key string) error {
+	_, err := tx.ExecContext(ctx, s.rebind("DELETE FROM gostorage_chunks WHERE file_id IN (SELECT id FROM gostorage_files WHERE path = ?)"), key)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, s.rebind("DELETE FROM gostorage_files WHERE path = ?"), key)
+	return err
+}
+
+// Exists checks if a file exists for the key.
+// Usage: Call before uploading or deleting to verify the file's presence.
+func (s *SQLBlobStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return false, err
+	}
+
+	var one int
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT 1 FROM gostorage_files WHERE path = ?"), key).Scan(&one)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to check if file exists in database")
+		return false, gostorage.ErrInternal
+	}
+
+	return true, nil
+}
+
+// GetRange returns a reader over the requested byte range that fetches one chunk row at a time.
+// A negative length reads to the end of the file; an offset past the end yields an empty body.
+// Usage: Used by StorageManager.GetRange and OpenReaderAt, and by httpserve.Handler to serve files.
+func (s *SQLBlobStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	var id, size int64
+	var chunkSize int
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT id, size, chunk_size FROM gostorage_files WHERE path = ?"), key).
+		Scan(&id, &size, &chunkSize)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to open file in database")
+		return nil, gostorage.ErrInternal
+	}
+
+	if offset >= size {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if length < 0 || length > size-offset {
+		length = size - offset
+	}
+
+	return &chunkReader{
+		ctx:       ctx,
+		storage:   s,
+		key:       key,
+		fileID:    id,
+		seq:       offset / int64(chunkSize),
+		skip:      int(offset % int64(chunkSize)),
+		remaining: length,
+	}, nil
+}
+
+// GetSignedURL returns a URL below BaseURL signed with SigningSecret, to be verified by an
+// httpserve.Handler serving this storage with httpserve.WithSigner and the same secret.
+// Returns gostorage.ErrNotSupported if BaseURL or SigningSecret is not configured.
+// Usage: Call this when you need to share temporary access to a private file.
+func (s *SQLBlobStorage) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.signer == nil {
+		return "", gostorage.ErrNotSupported
+	}
+
+	if err := gostorage.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	return s.signer.SignURL(s.config.BaseURL, key, expiry), nil
+}
+
+// GetURL returns BaseURL joined with the key.
+// Returns gostorage.ErrNotSupported if no BaseURL is configured.
+// Usage: Call this when the storage is served publicly by an httpserve.Handler.
+func (s *SQLBlobStorage) GetURL(ctx context.Context, key string) (string, error) {
+	if s.config.BaseURL == "" {
+		return "", gostorage.ErrNotSupported
+	}
+
+	return strings.TrimRight(s.config.BaseURL, "/") + "/" + key, nil
+}
+
+// List calls fn for every file under opts.Prefix in lexical key order.
+// Rows are read in pages, so fn may use the storage while listing.
+// Usage: Used by StorageManager.List and StorageManager.DeletePrefix.
+func (s *SQLBlobStorage) List(ctx context.Context, opts gostorage.ListOptions, fn func(gostorage.ObjectInfo) error) error {
+	query := "SELECT path, size, modified_at, etag FROM gostorage_files WHERE path >= ? ORDER BY path LIMIT ?"
+	cursor := opts.Prefix
+	lastDir := ""
+
+	for {
+		page, err := s.listPage(ctx, query, cursor)
+		if err != nil {
+			log.Error().Err(err).Str("prefix", opts.Prefix).Msg("failed to list files in database")
+			return gostorage.ErrInternal
+		}
+
+		var objects []gostorage.ObjectInfo
+		done := len(page) < listPageSize
+		for _, obj := range page {
+			if !strings.HasPrefix(obj.Key, opts.Prefix) {
+				done = true
+				break
+			}
+			cursor = obj.Key
+
+			// Collapse everything below the next "/" into a single directory entry.
+			if i := strings.Index(obj.Key[len(opts.Prefix):], "/"); i >= 0 && !opts.Recursive {
+				dir := obj.Key[:len(opts.Prefix)+i+1]
+				if dir != lastDir {
+					objects = append(objects, gostorage.ObjectInfo{Key: dir, IsDir: true})
+					lastDir = dir
+				}
+
+				// No valid key has a byte above 0x7f, so this skips the rest of dir.
+				cursor = dir + "\x7f"
+				continue
+			}
+
+			objects = append(objects, obj)
+		}
+
+		for _, obj := range objects {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+
+		if done {
+			return nil
+		}
+		query = "SELECT path, size, modified_at, etag FROM gostorage_files WHERE path > ? ORDER BY path LIMIT ?"
+	}
+}
+
+// listPage reads up to listPageSize committed files starting at cursor.
+func (s *SQLBlobStorage) listPage(ctx context.Context, query, cursor string) ([]gostorage.ObjectInfo, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), cursor, listPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := make([]gostorage.ObjectInfo, 0, listPageSize)
+	for rows.Next() {
+		var obj gostorage.ObjectInfo
+		var modified int64
+		if err := rows.Scan(&obj.Key, &obj.Size, &modified, &obj.ETag); err != nil {
+			return nil, err
+		}
+
+		obj.LastModified = time.Unix(0, modified)
+		page = append(page, obj)
+	}
+
+	return page, rows.Err()
+}
+
+// Stat returns the size, modification time and ETag of a file.
+// Usage: Used by StorageManager.Stat and OpenReaderAt.
+func (s *SQLBlobStorage) Stat(ctx context.Context, key string) (gostorage.ObjectInfo, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		return gostorage.ObjectInfo{}, err
+	}
+
+	obj := gostorage.ObjectInfo{Key: key}
+	var modified int64
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT size, modified_at, etag FROM gostorage_files WHERE path = ?"), key).
+		Scan(&obj.Size, &modified, &obj.ETag)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return gostorage.ObjectInfo{}, gostorage.ErrNotFound
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to stat file in database")
+		return gostorage.ObjectInfo{}, gostorage.ErrInternal
+	}
+
+	obj.LastModified = time.Unix(0, modified)
+
+	return obj, nil
+}
+
+// Put stores a file chunk by chunk under a pending row, then replaces any previous file at key
+// in a short transaction, so readers never observe a partially written file and the database
+// is not locked for the duration of the upload. The ETag is the quoted MD5 of the content.
+// Returns the public URL if BaseURL is configured, otherwise an empty string.
+// Usage: Call this to save a new file or overwrite an existing file.
+func (s *SQLBlobStorage) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if err := gostorage.ValidateKey(key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("invalid key")
+		return "", err
+	}
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, s.rebind("INSERT INTO gostorage_files (chunk_size, modified_at) VALUES (?, ?) RETURNING id"),
+		s.config.ChunkSize, time.Now().UnixNano()).Scan(&id)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to create file in database")
+		return "", gostorage.ErrInternal
+	}
+
+	if err := s.write(ctx, id, key, file); err != nil {
+		if purgeErr := s.purge(context.WithoutCancel(ctx), id); purgeErr != nil {
+			log.Error().Err(purgeErr).Str("key", key).Msg("failed to remove incomplete file from database")
+		}
+
+		log.Error().Err(err).Str("key", key).Msg("failed to write file to database")
+		return "", gostorage.ErrInternal
+	}
+
+	if s.config.BaseURL == "" {
+		return "", nil
+	}
+
+	return s.GetURL(ctx, key)
+}
+
+// write stores the content of file as chunks of the pending file id and commits it under key.
+func (s *SQLBlobStorage) write(ctx context.Context, id int64, key string, file io.Reader) error {
+	hash := md5.New()
+	buf := make([]byte, s.config.ChunkSize)
+	var size int64
+
+	for seq := 0; ; seq++ {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			size += int64(n)
+
+			_, execErr := s.db.ExecContext(ctx, s.rebind("INSERT INTO gostorage_chunks (file_id, seq, data) VALUES (?, ?, ?)"), id, seq, buf[:n])
+			if execErr != nil {
+				return execErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	if err := s.deletePath(ctx, tx, key); err != nil {
+		return err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	_, err = tx.ExecContext(ctx, s.rebind("UPDATE gostorage_files SET path = ?, size = ?, etag = ?, modified_at = ? WHERE id = ?"),
+		key, size, etag, time.Now().UnixNano(), id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// purge removes a file row and its chunks by id.
+func (s *SQLBlobStorage) purge(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, s.rebind("DELETE FROM gostorage_chunks WHERE file_id = ?"), id); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, s.rebind("DELETE FROM gostorage_files WHERE id = ?"), id)
+	return err
+}
+
+// PurgeIncompleteUploads removes uploads that were started more than olderThan ago and never
+// committed, e.g. because the process crashed during Put. It returns the number of uploads removed.
+// Usage: Run periodically, with olderThan well above the duration of the longest upload.
+func (s *SQLBlobStorage) PurgeIncompleteUploads(ctx context.Context, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan).UnixNano()
+
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT id FROM gostorage_files WHERE path IS NULL AND modified_at < ?"), cutoff)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find incomplete uploads in database")
+		return 0, gostorage.ErrInternal
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			log.Error().Err(err).Msg("failed to find incomplete uploads in database")
+			return 0, gostorage.ErrInternal
+		}
+		ids = append(ids, id)
+	}
+	if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+		log.Error().Err(err).Msg("failed to find incomplete uploads in database")
+		return 0, gostorage.ErrInternal
+	}
+
+	for i, id := range ids {
+		if err := s.purge(ctx, id); err != nil {
+			log.Error().Err(err).Int64("id", id).Msg("failed to remove incomplete upload from database")
+			return i, gostorage.ErrInternal
+		}
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,478 @@
+package sqlblobdriver
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/shoraid/go-storage/httpserve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// newTestStorage creates a migrated SQLite storage in a temporary directory seeded with the given keys.
+// A chunk size of 4 bytes makes every seeded file span several chunk rows.
+func newTestStorage(t *testing.T, cfg SQLBlobStorageConfig, keys ...string) *SQLBlobStorage {
+	t.Helper()
+
+	cfg.SQLDriver = "sqlite"
+	cfg.DSN = filepath.Join(t.TempDir(), "files.db")
+	cfg.Dialect = DialectSQLite
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = 4
+	}
+
+	driver, err := NewSQLBlobStorage(cfg)
+	require.NoError(t, err, "expected no error creating SQL blob storage")
+
+	storage := driver.(*SQLBlobStorage)
+	t.Cleanup(func() { storage.db.Close() })
+	require.NoError(t, storage.Migrate(context.Background()), "expected no error migrating")
+
+	for _, key := range keys {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("content of "+key))
+		require.NoError(t, err, "expected no error seeding file")
+	}
+
+	return storage
+}
+
+// countRows returns the number of rows of a table.
+func countRows(t *testing.T, s *SQLBlobStorage, table string) int {
+	t.Helper()
+
+	var n int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n), "expected no error counting rows")
+
+	return n
+}
+
+// failingReader returns data once, then fails.
+type failingReader struct{ data string }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errors.New("connection reset")
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func TestNewSQLBlobStorage(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         SQLBlobStorageConfig
+		expectedErr error
+	}{
+		{
+			name:        "should create storage from driver and DSN",
+			cfg:         SQLBlobStorageConfig{SQLDriver: "sqlite", DSN: filepath.Join(t.TempDir(), "files.db"), Dialect: DialectSQLite},
+			expectedErr: nil,
+		},
+		{
+			name:        "should return error when dialect is unknown",
+			cfg:         SQLBlobStorageConfig{SQLDriver: "sqlite", DSN: "files.db", Dialect: "mysql"},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error when database is missing",
+			cfg:         SQLBlobStorageConfig{Dialect: DialectPostgres},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error when SQL driver is not registered",
+			cfg:         SQLBlobStorageConfig{SQLDriver: "missing", DSN: "files.db", Dialect: DialectSQLite},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+		{
+			name:        "should return error when chunk size is negative",
+			cfg:         SQLBlobStorageConfig{SQLDriver: "sqlite", DSN: "files.db", Dialect: DialectSQLite, ChunkSize: -1},
+			expectedErr: gostorage.ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewSQLBlobStorage(tt.cfg)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error when config is invalid")
+				assert.Nil(t, storage, "expected storage to be nil on error")
+			} else {
+				assert.NoError(t, err, "expected no error creating storage")
+				assert.Equal(t, DefaultChunkSize, storage.(*SQLBlobStorage).config.ChunkSize, "expected default chunk size")
+			}
+		})
+	}
+}
+
+func TestSQLBlobStorageConfig_String(t *testing.T) {
+	cfg := SQLBlobStorageConfig{
+		SQLDriver:     "pgx",
+		DSN:           "postgres://app:super-secret@db/app",
+		Dialect:       DialectPostgres,
+		SigningSecret: "super-secret",
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%s", "%#v"} {
+		assert.NotContains(t, fmt.Sprintf(verb, cfg), "super-secret", "expected secrets to be redacted with "+verb)
+	}
+}
+
+func TestSQLBlobStorage_Migrate(t *testing.T) {
+	storage := newTestStorage(t, SQLBlobStorageConfig{})
+
+	assert.NoError(t, storage.Migrate(context.Background()), "expected migrating twice to be a no-op")
+	assert.Equal(t, 1, countRows(t, storage, "gostorage_schema_migrations"), "expected every migration to be recorded once")
+
+	for _, dialect := range []Dialect{DialectSQLite, DialectPostgres} {
+		list, err := loadMigrations(dialect)
+		assert.NoError(t, err, "expected no error loading migrations of "+string(dialect))
+		assert.NotEmpty(t, list, "expected migrations for "+string(dialect))
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- comment; with semicolon\nCREATE TABLE a (id INTEGER);\n\nCREATE TABLE b (id INTEGER);\n"
+
+	assert.Equal(t, []string{"CREATE TABLE a (id INTEGER)", "CREATE TABLE b (id INTEGER)"}, splitStatements(script), "expected statements without comments")
+}
+
+func TestSQLBlobStorage_Rebind(t *testing.T) {
+	postgres := &SQLBlobStorage{config: SQLBlobStorageConfig{Dialect: DialectPostgres}}
+	sqlite := &SQLBlobStorage{config: SQLBlobStorageConfig{Dialect: DialectSQLite}}
+	query := "UPDATE t SET a = ?, b = ? WHERE id = ?"
+
+	assert.Equal(t, "UPDATE t SET a = $1, b = $2 WHERE id = $3", postgres.rebind(query), "expected numbered placeholders for postgres")
+	assert.Equal(t, query, sqlite.rebind(query), "expected question marks for sqlite")
+}
+
+func TestSQLBlobStorage_Put(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		content string
+		chunks  int
+	}{
+		{name: "should store empty file without chunks", content: "", chunks: 0},
+		{name: "should store file smaller than a chunk", content: "abc", chunks: 1},
+		{name: "should split file into chunks", content: "hello, chunked world", chunks: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newTestStorage(t, SQLBlobStorageConfig{})
+
+			location, err := storage.Put(ctx, "docs/file.txt", strings.NewReader(tt.content))
+			assert.NoError(t, err, "expected no error putting file")
+			assert.Empty(t, location, "expected no URL without base URL")
+			assert.Equal(t, tt.chunks, countRows(t, storage, "gostorage_chunks"), "expected chunk rows to match")
+
+			body, err := storage.GetRange(ctx, "docs/file.txt", 0, -1)
+			require.NoError(t, err, "expected no error reading file")
+			got, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.content, string(got), "expected content to round-trip")
+
+			sum := md5.Sum([]byte(tt.content))
+			info, err := storage.Stat(ctx, "docs/file.txt")
+			assert.NoError(t, err, "expected no error stating file")
+			assert.Equal(t, `"`+hex.EncodeToString(sum[:])+`"`, info.ETag, "expected MD5 ETag")
+			assert.Equal(t, int64(len(tt.content)), info.Size, "expected size to match")
+		})
+	}
+}
+
+func TestSQLBlobStorage_PutOverwrite(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{BaseURL: "https://api.example.com/files"}, "docs/a.txt")
+
+	location, err := storage.Put(ctx, "docs/a.txt", strings.NewReader("new"))
+	assert.NoError(t, err, "expected no error overwriting file")
+	assert.Equal(t, "https://api.example.com/files/docs/a.txt", location, "expected public URL with base URL")
+
+	assert.Equal(t, 1, countRows(t, storage, "gostorage_files"), "expected the previous file row to be replaced")
+	assert.Equal(t, 1, countRows(t, storage, "gostorage_chunks"), "expected the previous chunks to be removed")
+
+	_, err = storage.Put(ctx, "docs/a.txt", &failingReader{data: "partial content"})
+	assert.ErrorIs(t, err, gostorage.ErrInternal, "expected ErrInternal when the upload fails")
+
+	body, err := storage.GetRange(ctx, "docs/a.txt", 0, -1)
+	require.NoError(t, err, "expected the previous file to survive a failed upload")
+	got, _ := io.ReadAll(body)
+	assert.Equal(t, "new", string(got), "expected previous content after failed upload")
+	assert.Equal(t, 1, countRows(t, storage, "gostorage_files"), "expected the incomplete upload to be removed")
+
+	_, err = storage.Put(ctx, "../a.txt", strings.NewReader("x"))
+	assert.ErrorIs(t, err, gostorage.ErrInvalidKey, "expected invalid keys to be rejected")
+}
+
+func TestSQLBlobStorage_DeleteAndExists(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, "a/b.txt", "a/c.txt")
+
+	exists, err := storage.Exists(ctx, "a/b.txt")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.True(t, exists, "expected file to exist")
+
+	assert.NoError(t, storage.Delete(ctx, "a/b.txt"), "expected no error deleting file")
+	assert.NoError(t, storage.Delete(ctx, "a/b.txt"), "expected deleting a missing file not to fail")
+
+	exists, err = storage.Exists(ctx, "a/b.txt")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.False(t, exists, "expected file to be deleted")
+
+	exists, err = storage.Exists(ctx, "a/c.txt")
+	assert.NoError(t, err, "expected no error checking existence")
+	assert.True(t, exists, "expected sibling file to be kept")
+	assert.Equal(t, 5, countRows(t, storage, "gostorage_chunks"), "expected only the chunks of the sibling file to remain")
+
+	assert.ErrorIs(t, storage.Delete(ctx, "../b.txt"), gostorage.ErrInvalidKey, "expected invalid keys to be rejected")
+}
+
+func TestSQLBlobStorage_GetRange(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, "videos/clip.txt") // "content of videos/clip.txt"
+
+	tests := []struct {
+		name        string
+		key         string
+		offset      int64
+		length      int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "should read bounded range across chunks", key: "videos/clip.txt", offset: 11, length: 6, expected: "videos"},
+		{name: "should read within a single chunk", key: "videos/clip.txt", offset: 1, length: 2, expected: "on"},
+		{name: "should read to the end for negative length", key: "videos/clip.txt", offset: 18, length: -1, expected: "clip.txt"},
+		{name: "should clamp length to the end", key: "videos/clip.txt", offset: 22, length: 100, expected: ".txt"},
+		{name: "should return empty body past the end", key: "videos/clip.txt", offset: 100, length: 5, expected: ""},
+		{name: "should return ErrNotFound for missing file", key: "videos/missing.txt", length: 5, expectedErr: gostorage.ErrNotFound},
+		{name: "should return ErrInvalidKey for invalid key", key: "../clip.txt", length: 5, expectedErr: gostorage.ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := storage.GetRange(ctx, tt.key, tt.offset, tt.length)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error reading range")
+			defer body.Close()
+
+			got, err := io.ReadAll(body)
+			assert.NoError(t, err, "expected no error reading body")
+			assert.Equal(t, tt.expected, string(got), "expected range content to match")
+		})
+	}
+}
+
+func TestSQLBlobStorage_GetRangeDeletedWhileReading(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, "docs/a.txt")
+
+	body, err := storage.GetRange(ctx, "docs/a.txt", 0, -1)
+	require.NoError(t, err, "expected no error opening file")
+	defer body.Close()
+
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(body, buf)
+	require.NoError(t, err, "expected no error reading the first chunk")
+
+	require.NoError(t, storage.Delete(ctx, "docs/a.txt"), "expected no error deleting file")
+
+	_, err = io.ReadAll(body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "expected a truncated read once the file is gone")
+}
+
+func TestSQLBlobStorage_List(t *testing.T) {
+	keys := []string{"a.txt", "a/b.txt", "a/c/d.txt", "a/e.txt", "ab.txt", "b/e.txt"}
+
+	tests := []struct {
+		name     string
+		opts     gostorage.ListOptions
+		expected []string
+	}{
+		{
+			name:     "should list every file recursively in lexical order",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "a/e.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list recursively under a directory prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/", Recursive: true},
+			expected: []string{"a/b.txt", "a/c/d.txt", "a/e.txt"},
+		},
+		{
+			name:     "should collapse nested directories under a prefix",
+			opts:     gostorage.ListOptions{Prefix: "a/"},
+			expected: []string{"a/b.txt", "a/c/", "a/e.txt"},
+		},
+		{
+			name:     "should match partial segment prefixes",
+			opts:     gostorage.ListOptions{Prefix: "a", Recursive: false},
+			expected: []string{"a.txt", "a/", "ab.txt"},
+		},
+		{
+			name:     "should match a key equal to the prefix",
+			opts:     gostorage.ListOptions{Prefix: "ab.txt"},
+			expected: []string{"ab.txt"},
+		},
+		{
+			name:     "should return nothing for unknown prefix",
+			opts:     gostorage.ListOptions{Prefix: "missing/", Recursive: true},
+			expected: nil,
+		},
+	}
+
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, keys...)
+
+	pageSize := listPageSize
+	listPageSize = 2 // exercise paging
+	t.Cleanup(func() { listPageSize = pageSize })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := storage.List(context.Background(), tt.opts, func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			assert.NoError(t, err, "expected no error listing files")
+			assert.Equal(t, tt.expected, got, "expected listed keys to match")
+		})
+	}
+}
+
+func TestSQLBlobStorage_DeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, "users/1/a.txt", "users/1/b/c.txt", "users/10/d.txt", "users/2/e.txt")
+
+	manager, err := gostorage.NewStorageManager("db", map[string]gostorage.StorageDriver{"db": storage})
+	require.NoError(t, err, "expected no error creating manager")
+
+	report, err := manager.DeletePrefix(ctx, "users/1/", gostorage.DeletePrefixOptions{})
+	assert.NoError(t, err, "expected no error deleting prefix")
+	assert.Equal(t, 2, report.Deleted, "expected both files under the prefix to be deleted")
+
+	for key, expected := range map[string]bool{"users/1/a.txt": false, "users/10/d.txt": true, "users/2/e.txt": true} {
+		exists, err := storage.Exists(ctx, key)
+		assert.NoError(t, err, "expected no error checking existence")
+		assert.Equal(t, expected, exists, "expected existence of "+key+" to match")
+	}
+}
+
+func TestSQLBlobStorage_Stat(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, "docs/a.txt")
+
+	info, err := storage.Stat(ctx, "docs/a.txt")
+	assert.NoError(t, err, "expected no error stating file")
+	assert.Equal(t, "docs/a.txt", info.Key, "expected key to match")
+	assert.Equal(t, int64(len("content of docs/a.txt")), info.Size, "expected size to match")
+	assert.WithinDuration(t, time.Now(), info.LastModified, time.Minute, "expected recent modification time")
+
+	_, err = storage.Stat(ctx, "docs")
+	assert.ErrorIs(t, err, gostorage.ErrNotFound, "expected directories to be reported as not found")
+}
+
+func TestSQLBlobStorage_URLs(t *testing.T) {
+	ctx := context.Background()
+	withoutBaseURL := newTestStorage(t, SQLBlobStorageConfig{})
+	withBaseURL := newTestStorage(t, SQLBlobStorageConfig{BaseURL: "https://api.example.com/files/"})
+
+	location, err := withBaseURL.GetURL(ctx, "a/b.txt")
+	assert.NoError(t, err, "expected no error getting URL")
+	assert.Equal(t, "https://api.example.com/files/a/b.txt", location, "expected key below base URL")
+	assert.True(t, withBaseURL.Capabilities().PublicURL, "expected public URL capability with base URL")
+
+	_, err = withoutBaseURL.GetURL(ctx, "a/b.txt")
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected not supported without base URL")
+
+	_, err = withBaseURL.GetSignedURL(ctx, "a/b.txt", time.Minute)
+	assert.ErrorIs(t, err, gostorage.ErrNotSupported, "expected signed URLs not to be supported without signing secret")
+	assert.False(t, withBaseURL.Capabilities().SignedURL, "expected no signed URL capability without signing secret")
+}
+
+func TestSQLBlobStorage_SignedURLServedByHandler(t *testing.T) {
+	ctx := context.Background()
+
+	var storage *SQLBlobStorage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		manager, err := gostorage.NewStorageManager("db", map[string]gostorage.StorageDriver{"db": storage})
+		require.NoError(t, err, "expected no error creating manager")
+
+		signer := httpserve.NewSigner([]byte("test-secret"))
+		http.StripPrefix("/files", httpserve.New(manager, httpserve.WithSigner(signer))).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	storage = newTestStorage(t, SQLBlobStorageConfig{BaseURL: server.URL + "/files", SigningSecret: "test-secret"}, "docs/report.txt")
+	assert.True(t, storage.Capabilities().SignedURL, "expected signed URL capability with signing secret")
+
+	signed, err := storage.GetSignedURL(ctx, "docs/report.txt", time.Minute)
+	require.NoError(t, err, "expected no error signing URL")
+
+	req, err := http.NewRequest(http.MethodGet, signed, nil)
+	require.NoError(t, err, "expected a valid request")
+	req.Header.Set("Range", "bytes=11-14")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "expected no error requesting signed URL")
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusPartialContent, res.StatusCode, "expected partial content")
+	assert.Equal(t, "docs", string(body), "expected requested range")
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err, "expected a valid URL")
+	res, err = http.Get(server.URL + u.Path)
+	require.NoError(t, err, "expected no error requesting unsigned URL")
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "expected unsigned requests to be rejected")
+}
+
+func TestSQLBlobStorage_PurgeIncompleteUploads(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, SQLBlobStorageConfig{}, "docs/a.txt")
+
+	old := time.Now().Add(-2 * time.Hour).UnixNano()
+	var id int64
+	require.NoError(t, storage.db.QueryRow("INSERT INTO gostorage_files (chunk_size, modified_at) VALUES (4, ?) RETURNING id", old).Scan(&id), "expected no error creating pending upload")
+	_, err := storage.db.Exec("INSERT INTO gostorage_chunks (file_id, seq, data) VALUES (?, 0, ?)", id, []byte("left"))
+	require.NoError(t, err, "expected no error creating pending chunk")
+
+	_, err = storage.db.Exec("INSERT INTO gostorage_files (chunk_size, modified_at) VALUES (4, ?)", time.Now().UnixNano())
+	require.NoError(t, err, "expected no error creating recent pending upload")
+
+	purged, err := storage.PurgeIncompleteUploads(ctx, time.Hour)
+	assert.NoError(t, err, "expected no error purging")
+	assert.Equal(t, 1, purged, "expected only the stale upload to be purged")
+	assert.Equal(t, 2, countRows(t, storage, "gostorage_files"), "expected committed file and recent upload to be kept")
+	assert.Equal(t, 6, countRows(t, storage, "gostorage_chunks"), "expected only the chunks of the committed file to remain")
+}
@@ -0,0 +1,215 @@
+package gostorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces secret values when configurations or DSNs are printed.
+const redacted = "xxxxx"
+
+// URLParser converts a storage DSN such as "s3://key:secret@host/bucket" into a DiskConfig.
+// Drivers register one per URL scheme with RegisterURLScheme.
+type URLParser func(u *url.URL) (DiskConfig, error)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = make(map[string]URLParser)
+)
+
+// RegisterURLScheme makes a DSN scheme (e.g. "s3" or "file") available to OpenURL and ParseDSN.
+// It is meant to be called from a driver package's init function next to RegisterDriver.
+// It panics if parse is nil or the scheme is already registered.
+func RegisterURLScheme(scheme string, parse URLParser) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+
+	if parse == nil {
+		panic("storage: RegisterURLScheme parser is nil")
+	}
+
+	scheme = strings.ToLower(scheme)
+	if _, dup := schemes[scheme]; dup {
+		panic("storage: RegisterURLScheme called twice for scheme " + scheme)
+	}
+
+	schemes[scheme] = parse
+}
+
+// URLSchemes returns all registered DSN schemes, sorted alphabetically.
+func URLSchemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ParseDSN converts a storage DSN into a DiskConfig using the parser registered for its scheme:
+//
+//	s3://key:secret@minio:9000/bucket?region=us-east-1&visibility=private&ssl=false
+//	file:///var/data?base_url=https://cdn.example.com/media
+//
+// Errors never include the DSN itself, so they are safe to log.
+func ParseDSN(dsn string) (DiskConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return DiskConfig{}, &ConfigError{Field: "dsn", Reason: "is not a valid storage URL"}
+	}
+
+	schemesMu.RLock()
+	parse, ok := schemes[strings.ToLower(u.Scheme)]
+	schemesMu.RUnlock()
+
+	if !ok {
+		return DiskConfig{}, &ConfigError{
+			Field:  "dsn",
+			Reason: fmt.Sprintf("has unsupported scheme %q (registered: %s)", u.Scheme, strings.Join(URLSchemes(), ", ")),
+		}
+	}
+
+	return parse(u)
+}
+
+// OpenURL opens a single storage driver from a DSN, dispatching to the driver registered for its scheme.
+// Drivers must be registered first, usually by importing their package:
+//
+//	import _ "github.com/shoraid/go-storage/drivers/s3"
+//
+//	driver, err := gostorage.OpenURL(ctx, os.Getenv("MEDIA_STORAGE_URL"))
+func OpenURL(ctx context.Context, dsn string) (StorageDriver, error) {
+	disk, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	opener, ok := lookupDriver(disk.Driver)
+	if !ok {
+		return nil, &ConfigError{Field: "driver", Reason: fmt.Sprintf("refers to unregistered driver %q", disk.Driver)}
+	}
+
+	return opener(ctx, disk)
+}
+
+// RedactDSN returns dsn with its password and any secret query parameters replaced,
+// so it can be logged safely. Invalid DSNs are fully redacted.
+func RedactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return redacted
+	}
+
+	query := u.Query()
+	for key := range query {
+		if isSecretOption(key) {
+			query.Set(key, redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	return u.String()
+}
+
+// isSecretOption reports whether an option or query parameter holds a credential.
+func isSecretOption(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, marker := range []string{"secret", "password", "passphrase", "token", "account_key", "private_key", "credential", "signature"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+
+	return key == "dsn" // may embed a password
+}
+
+// expand merges the options of the disk's "dsn" option into it.
+// Options set explicitly on the disk take precedence over the ones parsed from the DSN.
+func (d DiskConfig) expand() (DiskConfig, error) {
+	dsn := d.Options["dsn"]
+	if dsn == "" {
+		return d, nil
+	}
+
+	parsed, err := ParseDSN(dsn)
+	if err != nil {
+		return d, err
+	}
+
+	if d.Driver != "" && d.Driver != parsed.Driver {
+		return d, &ConfigError{Field: "driver", Reason: fmt.Sprintf("%q does not match dsn driver %q", d.Driver, parsed.Driver)}
+	}
+
+	merged := DiskConfig{Driver: parsed.Driver, Options: parsed.Options}
+	if merged.Options == nil {
+		merged.Options = make(map[string]string)
+	}
+	for key, value := range d.Options {
+		if key != "dsn" {
+			merged.Options[key] = value
+		}
+	}
+
+	return merged, nil
+}
+
+// Format prints the disk with secret options (keys, passwords, tokens, DSNs) redacted,
+// so a DiskConfig or Config can be logged with fmt without leaking credentials.
+func (d DiskConfig) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "{Driver:%s Options:map[", d.Driver)
+
+	options := d.redactedOptions()
+	for i, key := range sortedKeys(options) {
+		if i > 0 {
+			fmt.Fprint(f, " ")
+		}
+
+		fmt.Fprintf(f, "%s:%s", key, options[key])
+	}
+
+	fmt.Fprint(f, "]}")
+}
+
+// MarshalJSON encodes the disk as the flat object UnmarshalJSON accepts, with secret options redacted
+// like Format does, so a DiskConfig or Config can be logged as JSON without leaking credentials.
+// The output is therefore not meant to be loaded back as a working configuration.
+func (d DiskConfig) MarshalJSON() ([]byte, error) {
+	flat := d.redactedOptions()
+	if d.Driver != "" {
+		flat["driver"] = d.Driver
+	}
+
+	return json.Marshal(flat)
+}
+
+// redactedOptions returns a copy of the disk options with secret values replaced.
+func (d DiskConfig) redactedOptions() map[string]string {
+	options := make(map[string]string, len(d.Options)+1)
+
+	for key, value := range d.Options {
+		switch {
+		case key == "dsn" || key == "connection" || strings.HasSuffix(key, "_dsn"):
+			value = RedactDSN(value)
+		case isSecretOption(key):
+			value = redacted
+		}
+
+		options[key] = value
+	}
+
+	return options
+}
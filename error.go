@@ -7,5 +7,7 @@ var (
 	ErrInvalidConfig         = errors.New("storage: invalid configuration")
 	ErrInvalidDefaultStorage = errors.New("storage: invalid default storage")
 	ErrInvalidKey            = errors.New("storage: invalid key name")
+	ErrInvalidRange          = errors.New("storage: invalid byte range")
 	ErrNotFound              = errors.New("storage: file not found")
+	ErrNotSupported          = errors.New("storage: operation not supported by driver")
 )
@@ -0,0 +1,240 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errFound stops a listing at its first entry.
+var errFound = errors.New("storage: found")
+
+// FS returns a read-only fs.FS over the storage registered under alias, or over the manager's
+// default storage if alias is empty. It also implements fs.StatFS, fs.ReadDirFS and fs.ReadFileFS,
+// so it works with html/template.ParseFS, http.FileServerFS and fs.WalkDir.
+//
+// Directories are emulated over key prefixes: "a/b" is a directory as long as some key starts
+// with "a/b/". The storage must implement RangeReader and Lister. Files opened from the FS
+// support io.Seeker and io.ReaderAt, reading ranges from the storage on demand.
+func FS(manager StorageManager, alias string) fs.FS {
+	if alias != "" {
+		manager = manager.Storage(alias)
+	}
+
+	return &storageFS{manager: manager}
+}
+
+// storageFS implements fs.FS on top of a StorageManager.
+type storageFS struct {
+	manager StorageManager
+}
+
+var (
+	_ fs.StatFS     = (*storageFS)(nil)
+	_ fs.ReadDirFS  = (*storageFS)(nil)
+	_ fs.ReadFileFS = (*storageFS)(nil)
+)
+
+// Open opens a file for reading, or a directory for fs.ReadDirFile.ReadDir.
+func (s *storageFS) Open(name string) (fs.File, error) {
+	info, err := s.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &fsDir{fs: s, name: name, info: info}, nil
+	}
+
+	reader, err := s.manager.OpenReaderAt(context.Background(), name)
+	if err != nil {
+		return nil, fsPathError("open", name, err)
+	}
+
+	return &fsFile{info: info, SectionReader: io.NewSectionReader(reader, 0, reader.Size())}, nil
+}
+
+// Stat describes a file or emulated directory.
+func (s *storageFS) Stat(name string) (fs.FileInfo, error) {
+	return s.stat("stat", name)
+}
+
+// ReadDir lists the files and directories directly below name, sorted by name.
+func (s *storageFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, err := s.stat("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	var entries []fs.DirEntry
+	err = s.manager.List(context.Background(), ListOptions{Prefix: prefix}, func(obj ObjectInfo) error {
+		entries = append(entries, fs.FileInfoToDirEntry(newFSFileInfo(obj)))
+		return nil
+	})
+	if err != nil {
+		return nil, fsPathError("readdir", name, err)
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// ReadFile returns the whole content of a file.
+func (s *storageFS) ReadFile(name string) ([]byte, error) {
+	info, err := s.stat("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("is a directory")}
+	}
+
+	body, err := s.manager.GetRange(context.Background(), name, 0, -1)
+	if err != nil {
+		return nil, fsPathError("readfile", name, err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fsPathError("readfile", name, err)
+	}
+
+	return data, nil
+}
+
+// stat describes name, preferring a file over a directory of the same name.
+func (s *storageFS) stat(op, name string) (*fsFileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &fsFileInfo{name: ".", dir: true}, nil
+	}
+
+	ctx := context.Background()
+
+	obj, err := s.manager.Stat(ctx, name)
+	if err == nil {
+		return newFSFileInfo(obj), nil
+	}
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInvalidKey) {
+		return nil, fsPathError(op, name, err)
+	}
+
+	err = s.manager.List(ctx, ListOptions{Prefix: name + "/"}, func(ObjectInfo) error {
+		return errFound
+	})
+	switch {
+	case errors.Is(err, errFound):
+		return &fsFileInfo{name: path.Base(name), dir: true}, nil
+	case err == nil:
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	default:
+		return nil, fsPathError(op, name, err)
+	}
+}
+
+// fsPathError wraps a storage error, mapping ErrNotFound and ErrInvalidKey to fs.ErrNotExist.
+func fsPathError(op, name string, err error) error {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidKey) {
+		err = fs.ErrNotExist
+	}
+
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// fsFileInfo describes a file or emulated directory of a storageFS.
+type fsFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func newFSFileInfo(obj ObjectInfo) *fsFileInfo {
+	return &fsFileInfo{
+		name:    path.Base(strings.TrimSuffix(obj.Key, "/")),
+		size:    obj.Size,
+		modTime: obj.LastModified,
+		dir:     obj.IsDir,
+	}
+}
+
+func (i *fsFileInfo) Name() string       { return i.name }
+func (i *fsFileInfo) Size() int64        { return i.size }
+func (i *fsFileInfo) ModTime() time.Time { return i.modTime }
+func (i *fsFileInfo) IsDir() bool        { return i.dir }
+func (i *fsFileInfo) Sys() any           { return nil }
+
+func (i *fsFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+// fsFile is a file opened from a storageFS.
+type fsFile struct {
+	*io.SectionReader
+	info *fsFileInfo
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fsFile) Close() error               { return nil }
+
+// fsDir is a directory opened from a storageFS. Its entries are listed on the first ReadDir call.
+type fsDir struct {
+	fs      *storageFS
+	name    string
+	info    *fsFileInfo
+	entries []fs.DirEntry
+	loaded  bool
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+
+		d.entries, d.loaded = entries, true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
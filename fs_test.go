@@ -0,0 +1,196 @@
+package gostorage
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDriver is an in-memory driver with listing and range reads.
+type memoryDriver struct {
+	MockStorageDriver
+	files map[string][]byte
+}
+
+func (d *memoryDriver) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	data, ok := d.files[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	offset = min(offset, int64(len(data)))
+	end := int64(len(data))
+	if length >= 0 {
+		end = min(offset+length, end)
+	}
+
+	return io.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (d *memoryDriver) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	data, ok := d.files[key]
+	if !ok {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	return ObjectInfo{Key: key, Size: int64(len(data)), LastModified: time.Unix(1700000000, 0)}, nil
+}
+
+func (d *memoryDriver) List(ctx context.Context, opts ListOptions, fn func(ObjectInfo) error) error {
+	dir := opts.Prefix[:strings.LastIndex(opts.Prefix, "/")+1]
+
+	seen := map[string]bool{}
+	var entries []ObjectInfo
+	for key, data := range d.files {
+		if !strings.HasPrefix(key, opts.Prefix) {
+			continue
+		}
+
+		entry := ObjectInfo{Key: key, Size: int64(len(data)), LastModified: time.Unix(1700000000, 0)}
+		if rest := strings.TrimPrefix(key, dir); !opts.Recursive && strings.Contains(rest, "/") {
+			entry = ObjectInfo{Key: dir + rest[:strings.Index(rest, "/")+1], IsDir: true}
+		}
+
+		if !seen[entry.Key] {
+			seen[entry.Key] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	for _, entry := range entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newTestFS returns an FS over an in-memory storage registered as "assets".
+func newTestFS(t *testing.T) fs.FS {
+	t.Helper()
+
+	driver := &memoryDriver{files: map[string][]byte{
+		"index.html":                []byte("<h1>home</h1>"),
+		"css/site.css":              []byte("body { margin: 0 }"),
+		"templates/layout.tmpl":     []byte(`{{define "layout"}}<main>{{template "content" .}}</main>{{end}}`),
+		"templates/pages/home.tmpl": []byte(`{{define "content"}}Hello {{.}}{{end}}`),
+		"templates/pages.txt":       []byte("file next to a directory"),
+	}}
+
+	manager, err := NewStorageManager("uploads", map[string]StorageDriver{
+		"uploads": new(MockStorageDriver),
+		"assets":  driver,
+	})
+	require.NoError(t, err, "expected no error creating manager")
+
+	return FS(manager, "assets")
+}
+
+func TestFS_Conformance(t *testing.T) {
+	err := fstest.TestFS(newTestFS(t),
+		"index.html",
+		"css/site.css",
+		"templates/layout.tmpl",
+		"templates/pages/home.tmpl",
+		"templates/pages.txt",
+	)
+
+	assert.NoError(t, err, "expected FS to pass fstest.TestFS")
+}
+
+func TestFS_Stat(t *testing.T) {
+	fsys := newTestFS(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		expectedDir bool
+		expectedErr error
+	}{
+		{name: "should stat root", path: ".", expectedDir: true},
+		{name: "should stat file", path: "css/site.css"},
+		{name: "should stat emulated directory", path: "templates/pages", expectedDir: true},
+		{name: "should not find missing file", path: "css/missing.css", expectedErr: fs.ErrNotExist},
+		{name: "should not find partial directory name", path: "temp", expectedErr: fs.ErrNotExist},
+		{name: "should not find invalid key", path: "my file.txt", expectedErr: fs.ErrNotExist},
+		{name: "should reject invalid path", path: "/index.html", expectedErr: fs.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := fs.Stat(fsys, tt.path)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			require.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expectedDir, info.IsDir(), "expected directory flag to match")
+		})
+	}
+}
+
+func TestFS_ReadDir(t *testing.T) {
+	entries, err := fs.ReadDir(newTestFS(t), "templates")
+	require.NoError(t, err, "expected no error reading directory")
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	assert.Equal(t, []string{"layout.tmpl", "pages", "pages.txt"}, names, "expected entries sorted by name")
+	assert.True(t, entries[1].IsDir(), "expected emulated subdirectory")
+}
+
+func TestFS_Consumers(t *testing.T) {
+	fsys := newTestFS(t)
+
+	t.Run("should parse templates", func(t *testing.T) {
+		tmpl, err := template.ParseFS(fsys, "templates/*.tmpl", "templates/pages/*.tmpl")
+		require.NoError(t, err, "expected no error parsing templates")
+
+		var out strings.Builder
+		require.NoError(t, tmpl.ExecuteTemplate(&out, "layout", "gopher"), "expected no error executing template")
+		assert.Equal(t, "<main>Hello gopher</main>", out.String(), "expected rendered template")
+	})
+
+	t.Run("should serve files over HTTP", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/css/site.css", nil)
+		req.Header.Set("Range", "bytes=0-3")
+
+		http.FileServerFS(fsys).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPartialContent, rec.Code, "expected range response")
+		assert.Equal(t, "body", rec.Body.String(), "expected requested range")
+	})
+
+	t.Run("should walk every file", func(t *testing.T) {
+		var files []string
+		err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, path)
+			}
+			return err
+		})
+
+		require.NoError(t, err, "expected no error walking")
+		assert.Equal(t, []string{"css/site.css", "index.html", "templates/layout.tmpl", "templates/pages/home.tmpl", "templates/pages.txt"}, files, "expected every file")
+	})
+}
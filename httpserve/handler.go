@@ -0,0 +1,139 @@
+// Package httpserve serves files of a StorageManager over HTTP, like a CDN origin.
+//
+//	manager, _ := gostorage.NewFromConfig(cfg)
+//	http.Handle("/media/", http.StripPrefix("/media", httpserve.New(manager,
+//		httpserve.WithAlias("media"),
+//		httpserve.WithSigner(httpserve.NewSigner(secret)),
+//	)))
+//
+// Any driver implementing gostorage.RangeReader can be served, including the local disk.
+// Range requests, conditional requests (ETag and Last-Modified) and HEAD are handled by
+// http.ServeContent on top of gostorage.ObjectReaderAt.
+//
+// UploadHandler is the counterpart for writes: it streams validated multipart uploads into a storage.
+package httpserve
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// DownloadParam is the query parameter that makes Handler send a file as an attachment.
+const DownloadParam = "download"
+
+// Handler is an http.Handler mapping request paths to keys of a StorageManager.
+// The path without its leading "/" is the key; use http.StripPrefix to mount it below a path.
+type Handler struct {
+	manager      gostorage.StorageManager
+	signer       *Signer
+	cacheControl string
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithAlias serves the storage registered under alias instead of the manager's default storage.
+func WithAlias(alias string) Option {
+	return func(h *Handler) {
+		h.manager = h.manager.Storage(alias)
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of successful responses,
+// e.g. "public, max-age=31536000, immutable" for content-addressed keys.
+func WithCacheControl(value string) Option {
+	return func(h *Handler) {
+		h.cacheControl = value
+	}
+}
+
+// WithSigner only serves requests carrying a valid signature created by signer.
+// Requests with a missing, forged or expired signature get 403 Forbidden.
+func WithSigner(signer *Signer) Option {
+	return func(h *Handler) {
+		h.signer = signer
+	}
+}
+
+// New returns a Handler serving files of manager's default storage.
+func New(manager gostorage.StorageManager, opts ...Option) *Handler {
+	h := &Handler{manager: manager}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ServeHTTP serves the file named by the request path.
+// Content-Type is derived from the key's extension, falling back to content sniffing.
+// Files are sent inline unless the request has a "download" query parameter.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if gostorage.ValidateKey(key) != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.signer != nil {
+		if err := h.signer.Verify(key, r.URL.Query()); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+	}
+
+	reader, err := h.manager.OpenReaderAt(r.Context(), key)
+	if err != nil {
+		h.serveError(w, r, key, err)
+		return
+	}
+
+	info := reader.Info()
+	header := w.Header()
+
+	if info.ETag != "" {
+		header.Set("ETag", info.ETag)
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	disposition := "inline"
+	if r.URL.Query().Has(DownloadParam) {
+		disposition = "attachment"
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(key)}))
+
+	if h.cacheControl != "" {
+		header.Set("Cache-Control", h.cacheControl)
+	}
+
+	http.ServeContent(w, r, "", info.LastModified, io.NewSectionReader(reader, 0, reader.Size()))
+}
+
+// serveError maps storage errors to HTTP status codes.
+func (h *Handler) serveError(w http.ResponseWriter, r *http.Request, key string, err error) {
+	switch {
+	case errors.Is(err, gostorage.ErrNotFound), errors.Is(err, gostorage.ErrInvalidKey):
+		http.NotFound(w, r)
+	case errors.Is(err, gostorage.ErrNotSupported):
+		http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+	default:
+		log.Error().Err(err).Str("key", key).Msg("failed to serve file")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,210 @@
+package httpserve_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/shoraid/go-storage/httpserve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const content = "0123456789abcdefghij"
+
+// newTestManager returns a manager over a local disk seeded with docs/report.pdf.
+func newTestManager(t *testing.T, cfg localdriver.DiskStorageConfig) gostorage.StorageManager {
+	t.Helper()
+
+	cfg.Root = t.TempDir()
+	driver, err := localdriver.NewDiskStorage(cfg)
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	manager, err := gostorage.NewStorageManager("local", map[string]gostorage.StorageDriver{
+		"local": driver,
+		"other": new(gostorage.MockStorageDriver),
+	})
+	require.NoError(t, err, "expected no error creating manager")
+
+	_, err = manager.Put(context.Background(), "docs/report.pdf", strings.NewReader(content))
+	require.NoError(t, err, "expected no error seeding file")
+
+	return manager
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	manager := newTestManager(t, localdriver.DiskStorageConfig{})
+	info, err := manager.Stat(context.Background(), "docs/report.pdf")
+	require.NoError(t, err, "expected no error stating seeded file")
+
+	handler := httpserve.New(manager, httpserve.WithCacheControl("private, max-age=60"))
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		header          map[string]string
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "should serve whole file with metadata headers",
+			method:         http.MethodGet,
+			target:         "/docs/report.pdf",
+			expectedStatus: http.StatusOK,
+			expectedBody:   content,
+			expectedHeaders: map[string]string{
+				"Content-Type":        "application/pdf",
+				"Content-Disposition": `inline; filename=report.pdf`,
+				"Content-Length":      "20",
+				"Accept-Ranges":       "bytes",
+				"ETag":                info.ETag,
+				"Last-Modified":       info.LastModified.UTC().Format(http.TimeFormat),
+				"Cache-Control":       "private, max-age=60",
+			},
+		},
+		{
+			name:           "should serve byte range with 206",
+			method:         http.MethodGet,
+			target:         "/docs/report.pdf",
+			header:         map[string]string{"Range": "bytes=10-14"},
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "abcde",
+			expectedHeaders: map[string]string{
+				"Content-Range": "bytes 10-14/20",
+			},
+		},
+		{
+			name:           "should return 416 for unsatisfiable range",
+			method:         http.MethodGet,
+			target:         "/docs/report.pdf",
+			header:         map[string]string{"Range": "bytes=100-"},
+			expectedStatus: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			name:           "should return 304 when ETag matches",
+			method:         http.MethodGet,
+			target:         "/docs/report.pdf",
+			header:         map[string]string{"If-None-Match": info.ETag},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "should return 304 when not modified since",
+			method:         http.MethodGet,
+			target:         "/docs/report.pdf",
+			header:         map[string]string{"If-Modified-Since": info.LastModified.Add(time.Hour).UTC().Format(http.TimeFormat)},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "should send headers without body for HEAD",
+			method:         http.MethodHead,
+			target:         "/docs/report.pdf",
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Length": "20",
+			},
+		},
+		{
+			name:           "should send attachment when download is requested",
+			method:         http.MethodGet,
+			target:         "/docs/report.pdf?download",
+			expectedStatus: http.StatusOK,
+			expectedBody:   content,
+			expectedHeaders: map[string]string{
+				"Content-Disposition": `attachment; filename=report.pdf`,
+			},
+		},
+		{
+			name:           "should return 404 for missing file",
+			method:         http.MethodGet,
+			target:         "/docs/missing.pdf",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "should return 404 for directory",
+			method:         http.MethodGet,
+			target:         "/docs",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "should return 404 for path traversal",
+			method:         http.MethodGet,
+			target:         "/docs/../../etc/passwd",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "should return 405 for other methods",
+			method:         http.MethodPost,
+			target:         "/docs/report.pdf",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedHeaders: map[string]string{
+				"Allow": "GET, HEAD",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, "expected status code to match")
+			if tt.expectedBody != "" || tt.method == http.MethodHead {
+				assert.Equal(t, tt.expectedBody, rec.Body.String(), "expected body to match")
+			}
+			for key, value := range tt.expectedHeaders {
+				assert.Equal(t, value, rec.Header().Get(key), "expected %s header to match", key)
+			}
+		})
+	}
+}
+
+func TestHandler_WithAlias(t *testing.T) {
+	manager := newTestManager(t, localdriver.DiskStorageConfig{})
+
+	rec := httptest.NewRecorder()
+	httpserve.New(manager, httpserve.WithAlias("other")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/report.pdf", nil))
+
+	assert.Equal(t, http.StatusNotImplemented, rec.Code, "expected 501 for a storage without range reads")
+}
+
+func TestHandler_WithSigner(t *testing.T) {
+	manager := newTestManager(t, localdriver.DiskStorageConfig{
+		BaseURL:       "https://api.example.com/files",
+		SigningSecret: "secret",
+	})
+	handler := http.StripPrefix("/files", httpserve.New(manager, httpserve.WithSigner(httpserve.NewSigner([]byte("secret")))))
+
+	signed, err := manager.GetSignedURL(context.Background(), "docs/report.pdf", time.Minute)
+	require.NoError(t, err, "expected local disk to sign URLs")
+
+	tests := []struct {
+		name           string
+		target         string
+		expectedStatus int
+	}{
+		{name: "should serve signed URL", target: signed, expectedStatus: http.StatusOK},
+		{name: "should reject unsigned URL", target: "/files/docs/report.pdf", expectedStatus: http.StatusForbidden},
+		{name: "should reject tampered URL", target: strings.Replace(signed, "expires=", "expires=1", 1), expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, "expected status code to match")
+		})
+	}
+}
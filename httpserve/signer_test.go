@@ -0,0 +1,84 @@
+package httpserve
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewSigner([]byte("secret"))
+	signer.now = func() time.Time { return now }
+
+	valid := signer.Sign("docs/a.pdf", now.Add(time.Minute))
+
+	tests := []struct {
+		name        string
+		key         string
+		query       url.Values
+		expectedErr error
+	}{
+		{
+			name:  "should accept valid signature",
+			key:   "docs/a.pdf",
+			query: valid,
+		},
+		{
+			name:        "should reject signature for another key",
+			key:         "docs/b.pdf",
+			query:       valid,
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "should reject extended expiry",
+			key:         "docs/a.pdf",
+			query:       url.Values{ExpiresParam: {"9999999999"}, SignatureParam: valid[SignatureParam]},
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "should reject signature from another secret",
+			key:         "docs/a.pdf",
+			query:       NewSigner([]byte("other")).Sign("docs/a.pdf", now.Add(time.Minute)),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "should reject missing parameters",
+			key:         "docs/a.pdf",
+			query:       url.Values{},
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "should reject expired signature",
+			key:         "docs/a.pdf",
+			query:       signer.Sign("docs/a.pdf", now.Add(-time.Second)),
+			expectedErr: ErrSignatureExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signer.Verify(tt.key, tt.query)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected signature to verify")
+			}
+		})
+	}
+}
+
+func TestSigner_SignURL(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+
+	signed := signer.SignURL("https://cdn.example.com/media/", "docs/a.pdf", time.Minute)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err, "expected a valid URL")
+	assert.Equal(t, "/media/docs/a.pdf", u.Path, "expected key below base URL without double slash")
+	assert.NoError(t, signer.Verify("docs/a.pdf", u.Query()), "expected signed URL to verify")
+}
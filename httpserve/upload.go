@@ -0,0 +1,389 @@
+package httpserve
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+const (
+	// DefaultMaxUploadSize is the largest file UploadHandler accepts when no limit is configured.
+	DefaultMaxUploadSize = 32 << 20
+
+	// DefaultMaxUploadFiles is the largest number of files UploadHandler accepts per request.
+	DefaultMaxUploadFiles = 10
+
+	// sniffLen is the number of bytes http.DetectContentType looks at.
+	sniffLen = 512
+)
+
+// extensionPattern matches extensions that can be kept in generated keys.
+var extensionPattern = regexp.MustCompile(`^\.[a-z0-9]+$`)
+
+// UploadedFile describes a file part before it is stored, for KeyFunc.
+type UploadedFile struct {
+	Field       string // form field name
+	Filename    string // file name sent by the client, without directories
+	ContentType string // type sniffed from the content, never the client's claim
+}
+
+// UploadResult is the JSON reported for every stored file.
+type UploadResult struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	Key         string `json:"key"`
+	URL         string `json:"url,omitempty"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// KeyFunc chooses the storage key of an uploaded file.
+// The returned key must pass gostorage.ValidateKey.
+type KeyFunc func(r *http.Request, file UploadedFile) (string, error)
+
+// RandomKey generates keys of 32 random hex characters below prefix,
+// keeping the file's extension if it is plain alphanumeric, e.g. "avatars/3f2a...9c.png".
+func RandomKey(prefix string) KeyFunc {
+	return func(r *http.Request, file UploadedFile) (string, error) {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+
+		key := hex.EncodeToString(b[:])
+		if ext := strings.ToLower(path.Ext(file.Filename)); extensionPattern.MatchString(ext) {
+			key += ext
+		}
+
+		return prefix + key, nil
+	}
+}
+
+// UploadHandler is an http.Handler accepting multipart/form-data uploads.
+// Each file part is validated and streamed straight into the storage without buffering
+// the whole file in memory or on disk. It responds with {"files": [UploadResult...]}.
+//
+// If any file is rejected, files already stored by the same request are deleted again
+// and the request fails with a JSON {"error": "..."} body.
+type UploadHandler struct {
+	manager      gostorage.StorageManager
+	maxSize      int64
+	maxFiles     int
+	allowedTypes []string
+	allowedExts  []string
+	field        string
+	keyFunc      KeyFunc
+}
+
+// UploadOption configures an UploadHandler.
+type UploadOption func(*UploadHandler)
+
+// WithUploadAlias stores files in the storage registered under alias instead of the default storage.
+func WithUploadAlias(alias string) UploadOption {
+	return func(h *UploadHandler) {
+		h.manager = h.manager.Storage(alias)
+	}
+}
+
+// WithMaxFileSize rejects files larger than size bytes with 413 Request Entity Too Large.
+func WithMaxFileSize(size int64) UploadOption {
+	return func(h *UploadHandler) {
+		h.maxSize = size
+	}
+}
+
+// WithMaxFiles rejects requests with more than n files.
+func WithMaxFiles(n int) UploadOption {
+	return func(h *UploadHandler) {
+		h.maxFiles = n
+	}
+}
+
+// WithAllowedTypes only accepts files whose sniffed MIME type matches one of types.
+// Types may use a wildcard subtype, e.g. "image/*". Other files get 415 Unsupported Media Type.
+func WithAllowedTypes(types ...string) UploadOption {
+	return func(h *UploadHandler) {
+		h.allowedTypes = types
+	}
+}
+
+// WithAllowedExtensions only accepts files whose name ends in one of exts, e.g. ".jpg", ".png".
+// Matching is case-insensitive. Other files get 415 Unsupported Media Type.
+func WithAllowedExtensions(exts ...string) UploadOption {
+	return func(h *UploadHandler) {
+		h.allowedExts = make([]string, len(exts))
+		for i, ext := range exts {
+			h.allowedExts[i] = strings.ToLower(ext)
+		}
+	}
+}
+
+// WithFormField only accepts files sent in the named form field; other file parts are rejected.
+func WithFormField(name string) UploadOption {
+	return func(h *UploadHandler) {
+		h.field = name
+	}
+}
+
+// WithKeyFunc replaces the default RandomKey("") key strategy.
+func WithKeyFunc(fn KeyFunc) UploadOption {
+	return func(h *UploadHandler) {
+		h.keyFunc = fn
+	}
+}
+
+// NewUploadHandler returns an UploadHandler storing files in manager's default storage.
+func NewUploadHandler(manager gostorage.StorageManager, opts ...UploadOption) *UploadHandler {
+	h := &UploadHandler{
+		manager:  manager,
+		maxSize:  DefaultMaxUploadSize,
+		maxFiles: DefaultMaxUploadFiles,
+		keyFunc:  RandomKey(""),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// uploadError is a rejected upload with the HTTP status to report.
+type uploadError struct {
+	status  int
+	message string
+}
+
+func (e *uploadError) Error() string {
+	return e.message
+}
+
+// ServeHTTP stores the uploaded files and responds with their keys and URLs.
+func (h *UploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	results, err := h.Upload(r)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string][]UploadResult{"files": results})
+}
+
+// Middleware stores the uploaded files and calls next with the results available
+// through UploadedFiles(r.Context()), e.g. to save the keys alongside a database record.
+// Rejected uploads are answered directly and never reach next.
+func (h *UploadHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results, err := h.Upload(r)
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), uploadResultsKey{}, results)))
+	})
+}
+
+// uploadResultsKey is the context key of the results stored by UploadHandler.Middleware.
+type uploadResultsKey struct{}
+
+// UploadedFiles returns the files stored by UploadHandler.Middleware for the current request.
+func UploadedFiles(ctx context.Context) []UploadResult {
+	results, _ := ctx.Value(uploadResultsKey{}).([]UploadResult)
+	return results
+}
+
+// Upload reads the multipart request and stores every file part.
+// Non-file form fields are ignored. On error, files already stored are deleted again.
+func (h *UploadHandler) Upload(r *http.Request) ([]UploadResult, error) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		return nil, &uploadError{status: http.StatusMethodNotAllowed, message: "method not allowed"}
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, &uploadError{status: http.StatusBadRequest, message: "expected multipart/form-data request"}
+	}
+
+	var results []UploadResult
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.cleanup(r.Context(), results)
+			return nil, &uploadError{status: http.StatusBadRequest, message: "malformed multipart body"}
+		}
+
+		if part.FileName() == "" {
+			continue
+		}
+
+		if len(results) == h.maxFiles {
+			h.cleanup(r.Context(), results)
+			return nil, &uploadError{status: http.StatusBadRequest, message: fmt.Sprintf("at most %d files may be uploaded at once", h.maxFiles)}
+		}
+
+		result, err := h.store(r, part.FormName(), part.FileName(), part)
+		if err != nil {
+			h.cleanup(r.Context(), results)
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, &uploadError{status: http.StatusBadRequest, message: "no file uploaded"}
+	}
+
+	return results, nil
+}
+
+// store validates a single file part and streams it into the storage.
+func (h *UploadHandler) store(r *http.Request, field, filename string, body io.Reader) (UploadResult, error) {
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+
+	if h.field != "" && field != h.field {
+		return UploadResult{}, &uploadError{status: http.StatusBadRequest, message: fmt.Sprintf("unexpected file field %q", field)}
+	}
+
+	if len(h.allowedExts) > 0 && !slices.Contains(h.allowedExts, strings.ToLower(path.Ext(filename))) {
+		return UploadResult{}, &uploadError{status: http.StatusUnsupportedMediaType, message: fmt.Sprintf("file extension of %q is not allowed", filename)}
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return UploadResult{}, &uploadError{status: http.StatusBadRequest, message: "failed to read upload"}
+	}
+	head = head[:n]
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !h.typeAllowed(contentType) {
+		return UploadResult{}, &uploadError{status: http.StatusUnsupportedMediaType, message: fmt.Sprintf("file type %s of %q is not allowed", contentType, filename)}
+	}
+
+	file := UploadedFile{Field: field, Filename: filename, ContentType: contentType}
+	key, err := h.keyFunc(r, file)
+	if err != nil {
+		return UploadResult{}, err
+	}
+
+	limited := &sizeLimitReader{r: io.MultiReader(bytes.NewReader(head), body), remaining: h.maxSize}
+	url, err := h.manager.Put(r.Context(), key, limited)
+	if limited.exceeded {
+		if err == nil {
+			_ = h.manager.Delete(context.WithoutCancel(r.Context()), key)
+		}
+		return UploadResult{}, &uploadError{status: http.StatusRequestEntityTooLarge, message: fmt.Sprintf("file %q exceeds %d bytes", filename, h.maxSize)}
+	}
+	if err != nil {
+		return UploadResult{}, err
+	}
+
+	return UploadResult{
+		Field:       field,
+		Filename:    filename,
+		Key:         key,
+		URL:         url,
+		Size:        limited.read,
+		ContentType: contentType,
+	}, nil
+}
+
+// typeAllowed reports whether contentType matches the allowed types, if any are configured.
+func (h *UploadHandler) typeAllowed(contentType string) bool {
+	if len(h.allowedTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range h.allowedTypes {
+		if allowed == contentType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cleanup deletes files stored earlier in a request that failed. It runs even if the request was
+// cancelled, e.g. because the client disconnected mid-upload, which is a common cause of the failure.
+func (h *UploadHandler) cleanup(ctx context.Context, results []UploadResult) {
+	ctx = context.WithoutCancel(ctx)
+	for _, result := range results {
+		if err := h.manager.Delete(ctx, result.Key); err != nil {
+			log.Error().Err(err).Str("key", result.Key).Msg("failed to delete file of rejected upload")
+		}
+	}
+}
+
+// errFileTooLarge is returned by sizeLimitReader once more than the limit was read.
+var errFileTooLarge = errors.New("httpserve: file too large")
+
+// sizeLimitReader fails the upload as soon as it exceeds the size limit,
+// so oversized files are never fully transferred to the storage.
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+	exceeded  bool
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, errFileTooLarge
+	}
+
+	// read one byte past the limit to tell "exactly the limit" from "too large"
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, errFileTooLarge
+	}
+
+	return n, err
+}
+
+// writeUploadError responds with the status of an uploadError, or 500 for storage errors.
+func writeUploadError(w http.ResponseWriter, err error) {
+	var uerr *uploadError
+	if !errors.As(err, &uerr) {
+		log.Error().Err(err).Msg("failed to store upload")
+		uerr = &uploadError{status: http.StatusInternalServerError, message: "failed to store upload"}
+	}
+
+	writeJSON(w, uerr.status, map[string]string{"error": uerr.message})
+}
+
+// writeJSON responds with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,269 @@
+package httpserve_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/shoraid/go-storage/httpserve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngData starts with the PNG signature so it is sniffed as image/png.
+var pngData = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+
+type formFile struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+// newUploadRequest builds a multipart POST request with the given files and a plain "title" field.
+func newUploadRequest(t *testing.T, files ...formFile) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("title", "holiday"), "expected no error writing field")
+	for _, f := range files {
+		w, err := mw.CreateFormFile(f.field, f.filename)
+		require.NoError(t, err, "expected no error creating form file")
+		_, err = w.Write(f.content)
+		require.NoError(t, err, "expected no error writing form file")
+	}
+	require.NoError(t, mw.Close(), "expected no error closing multipart writer")
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// newUploadManager returns a manager over an empty local disk served at https://cdn.example.com.
+func newUploadManager(t *testing.T) gostorage.StorageManager {
+	t.Helper()
+
+	driver, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir(), BaseURL: "https://cdn.example.com"})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	manager, err := gostorage.NewStorageManager("local", map[string]gostorage.StorageDriver{"local": driver})
+	require.NoError(t, err, "expected no error creating manager")
+
+	return manager
+}
+
+// storedKeys lists all keys in the manager's default storage.
+func storedKeys(t *testing.T, manager gostorage.StorageManager) []string {
+	t.Helper()
+
+	var keys []string
+	err := manager.List(context.Background(), gostorage.ListOptions{Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	require.NoError(t, err, "expected no error listing storage")
+
+	return keys
+}
+
+func TestUploadHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []httpserve.UploadOption
+		files          []formFile
+		expectedStatus int
+		expectedError  string
+		expectedFiles  int
+	}{
+		{
+			name:           "should store allowed image",
+			opts:           []httpserve.UploadOption{httpserve.WithAllowedTypes("image/*"), httpserve.WithAllowedExtensions(".PNG", ".jpg")},
+			files:          []formFile{{field: "file", filename: "Cat.png", content: pngData}},
+			expectedStatus: http.StatusCreated,
+			expectedFiles:  1,
+		},
+		{
+			name:           "should store several files",
+			files:          []formFile{{field: "a", filename: "a.png", content: pngData}, {field: "b", filename: "b.txt", content: []byte("hello")}},
+			expectedStatus: http.StatusCreated,
+			expectedFiles:  2,
+		},
+		{
+			name:           "should reject type claimed by extension but not matching content",
+			opts:           []httpserve.UploadOption{httpserve.WithAllowedTypes("image/png")},
+			files:          []formFile{{field: "file", filename: "evil.png", content: []byte("<html><script>alert(1)</script>")}},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  `file type text/html of "evil.png" is not allowed`,
+		},
+		{
+			name:           "should reject disallowed extension",
+			opts:           []httpserve.UploadOption{httpserve.WithAllowedExtensions(".jpg")},
+			files:          []formFile{{field: "file", filename: "cat.png", content: pngData}},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  `file extension of "cat.png" is not allowed`,
+		},
+		{
+			name:           "should reject file larger than max size",
+			opts:           []httpserve.UploadOption{httpserve.WithMaxFileSize(50)},
+			files:          []formFile{{field: "file", filename: "cat.png", content: pngData}},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  `file "cat.png" exceeds 50 bytes`,
+		},
+		{
+			name:           "should accept file of exactly max size",
+			opts:           []httpserve.UploadOption{httpserve.WithMaxFileSize(int64(len(pngData)))},
+			files:          []formFile{{field: "file", filename: "cat.png", content: pngData}},
+			expectedStatus: http.StatusCreated,
+			expectedFiles:  1,
+		},
+		{
+			name:           "should delete earlier files when a later one is rejected",
+			opts:           []httpserve.UploadOption{httpserve.WithAllowedTypes("image/png")},
+			files:          []formFile{{field: "a", filename: "a.png", content: pngData}, {field: "b", filename: "b.txt", content: []byte("hello")}},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  `file type text/plain of "b.txt" is not allowed`,
+		},
+		{
+			name:           "should reject too many files",
+			opts:           []httpserve.UploadOption{httpserve.WithMaxFiles(1)},
+			files:          []formFile{{field: "a", filename: "a.png", content: pngData}, {field: "b", filename: "b.png", content: pngData}},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "at most 1 files may be uploaded at once",
+		},
+		{
+			name:           "should reject unexpected field",
+			opts:           []httpserve.UploadOption{httpserve.WithFormField("avatar")},
+			files:          []formFile{{field: "file", filename: "a.png", content: pngData}},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `unexpected file field "file"`,
+		},
+		{
+			name:           "should reject request without files",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "no file uploaded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newUploadManager(t)
+			rec := httptest.NewRecorder()
+
+			httpserve.NewUploadHandler(manager, tt.opts...).ServeHTTP(rec, newUploadRequest(t, tt.files...))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, "expected status code to match")
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "expected JSON response")
+
+			var resp struct {
+				Files []httpserve.UploadResult `json:"files"`
+				Error string                   `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), "expected valid JSON")
+
+			assert.Equal(t, tt.expectedError, resp.Error, "expected error message to match")
+			assert.Len(t, resp.Files, tt.expectedFiles, "expected number of stored files to match")
+			assert.Len(t, storedKeys(t, manager), tt.expectedFiles, "expected only accepted files to remain in storage")
+
+			for _, f := range resp.Files {
+				assert.Equal(t, "https://cdn.example.com/"+f.Key, f.URL, "expected URL of stored file")
+				assert.NoError(t, gostorage.ValidateKey(f.Key), "expected generated key to be valid")
+			}
+		})
+	}
+}
+
+func TestUploadHandler_Result(t *testing.T) {
+	manager := newUploadManager(t)
+	rec := httptest.NewRecorder()
+
+	handler := httpserve.NewUploadHandler(manager, httpserve.WithKeyFunc(httpserve.RandomKey("avatars/")))
+	handler.ServeHTTP(rec, newUploadRequest(t, formFile{field: "avatar", filename: `C:\Users\me\Cat.PNG`, content: pngData}))
+
+	require.Equal(t, http.StatusCreated, rec.Code, "expected upload to succeed")
+
+	var resp struct {
+		Files []httpserve.UploadResult `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), "expected valid JSON")
+	require.Len(t, resp.Files, 1, "expected one stored file")
+
+	f := resp.Files[0]
+	assert.Equal(t, "avatar", f.Field, "expected form field")
+	assert.Equal(t, "Cat.PNG", f.Filename, "expected file name without directories")
+	assert.Equal(t, "image/png", f.ContentType, "expected sniffed content type")
+	assert.Equal(t, int64(len(pngData)), f.Size, "expected size of stored file")
+	assert.Regexp(t, `^avatars/[0-9a-f]{32}\.png$`, f.Key, "expected random key with prefix and lowercase extension")
+
+	info, err := manager.Stat(context.Background(), f.Key)
+	require.NoError(t, err, "expected stored file to exist")
+	assert.Equal(t, f.Size, info.Size, "expected stored content to be complete")
+}
+
+func TestUploadHandler_Middleware(t *testing.T) {
+	manager := newUploadManager(t)
+
+	var seen []httpserve.UploadResult
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = httpserve.UploadedFiles(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	httpserve.NewUploadHandler(manager).Middleware(next).ServeHTTP(rec, newUploadRequest(t, formFile{field: "file", filename: "a.txt", content: []byte("hello")}))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected next handler to respond")
+	require.Len(t, seen, 1, "expected uploaded files in request context")
+	assert.Equal(t, "text/plain", seen[0].ContentType, "expected sniffed content type without parameters")
+}
+
+func TestUploadHandler_NotMultipart(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{"file":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	httpserve.NewUploadHandler(newUploadManager(t)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "expected 400 for non-multipart request")
+	assert.JSONEq(t, `{"error":"expected multipart/form-data request"}`, rec.Body.String(), "expected error body")
+}
+
+// ctxDisk is a local disk whose deletes fail once the context is done, like network storages.
+type ctxDisk struct {
+	*localdriver.DiskStorage
+}
+
+func (d ctxDisk) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.DiskStorage.Delete(ctx, key)
+}
+
+func TestUploadHandler_CleanupAfterCancel(t *testing.T) {
+	driver, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir(), BaseURL: "https://cdn.example.com"})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	manager, err := gostorage.NewStorageManager("local", map[string]gostorage.StorageDriver{"local": ctxDisk{driver.(*localdriver.DiskStorage)}})
+	require.NoError(t, err, "expected no error creating manager")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := newUploadRequest(t,
+		formFile{field: "file", filename: "a.txt", content: []byte("hello")},
+		formFile{field: "file", filename: "b.txt", content: []byte("world")},
+	).WithContext(ctx)
+
+	rec := httptest.NewRecorder()
+	httpserve.NewUploadHandler(manager, httpserve.WithMaxFiles(1)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "expected 400 for too many files")
+	assert.Empty(t, storedKeys(t, manager), "expected files of the rejected upload to be deleted despite the cancelled request")
+}
@@ -34,3 +34,89 @@ type StorageDriver interface {
 	// Usage: Call this to save a new file or overwrite an existing one.
 	Put(ctx context.Context, key string, file io.Reader) (url string, err error)
 }
+
+// BatchDeleter is an optional interface for drivers that can remove many files in a single request.
+// StorageManager.DeleteMany and StorageManager.BatchDelete use it automatically when a driver implements it.
+type BatchDeleter interface {
+	// DeleteBatch removes all given keys and reports the outcome of every key, in input order.
+	// Usage: Prefer this over calling Delete in a loop when removing large numbers of files.
+	DeleteBatch(ctx context.Context, keys []string) BatchResults
+}
+
+// Lister is an optional interface for drivers that can enumerate the files they store.
+type Lister interface {
+	// List calls fn for every file matching opts, in lexical key order.
+	// If fn returns an error, listing stops and that error is returned.
+	// Usage: Walk a "directory" of files or collect keys for bulk operations.
+	List(ctx context.Context, opts ListOptions, fn func(ObjectInfo) error) error
+}
+
+// CapabilityReporter is an optional interface for drivers that describe the features they support.
+// Drivers should implement it so callers (and StorageManager) can tell an unsupported operation
+// from a failed one, e.g. signed URLs on a public bucket.
+type CapabilityReporter interface {
+	// Capabilities returns the features supported by this driver instance.
+	// Usage: Check before calling an operation that not every backend supports.
+	Capabilities() Capabilities
+}
+
+// VisibilityManager is an optional interface for drivers that store visibility per file,
+// e.g. through object ACLs. StorageManager.URL uses it to pick a public or signed URL per file.
+type VisibilityManager interface {
+	// Visibility returns the visibility of a single file.
+	// It may return an empty Visibility if the file has none of its own.
+	Visibility(ctx context.Context, key string) (Visibility, error)
+
+	// SetVisibility makes a single file public or private.
+	// Usage: Publish a file from a private storage, or hide a file in a public one.
+	SetVisibility(ctx context.Context, key string, visibility Visibility) error
+}
+
+// DefaultsReporter is an optional interface for drivers with storage-wide URL defaults,
+// such as the configured visibility and signed URL expiry of an S3 bucket.
+type DefaultsReporter interface {
+	// DefaultVisibility returns the visibility of files without one of their own.
+	DefaultVisibility() Visibility
+
+	// DefaultExpiry returns the lifetime of signed URLs when the caller does not choose one.
+	DefaultExpiry() time.Duration
+}
+
+// RangeReader is an optional interface for drivers that can read part of a file without downloading all of it.
+// It backs StorageManager.GetRange, Stat and OpenReaderAt, e.g. for video seeking or opening ZIP archives.
+type RangeReader interface {
+	// GetRange returns up to length bytes of the file starting at offset, or everything from offset
+	// if length is negative. An offset at or beyond the end of the file yields an empty body.
+	// Returns ErrNotFound if the file does not exist.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+
+	// Stat returns the size, modification time and ETag of a single file.
+	// Returns ErrNotFound if the file does not exist.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+}
+
+// MultipartUploader is an optional interface for drivers that assemble a file from separately
+// uploaded parts, like S3 multipart uploads. Nothing is visible under key until the upload is completed.
+// Storages may require a minimum size for every part but the last (5 MiB on S3).
+type MultipartUploader interface {
+	// CreateMultipartUpload starts an upload to key and returns its upload ID.
+	CreateMultipartUpload(ctx context.Context, key string) (string, error)
+
+	// UploadPart uploads size bytes of body as part number (starting at 1).
+	// Uploading the same number again replaces the part.
+	UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error)
+
+	// CompleteMultipartUpload assembles the parts, in order, into the file at key.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error
+
+	// AbortMultipartUpload discards the upload and all its parts.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
+
+// Pinger is an optional interface for drivers that can check the connection to their backend
+// cheaply, e.g. with a HEAD request on the bucket. Health checks prefer it over probing a file.
+type Pinger interface {
+	// Ping returns an error if the backend cannot be reached or rejects the credentials.
+	// Usage: Check backend health for readiness probes or failover decisions.
+	Ping(ctx context.Context) error
+}
@@ -37,3 +37,141 @@ func (m *MockStorageDriver) Put(ctx context.Context, key string, file io.Reader)
 	args := m.Called(ctx, key, file)
 	return args.String(0), args.Error(1)
 }
+
+// MockBatchDeleter is a testify.Mock implementation of StorageDriver that also implements BatchDeleter.
+type MockBatchDeleter struct {
+	MockStorageDriver
+}
+
+func (m *MockBatchDeleter) DeleteBatch(ctx context.Context, keys []string) BatchResults {
+	args := m.Called(ctx, keys)
+	if results, ok := args.Get(0).(BatchResults); ok {
+		return results
+	}
+	return nil
+}
+
+// MockLister is a testify.Mock implementation of StorageDriver that also implements Lister and BatchDeleter.
+// The objects returned by the mocked List call are passed to fn one by one.
+type MockLister struct {
+	MockBatchDeleter
+}
+
+func (m *MockLister) List(ctx context.Context, opts ListOptions, fn func(ObjectInfo) error) error {
+	args := m.Called(ctx, opts)
+	if objects, ok := args.Get(0).([]ObjectInfo); ok {
+		for _, obj := range objects {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// MockCapabilityReporter is a testify.Mock implementation of StorageDriver that also implements CapabilityReporter.
+type MockCapabilityReporter struct {
+	MockStorageDriver
+}
+
+func (m *MockCapabilityReporter) Capabilities() Capabilities {
+	args := m.Called()
+	if caps, ok := args.Get(0).(Capabilities); ok {
+		return caps
+	}
+	return Capabilities{}
+}
+
+// MockVisibilityManager is a testify.Mock implementation of StorageDriver that also implements
+// VisibilityManager and DefaultsReporter.
+type MockVisibilityManager struct {
+	MockStorageDriver
+}
+
+func (m *MockVisibilityManager) DefaultExpiry() time.Duration {
+	args := m.Called()
+	if expiry, ok := args.Get(0).(time.Duration); ok {
+		return expiry
+	}
+	return 0
+}
+
+func (m *MockVisibilityManager) DefaultVisibility() Visibility {
+	args := m.Called()
+	if visibility, ok := args.Get(0).(Visibility); ok {
+		return visibility
+	}
+	return ""
+}
+
+func (m *MockVisibilityManager) SetVisibility(ctx context.Context, key string, visibility Visibility) error {
+	args := m.Called(ctx, key, visibility)
+	return args.Error(0)
+}
+
+func (m *MockVisibilityManager) Visibility(ctx context.Context, key string) (Visibility, error) {
+	args := m.Called(ctx, key)
+	if visibility, ok := args.Get(0).(Visibility); ok {
+		return visibility, args.Error(1)
+	}
+	return "", args.Error(1)
+}
+
+// MockRangeReader is a testify.Mock implementation of StorageDriver that also implements RangeReader.
+type MockRangeReader struct {
+	MockStorageDriver
+}
+
+func (m *MockRangeReader) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	args := m.Called(ctx, key, offset, length)
+	if body, ok := args.Get(0).(io.ReadCloser); ok {
+		return body, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRangeReader) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	args := m.Called(ctx, key)
+	if info, ok := args.Get(0).(ObjectInfo); ok {
+		return info, args.Error(1)
+	}
+	return ObjectInfo{}, args.Error(1)
+}
+
+// MockMultipartUploader is a testify.Mock implementation of StorageDriver that also implements MultipartUploader.
+type MockMultipartUploader struct {
+	MockStorageDriver
+}
+
+func (m *MockMultipartUploader) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	args := m.Called(ctx, key, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockMultipartUploader) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	args := m.Called(ctx, key, uploadID, parts)
+	return args.Error(0)
+}
+
+func (m *MockMultipartUploader) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMultipartUploader) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error) {
+	args := m.Called(ctx, key, uploadID, number, body, size)
+	if part, ok := args.Get(0).(Part); ok {
+		return part, args.Error(1)
+	}
+	return Part{}, args.Error(1)
+}
+
+// MockPinger is a testify.Mock implementation of StorageDriver that also implements Pinger.
+type MockPinger struct {
+	MockStorageDriver
+}
+
+func (m *MockPinger) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,123 @@
+// Package connpool implements the connection pool shared by the SFTP and FTP drivers.
+package connpool
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// Pool keeps up to size connections open. Connections are dialed lazily, reused while they
+// are usable and replaced once they break.
+type Pool[C any] struct {
+	dial   func(ctx context.Context) (C, error)
+	usable func(C) bool // reports whether an idle connection can be reused
+	close  func(C)
+	slots  chan struct{} // one token per connection that may be open
+	idle   chan C
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New returns a pool of up to size connections opened by dial and closed by close.
+// Idle connections are checked with usable before they are handed out again.
+func New[C any](size int, dial func(ctx context.Context) (C, error), usable func(C) bool, close func(C)) *Pool[C] {
+	return &Pool[C]{
+		dial:   dial,
+		usable: usable,
+		close:  close,
+		slots:  make(chan struct{}, size),
+		idle:   make(chan C, size),
+	}
+}
+
+// Get returns an idle connection, or dials a new one while the pool is not full.
+// It blocks until a connection is released when every connection is in use.
+func (p *Pool[C]) Get(ctx context.Context) (C, error) {
+	var zero C
+	for {
+		// prefer idle connections: select picks randomly among ready cases
+		select {
+		case c := <-p.idle:
+			if p.reuse(c) {
+				return c, nil
+			}
+			continue
+		default:
+		}
+
+		select {
+		case c := <-p.idle:
+			if p.reuse(c) {
+				return c, nil
+			}
+		case p.slots <- struct{}{}:
+			if p.isClosed() {
+				<-p.slots
+				return zero, net.ErrClosed
+			}
+
+			c, err := p.dial(ctx)
+			if err != nil {
+				<-p.slots
+				return zero, err
+			}
+			return c, nil
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// reuse reports whether an idle connection can be handed out, discarding it otherwise.
+func (p *Pool[C]) reuse(c C) bool {
+	if p.usable(c) {
+		return true
+	}
+
+	p.Discard(c)
+	return false
+}
+
+// Put returns a connection to the pool, closing it instead if the pool is closed.
+func (p *Pool[C]) Put(c C) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.Discard(c)
+		return
+	}
+
+	p.idle <- c
+}
+
+// Discard closes a broken connection and frees its slot.
+func (p *Pool[C]) Discard(c C) {
+	p.close(c)
+	<-p.slots
+}
+
+func (p *Pool[C]) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.closed
+}
+
+// Close closes every idle connection; connections in use are closed when they are put back.
+func (p *Pool[C]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for {
+		select {
+		case c := <-p.idle:
+			p.Discard(c)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package connpool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConn is a pooled connection that can be marked broken.
+type testConn struct {
+	id     int
+	broken bool
+	closed bool
+}
+
+// newTestPool returns a pool of size test connections and the list of every connection dialed.
+func newTestPool(size int) (*Pool[*testConn], *[]*testConn) {
+	var dialed []*testConn
+	pool := New(size,
+		func(ctx context.Context) (*testConn, error) {
+			c := &testConn{id: len(dialed) + 1}
+			dialed = append(dialed, c)
+			return c, nil
+		},
+		func(c *testConn) bool { return !c.broken },
+		func(c *testConn) { c.closed = true },
+	)
+
+	return pool, &dialed
+}
+
+func TestPool_ReusesIdleConnections(t *testing.T) {
+	pool, dialed := newTestPool(2)
+	ctx := context.Background()
+
+	c, err := pool.Get(ctx)
+	require.NoError(t, err, "expected no error dialing")
+	pool.Put(c)
+
+	again, err := pool.Get(ctx)
+	require.NoError(t, err, "expected no error getting an idle connection")
+	assert.Same(t, c, again, "expected the idle connection to be reused")
+	assert.Len(t, *dialed, 1, "expected no new connection to be dialed")
+}
+
+func TestPool_ReplacesBrokenConnections(t *testing.T) {
+	pool, dialed := newTestPool(1)
+	ctx := context.Background()
+
+	c, err := pool.Get(ctx)
+	require.NoError(t, err, "expected no error dialing")
+	c.broken = true
+	pool.Put(c)
+
+	replacement, err := pool.Get(ctx)
+	require.NoError(t, err, "expected a broken idle connection to be replaced")
+	assert.True(t, c.closed, "expected the broken connection to be closed")
+	assert.Equal(t, 2, replacement.id, "expected a new connection")
+	assert.Len(t, *dialed, 2, "expected one connection to be dialed again")
+}
+
+func TestPool_BlocksWhenFull(t *testing.T) {
+	pool, _ := newTestPool(1)
+
+	c, err := pool.Get(context.Background())
+	require.NoError(t, err, "expected no error dialing")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = pool.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "expected Get to wait for a free connection")
+
+	pool.Discard(c)
+	_, err = pool.Get(context.Background())
+	assert.NoError(t, err, "expected a discarded connection to free its slot")
+}
+
+func TestPool_Close(t *testing.T) {
+	pool, _ := newTestPool(2)
+	ctx := context.Background()
+
+	idle, err := pool.Get(ctx)
+	require.NoError(t, err, "expected no error dialing")
+	inUse, err := pool.Get(ctx)
+	require.NoError(t, err, "expected no error dialing")
+	pool.Put(idle)
+
+	pool.Close()
+	assert.True(t, idle.closed, "expected idle connections to be closed")
+	assert.False(t, inUse.closed, "expected connections in use to stay open")
+
+	pool.Put(inUse)
+	assert.True(t, inUse.closed, "expected connections put back after Close to be closed")
+
+	_, err = pool.Get(ctx)
+	assert.ErrorIs(t, err, net.ErrClosed, "expected Get to fail once closed")
+}
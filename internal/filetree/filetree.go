@@ -0,0 +1,157 @@
+// Package filetree implements what drivers storing keys as files in a directory tree share:
+// key-ordered listings over their directories, the ObjectInfo of their files, temporary file
+// names for atomic writes and the cleanup of directories emptied by deletes.
+package filetree
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// TempFilePrefix marks files that are still being written by Put.
+// They are skipped by List so readers never see partial uploads.
+const TempFilePrefix = ".gostorage-tmp-"
+
+// TempName returns a random file name starting with TempFilePrefix, for the temporary file
+// Put writes before renaming it into place.
+func TempName() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return TempFilePrefix + hex.EncodeToString(b)
+}
+
+// RemoveEmptyParents calls remove for the directories containing key, deepest first, and stops
+// at the first one it fails to remove, usually because the directory is not empty.
+// Drivers call it after deleting key so emptied directories do not pile up.
+func RemoveEmptyParents(key string, remove func(dir string) error) {
+	for dir := path.Dir(key); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// ReadDirFunc reads the entries of dir, a key prefix without trailing slash or "" for the root.
+// It returns no entries for a directory that does not exist, and logs and maps any other
+// failure to a storage error itself, since only the driver knows where it was listing.
+type ReadDirFunc func(ctx context.Context, dir string) ([]fs.DirEntry, error)
+
+// List calls fn for every regular file under opts.Prefix in lexical key order, reading
+// directories with readDir. Non-recursive listings report the directories directly below the
+// prefix with IsDir set instead of descending into them.
+func List(ctx context.Context, opts gostorage.ListOptions, readDir ReadDirFunc, fn func(gostorage.ObjectInfo) error) error {
+	dir := strings.TrimSuffix(opts.Prefix, "/")
+	if !strings.HasSuffix(opts.Prefix, "/") {
+		dir = path.Dir(opts.Prefix)
+	}
+
+	if dir == "." || dir == "" {
+		dir = ""
+	} else if err := gostorage.ValidateKey(dir); err != nil {
+		return err
+	}
+
+	return walk(ctx, dir, opts, readDir, fn)
+}
+
+// walk lists dir and recurses into subdirectories when opts.Recursive is set. Entries are
+// sorted by key, not by file name, so "a.txt" comes before "a/b.txt" exactly like an S3 listing.
+// Each directory is read by its own readDir call, so fn may use the storage itself.
+func walk(ctx context.Context, dir string, opts gostorage.ListOptions, readDir ReadDirFunc, fn func(gostorage.ObjectInfo) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := readDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		key string
+		fs.DirEntry
+	}
+
+	sorted := make([]entry, 0, len(entries))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), TempFilePrefix) {
+			continue
+		}
+
+		key := path.Join(dir, e.Name())
+		switch {
+		case e.IsDir():
+			key += "/"
+		case !e.Type().IsRegular():
+			continue
+		}
+
+		// Keep entries matching the prefix, and directories the prefix continues into.
+		if !strings.HasPrefix(key, opts.Prefix) && !strings.HasPrefix(opts.Prefix, key) {
+			continue
+		}
+
+		sorted = append(sorted, entry{key: key, DirEntry: e})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+
+	for _, e := range sorted {
+		if e.IsDir() {
+			if opts.Recursive || !strings.HasPrefix(e.key, opts.Prefix) {
+				if err := walk(ctx, strings.TrimSuffix(e.key, "/"), opts, readDir, fn); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := fn(gostorage.ObjectInfo{Key: e.key, IsDir: true}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // removed while listing
+			}
+
+			log.Error().Err(err).Str("key", e.key).Msg("failed to stat file while listing")
+			return gostorage.ErrInternal
+		}
+
+		if err := fn(ObjectInfo(e.key, info)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ObjectInfo builds the ObjectInfo of a regular file. The ETag is derived from modification
+// time and size, which changes whenever the file is rewritten; files without a modification
+// time, like those of an embed.FS, get none since equally sized files would share it.
+func ObjectInfo(key string, info fs.FileInfo) gostorage.ObjectInfo {
+	obj := gostorage.ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+	}
+
+	if !info.ModTime().IsZero() {
+		obj.ETag = fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+	}
+
+	return obj
+}
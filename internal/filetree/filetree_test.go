@@ -0,0 +1,102 @@
+package filetree
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// readMapFS returns a ReadDirFunc over fsys.
+func readMapFS(fsys fs.FS) ReadDirFunc {
+	return func(ctx context.Context, dir string) ([]fs.DirEntry, error) {
+		if dir == "" {
+			dir = "."
+		}
+
+		entries, err := fs.ReadDir(fsys, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return entries, err
+	}
+}
+
+func TestList(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, key := range []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt", "b/" + TempFilePrefix + "123"} {
+		fsys[key] = &fstest.MapFile{Data: []byte(key), ModTime: time.Unix(1700000000, 0)}
+	}
+
+	tests := []struct {
+		name        string
+		opts        gostorage.ListOptions
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:     "should list every file recursively in key order without temporary files",
+			opts:     gostorage.ListOptions{Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt", "b/e.txt"},
+		},
+		{
+			name:     "should list direct children with directories collapsed",
+			opts:     gostorage.ListOptions{},
+			expected: []string{"a.txt", "a/", "ab.txt", "b/"},
+		},
+		{
+			name:     "should list keys starting with a partial name",
+			opts:     gostorage.ListOptions{Prefix: "a", Recursive: true},
+			expected: []string{"a.txt", "a/b.txt", "a/c/d.txt", "ab.txt"},
+		},
+		{
+			name:     "should list nothing below a missing directory",
+			opts:     gostorage.ListOptions{Prefix: "missing/"},
+			expected: nil,
+		},
+		{
+			name:        "should reject invalid prefixes",
+			opts:        gostorage.ListOptions{Prefix: "../a/"},
+			expectedErr: gostorage.ErrInvalidKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := List(context.Background(), tt.opts, readMapFS(fsys), func(obj gostorage.ObjectInfo) error {
+				got = append(got, obj.Key)
+				return nil
+			})
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error listing")
+			assert.Equal(t, tt.expected, got, "expected keys to match")
+		})
+	}
+}
+
+func TestObjectInfo(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dated.txt":    {Data: []byte("abc"), ModTime: time.Unix(1700000000, 0)},
+		"embedded.txt": {Data: []byte("abc")},
+	}
+
+	dated, _ := fs.Stat(fsys, "dated.txt")
+	obj := ObjectInfo("dated.txt", dated)
+	assert.Equal(t, int64(3), obj.Size, "expected size to match")
+	assert.NotEmpty(t, obj.ETag, "expected ETag from modification time and size")
+
+	embedded, _ := fs.Stat(fsys, "embedded.txt")
+	assert.Empty(t, ObjectInfo("embedded.txt", embedded).ETag, "expected no ETag without modification time")
+}
@@ -0,0 +1,65 @@
+// Package storagetest implements the fixtures shared by the tests of the drivers combining
+// several storages, like the mirror and failover drivers.
+package storagetest
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/stretchr/testify/require"
+)
+
+// FlakyDisk is a local disk that fails every operation with gostorage.ErrInternal while Down is set.
+type FlakyDisk struct {
+	*localdriver.DiskStorage
+	Down atomic.Bool
+}
+
+// NewFlakyDisk returns a FlakyDisk in a temporary directory serving URLs below baseURL.
+func NewFlakyDisk(t *testing.T, baseURL string) *FlakyDisk {
+	t.Helper()
+
+	driver, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir(), BaseURL: baseURL})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	return &FlakyDisk{DiskStorage: driver.(*localdriver.DiskStorage)}
+}
+
+func (d *FlakyDisk) Put(ctx context.Context, key string, file io.Reader) (string, error) {
+	if d.Down.Load() {
+		return "", gostorage.ErrInternal
+	}
+	return d.DiskStorage.Put(ctx, key, file)
+}
+
+func (d *FlakyDisk) Delete(ctx context.Context, key string) error {
+	if d.Down.Load() {
+		return gostorage.ErrInternal
+	}
+	return d.DiskStorage.Delete(ctx, key)
+}
+
+func (d *FlakyDisk) Exists(ctx context.Context, key string) (bool, error) {
+	if d.Down.Load() {
+		return false, gostorage.ErrInternal
+	}
+	return d.DiskStorage.Exists(ctx, key)
+}
+
+func (d *FlakyDisk) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if d.Down.Load() {
+		return nil, gostorage.ErrInternal
+	}
+	return d.DiskStorage.GetRange(ctx, key, offset, length)
+}
+
+func (d *FlakyDisk) GetURL(ctx context.Context, key string) (string, error) {
+	if d.Down.Load() {
+		return "", gostorage.ErrInternal
+	}
+	return d.DiskStorage.GetURL(ctx, key)
+}
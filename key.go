@@ -0,0 +1,33 @@
+package gostorage
+
+import (
+	"regexp"
+	"strings"
+)
+
+// keySegmentRegex matches a single path segment of a key.
+var keySegmentRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ValidateKey ensures that a key is safe to store on any driver.
+// A key is one or more segments separated by "/", where every segment only contains
+// letters, digits, ".", "_" or "-" and is neither "." nor "..".
+// Returns ErrInvalidKey if the key is empty, absolute, has empty segments or invalid characters.
+// Usage: Drivers call this before writing a file so keys behave the same across backends.
+func ValidateKey(key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	for segment := range strings.SplitSeq(key, "/") {
+		switch {
+		case segment == "":
+			return ErrInvalidKey
+		case segment == "." || segment == "..":
+			return ErrInvalidKey
+		case !keySegmentRegex.MatchString(segment):
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package gostorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		expectErr bool
+	}{
+		{name: "should accept simple file name", key: "file.txt", expectErr: false},
+		{name: "should accept nested key", key: "users/42/avatar_1-x.png", expectErr: false},
+		{name: "should reject empty key", key: "", expectErr: true},
+		{name: "should reject absolute key", key: "/etc/passwd", expectErr: true},
+		{name: "should reject trailing slash", key: "users/", expectErr: true},
+		{name: "should reject empty segment", key: "users//a.txt", expectErr: true},
+		{name: "should reject parent segment", key: "users/../a.txt", expectErr: true},
+		{name: "should reject current segment", key: "./a.txt", expectErr: true},
+		{name: "should reject invalid characters", key: "bad key.txt", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKey(tt.key)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidKey, "expected invalid key error")
+			} else {
+				assert.NoError(t, err, "expected key to be valid")
+			}
+		})
+	}
+}
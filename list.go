@@ -0,0 +1,51 @@
+package gostorage
+
+import "time"
+
+// ObjectInfo describes a single file (or directory prefix) in storage.
+type ObjectInfo struct {
+	Key          string    // full key of the file, or the directory prefix ending in "/" when IsDir is true
+	Size         int64     // size in bytes, zero for directories
+	LastModified time.Time // last modification time, zero if unknown
+	ETag         string    // entity tag as reported by the driver, empty if unknown
+	IsDir        bool      // true for directory prefixes returned by non-recursive listings
+}
+
+// ListOptions controls which files a Lister returns.
+type ListOptions struct {
+	// Prefix restricts the listing to keys starting with this value.
+	// It is matched as a plain string, so "user/1" also matches "user/10/a.png".
+	Prefix string
+
+	// Recursive lists every key under Prefix. When false, only direct children of
+	// the prefix "directory" are returned and deeper keys are collapsed into a
+	// single ObjectInfo with IsDir set, like `ls` instead of `find`.
+	Recursive bool
+}
+
+// DeletePrefixOptions controls the behavior of StorageManager.DeletePrefix.
+type DeletePrefixOptions struct {
+	// DryRun lists the matching keys without deleting anything.
+	DryRun bool
+
+	// BatchSize is the number of keys deleted per batch. Defaults to 1000.
+	BatchSize int
+
+	// Progress, if set, is called after every batch with the running totals.
+	Progress func(DeletePrefixProgress)
+}
+
+// DeletePrefixProgress reports the running totals of a DeletePrefix call.
+type DeletePrefixProgress struct {
+	Listed  int // keys found under the prefix so far
+	Deleted int // keys deleted so far (always zero in dry-run mode)
+	Failed  int // keys that could not be deleted so far
+}
+
+// DeletePrefixReport is the final outcome of a DeletePrefix call.
+type DeletePrefixReport struct {
+	DeletePrefixProgress
+
+	Keys   []string     // keys that would be deleted, only populated in dry-run mode
+	Errors BatchResults // per-key failures, empty when every deletion succeeded
+}
@@ -2,8 +2,9 @@ package gostorage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
-	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -16,19 +17,85 @@ import (
 type StorageManager interface {
 	// Storage returns a new StorageManager that uses the storage alias provided.
 	// Useful when you have multiple storage backends and need to switch dynamically.
+	// If the alias is unknown, every operation on the returned manager fails with ErrInvalidDefaultStorage.
 	Storage(alias string) StorageManager
 
+	// StorageE is like Storage but returns ErrInvalidDefaultStorage if the alias is unknown.
+	StorageE(alias string) (StorageManager, error)
+
+	// MustStorage is like Storage but panics if the alias is unknown.
+	// Useful during application startup where a missing alias is a configuration bug.
+	MustStorage(alias string) StorageManager
+
+	// Aliases returns the names of all configured storages, sorted alphabetically.
+	Aliases() []string
+
+	// Has reports whether a storage is configured under the given alias.
+	Has(alias string) bool
+
+	// Register adds or replaces the driver stored under alias at runtime.
+	// Every manager bound to the alias uses the new driver from its next call on.
+	Register(alias string, driver StorageDriver) error
+
+	// RegisterFactory adds or replaces alias with a driver that is created on first use.
+	// Useful for per-tenant storages that should only be set up when actually needed.
+	RegisterFactory(alias string, factory DriverFactory) error
+
+	// Unregister removes the storage registered under alias. The default alias cannot be removed.
+	Unregister(alias string) error
+
+	// SetDefault changes which alias is used by managers not bound to an alias with Storage.
+	SetDefault(alias string) error
+
+	// AbortMultipartUpload discards a multipart upload and all its parts.
+	// Returns ErrNotSupported if the storage has no multipart uploads.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+
+	// BatchDelete removes multiple files concurrently and reports the outcome of every key.
+	// Unlike DeleteMany, a failing key does not cancel the remaining deletions.
+	BatchDelete(ctx context.Context, keys []string) BatchResults
+
+	// BatchGetSignedURLs returns signed URLs for multiple files concurrently with a per-key report.
+	// Unlike GetSignedURLs, a failing key does not discard the URLs of the others.
+	BatchGetSignedURLs(ctx context.Context, keys []string, expiry time.Duration) BatchResults
+
+	// BatchGetURLs returns public URLs for multiple files concurrently with a per-key report.
+	// Unlike GetURLs, a failing key does not discard the URLs of the others.
+	BatchGetURLs(ctx context.Context, keys []string) BatchResults
+
+	// Capabilities describes the optional features supported by the selected storage.
+	Capabilities(ctx context.Context) (Capabilities, error)
+
+	// CompleteMultipartUpload assembles the uploaded parts, in order, into the file at key.
+	// Returns ErrNotSupported if the storage has no multipart uploads.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error
+
+	// CreateMultipartUpload starts a multipart upload to key and returns its upload ID.
+	// Returns ErrNotSupported if the storage has no multipart uploads.
+	CreateMultipartUpload(ctx context.Context, key string) (string, error)
+
 	// Delete removes a single file identified by key.
 	Delete(ctx context.Context, key string) error
 
 	// DeleteMany removes multiple files concurrently.
 	DeleteMany(ctx context.Context, keys ...string) error
 
+	// DeletePrefix removes every file whose key starts with prefix, e.g. all files of a deleted user.
+	// Keys are listed and deleted in batches; use opts for dry-run mode and progress reporting.
+	// Returns ErrNotSupported if the storage cannot list files.
+	DeletePrefix(ctx context.Context, prefix string, opts DeletePrefixOptions) (DeletePrefixReport, error)
+
 	// Exists checks if a file exists by key.
 	Exists(ctx context.Context, key string) (bool, error)
 
+	// GetRange returns up to length bytes of a file starting at offset, or the rest of the file
+	// if length is negative. The caller must close the returned body.
+	// Returns ErrNotSupported if the storage cannot read byte ranges.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+
 	// GetSignedURL returns a temporary signed URL for accessing a file.
 	// This is typically used for private storages with time-limited access.
+	// Returns ErrNotSupported if the storage cannot sign URLs.
 	GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
 
 	// GetSignedURLs returns signed URLs for multiple files concurrently.
@@ -36,78 +103,314 @@ type StorageManager interface {
 
 	// GetURL returns a public URL for a file.
 	// This is typically used for public storages where files can be accessed directly.
+	// Returns ErrNotSupported if the storage has no public URLs.
 	GetURL(ctx context.Context, key string) (string, error)
 
 	// GetURLs returns public URLs for multiple files concurrently.
 	GetURLs(ctx context.Context, keys []string) ([]string, error)
 
+	// List calls fn for every file matching opts, in lexical key order.
+	// Returns ErrNotSupported if the storage cannot list files.
+	List(ctx context.Context, opts ListOptions, fn func(ObjectInfo) error) error
+
 	// Missing returns true if a file does NOT exist (inverse of Exists).
 	Missing(ctx context.Context, key string) (bool, error)
 
+	// OpenReaderAt returns an io.ReaderAt over a file that reads it through ranged requests,
+	// e.g. to open a ZIP archive in place with zip.NewReader(r, r.Size()).
+	// Returns ErrNotSupported if the storage cannot read byte ranges.
+	OpenReaderAt(ctx context.Context, key string) (*ObjectReaderAt, error)
+
 	// Put uploads a file to the storage with the given key and returns its URL.
 	Put(ctx context.Context, key string, file io.Reader) (string, error)
+
+	// SetVisibility makes a single file public or private.
+	// Returns ErrNotSupported if the storage has no per-file visibility.
+	SetVisibility(ctx context.Context, key string, visibility Visibility) error
+
+	// Stat returns the size, modification time and ETag of a single file.
+	// Returns ErrNotFound if the file does not exist, or ErrNotSupported if the storage cannot stat files.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+
+	// UploadPart uploads size bytes of body as part number (starting at 1) of a multipart upload.
+	// Returns ErrNotSupported if the storage has no multipart uploads.
+	UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error)
+
+	// URL returns a URL anyone holding it can open: a public URL for public files and a
+	// signed URL (with the storage's default expiry unless opts says otherwise) for private ones.
+	// Callers no longer need to know whether a storage is public or private.
+	URL(ctx context.Context, key string, opts URLOptions) (string, error)
+
+	// Visibility returns whether a file is public or private.
+	// Files without their own visibility report the storage default.
+	Visibility(ctx context.Context, key string) (Visibility, error)
+}
+
+// DefaultConcurrency is the maximum number of driver calls a batch operation runs at once
+// when no limit is configured with WithConcurrency.
+const DefaultConcurrency = 16
+
+// defaultDeletePrefixBatchSize is the number of keys DeletePrefix deletes at once when no batch size is set.
+// It matches the S3 DeleteObjects limit so each batch maps to a single request.
+const defaultDeletePrefixBatchSize = 1000
+
+// ManagerOption configures optional behavior of a StorageManager.
+type ManagerOption func(*storageManagerImpl)
+
+// WithConcurrency limits how many driver calls batch operations (DeleteMany, GetURLs, etc.) run at once.
+// Values lower than 1 are ignored and DefaultConcurrency is used instead.
+func WithConcurrency(n int) ManagerOption {
+	return func(m *storageManagerImpl) {
+		m.concurrency = n
+	}
 }
 
 // storageManagerImpl is the concrete implementation of StorageManager.
-// It delegates calls to the defaultStorage or a selected storage from storageMap.
+// It delegates calls to the storage selected by alias in the shared registry.
 type storageManagerImpl struct {
-	mu             sync.RWMutex
-	storageMap     map[string]StorageDriver // all available storages by alias
-	defaultStorage StorageDriver            // the currently selected storage
+	registry    *registry // all available storages, shared with derived managers
+	alias       string    // selected storage alias, empty to follow the registry default
+	concurrency int       // max in-flight driver calls for batch operations
 }
 
 // NewManager creates a new StorageManager with a default storage alias.
 // Returns an error if the alias does not exist in the provided storage map.
-func NewStorageManager(defaultStorageAlias string, storage map[string]StorageDriver) (StorageManager, error) {
-	defaultStorage, exists := storage[defaultStorageAlias]
-	if !exists {
+func NewStorageManager(defaultStorageAlias string, storage map[string]StorageDriver, opts ...ManagerOption) (StorageManager, error) {
+	if _, exists := storage[defaultStorageAlias]; !exists {
 		log.
 			Warn().
 			Str("defaultStorageAlias", defaultStorageAlias).
-			Msg("storage: storage alias not found, returning manager with nil default storage")
+			Msg("storage: default storage alias not found, no manager created")
 
 		return nil, ErrInvalidDefaultStorage
 	}
 
-	return &storageManagerImpl{
-		storageMap:     storage,
-		defaultStorage: defaultStorage,
-	}, nil
+	m := &storageManagerImpl{
+		registry: newRegistry(defaultStorageAlias, storage),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m, nil
 }
 
 // Storage returns a new StorageManager using the given alias as its default storage.
-// If alias is not found, defaultStorage will be nil (be careful when calling methods).
+// If alias is not found, a warning is logged and every operation on the returned manager
+// fails with ErrInvalidDefaultStorage. Use StorageE to handle the error up front.
 func (m *storageManagerImpl) Storage(alias string) StorageManager {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	defaultStorage, exists := m.storageMap[alias]
-	if !exists {
+	if !m.registry.has(alias) {
 		log.
 			Warn().
 			Str("alias", alias).
-			Msg("storage: storage alias not found, returning manager with nil default storage")
+			Msg("storage: storage alias not found, operations will fail until it is registered")
+	}
+
+	return m.withAlias(alias)
+}
+
+// StorageE returns a new StorageManager using the given alias as its default storage.
+// Returns ErrInvalidDefaultStorage if the alias is not found.
+func (m *storageManagerImpl) StorageE(alias string) (StorageManager, error) {
+	if !m.registry.has(alias) {
+		return nil, fmt.Errorf("%w: unknown alias %q", ErrInvalidDefaultStorage, alias)
+	}
+
+	return m.withAlias(alias), nil
+}
+
+// MustStorage returns a new StorageManager using the given alias as its default storage.
+// It panics if the alias is not found.
+func (m *storageManagerImpl) MustStorage(alias string) StorageManager {
+	mgr, err := m.StorageE(alias)
+	if err != nil {
+		panic(err)
 	}
 
+	return mgr
+}
+
+// withAlias returns a manager bound to alias that shares this manager's registry and options.
+// The driver is looked up on every call, so re-registering the alias hot-swaps it.
+func (m *storageManagerImpl) withAlias(alias string) *storageManagerImpl {
 	return &storageManagerImpl{
-		storageMap:     m.storageMap,
-		defaultStorage: defaultStorage,
+		registry:    m.registry,
+		alias:       alias,
+		concurrency: m.concurrency,
+	}
+}
+
+// Aliases returns the names of all configured storages, sorted alphabetically.
+func (m *storageManagerImpl) Aliases() []string {
+	return m.registry.aliases()
+}
+
+// Has reports whether a storage is configured under the given alias.
+func (m *storageManagerImpl) Has(alias string) bool {
+	return m.registry.has(alias)
+}
+
+// Register adds a driver under alias, replacing any driver or factory already registered there.
+// Managers bound to the alias pick up the new driver on their next call, which allows hot-swapping
+// a storage when its credentials rotate. The replaced driver is not closed.
+// Returns ErrInvalidConfig if alias is empty or driver is nil.
+func (m *storageManagerImpl) Register(alias string, driver StorageDriver) error {
+	if alias == "" || driver == nil {
+		return ErrInvalidConfig
+	}
+
+	m.registry.set(alias, &registryEntry{driver: driver})
+	return nil
+}
+
+// RegisterFactory adds a lazily created driver under alias, replacing any existing registration.
+// The factory runs on the first operation that uses the alias, e.g. to create a tenant's storage on demand.
+// Returns ErrInvalidConfig if alias is empty or factory is nil.
+func (m *storageManagerImpl) RegisterFactory(alias string, factory DriverFactory) error {
+	if alias == "" || factory == nil {
+		return ErrInvalidConfig
+	}
+
+	m.registry.set(alias, &registryEntry{factory: factory})
+	return nil
+}
+
+// Unregister removes the storage registered under alias.
+// Managers bound to the alias fail with ErrInvalidDefaultStorage afterwards.
+// Returns ErrInvalidDefaultStorage if the alias is unknown or is the current default.
+func (m *storageManagerImpl) Unregister(alias string) error {
+	return m.registry.remove(alias)
+}
+
+// SetDefault changes the default storage used by managers that were not bound to an alias with Storage.
+// Returns ErrInvalidDefaultStorage if the alias is unknown.
+func (m *storageManagerImpl) SetDefault(alias string) error {
+	return m.registry.setDefault(alias)
+}
+
+// driver returns the selected storage, or ErrInvalidDefaultStorage if its alias is not registered.
+// Lazily registered storages are created here on first use.
+func (m *storageManagerImpl) driver(ctx context.Context) (StorageDriver, error) {
+	if m.registry == nil {
+		return nil, ErrInvalidDefaultStorage
+	}
+
+	return m.registry.resolve(ctx, m.alias)
+}
+
+// limit returns the configured batch concurrency, falling back to DefaultConcurrency.
+func (m *storageManagerImpl) limit() int {
+	if m.concurrency < 1 {
+		return DefaultConcurrency
+	}
+
+	return m.concurrency
+}
+
+// AbortMultipartUpload discards a multipart upload if the driver implements MultipartUploader.
+func (m *storageManagerImpl) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	uploader, err := m.multipartUploader(ctx)
+	if err != nil {
+		return err
+	}
+
+	return uploader.AbortMultipartUpload(ctx, key, uploadID)
+}
+
+// BatchDelete removes multiple files with bounded concurrency and reports the outcome of every key.
+// If the driver implements BatchDeleter, its native bulk deletion is used instead.
+func (m *storageManagerImpl) BatchDelete(ctx context.Context, keys []string) BatchResults {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return failBatch(keys, err)
+	}
+
+	if bd, ok := driver.(BatchDeleter); ok {
+		return bd.DeleteBatch(ctx, keys)
+	}
+
+	return runBatch(ctx, m.limit(), keys, func(ctx context.Context, key string) (string, error) {
+		return "", driver.Delete(ctx, key)
+	})
+}
+
+// BatchGetSignedURLs returns signed URLs with bounded concurrency and reports the outcome of every key.
+func (m *storageManagerImpl) BatchGetSignedURLs(ctx context.Context, keys []string, expiry time.Duration) BatchResults {
+	return runBatch(ctx, m.limit(), keys, func(ctx context.Context, key string) (string, error) {
+		return m.GetSignedURL(ctx, key, expiry)
+	})
+}
+
+// BatchGetURLs returns public URLs with bounded concurrency and reports the outcome of every key.
+func (m *storageManagerImpl) BatchGetURLs(ctx context.Context, keys []string) BatchResults {
+	return runBatch(ctx, m.limit(), keys, m.GetURL)
+}
+
+// Capabilities returns the features reported by the driver, or inferred from the interfaces it implements.
+func (m *storageManagerImpl) Capabilities(ctx context.Context) (Capabilities, error) {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	return CapabilitiesOf(driver), nil
+}
+
+// CompleteMultipartUpload assembles a multipart upload if the driver implements MultipartUploader.
+func (m *storageManagerImpl) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	uploader, err := m.multipartUploader(ctx)
+	if err != nil {
+		return err
+	}
+
+	return uploader.CompleteMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// CreateMultipartUpload starts a multipart upload if the driver implements MultipartUploader.
+func (m *storageManagerImpl) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
 	}
+
+	uploader, err := m.multipartUploader(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return uploader.CreateMultipartUpload(ctx, key)
 }
 
 // Delete removes a single file from the storage.
 func (m *storageManagerImpl) Delete(ctx context.Context, key string) error {
-	return m.defaultStorage.Delete(ctx, key)
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return err
+	}
+
+	return driver.Delete(ctx, key)
 }
 
 // DeleteMany removes multiple files concurrently from the storage.
 // Uses errgroup to run deletions in parallel and return the first error encountered.
+// At most the configured concurrency limit of deletions run at once.
+// If the driver implements BatchDeleter, its native bulk deletion is used instead.
 func (m *storageManagerImpl) DeleteMany(ctx context.Context, keys ...string) error {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return err
+	}
+
+	if bd, ok := driver.(BatchDeleter); ok {
+		return bd.DeleteBatch(ctx, keys).Err()
+	}
+
 	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(m.limit())
 
 	for _, key := range keys {
 		g.Go(func() error {
-			return m.Delete(ctx, key)
+			return driver.Delete(ctx, key)
 		})
 	}
 
@@ -118,20 +421,119 @@ func (m *storageManagerImpl) DeleteMany(ctx context.Context, keys ...string) err
 	return nil
 }
 
+// DeletePrefix lists every file under prefix and deletes them batch by batch.
+// An empty prefix is rejected with ErrInvalidKey to prevent wiping a whole storage by accident.
+// Per-key failures are collected in the report and do not stop the remaining batches.
+func (m *storageManagerImpl) DeletePrefix(ctx context.Context, prefix string, opts DeletePrefixOptions) (DeletePrefixReport, error) {
+	var report DeletePrefixReport
+
+	if prefix == "" {
+		return report, ErrInvalidKey
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultDeletePrefixBatchSize
+	}
+
+	batch := make([]string, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if opts.DryRun {
+			report.Keys = append(report.Keys, batch...)
+		} else {
+			failed := m.BatchDelete(ctx, batch).Failed()
+			report.Deleted += len(batch) - len(failed)
+			report.Failed += len(failed)
+			report.Errors = append(report.Errors, failed...)
+		}
+
+		batch = batch[:0]
+
+		if opts.Progress != nil {
+			opts.Progress(report.DeletePrefixProgress)
+		}
+	}
+
+	err := m.List(ctx, ListOptions{Prefix: prefix, Recursive: true}, func(obj ObjectInfo) error {
+		report.Listed++
+		batch = append(batch, obj.Key)
+
+		if len(batch) == batchSize {
+			flush()
+		}
+
+		return ctx.Err()
+	})
+	if err != nil {
+		return report, err
+	}
+
+	flush()
+
+	return report, nil
+}
+
 // Exists checks whether a file exists in the storage.
 func (m *storageManagerImpl) Exists(ctx context.Context, key string) (bool, error) {
-	return m.defaultStorage.Exists(ctx, key)
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return driver.Exists(ctx, key)
+}
+
+// GetRange reads a byte range of a file if the driver implements RangeReader.
+func (m *storageManagerImpl) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, ErrInvalidRange
+	}
+
+	reader, err := m.rangeReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader.GetRange(ctx, key, offset, length)
 }
 
 // GetSignedURL returns a temporary signed URL for accessing the file in storage.
+// Drivers that cannot sign URLs, or that return an empty URL, yield ErrNotSupported.
 func (m *storageManagerImpl) GetSignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
-	return m.defaultStorage.GetSignedURL(ctx, key, expiry)
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !CapabilitiesOf(driver).SignedURL {
+		return "", ErrNotSupported
+	}
+
+	url, err := driver.GetSignedURL(ctx, key, expiry)
+	if err != nil {
+		return "", err
+	}
+
+	if url == "" {
+		return "", ErrNotSupported
+	}
+
+	return url, nil
 }
 
 // GetSignedURLs returns signed URLs for multiple files concurrently.
 func (m *storageManagerImpl) GetSignedURLs(ctx context.Context, keys []string, expiry time.Duration) ([]string, error) {
+	if _, err := m.driver(ctx); err != nil {
+		return nil, err
+	}
+
 	urls := make([]string, len(keys))
 	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(m.limit())
 
 	for i, key := range keys {
 		i, key := i, key // avoid closure capture bug
@@ -155,14 +557,38 @@ func (m *storageManagerImpl) GetSignedURLs(ctx context.Context, keys []string, e
 }
 
 // GetURL returns the direct (public) URL of a file from the storage.
+// Drivers without public URLs, or that return an empty URL, yield ErrNotSupported.
 func (m *storageManagerImpl) GetURL(ctx context.Context, key string) (string, error) {
-	return m.defaultStorage.GetURL(ctx, key)
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !CapabilitiesOf(driver).PublicURL {
+		return "", ErrNotSupported
+	}
+
+	url, err := driver.GetURL(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if url == "" {
+		return "", ErrNotSupported
+	}
+
+	return url, nil
 }
 
 // GetURLs returns direct URLs for multiple files concurrently.
 func (m *storageManagerImpl) GetURLs(ctx context.Context, keys []string) ([]string, error) {
+	if _, err := m.driver(ctx); err != nil {
+		return nil, err
+	}
+
 	urls := make([]string, len(keys))
 	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(m.limit())
 
 	for i, key := range keys {
 		i, key := i, key // avoid closure capture bug
@@ -185,6 +611,21 @@ func (m *storageManagerImpl) GetURLs(ctx context.Context, keys []string) ([]stri
 	return urls, nil
 }
 
+// List enumerates files in the storage if the driver implements Lister.
+func (m *storageManagerImpl) List(ctx context.Context, opts ListOptions, fn func(ObjectInfo) error) error {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return err
+	}
+
+	lister, ok := driver.(Lister)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	return lister.List(ctx, opts, fn)
+}
+
 // Missing returns true if the file does not exist in the storage.
 func (m *storageManagerImpl) Missing(ctx context.Context, key string) (bool, error) {
 	exists, err := m.Exists(ctx, key)
@@ -195,7 +636,135 @@ func (m *storageManagerImpl) Missing(ctx context.Context, key string) (bool, err
 	return !exists, nil
 }
 
+// OpenReaderAt opens a random-access reader over a file if the driver implements RangeReader.
+func (m *storageManagerImpl) OpenReaderAt(ctx context.Context, key string) (*ObjectReaderAt, error) {
+	reader, err := m.rangeReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewObjectReaderAt(ctx, reader, key)
+}
+
 // Put uploads a file to the storage and returns its resulting URL.
 func (m *storageManagerImpl) Put(ctx context.Context, key string, file io.Reader) (string, error) {
-	return m.defaultStorage.Put(ctx, key, file)
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return driver.Put(ctx, key, file)
+}
+
+// SetVisibility changes the visibility of a single file if the driver implements VisibilityManager.
+func (m *storageManagerImpl) SetVisibility(ctx context.Context, key string, visibility Visibility) error {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return err
+	}
+
+	vm, ok := driver.(VisibilityManager)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	return vm.SetVisibility(ctx, key, visibility)
+}
+
+// Stat returns information about a single file if the driver implements RangeReader.
+func (m *storageManagerImpl) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	reader, err := m.rangeReader(ctx)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return reader.Stat(ctx, key)
+}
+
+// UploadPart uploads a part of a multipart upload if the driver implements MultipartUploader.
+func (m *storageManagerImpl) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error) {
+	uploader, err := m.multipartUploader(ctx)
+	if err != nil {
+		return Part{}, err
+	}
+
+	return uploader.UploadPart(ctx, key, uploadID, number, body, size)
+}
+
+// URL resolves the visibility of the file and returns a public or signed URL accordingly.
+// Visibility comes from opts, then the file itself, then the storage default.
+// A public file on a storage without direct URLs (e.g. a public-read object in a private
+// bucket) still gets a working signed URL.
+func (m *storageManagerImpl) URL(ctx context.Context, key string, opts URLOptions) (string, error) {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	visibility := opts.Visibility
+	if visibility == "" {
+		if visibility, err = m.Visibility(ctx, key); err != nil {
+			return "", err
+		}
+	}
+
+	if visibility == VisibilityPublic {
+		url, err := m.GetURL(ctx, key)
+		if !errors.Is(err, ErrNotSupported) {
+			return url, err
+		}
+	}
+
+	return m.GetSignedURL(ctx, key, signedURLExpiry(driver, opts.Expiry))
+}
+
+// Visibility returns the visibility stored with the file, or the storage default.
+func (m *storageManagerImpl) Visibility(ctx context.Context, key string) (Visibility, error) {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if vm, ok := driver.(VisibilityManager); ok {
+		visibility, err := vm.Visibility(ctx, key)
+		if err != nil {
+			return "", err
+		}
+
+		if visibility != "" {
+			return visibility, nil
+		}
+	}
+
+	return defaultVisibility(driver), nil
+}
+
+// rangeReader returns the selected driver if it implements RangeReader.
+func (m *storageManagerImpl) rangeReader(ctx context.Context) (RangeReader, error) {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, ok := driver.(RangeReader)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	return reader, nil
+}
+
+// multipartUploader returns the selected driver if it implements MultipartUploader.
+func (m *storageManagerImpl) multipartUploader(ctx context.Context) (MultipartUploader, error) {
+	driver, err := m.driver(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader, ok := driver.(MultipartUploader)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	return uploader, nil
 }
@@ -23,6 +23,87 @@ func (m *MockStorageManager) Storage(alias string) StorageManager {
 	return nil
 }
 
+func (m *MockStorageManager) StorageE(alias string) (StorageManager, error) {
+	args := m.Called(alias)
+	if mgr, ok := args.Get(0).(StorageManager); ok {
+		return mgr, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStorageManager) MustStorage(alias string) StorageManager {
+	args := m.Called(alias)
+	if mgr, ok := args.Get(0).(StorageManager); ok {
+		return mgr
+	}
+	return nil
+}
+
+func (m *MockStorageManager) Aliases() []string {
+	args := m.Called()
+	if aliases, ok := args.Get(0).([]string); ok {
+		return aliases
+	}
+	return nil
+}
+
+func (m *MockStorageManager) Has(alias string) bool {
+	args := m.Called(alias)
+	return args.Bool(0)
+}
+
+func (m *MockStorageManager) Register(alias string, driver StorageDriver) error {
+	args := m.Called(alias, driver)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) RegisterFactory(alias string, factory DriverFactory) error {
+	args := m.Called(alias, factory)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) Unregister(alias string) error {
+	args := m.Called(alias)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) SetDefault(alias string) error {
+	args := m.Called(alias)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) BatchDelete(ctx context.Context, keys []string) BatchResults {
+	args := m.Called(ctx, keys)
+	if results, ok := args.Get(0).(BatchResults); ok {
+		return results
+	}
+	return nil
+}
+
+func (m *MockStorageManager) BatchGetSignedURLs(ctx context.Context, keys []string, expiry time.Duration) BatchResults {
+	args := m.Called(ctx, keys, expiry)
+	if results, ok := args.Get(0).(BatchResults); ok {
+		return results
+	}
+	return nil
+}
+
+func (m *MockStorageManager) BatchGetURLs(ctx context.Context, keys []string) BatchResults {
+	args := m.Called(ctx, keys)
+	if results, ok := args.Get(0).(BatchResults); ok {
+		return results
+	}
+	return nil
+}
+
+func (m *MockStorageManager) Capabilities(ctx context.Context) (Capabilities, error) {
+	args := m.Called(ctx)
+	if caps, ok := args.Get(0).(Capabilities); ok {
+		return caps, args.Error(1)
+	}
+	return Capabilities{}, args.Error(1)
+}
+
 func (m *MockStorageManager) Delete(ctx context.Context, key string) error {
 	args := m.Called(ctx, key)
 	return args.Error(0)
@@ -34,6 +115,14 @@ func (m *MockStorageManager) DeleteMany(ctx context.Context, keys ...string) err
 	return args.Error(0)
 }
 
+func (m *MockStorageManager) DeletePrefix(ctx context.Context, prefix string, opts DeletePrefixOptions) (DeletePrefixReport, error) {
+	args := m.Called(ctx, prefix, opts)
+	if report, ok := args.Get(0).(DeletePrefixReport); ok {
+		return report, args.Error(1)
+	}
+	return DeletePrefixReport{}, args.Error(1)
+}
+
 func (m *MockStorageManager) Exists(ctx context.Context, key string) (bool, error) {
 	args := m.Called(ctx, key)
 	return args.Bool(0), args.Error(1)
@@ -65,6 +154,11 @@ func (m *MockStorageManager) GetURLs(ctx context.Context, keys []string) ([]stri
 	return nil, args.Error(1)
 }
 
+func (m *MockStorageManager) List(ctx context.Context, opts ListOptions, fn func(ObjectInfo) error) error {
+	args := m.Called(ctx, opts, fn)
+	return args.Error(0)
+}
+
 func (m *MockStorageManager) Missing(ctx context.Context, key string) (bool, error) {
 	args := m.Called(ctx, key)
 	return args.Bool(0), args.Error(1)
@@ -75,6 +169,71 @@ func (m *MockStorageManager) Put(ctx context.Context, key string, file io.Reader
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockStorageManager) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	args := m.Called(ctx, key, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	args := m.Called(ctx, key, uploadID, parts)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorageManager) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	args := m.Called(ctx, key, offset, length)
+	if body, ok := args.Get(0).(io.ReadCloser); ok {
+		return body, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStorageManager) OpenReaderAt(ctx context.Context, key string) (*ObjectReaderAt, error) {
+	args := m.Called(ctx, key)
+	if r, ok := args.Get(0).(*ObjectReaderAt); ok {
+		return r, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStorageManager) SetVisibility(ctx context.Context, key string, visibility Visibility) error {
+	args := m.Called(ctx, key, visibility)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	args := m.Called(ctx, key)
+	if info, ok := args.Get(0).(ObjectInfo); ok {
+		return info, args.Error(1)
+	}
+	return ObjectInfo{}, args.Error(1)
+}
+
+func (m *MockStorageManager) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error) {
+	args := m.Called(ctx, key, uploadID, number, body, size)
+	if part, ok := args.Get(0).(Part); ok {
+		return part, args.Error(1)
+	}
+	return Part{}, args.Error(1)
+}
+
+func (m *MockStorageManager) URL(ctx context.Context, key string, opts URLOptions) (string, error) {
+	args := m.Called(ctx, key, opts)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorageManager) Visibility(ctx context.Context, key string) (Visibility, error) {
+	args := m.Called(ctx, key)
+	if visibility, ok := args.Get(0).(Visibility); ok {
+		return visibility, args.Error(1)
+	}
+	return "", args.Error(1)
+}
+
 func stringSliceToInterface(slice []string) []any {
 	res := make([]any, len(slice))
 	for i, v := range slice {
@@ -3,15 +3,25 @@ package gostorage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+// newTestManager returns a manager whose default storage is driver.
+func newTestManager(driver StorageDriver, opts ...ManagerOption) *storageManagerImpl {
+	mgr, _ := NewStorageManager("default", map[string]StorageDriver{"default": driver}, opts...)
+	return mgr.(*storageManagerImpl)
+}
+
 func TestStorageManager_NewStorageManager(t *testing.T) {
 	mockDriver := new(MockStorageDriver)
 
@@ -105,30 +115,656 @@ func TestStorageManager_Storage(t *testing.T) {
 			impl, ok := newMgr.(*storageManagerImpl)
 			assert.True(t, ok, "expected returned StorageManager to be *storageManagerImpl")
 
+			storage, err := impl.driver(context.Background())
+			defaultStorage, _ := manager.(*storageManagerImpl).driver(context.Background())
+
 			if tt.expectNil {
-				assert.Nil(t, impl.defaultStorage, "expected defaultStorage to be nil")
+				assert.ErrorIs(t, err, ErrInvalidDefaultStorage, "expected unknown alias error")
+				assert.Nil(t, storage, "expected storage to be nil")
 			} else {
-				assert.NotNil(t, impl.defaultStorage, "expected defaultStorage to be non-nil")
+				assert.NoError(t, err, "expected no error")
+				assert.NotNil(t, storage, "expected storage to be non-nil")
 			}
 
 			if tt.expectSame {
-				assert.Equal(t, manager.(*storageManagerImpl).defaultStorage, impl.defaultStorage, "expected same storage reference")
+				assert.Equal(t, defaultStorage, storage, "expected same storage reference")
 			} else if !tt.expectNil {
-				assert.NotSame(t, manager.(*storageManagerImpl).defaultStorage, impl.defaultStorage, "expected different storage reference")
+				assert.NotSame(t, defaultStorage, storage, "expected different storage reference")
+			}
+		})
+	}
+}
+
+func TestStorageManager_StorageE(t *testing.T) {
+	mockDefault := new(MockStorageDriver)
+	mockOther := new(MockStorageDriver)
+
+	manager, err := NewStorageManager("default", map[string]StorageDriver{
+		"default": mockDefault,
+		"other":   mockOther,
+	})
+	assert.NoError(t, err, "expected no error creating manager")
+
+	tests := []struct {
+		name      string
+		alias     string
+		expectErr error
+	}{
+		{
+			name:      "should return manager for existing alias",
+			alias:     "other",
+			expectErr: nil,
+		},
+		{
+			name:      "should return error for unknown alias",
+			alias:     "missing",
+			expectErr: ErrInvalidDefaultStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, err := manager.StorageE(tt.alias)
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr, "expected error to match")
+				assert.Contains(t, err.Error(), tt.alias, "expected error to name the alias")
+				assert.Nil(t, mgr, "expected manager to be nil")
+				assert.Panics(t, func() { manager.MustStorage(tt.alias) }, "expected MustStorage to panic")
+			} else {
+				assert.NoError(t, err, "expected no error")
+				storage, _ := mgr.(*storageManagerImpl).driver(context.Background())
+				assert.Same(t, mockOther, storage, "expected selected storage")
+				assert.NotPanics(t, func() { manager.MustStorage(tt.alias) }, "expected MustStorage not to panic")
+			}
+		})
+	}
+}
+
+func TestStorageManager_AliasesAndHas(t *testing.T) {
+	manager, err := NewStorageManager("b", map[string]StorageDriver{
+		"b": new(MockStorageDriver),
+		"a": new(MockStorageDriver),
+		"c": new(MockStorageDriver),
+	})
+	assert.NoError(t, err, "expected no error creating manager")
+
+	assert.Equal(t, []string{"a", "b", "c"}, manager.Aliases(), "expected sorted aliases")
+	assert.True(t, manager.Has("a"), "expected alias a to exist")
+	assert.False(t, manager.Has("missing"), "expected unknown alias not to exist")
+	assert.Equal(t, []string{"a", "b", "c"}, manager.Storage("missing").Aliases(), "expected aliases from derived manager")
+}
+
+func TestStorageManager_UnknownAlias(t *testing.T) {
+	ctx := context.Background()
+
+	manager, err := NewStorageManager("default", map[string]StorageDriver{"default": new(MockStorageDriver)})
+	assert.NoError(t, err, "expected no error creating manager")
+
+	unknown := manager.Storage("missing")
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "Delete",
+			call: func() error { return unknown.Delete(ctx, "key") },
+		},
+		{
+			name: "DeleteMany",
+			call: func() error { return unknown.DeleteMany(ctx, "key1", "key2") },
+		},
+		{
+			name: "BatchDelete",
+			call: func() error { return unknown.BatchDelete(ctx, []string{"key1"}).Err() },
+		},
+		{
+			name: "DeletePrefix",
+			call: func() error {
+				_, err := unknown.DeletePrefix(ctx, "users/", DeletePrefixOptions{})
+				return err
+			},
+		},
+		{
+			name: "Exists",
+			call: func() error {
+				_, err := unknown.Exists(ctx, "key")
+				return err
+			},
+		},
+		{
+			name: "GetSignedURL",
+			call: func() error {
+				_, err := unknown.GetSignedURL(ctx, "key", time.Minute)
+				return err
+			},
+		},
+		{
+			name: "GetSignedURLs",
+			call: func() error {
+				_, err := unknown.GetSignedURLs(ctx, []string{"key"}, time.Minute)
+				return err
+			},
+		},
+		{
+			name: "GetURL",
+			call: func() error {
+				_, err := unknown.GetURL(ctx, "key")
+				return err
+			},
+		},
+		{
+			name: "GetURLs",
+			call: func() error {
+				_, err := unknown.GetURLs(ctx, []string{"key"})
+				return err
+			},
+		},
+		{
+			name: "List",
+			call: func() error {
+				return unknown.List(ctx, ListOptions{}, func(ObjectInfo) error { return nil })
+			},
+		},
+		{
+			name: "Missing",
+			call: func() error {
+				_, err := unknown.Missing(ctx, "key")
+				return err
+			},
+		},
+		{
+			name: "Put",
+			call: func() error {
+				_, err := unknown.Put(ctx, "key", strings.NewReader("content"))
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("should return invalid default storage from "+tt.name, func(t *testing.T) {
+			var err error
+			assert.NotPanics(t, func() { err = tt.call() }, "expected no panic on unknown alias")
+			assert.ErrorIs(t, err, ErrInvalidDefaultStorage, "expected invalid default storage error")
+		})
+	}
+}
+
+func TestStorageManager_Register(t *testing.T) {
+	ctx := context.Background()
+	mockDefault := new(MockStorageDriver)
+	mockOld := new(MockStorageDriver)
+	mockNew := new(MockStorageDriver)
+
+	manager := newTestManager(mockDefault)
+
+	assert.ErrorIs(t, manager.Register("", mockOld), ErrInvalidConfig, "expected error for empty alias")
+	assert.ErrorIs(t, manager.Register("tenant", nil), ErrInvalidConfig, "expected error for nil driver")
+
+	tenant := manager.Storage("tenant")
+	_, err := tenant.Exists(ctx, "key")
+	assert.ErrorIs(t, err, ErrInvalidDefaultStorage, "expected error before alias is registered")
+
+	assert.NoError(t, manager.Register("tenant", mockOld), "expected no error registering driver")
+	assert.True(t, manager.Has("tenant"), "expected alias to be registered")
+	assert.Equal(t, []string{"default", "tenant"}, manager.Aliases(), "expected new alias in list")
+
+	mockOld.On("Exists", ctx, "key").Return(true, nil).Once()
+	exists, err := tenant.Exists(ctx, "key")
+	assert.NoError(t, err, "expected no error after registering alias")
+	assert.True(t, exists, "expected result from registered driver")
+
+	assert.NoError(t, manager.Register("tenant", mockNew), "expected no error replacing driver")
+	mockNew.On("Exists", ctx, "key").Return(false, nil).Once()
+	exists, err = tenant.Exists(ctx, "key")
+	assert.NoError(t, err, "expected no error after hot-swapping driver")
+	assert.False(t, exists, "expected result from replacement driver")
+
+	mockOld.AssertExpectations(t)
+	mockNew.AssertExpectations(t)
+}
+
+func TestStorageManager_RegisterFactory(t *testing.T) {
+	ctx := context.Background()
+	mockTenant := new(MockStorageDriver)
+	factoryErr := errors.New("tenant credentials not found")
+
+	manager := newTestManager(new(MockStorageDriver))
+
+	var calls atomic.Int32
+	fail := true
+	err := manager.RegisterFactory("tenant", func(ctx context.Context) (StorageDriver, error) {
+		calls.Add(1)
+		if fail {
+			return nil, factoryErr
+		}
+		return mockTenant, nil
+	})
+	assert.NoError(t, err, "expected no error registering factory")
+	assert.ErrorIs(t, manager.RegisterFactory("tenant", nil), ErrInvalidConfig, "expected error for nil factory")
+	assert.True(t, manager.Has("tenant"), "expected lazy alias to be registered")
+	assert.Equal(t, int32(0), calls.Load(), "expected factory not to run before first use")
+
+	tenant := manager.Storage("tenant")
+
+	_, err = tenant.Exists(ctx, "key")
+	assert.ErrorIs(t, err, factoryErr, "expected factory error to be returned")
+
+	fail = false
+	mockTenant.On("Exists", mock.Anything, "key").Return(true, nil).Times(10)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exists, err := tenant.Exists(ctx, "key")
+			assert.NoError(t, err, "expected no error once factory succeeds")
+			assert.True(t, exists, "expected result from lazily created driver")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), calls.Load(), "expected factory to run once more after the failure, then be cached")
+	mockTenant.AssertExpectations(t)
+}
+
+func TestStorageManager_Unregister(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(new(MockStorageDriver))
+	assert.NoError(t, manager.Register("tenant", new(MockStorageDriver)), "expected no error registering driver")
+
+	tenant := manager.Storage("tenant")
+
+	assert.ErrorIs(t, manager.Unregister("default"), ErrInvalidDefaultStorage, "expected error unregistering default alias")
+	assert.ErrorIs(t, manager.Unregister("missing"), ErrInvalidDefaultStorage, "expected error unregistering unknown alias")
+	assert.NoError(t, manager.Unregister("tenant"), "expected no error unregistering alias")
+	assert.False(t, manager.Has("tenant"), "expected alias to be removed")
+
+	_, err := tenant.Exists(ctx, "key")
+	assert.ErrorIs(t, err, ErrInvalidDefaultStorage, "expected bound manager to fail after unregister")
+}
+
+func TestStorageManager_SetDefault(t *testing.T) {
+	ctx := context.Background()
+	mockDefault := new(MockStorageDriver)
+	mockOther := new(MockStorageDriver)
+
+	manager := newTestManager(mockDefault)
+	assert.NoError(t, manager.Register("other", mockOther), "expected no error registering driver")
+
+	bound := manager.Storage("default")
+
+	assert.ErrorIs(t, manager.SetDefault("missing"), ErrInvalidDefaultStorage, "expected error for unknown alias")
+	assert.NoError(t, manager.SetDefault("other"), "expected no error changing default")
+
+	mockOther.On("Exists", ctx, "key").Return(true, nil).Once()
+	mockDefault.On("Exists", ctx, "key").Return(false, nil).Once()
+
+	exists, err := manager.Exists(ctx, "key")
+	assert.NoError(t, err, "expected no error")
+	assert.True(t, exists, "expected root manager to follow the new default")
+
+	exists, err = bound.Exists(ctx, "key")
+	assert.NoError(t, err, "expected no error")
+	assert.False(t, exists, "expected bound manager to keep its alias")
+
+	assert.NoError(t, manager.Unregister("default"), "expected old default to be removable")
+
+	mockDefault.AssertExpectations(t)
+	mockOther.AssertExpectations(t)
+}
+
+func TestStorageManager_WithConcurrency(t *testing.T) {
+	mockDriver := new(MockStorageDriver)
+
+	tests := []struct {
+		name        string
+		opts        []ManagerOption
+		expectLimit int
+	}{
+		{
+			name:        "should use default concurrency when no option is given",
+			opts:        nil,
+			expectLimit: DefaultConcurrency,
+		},
+		{
+			name:        "should use configured concurrency",
+			opts:        []ManagerOption{WithConcurrency(4)},
+			expectLimit: 4,
+		},
+		{
+			name:        "should fall back to default concurrency when limit is not positive",
+			opts:        []ManagerOption{WithConcurrency(0)},
+			expectLimit: DefaultConcurrency,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, err := NewStorageManager("default", map[string]StorageDriver{"default": mockDriver}, tt.opts...)
+			assert.NoError(t, err, "expected no error creating manager")
+
+			impl := mgr.(*storageManagerImpl)
+			assert.Equal(t, tt.expectLimit, impl.limit(), "expected concurrency limit to match")
+
+			other := mgr.Storage("default").(*storageManagerImpl)
+			assert.Equal(t, tt.expectLimit, other.limit(), "expected Storage to keep the concurrency limit")
+		})
+	}
+}
+
+// concurrencyDriver records the highest number of Delete calls running at the same time.
+type concurrencyDriver struct {
+	MockStorageDriver
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (d *concurrencyDriver) Delete(ctx context.Context, key string) error {
+	n := d.inFlight.Add(1)
+	defer d.inFlight.Add(-1)
+
+	for {
+		peak := d.peak.Load()
+		if n <= peak || d.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func TestStorageManager_DeleteMany_BoundedConcurrency(t *testing.T) {
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	driver := &concurrencyDriver{}
+	manager := newTestManager(driver, WithConcurrency(3))
+
+	err := manager.DeleteMany(context.Background(), keys...)
+	assert.NoError(t, err, "expected no error when delete many succeeds")
+	assert.LessOrEqual(t, driver.peak.Load(), int32(3), "expected at most 3 concurrent deletions")
+
+	driver.peak.Store(0)
+	results := manager.BatchDelete(context.Background(), keys)
+	assert.NoError(t, results.Err(), "expected no error when batch delete succeeds")
+	assert.LessOrEqual(t, driver.peak.Load(), int32(3), "expected at most 3 concurrent batch deletions")
+}
+
+func TestStorageManager_BatchDelete(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"key1", "key2", "key3"}
+	deleteErr := errors.New("delete failed")
+
+	tests := []struct {
+		name         string
+		keys         []string
+		failKey      string
+		expectFailed []string
+	}{
+		{
+			name:         "should delete all keys successfully",
+			keys:         keys,
+			expectFailed: nil,
+		},
+		{
+			name:         "should report failing key and still delete the others",
+			keys:         keys,
+			failKey:      "key2",
+			expectFailed: []string{"key2"},
+		},
+		{
+			name:         "should handle empty keys list",
+			keys:         []string{},
+			expectFailed: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDriver := new(MockStorageDriver)
+			for _, k := range tt.keys {
+				if k == tt.failKey {
+					mockDriver.On("Delete", mock.Anything, k).Return(deleteErr).Once()
+				} else {
+					mockDriver.On("Delete", mock.Anything, k).Return(nil).Once()
+				}
+			}
+
+			manager := newTestManager(mockDriver)
+
+			results := manager.BatchDelete(ctx, tt.keys)
+
+			assert.Len(t, results, len(tt.keys), "expected one result per key")
+			assert.Equal(t, tt.keys, results.Keys(), "expected results in input order")
+
+			if tt.expectFailed != nil {
+				assert.Equal(t, tt.expectFailed, results.Failed().Keys(), "expected failed keys to match")
+				assert.ErrorIs(t, results.Err(), deleteErr, "expected joined error to wrap driver error")
+			} else {
+				assert.Empty(t, results.Failed(), "expected no failed keys")
+				assert.NoError(t, results.Err(), "expected no error")
+			}
+
+			mockDriver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageManager_BatchGetSignedURLs(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"key1", "key2", "key3"}
+	expiry := 5 * time.Minute
+	signErr := errors.New("signed URL failed")
+
+	tests := []struct {
+		name       string
+		failKey    string
+		expectURLs []string
+	}{
+		{
+			name:    "should return signed URLs for all keys",
+			failKey: "",
+			expectURLs: []string{
+				"https://signed.example.com/key1",
+				"https://signed.example.com/key2",
+				"https://signed.example.com/key3",
+			},
+		},
+		{
+			name:    "should keep successful URLs when one key fails",
+			failKey: "key2",
+			expectURLs: []string{
+				"https://signed.example.com/key1",
+				"",
+				"https://signed.example.com/key3",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDriver := new(MockStorageDriver)
+			for _, k := range keys {
+				if k == tt.failKey {
+					mockDriver.On("GetSignedURL", mock.Anything, k, expiry).Return("", signErr).Once()
+				} else {
+					mockDriver.On("GetSignedURL", mock.Anything, k, expiry).Return("https://signed.example.com/"+k, nil).Once()
+				}
+			}
+
+			manager := newTestManager(mockDriver)
+
+			results := manager.BatchGetSignedURLs(ctx, keys, expiry)
+
+			assert.Equal(t, tt.expectURLs, results.URLs(), "expected URLs to match in order")
+			if tt.failKey != "" {
+				assert.Equal(t, []string{tt.failKey}, results.Failed().Keys(), "expected failed key to be reported")
+				assert.ErrorIs(t, results.Err(), signErr, "expected joined error to wrap driver error")
+			} else {
+				assert.NoError(t, results.Err(), "expected no error")
+			}
+
+			mockDriver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageManager_BatchGetURLs(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"key1", "key2", "key3"}
+	urlErr := errors.New("get URL failed")
+
+	tests := []struct {
+		name       string
+		failKey    string
+		expectURLs []string
+	}{
+		{
+			name:    "should return URLs for all keys",
+			failKey: "",
+			expectURLs: []string{
+				"http://example.com/key1",
+				"http://example.com/key2",
+				"http://example.com/key3",
+			},
+		},
+		{
+			name:    "should keep successful URLs when one key fails",
+			failKey: "key3",
+			expectURLs: []string{
+				"http://example.com/key1",
+				"http://example.com/key2",
+				"",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDriver := new(MockStorageDriver)
+			for _, k := range keys {
+				if k == tt.failKey {
+					mockDriver.On("GetURL", mock.Anything, k).Return("", urlErr).Once()
+				} else {
+					mockDriver.On("GetURL", mock.Anything, k).Return("http://example.com/"+k, nil).Once()
+				}
+			}
+
+			manager := newTestManager(mockDriver)
+
+			results := manager.BatchGetURLs(ctx, keys)
+
+			assert.Equal(t, tt.expectURLs, results.URLs(), "expected URLs to match in order")
+			if tt.failKey != "" {
+				assert.Equal(t, []string{tt.failKey}, results.Failed().Keys(), "expected failed key to be reported")
+				assert.ErrorIs(t, results.Err(), urlErr, "expected joined error to wrap driver error")
+			} else {
+				assert.NoError(t, results.Err(), "expected no error")
+			}
+
+			mockDriver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageManager_Capabilities(t *testing.T) {
+	ctx := context.Background()
+
+	reporter := new(MockCapabilityReporter)
+	reporter.On("Capabilities").Return(Capabilities{SignedURL: true, RangeRead: true})
+
+	tests := []struct {
+		name      string
+		manager   StorageManager
+		expected  Capabilities
+		expectErr error
+	}{
+		{
+			name:     "should return capabilities reported by the driver",
+			manager:  newTestManager(reporter),
+			expected: Capabilities{SignedURL: true, RangeRead: true},
+		},
+		{
+			name:     "should infer capabilities of a basic driver",
+			manager:  newTestManager(new(MockStorageDriver)),
+			expected: Capabilities{SignedURL: true, PublicURL: true},
+		},
+		{
+			name:     "should infer listing and bulk delete from implemented interfaces",
+			manager:  newTestManager(new(MockLister)),
+			expected: Capabilities{SignedURL: true, PublicURL: true, List: true, BatchDelete: true},
+		},
+		{
+			name:      "should return error for unknown alias",
+			manager:   newTestManager(new(MockStorageDriver)).Storage("missing"),
+			expectErr: ErrInvalidDefaultStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, err := tt.manager.Capabilities(ctx)
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, tt.expected, caps, "expected capabilities to match")
 			}
 		})
 	}
 }
 
+func TestStorageManager_NotSupportedURLs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not call driver when capability is missing", func(t *testing.T) {
+		reporter := new(MockCapabilityReporter)
+		reporter.On("Capabilities").Return(Capabilities{})
+		manager := newTestManager(reporter)
+
+		_, err := manager.GetURL(ctx, "key")
+		assert.ErrorIs(t, err, ErrNotSupported, "expected not supported for public URL")
+
+		_, err = manager.GetSignedURL(ctx, "key", time.Minute)
+		assert.ErrorIs(t, err, ErrNotSupported, "expected not supported for signed URL")
+
+		results := manager.BatchGetURLs(ctx, []string{"a", "b"})
+		assert.ErrorIs(t, results.Err(), ErrNotSupported, "expected not supported for every key")
+
+		reporter.AssertNotCalled(t, "GetURL", mock.Anything, mock.Anything)
+		reporter.AssertNotCalled(t, "GetSignedURL", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should convert empty URLs into not supported", func(t *testing.T) {
+		mockDriver := new(MockStorageDriver)
+		mockDriver.On("GetURL", ctx, "key").Return("", nil).Once()
+		mockDriver.On("GetSignedURL", ctx, "key", time.Minute).Return("", nil).Once()
+		manager := newTestManager(mockDriver)
+
+		_, err := manager.GetURL(ctx, "key")
+		assert.ErrorIs(t, err, ErrNotSupported, "expected not supported for empty public URL")
+
+		_, err = manager.GetSignedURL(ctx, "key", time.Minute)
+		assert.ErrorIs(t, err, ErrNotSupported, "expected not supported for empty signed URL")
+
+		mockDriver.AssertExpectations(t)
+	})
+}
+
 func TestStorageManager_Delete(t *testing.T) {
 	ctx := context.Background()
 	key := "test-key"
 	mockDriver := new(MockStorageDriver)
 
-	manager := &storageManagerImpl{
-		storageMap:     map[string]StorageDriver{"default": mockDriver},
-		defaultStorage: mockDriver,
-	}
+	manager := newTestManager(mockDriver)
 
 	tests := []struct {
 		name       string
@@ -209,9 +845,7 @@ func TestStorageManager_DeleteMany(t *testing.T) {
 				}
 			}
 
-			manager := &storageManagerImpl{
-				defaultStorage: mockDriver, // direct use, no adapter
-			}
+			manager := newTestManager(mockDriver)
 
 			err := manager.DeleteMany(ctx, tt.keys...)
 
@@ -227,15 +861,180 @@ func TestStorageManager_DeleteMany(t *testing.T) {
 	}
 }
 
+func TestStorageManager_DeleteMany_BatchDeleter(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"key1", "key2", "key3"}
+	deleteErr := errors.New("bulk delete failed")
+
+	tests := []struct {
+		name        string
+		mockResults BatchResults
+		expectErr   bool
+	}{
+		{
+			name: "should use native bulk delete when driver supports it",
+			mockResults: BatchResults{
+				{Key: "key1"}, {Key: "key2"}, {Key: "key3"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "should return error when native bulk delete reports a failed key",
+			mockResults: BatchResults{
+				{Key: "key1"}, {Key: "key2", Err: deleteErr}, {Key: "key3"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDriver := new(MockBatchDeleter)
+			mockDriver.On("DeleteBatch", ctx, keys).Return(tt.mockResults).Twice()
+
+			manager := newTestManager(mockDriver)
+
+			err := manager.DeleteMany(ctx, keys...)
+			results := manager.BatchDelete(ctx, keys)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, deleteErr, "expected DeleteMany to return the failed key error")
+				assert.Equal(t, []string{"key2"}, results.Failed().Keys(), "expected BatchDelete to report the failed key")
+			} else {
+				assert.NoError(t, err, "expected no error when bulk delete succeeds")
+				assert.Empty(t, results.Failed(), "expected no failed keys")
+			}
+
+			mockDriver.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			mockDriver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageManager_DeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	listOpts := ListOptions{Prefix: "users/1/", Recursive: true}
+	objects := []ObjectInfo{
+		{Key: "users/1/a.txt"},
+		{Key: "users/1/b.txt"},
+		{Key: "users/1/c.txt"},
+	}
+	deleteErr := errors.New("delete failed")
+
+	tests := []struct {
+		name           string
+		prefix         string
+		opts           DeletePrefixOptions
+		listErr        error
+		batches        [][]string
+		failKey        string
+		expectReport   DeletePrefixReport
+		expectProgress []DeletePrefixProgress
+		expectErr      error
+	}{
+		{
+			name:    "should delete every listed key in batches",
+			prefix:  "users/1/",
+			opts:    DeletePrefixOptions{BatchSize: 2},
+			batches: [][]string{{"users/1/a.txt", "users/1/b.txt"}, {"users/1/c.txt"}},
+			expectReport: DeletePrefixReport{
+				DeletePrefixProgress: DeletePrefixProgress{Listed: 3, Deleted: 3},
+			},
+			expectProgress: []DeletePrefixProgress{
+				{Listed: 2, Deleted: 2},
+				{Listed: 3, Deleted: 3},
+			},
+		},
+		{
+			name:    "should report failed keys and keep deleting",
+			prefix:  "users/1/",
+			opts:    DeletePrefixOptions{},
+			batches: [][]string{{"users/1/a.txt", "users/1/b.txt", "users/1/c.txt"}},
+			failKey: "users/1/b.txt",
+			expectReport: DeletePrefixReport{
+				DeletePrefixProgress: DeletePrefixProgress{Listed: 3, Deleted: 2, Failed: 1},
+				Errors:               BatchResults{{Key: "users/1/b.txt", Err: deleteErr}},
+			},
+			expectProgress: []DeletePrefixProgress{
+				{Listed: 3, Deleted: 2, Failed: 1},
+			},
+		},
+		{
+			name:   "should only list keys in dry-run mode",
+			prefix: "users/1/",
+			opts:   DeletePrefixOptions{DryRun: true},
+			expectReport: DeletePrefixReport{
+				DeletePrefixProgress: DeletePrefixProgress{Listed: 3},
+				Keys:                 []string{"users/1/a.txt", "users/1/b.txt", "users/1/c.txt"},
+			},
+			expectProgress: []DeletePrefixProgress{
+				{Listed: 3},
+			},
+		},
+		{
+			name:      "should return error when listing fails",
+			prefix:    "users/1/",
+			listErr:   errors.New("list failed"),
+			expectErr: errors.New("list failed"),
+		},
+		{
+			name:      "should reject empty prefix",
+			prefix:    "",
+			expectErr: ErrInvalidKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDriver := new(MockLister)
+			if tt.prefix != "" {
+				listed := objects
+				if tt.listErr != nil {
+					listed = nil
+				}
+				mockDriver.On("List", mock.Anything, listOpts).Return(listed, tt.listErr).Once()
+			}
+
+			for _, batch := range tt.batches {
+				results := make(BatchResults, len(batch))
+				for i, k := range batch {
+					results[i] = BatchResult{Key: k}
+					if k == tt.failKey {
+						results[i].Err = deleteErr
+					}
+				}
+				mockDriver.On("DeleteBatch", mock.Anything, batch).Return(results).Once()
+			}
+
+			manager := newTestManager(mockDriver)
+
+			var progress []DeletePrefixProgress
+			tt.opts.Progress = func(p DeletePrefixProgress) {
+				progress = append(progress, p)
+			}
+
+			report, err := manager.DeletePrefix(ctx, tt.prefix, tt.opts)
+
+			if tt.expectErr != nil {
+				assert.Error(t, err, "expected error")
+				assert.EqualError(t, err, tt.expectErr.Error(), "expected correct error message")
+			} else {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, tt.expectReport, report, "expected report to match")
+				assert.Equal(t, tt.expectProgress, progress, "expected progress after every batch")
+			}
+
+			mockDriver.AssertExpectations(t)
+		})
+	}
+}
+
 func TestStorageManager_Exists(t *testing.T) {
 	ctx := context.Background()
 	key := "test-key"
 	mockDriver := new(MockStorageDriver)
 
-	manager := &storageManagerImpl{
-		storageMap:     map[string]StorageDriver{"default": mockDriver},
-		defaultStorage: mockDriver,
-	}
+	manager := newTestManager(mockDriver)
 
 	tests := []struct {
 		name          string
@@ -302,10 +1101,7 @@ func TestStorageManager_GetSignedURL(t *testing.T) {
 	expectedURL := "https://signed.example.com/test-key"
 	mockDriver := new(MockStorageDriver)
 
-	manager := &storageManagerImpl{
-		storageMap:     map[string]StorageDriver{"default": mockDriver},
-		defaultStorage: mockDriver,
-	}
+	manager := newTestManager(mockDriver)
 
 	tests := []struct {
 		name          string
@@ -421,9 +1217,7 @@ func TestStorageManager_GetSignedURLs(t *testing.T) {
 				}
 			}
 
-			manager := &storageManagerImpl{
-				defaultStorage: mockDriver,
-			}
+			manager := newTestManager(mockDriver)
 
 			urls, err := manager.GetSignedURLs(ctx, tt.keys, tt.expiry)
 
@@ -448,10 +1242,7 @@ func TestStorageManager_GetURL(t *testing.T) {
 	expectedURL := "http://example.com/test-key"
 	mockDriver := new(MockStorageDriver)
 
-	manager := &storageManagerImpl{
-		storageMap:     map[string]StorageDriver{"default": mockDriver},
-		defaultStorage: mockDriver,
-	}
+	manager := newTestManager(mockDriver)
 
 	tests := []struct {
 		name          string
@@ -559,9 +1350,7 @@ func TestStorageManager_GetURLs(t *testing.T) {
 				}
 			}
 
-			manager := &storageManagerImpl{
-				defaultStorage: mockDriver,
-			}
+			manager := newTestManager(mockDriver)
 
 			urls, err := manager.GetURLs(ctx, tt.keys)
 
@@ -580,15 +1369,41 @@ func TestStorageManager_GetURLs(t *testing.T) {
 	}
 }
 
+func TestStorageManager_List(t *testing.T) {
+	ctx := context.Background()
+	opts := ListOptions{Prefix: "users/"}
+
+	t.Run("should list files when driver supports listing", func(t *testing.T) {
+		mockDriver := new(MockLister)
+		mockDriver.On("List", ctx, opts).Return([]ObjectInfo{{Key: "users/a.txt"}, {Key: "users/b/", IsDir: true}}, nil).Once()
+
+		manager := newTestManager(mockDriver)
+
+		var keys []string
+		err := manager.List(ctx, opts, func(obj ObjectInfo) error {
+			keys = append(keys, obj.Key)
+			return nil
+		})
+
+		assert.NoError(t, err, "expected no error listing files")
+		assert.Equal(t, []string{"users/a.txt", "users/b/"}, keys, "expected listed keys to match")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return not supported when driver cannot list", func(t *testing.T) {
+		manager := newTestManager(new(MockStorageDriver))
+
+		err := manager.List(ctx, opts, func(obj ObjectInfo) error { return nil })
+		assert.ErrorIs(t, err, ErrNotSupported, "expected not supported error")
+	})
+}
+
 func TestStorageManager_Missing(t *testing.T) {
 	ctx := context.Background()
 	key := "test-key"
 	mockDriver := new(MockStorageDriver)
 
-	manager := &storageManagerImpl{
-		storageMap:     map[string]StorageDriver{"default": mockDriver},
-		defaultStorage: mockDriver,
-	}
+	manager := newTestManager(mockDriver)
 
 	tests := []struct {
 		name          string
@@ -655,9 +1470,7 @@ func TestStorageManager_Put(t *testing.T) {
 	reader := strings.NewReader(content)
 	mockDriver := new(MockStorageDriver)
 
-	manager := &storageManagerImpl{
-		defaultStorage: mockDriver,
-	}
+	manager := newTestManager(mockDriver)
 
 	tests := []struct {
 		name      string
@@ -708,3 +1521,280 @@ func TestStorageManager_Put(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageManager_SetVisibility(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should delegate to driver implementing VisibilityManager", func(t *testing.T) {
+		mockDriver := new(MockVisibilityManager)
+		mockDriver.On("SetVisibility", ctx, "file.txt", VisibilityPublic).Return(nil).Once()
+
+		err := newTestManager(mockDriver).SetVisibility(ctx, "file.txt", VisibilityPublic)
+
+		assert.NoError(t, err, "expected no error when driver sets visibility")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrNotSupported when driver has no per-file visibility", func(t *testing.T) {
+		err := newTestManager(new(MockStorageDriver)).SetVisibility(ctx, "file.txt", VisibilityPublic)
+
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported")
+	})
+}
+
+func TestStorageManager_URL(t *testing.T) {
+	ctx := context.Background()
+	key := "file.txt"
+
+	tests := []struct {
+		name              string
+		opts              URLOptions
+		fileVisibility    Visibility
+		defaultVisibility Visibility
+		defaultExpiry     time.Duration
+		publicURL         string
+		publicURLErr      error
+		expectPublicURL   bool
+		expectSignedWith  time.Duration
+		expected          string
+		expectedErr       error
+	}{
+		{
+			name:            "should return public URL for public file",
+			fileVisibility:  VisibilityPublic,
+			publicURL:       "https://cdn.example.com/file.txt",
+			expectPublicURL: true,
+			expected:        "https://cdn.example.com/file.txt",
+		},
+		{
+			name:             "should return signed URL with storage default expiry for private file",
+			fileVisibility:   VisibilityPrivate,
+			defaultExpiry:    time.Hour,
+			expectSignedWith: time.Hour,
+			expected:         "https://signed.example.com/file.txt",
+		},
+		{
+			name:              "should use storage default visibility when file has none",
+			defaultVisibility: VisibilityPrivate,
+			expectSignedWith:  DefaultSignedURLExpiry,
+			expected:          "https://signed.example.com/file.txt",
+		},
+		{
+			name:             "should prefer expiry from options",
+			opts:             URLOptions{Expiry: time.Minute},
+			fileVisibility:   VisibilityPrivate,
+			defaultExpiry:    time.Hour,
+			expectSignedWith: time.Minute,
+			expected:         "https://signed.example.com/file.txt",
+		},
+		{
+			name:            "should skip lookup when options force visibility",
+			opts:            URLOptions{Visibility: VisibilityPublic},
+			publicURL:       "https://cdn.example.com/file.txt",
+			expectPublicURL: true,
+			expected:        "https://cdn.example.com/file.txt",
+		},
+		{
+			name:             "should fall back to signed URL when public file has no direct URL",
+			fileVisibility:   VisibilityPublic,
+			publicURL:        "",
+			expectPublicURL:  true,
+			expectSignedWith: DefaultSignedURLExpiry,
+			expected:         "https://signed.example.com/file.txt",
+		},
+		{
+			name:            "should return driver error from public URL",
+			fileVisibility:  VisibilityPublic,
+			publicURLErr:    ErrInternal,
+			expectPublicURL: true,
+			expectedErr:     ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDriver := new(MockVisibilityManager)
+			if tt.opts.Visibility == "" {
+				mockDriver.On("Visibility", ctx, key).Return(tt.fileVisibility, nil).Once()
+			}
+			mockDriver.On("DefaultVisibility").Return(tt.defaultVisibility).Maybe()
+			mockDriver.On("DefaultExpiry").Return(tt.defaultExpiry).Maybe()
+			if tt.expectPublicURL {
+				mockDriver.On("GetURL", ctx, key).Return(tt.publicURL, tt.publicURLErr).Once()
+			}
+			if tt.expectSignedWith != 0 {
+				mockDriver.On("GetSignedURL", ctx, key, tt.expectSignedWith).Return("https://signed.example.com/file.txt", nil).Once()
+			}
+
+			url, err := newTestManager(mockDriver).URL(ctx, key, tt.opts)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected no error when URL is resolved")
+			}
+
+			assert.Equal(t, tt.expected, url, "expected URL to match")
+			mockDriver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageManager_Visibility(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return visibility stored with the file", func(t *testing.T) {
+		mockDriver := new(MockVisibilityManager)
+		mockDriver.On("Visibility", ctx, "file.txt").Return(VisibilityPublic, nil).Once()
+
+		visibility, err := newTestManager(mockDriver).Visibility(ctx, "file.txt")
+
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, VisibilityPublic, visibility, "expected file visibility")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return driver error", func(t *testing.T) {
+		mockDriver := new(MockVisibilityManager)
+		mockDriver.On("Visibility", ctx, "file.txt").Return(Visibility(""), ErrNotFound).Once()
+
+		_, err := newTestManager(mockDriver).Visibility(ctx, "file.txt")
+
+		assert.ErrorIs(t, err, ErrNotFound, "expected ErrNotFound")
+	})
+
+	t.Run("should treat drivers serving public URLs as public", func(t *testing.T) {
+		visibility, err := newTestManager(new(MockStorageDriver)).Visibility(ctx, "file.txt")
+
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, VisibilityPublic, visibility, "expected public visibility")
+	})
+
+	t.Run("should treat drivers without public URLs as private", func(t *testing.T) {
+		mockDriver := new(MockCapabilityReporter)
+		mockDriver.On("Capabilities").Return(Capabilities{SignedURL: true})
+
+		visibility, err := newTestManager(mockDriver).Visibility(ctx, "file.txt")
+
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, VisibilityPrivate, visibility, "expected private visibility")
+	})
+}
+
+func TestStorageManager_GetRange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should delegate to driver implementing RangeReader", func(t *testing.T) {
+		body := io.NopCloser(strings.NewReader("bytes"))
+		mockDriver := new(MockRangeReader)
+		mockDriver.On("GetRange", ctx, "video.mp4", int64(100), int64(5)).Return(body, nil).Once()
+
+		got, err := newTestManager(mockDriver).GetRange(ctx, "video.mp4", 100, 5)
+
+		assert.NoError(t, err, "expected no error reading range")
+		assert.Equal(t, body, got, "expected driver body")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrInvalidRange for negative offset", func(t *testing.T) {
+		_, err := newTestManager(new(MockRangeReader)).GetRange(ctx, "video.mp4", -1, 5)
+
+		assert.ErrorIs(t, err, ErrInvalidRange, "expected ErrInvalidRange")
+	})
+
+	t.Run("should return ErrNotSupported when driver cannot read ranges", func(t *testing.T) {
+		_, err := newTestManager(new(MockStorageDriver)).GetRange(ctx, "video.mp4", 0, 5)
+
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported")
+	})
+}
+
+func TestStorageManager_OpenReaderAt(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should open reader with size from Stat", func(t *testing.T) {
+		mockDriver := new(MockRangeReader)
+		mockDriver.On("Stat", ctx, "archive.zip").Return(ObjectInfo{Key: "archive.zip", Size: 42}, nil).Once()
+
+		r, err := newTestManager(mockDriver).OpenReaderAt(ctx, "archive.zip")
+
+		require.NoError(t, err, "expected no error opening reader")
+		assert.Equal(t, int64(42), r.Size(), "expected size from Stat")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrNotSupported when driver cannot read ranges", func(t *testing.T) {
+		_, err := newTestManager(new(MockStorageDriver)).OpenReaderAt(ctx, "archive.zip")
+
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported")
+	})
+}
+
+func TestStorageManager_Stat(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return driver error", func(t *testing.T) {
+		mockDriver := new(MockRangeReader)
+		mockDriver.On("Stat", ctx, "missing.txt").Return(ObjectInfo{}, ErrNotFound).Once()
+
+		_, err := newTestManager(mockDriver).Stat(ctx, "missing.txt")
+
+		assert.ErrorIs(t, err, ErrNotFound, "expected ErrNotFound")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrNotSupported when driver cannot stat files", func(t *testing.T) {
+		_, err := newTestManager(new(MockStorageDriver)).Stat(ctx, "file.txt")
+
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported")
+	})
+}
+
+func TestStorageManager_MultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	parts := []Part{{Number: 1, ETag: `"a"`, Size: 5}}
+	body := strings.NewReader("hello")
+
+	t.Run("should delegate to driver implementing MultipartUploader", func(t *testing.T) {
+		mockDriver := new(MockMultipartUploader)
+		mockDriver.On("CreateMultipartUpload", ctx, "big.bin").Return("upload-1", nil).Once()
+		mockDriver.On("UploadPart", ctx, "big.bin", "upload-1", 1, body, int64(5)).Return(parts[0], nil).Once()
+		mockDriver.On("CompleteMultipartUpload", ctx, "big.bin", "upload-1", parts).Return(nil).Once()
+		mockDriver.On("AbortMultipartUpload", ctx, "big.bin", "upload-1").Return(nil).Once()
+		manager := newTestManager(mockDriver)
+
+		uploadID, err := manager.CreateMultipartUpload(ctx, "big.bin")
+		assert.NoError(t, err, "expected no error creating upload")
+		assert.Equal(t, "upload-1", uploadID, "expected driver upload ID")
+
+		part, err := manager.UploadPart(ctx, "big.bin", uploadID, 1, body, 5)
+		assert.NoError(t, err, "expected no error uploading part")
+		assert.Equal(t, parts[0], part, "expected driver part")
+
+		assert.NoError(t, manager.CompleteMultipartUpload(ctx, "big.bin", uploadID, parts), "expected no error completing upload")
+		assert.NoError(t, manager.AbortMultipartUpload(ctx, "big.bin", uploadID), "expected no error aborting upload")
+		mockDriver.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrInvalidKey for invalid key", func(t *testing.T) {
+		_, err := newTestManager(new(MockMultipartUploader)).CreateMultipartUpload(ctx, "../big.bin")
+
+		assert.ErrorIs(t, err, ErrInvalidKey, "expected ErrInvalidKey")
+	})
+
+	t.Run("should return ErrNotSupported when driver has no multipart uploads", func(t *testing.T) {
+		manager := newTestManager(new(MockStorageDriver))
+
+		_, err := manager.CreateMultipartUpload(ctx, "big.bin")
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported from CreateMultipartUpload")
+
+		_, err = manager.UploadPart(ctx, "big.bin", "upload-1", 1, body, 5)
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported from UploadPart")
+
+		err = manager.CompleteMultipartUpload(ctx, "big.bin", "upload-1", parts)
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported from CompleteMultipartUpload")
+
+		err = manager.AbortMultipartUpload(ctx, "big.bin", "upload-1")
+		assert.ErrorIs(t, err, ErrNotSupported, "expected ErrNotSupported from AbortMultipartUpload")
+	})
+}
@@ -0,0 +1,8 @@
+package gostorage
+
+// Part is an uploaded part of a multipart upload, needed to complete it.
+type Part struct {
+	Number int    `json:"number"` // 1-based position of the part in the file
+	ETag   string `json:"etag"`   // identifier returned by the storage for the part
+	Size   int64  `json:"size"`   // size of the part in bytes
+}
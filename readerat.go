@@ -0,0 +1,141 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultReadAhead is the minimum number of bytes ObjectReaderAt fetches per request.
+// Small reads, like the many record headers archive/zip parses, are then served from memory.
+const DefaultReadAhead = 64 << 10
+
+// maxReadAhead caps how far the read-ahead window grows during sequential reads.
+const maxReadAhead = 8 << 20
+
+// ObjectReaderAt reads a stored file at arbitrary offsets through ranged requests,
+// so formats with a trailing index can be opened in place:
+//
+//	r, err := manager.OpenReaderAt(ctx, "exports/archive.zip")
+//	zr, err := zip.NewReader(r, r.Size())
+//
+// Every ReadAt fetches at least DefaultReadAhead bytes and keeps the last chunk in memory.
+// Reads continuing where the last chunk ended double the window, so streaming a whole file
+// (e.g. through http.ServeContent) needs few requests.
+// It is safe for concurrent use. The context passed when opening is used for every request.
+type ObjectReaderAt struct {
+	ctx    context.Context
+	reader RangeReader
+	key    string
+	info   ObjectInfo
+
+	mu        sync.Mutex
+	readAhead int64  // current read-ahead window
+	buf       []byte // last read-ahead chunk
+	bufOffset int64  // file offset of buf[0]
+}
+
+// NewObjectReaderAt stats the file once to learn its size and returns a ReaderAt over it.
+func NewObjectReaderAt(ctx context.Context, reader RangeReader, key string) (*ObjectReaderAt, error) {
+	info, err := reader.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectReaderAt{
+		ctx:       ctx,
+		reader:    reader,
+		key:       key,
+		info:      info,
+		readAhead: DefaultReadAhead,
+	}, nil
+}
+
+// Info returns the size, modification time and ETag of the file when it was opened.
+func (r *ObjectReaderAt) Info() ObjectInfo {
+	return r.info
+}
+
+// Size returns the size of the file when it was opened.
+func (r *ObjectReaderAt) Size() int64 {
+	return r.info.Size
+}
+
+// ReadAt implements io.ReaderAt. It returns io.EOF when p extends past the end of the file.
+func (r *ObjectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidRange
+	}
+
+	size := r.info.Size
+	if off >= size {
+		return 0, io.EOF
+	}
+
+	want := min(int64(len(p)), size-off)
+
+	n, readAhead, ok := r.readBuffered(p, off, want)
+	if ok {
+		return n, eofIfShort(n, len(p))
+	}
+
+	data, err := r.fetch(off, min(max(want, readAhead), size-off))
+	n = copy(p, data)
+	if err != nil {
+		return n, err
+	}
+
+	if int64(len(data)) <= readAhead {
+		r.mu.Lock()
+		r.buf, r.bufOffset = data, off
+		r.mu.Unlock()
+	}
+
+	return n, eofIfShort(n, len(p))
+}
+
+// readBuffered copies [off, off+want) from the read-ahead buffer if it holds the whole range.
+// Otherwise it returns the read-ahead window to fetch with, grown if off continues the buffer.
+func (r *ObjectReaderAt) readBuffered(p []byte, off, want int64) (int, int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	end := r.bufOffset + int64(len(r.buf))
+	if off >= r.bufOffset && off+want <= end {
+		return copy(p, r.buf[off-r.bufOffset:]), 0, true
+	}
+
+	if len(r.buf) > 0 && off == end {
+		r.readAhead = min(r.readAhead*2, maxReadAhead)
+	}
+
+	return 0, r.readAhead, false
+}
+
+// fetch downloads length bytes starting at off.
+// A file that shrank since it was opened yields io.ErrUnexpectedEOF.
+func (r *ObjectReaderAt) fetch(off, length int64) ([]byte, error) {
+	body, err := r.reader.GetRange(r.ctx, r.key, off, length)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data := make([]byte, length)
+	n, err := io.ReadFull(body, data)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return data[:n], err
+}
+
+// eofIfShort returns io.EOF when fewer bytes than requested could be read.
+func eofIfShort(n, requested int) error {
+	if n < requested {
+		return io.EOF
+	}
+
+	return nil
+}
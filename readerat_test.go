@@ -0,0 +1,128 @@
+package gostorage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRangeReader serves a single in-memory file and records the ranges requested from it.
+type memoryRangeReader struct {
+	MockStorageDriver
+	data   []byte
+	ranges [][2]int64
+}
+
+func (r *memoryRangeReader) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	r.ranges = append(r.ranges, [2]int64{offset, length})
+	end := min(offset+length, int64(len(r.data)))
+	return io.NopCloser(bytes.NewReader(r.data[offset:end])), nil
+}
+
+func (r *memoryRangeReader) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	return ObjectInfo{Key: key, Size: int64(len(r.data))}, nil
+}
+
+func TestObjectReaderAt_ReadAt(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("0123456789"), DefaultReadAhead/5) // two read-ahead chunks
+
+	tests := []struct {
+		name        string
+		offset      int64
+		length      int
+		expected    []byte
+		expectedErr error
+	}{
+		{
+			name:     "should read from the start",
+			offset:   0,
+			length:   10,
+			expected: data[:10],
+		},
+		{
+			name:     "should read across read-ahead chunks",
+			offset:   DefaultReadAhead - 5,
+			length:   10,
+			expected: data[DefaultReadAhead-5 : DefaultReadAhead+5],
+		},
+		{
+			name:     "should read more than the read-ahead size",
+			offset:   3,
+			length:   DefaultReadAhead + 10,
+			expected: data[3 : DefaultReadAhead+13],
+		},
+		{
+			name:        "should return io.EOF with partial data at the end",
+			offset:      int64(len(data)) - 4,
+			length:      10,
+			expected:    data[len(data)-4:],
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "should return io.EOF past the end",
+			offset:      int64(len(data)),
+			length:      10,
+			expected:    []byte{},
+			expectedErr: io.EOF,
+		},
+		{
+			name:        "should return ErrInvalidRange for negative offset",
+			offset:      -1,
+			length:      10,
+			expected:    []byte{},
+			expectedErr: ErrInvalidRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewObjectReaderAt(ctx, &memoryRangeReader{data: data}, "file.bin")
+			require.NoError(t, err, "expected no error opening reader")
+
+			p := make([]byte, tt.length)
+			n, err := r.ReadAt(p, tt.offset)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "expected error to match")
+			} else {
+				assert.NoError(t, err, "expected no error reading range")
+			}
+
+			assert.Equal(t, tt.expected, p[:n], "expected bytes read to match")
+		})
+	}
+}
+
+func TestObjectReaderAt_ReadAhead(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("x"), 3*DefaultReadAhead)
+	driver := &memoryRangeReader{data: data}
+
+	r, err := NewObjectReaderAt(ctx, driver, "file.bin")
+	require.NoError(t, err, "expected no error opening reader")
+	assert.Equal(t, int64(len(data)), r.Size(), "expected size from Stat")
+
+	p := make([]byte, 16)
+	for off := int64(0); off < DefaultReadAhead; off += 16 {
+		_, err := r.ReadAt(p, off)
+		require.NoError(t, err, "expected no error reading small chunk")
+	}
+
+	assert.Equal(t, [][2]int64{{0, DefaultReadAhead}}, driver.ranges, "expected small reads to share one read-ahead request")
+}
+
+func TestNewObjectReaderAt(t *testing.T) {
+	driver := new(MockRangeReader)
+	driver.On("Stat", mock.Anything, "missing.zip").Return(ObjectInfo{}, ErrNotFound)
+
+	r, err := NewObjectReaderAt(context.Background(), driver, "missing.zip")
+
+	assert.ErrorIs(t, err, ErrNotFound, "expected ErrNotFound from Stat")
+	assert.Nil(t, r, "expected no reader")
+}
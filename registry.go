@@ -0,0 +1,142 @@
+package gostorage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DriverFactory creates a storage driver on demand.
+// It is called the first time an alias registered with RegisterFactory is used,
+// and again on the next use if it returned an error.
+type DriverFactory func(ctx context.Context) (StorageDriver, error)
+
+// registryEntry holds either a ready driver or a factory that creates it lazily.
+type registryEntry struct {
+	mu      sync.Mutex
+	driver  StorageDriver
+	factory DriverFactory
+}
+
+// get returns the entry's driver, creating it through the factory on first use.
+// Concurrent callers wait for a single factory call instead of creating duplicates.
+func (e *registryEntry) get(ctx context.Context) (StorageDriver, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.driver != nil {
+		return e.driver, nil
+	}
+
+	driver, err := e.factory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver == nil {
+		return nil, ErrInvalidConfig
+	}
+
+	e.driver = driver
+	return driver, nil
+}
+
+// registry is the set of storages shared by a StorageManager and every manager derived from it
+// with Storage, so runtime registrations are visible to all of them.
+type registry struct {
+	mu           sync.RWMutex
+	entries      map[string]*registryEntry
+	defaultAlias string
+}
+
+// newRegistry creates a registry from a fixed set of drivers.
+// The map is copied so later changes by the caller do not race with the manager.
+func newRegistry(defaultAlias string, storage map[string]StorageDriver) *registry {
+	entries := make(map[string]*registryEntry, len(storage))
+	for alias, driver := range storage {
+		entries[alias] = &registryEntry{driver: driver}
+	}
+
+	return &registry{
+		entries:      entries,
+		defaultAlias: defaultAlias,
+	}
+}
+
+// resolve returns the driver registered under alias, or the default alias when alias is empty.
+func (r *registry) resolve(ctx context.Context, alias string) (StorageDriver, error) {
+	r.mu.RLock()
+	if alias == "" {
+		alias = r.defaultAlias
+	}
+	entry, exists := r.entries[alias]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: unknown alias %q", ErrInvalidDefaultStorage, alias)
+	}
+
+	return entry.get(ctx)
+}
+
+// set registers or replaces the entry for alias.
+func (r *registry) set(alias string, entry *registryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[alias] = entry
+}
+
+// remove unregisters alias. The default alias cannot be removed.
+func (r *registry) remove(alias string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if alias == r.defaultAlias {
+		return fmt.Errorf("%w: cannot unregister default alias %q", ErrInvalidDefaultStorage, alias)
+	}
+
+	if _, exists := r.entries[alias]; !exists {
+		return fmt.Errorf("%w: unknown alias %q", ErrInvalidDefaultStorage, alias)
+	}
+
+	delete(r.entries, alias)
+	return nil
+}
+
+// setDefault changes the alias used by managers that follow the default storage.
+func (r *registry) setDefault(alias string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.entries[alias]; !exists {
+		return fmt.Errorf("%w: unknown alias %q", ErrInvalidDefaultStorage, alias)
+	}
+
+	r.defaultAlias = alias
+	return nil
+}
+
+// has reports whether alias is registered, either as a driver or a factory.
+func (r *registry) has(alias string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.entries[alias]
+	return exists
+}
+
+// aliases returns all registered aliases, sorted alphabetically.
+func (r *registry) aliases() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	aliases := make([]string, 0, len(r.entries))
+	for alias := range r.entries {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	return aliases
+}
@@ -0,0 +1,286 @@
+package s3gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+const (
+	// maxListKeys is the page size limit of S3 listings.
+	maxListKeys = 1000
+
+	// timeFormat is the timestamp format of S3 XML documents.
+	timeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+var (
+	listV1Params = []string{"prefix", "delimiter", "marker", "max-keys", "encoding-type"}
+	listV2Params = []string{"list-type", "prefix", "delimiter", "continuation-token", "start-after", "max-keys", "encoding-type", "fetch-owner"}
+
+	// errPageFull stops a listing once a page is complete.
+	errPageFull = errors.New("s3gateway: page full")
+)
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+var gatewayOwner = owner{ID: "go-storage", DisplayName: "go-storage"}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Owner   owner    `xml:"Owner"`
+	Buckets []bucket `xml:"Buckets>Bucket"`
+}
+
+type bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type locationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	Name        string   `xml:"Name"`
+	Prefix      string   `xml:"Prefix"`
+	Delimiter   string   `xml:"Delimiter,omitempty"`
+	MaxKeys     int      `xml:"MaxKeys"`
+	IsTruncated bool     `xml:"IsTruncated"`
+
+	EncodingType string `xml:"EncodingType,omitempty"`
+
+	// ListObjects (V1)
+	Marker     *string `xml:"Marker"`
+	NextMarker string  `xml:"NextMarker,omitempty"`
+
+	// ListObjectsV2
+	KeyCount              *int   `xml:"KeyCount"`
+	ContinuationToken     string `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string `xml:"StartAfter,omitempty"`
+
+	Contents       []object       `xml:"Contents"`
+	CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag,omitempty"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+	Owner        *owner `xml:"Owner,omitempty"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// listParams are the parameters shared by both listing versions.
+type listParams struct {
+	prefix     string
+	delimiter  string
+	after      string // list entries after this key or prefix
+	maxKeys    int
+	fetchOwner bool
+	encode     func(string) string
+}
+
+// listPage is one page of a listing.
+type listPage struct {
+	contents  []object
+	prefixes  []commonPrefix
+	truncated bool
+	last      string // last key or prefix of the page
+}
+
+// listBuckets answers ListBuckets with the aliases of the manager.
+func (h *Handler) listBuckets(w http.ResponseWriter) {
+	result := listAllMyBucketsResult{Xmlns: xmlNamespace, Owner: gatewayOwner}
+	for _, alias := range h.manager.Aliases() {
+		result.Buckets = append(result.Buckets, bucket{Name: alias, CreationDate: formatTime(h.created)})
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+// listObjectsV1 answers ListObjects, paginated by marker.
+func (h *Handler) listObjectsV1(w http.ResponseWriter, r *http.Request, storage gostorage.StorageManager, name string) {
+	query := r.URL.Query()
+	params, aerr := parseListParams(query)
+	if aerr != nil {
+		writeError(w, r, aerr)
+		return
+	}
+	params.after = query.Get("marker")
+	params.fetchOwner = true
+
+	page, err := list(r.Context(), storage, params)
+	if err != nil {
+		writeError(w, r, storageError(err, name, params.prefix))
+		return
+	}
+
+	marker := params.encode(params.after)
+	result := h.listResult(name, query, params, page)
+	result.Marker = &marker
+	if page.truncated {
+		result.NextMarker = params.encode(page.last)
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+// listObjectsV2 answers ListObjectsV2, paginated by opaque continuation tokens.
+func (h *Handler) listObjectsV2(w http.ResponseWriter, r *http.Request, storage gostorage.StorageManager, name string) {
+	query := r.URL.Query()
+	params, aerr := parseListParams(query)
+	if aerr != nil {
+		writeError(w, r, aerr)
+		return
+	}
+	params.after = query.Get("start-after")
+	params.fetchOwner = query.Get("fetch-owner") == "true"
+
+	token := query.Get("continuation-token")
+	if token != "" {
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			writeError(w, r, errInvalidArgument)
+			return
+		}
+		params.after = string(after)
+	}
+
+	page, err := list(r.Context(), storage, params)
+	if err != nil {
+		writeError(w, r, storageError(err, name, params.prefix))
+		return
+	}
+
+	count := len(page.contents) + len(page.prefixes)
+	result := h.listResult(name, query, params, page)
+	result.KeyCount = &count
+	result.ContinuationToken = token
+	result.StartAfter = params.encode(query.Get("start-after"))
+	if page.truncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(page.last))
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+// listResult fills the fields common to both listing versions.
+func (h *Handler) listResult(name string, query url.Values, params listParams, page listPage) listBucketResult {
+	return listBucketResult{
+		Xmlns:          xmlNamespace,
+		Name:           name,
+		Prefix:         params.encode(params.prefix),
+		Delimiter:      params.encode(params.delimiter),
+		MaxKeys:        params.maxKeys,
+		IsTruncated:    page.truncated,
+		EncodingType:   query.Get("encoding-type"),
+		Contents:       page.contents,
+		CommonPrefixes: page.prefixes,
+	}
+}
+
+// parseListParams reads prefix, delimiter, max-keys and encoding-type.
+func parseListParams(query url.Values) (listParams, *apiError) {
+	params := listParams{
+		prefix:    query.Get("prefix"),
+		delimiter: query.Get("delimiter"),
+		maxKeys:   maxListKeys,
+		encode:    func(s string) string { return s },
+	}
+
+	if value := query.Get("max-keys"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return params, errInvalidArgument
+		}
+		params.maxKeys = min(n, maxListKeys)
+	}
+
+	switch query.Get("encoding-type") {
+	case "":
+	case "url":
+		params.encode = func(s string) string { return strings.ReplaceAll(url.QueryEscape(s), "%2F", "/") }
+	default:
+		return params, errInvalidArgument
+	}
+
+	return params, nil
+}
+
+// list reads one page of keys and common prefixes in lexical order. A "/" delimiter maps to a
+// non-recursive listing of the storage; other delimiters group the keys of a recursive listing.
+func list(ctx context.Context, storage gostorage.StorageManager, params listParams) (listPage, error) {
+	var page listPage
+
+	opts := gostorage.ListOptions{Prefix: params.prefix, Recursive: params.delimiter != "/"}
+	err := storage.List(ctx, opts, func(obj gostorage.ObjectInfo) error {
+		name, isPrefix := obj.Key, obj.IsDir
+		if !isPrefix && params.delimiter != "" {
+			rest := strings.TrimPrefix(name, params.prefix)
+			if i := strings.Index(rest, params.delimiter); i >= 0 {
+				name, isPrefix = params.prefix+rest[:i+len(params.delimiter)], true
+			}
+		}
+
+		if name <= params.after || (isPrefix && name == page.last) {
+			return nil
+		}
+
+		if len(page.contents)+len(page.prefixes) == params.maxKeys {
+			page.truncated = true
+			return errPageFull
+		}
+		page.last = name
+
+		if isPrefix {
+			page.prefixes = append(page.prefixes, commonPrefix{Prefix: params.encode(name)})
+			return nil
+		}
+
+		entry := object{
+			Key:          params.encode(name),
+			LastModified: formatTime(obj.LastModified),
+			ETag:         obj.ETag,
+			Size:         obj.Size,
+			StorageClass: "STANDARD",
+		}
+		if params.fetchOwner {
+			entry.Owner = &gatewayOwner
+		}
+		page.contents = append(page.contents, entry)
+
+		return nil
+	})
+	if errors.Is(err, errPageFull) {
+		err = nil
+	}
+
+	return page, err
+}
+
+// formatTime formats t for S3 XML documents.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
@@ -0,0 +1,94 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// apiError is an S3 error response.
+type apiError struct {
+	Code    string
+	Message string
+	status  int
+}
+
+func (e *apiError) Error() string {
+	return "s3gateway: " + e.Code + ": " + e.Message
+}
+
+var (
+	errAccessDenied           = &apiError{"AccessDenied", "Access Denied", http.StatusForbidden}
+	errAuthorizationMalformed = &apiError{"AuthorizationHeaderMalformed", "The authorization header is malformed", http.StatusBadRequest}
+	errBadDigest              = &apiError{"BadDigest", "The Content-MD5 you specified did not match what we received", http.StatusBadRequest}
+	errContentSHA256Mismatch  = &apiError{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed", http.StatusBadRequest}
+	errExpiredRequest         = &apiError{"AccessDenied", "Request has expired", http.StatusForbidden}
+	errIncompleteBody         = &apiError{"IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header", http.StatusBadRequest}
+	errInternalError          = &apiError{"InternalError", "We encountered an internal error, please try again", http.StatusInternalServerError}
+	errInvalidAccessKeyID     = &apiError{"InvalidAccessKeyId", "The AWS access key ID you provided does not exist in our records", http.StatusForbidden}
+	errInvalidArgument        = &apiError{"InvalidArgument", "Invalid argument", http.StatusBadRequest}
+	errInvalidChunk           = &apiError{"InvalidRequest", "The aws-chunked request body is malformed", http.StatusBadRequest}
+	errInvalidCopySource      = &apiError{"InvalidArgument", "Copy Source must mention the source bucket and key: sourcebucket/sourcekey", http.StatusBadRequest}
+	errInvalidDigest          = &apiError{"InvalidDigest", "The Content-MD5 you specified is not valid", http.StatusBadRequest}
+	errInvalidKey             = &apiError{"InvalidArgument", "The object key is not valid for this storage", http.StatusBadRequest}
+	errMethodNotAllowed       = &apiError{"MethodNotAllowed", "The specified method is not allowed against this resource", http.StatusMethodNotAllowed}
+	errMissingContentSHA256   = &apiError{"InvalidRequest", "Missing required header for this request: x-amz-content-sha256", http.StatusBadRequest}
+	errNoSuchBucket           = &apiError{"NoSuchBucket", "The specified bucket does not exist", http.StatusNotFound}
+	errNoSuchKey              = &apiError{"NoSuchKey", "The specified key does not exist", http.StatusNotFound}
+	errNotImplemented         = &apiError{"NotImplemented", "A header or query parameter you provided implies functionality that is not implemented", http.StatusNotImplemented}
+	errRequestTimeTooSkewed   = &apiError{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large", http.StatusForbidden}
+	errSignatureDoesNotMatch  = &apiError{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided", http.StatusForbidden}
+	errUnsignedHeaders        = &apiError{"AccessDenied", "There were headers present in the request which were not signed", http.StatusForbidden}
+)
+
+// errorResponse is the XML body of an S3 error.
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// writeError sends aerr as an S3 XML error.
+func writeError(w http.ResponseWriter, r *http.Request, aerr *apiError) {
+	writeXML(w, aerr.status, errorResponse{
+		Code:      aerr.Code,
+		Message:   aerr.Message,
+		Resource:  r.URL.Path,
+		RequestID: w.Header().Get("X-Amz-Request-Id"),
+	})
+}
+
+// storageError maps a StorageManager error to the S3 error reported for it.
+func storageError(err error, bucket, key string) *apiError {
+	var aerr *apiError
+
+	switch {
+	case errors.As(err, &aerr):
+		return aerr
+	case errors.Is(err, gostorage.ErrNotFound):
+		return errNoSuchKey
+	case errors.Is(err, gostorage.ErrInvalidKey):
+		return errInvalidKey
+	case errors.Is(err, gostorage.ErrNotSupported):
+		return errNotImplemented
+	default:
+		log.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("failed to serve S3 request")
+		return errInternalError
+	}
+}
+
+// writeXML sends v as an XML document with the given status.
+func writeXML(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("failed to write S3 response")
+	}
+}
@@ -0,0 +1,184 @@
+// Package s3gateway serves a StorageManager through the S3 REST API, so tools such as
+// the aws cli or rclone can work with any driver, e.g. the local disk:
+//
+//	manager, _ := gostorage.NewFromConfig(cfg)
+//	http.ListenAndServe(":9000", s3gateway.New(manager,
+//		s3gateway.WithCredentials("AKIAGATEWAY", secret),
+//	))
+//
+//	aws --endpoint-url http://localhost:9000 s3 ls s3://media/
+//
+// Every alias of the manager appears as a bucket. Requests are addressed path-style
+// ("/<bucket>/<key>", e.g. rclone's force_path_style or the aws cli's
+// addressing_style = path) and must carry a valid Signature Version 4, either in the
+// Authorization header or as a presigned URL. Any region is accepted.
+//
+// Supported operations are ListBuckets, HeadBucket, GetBucketLocation, ListObjects (V1 and V2),
+// GetObject, HeadObject, PutObject, CopyObject and DeleteObject. Everything else, including
+// multipart uploads, is answered with NotImplemented; lower the aws cli's
+// multipart_threshold to avoid them for large files.
+package s3gateway
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// xmlNamespace is the namespace of S3 response documents.
+const xmlNamespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// Handler is an http.Handler implementing a subset of the S3 API on top of a StorageManager.
+// It must be served at the root of its host: the signed request path has to be the one it sees.
+type Handler struct {
+	manager     gostorage.StorageManager
+	credentials map[string]string
+	created     time.Time
+	now         func() time.Time
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithCredentials accepts requests signed with the given access key pair.
+// Can be repeated for several keys. Without credentials, every request is denied.
+func WithCredentials(accessKeyID, secretAccessKey string) Option {
+	return func(h *Handler) {
+		h.credentials[accessKeyID] = secretAccessKey
+	}
+}
+
+// New returns a Handler exposing every storage of manager as a bucket.
+func New(manager gostorage.StorageManager, opts ...Option) *Handler {
+	h := &Handler{
+		manager:     manager,
+		credentials: make(map[string]string),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.created = h.now().UTC()
+
+	return h
+}
+
+// ServeHTTP authenticates the request and dispatches it to the matching S3 operation.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Amz-Request-Id", newRequestID())
+
+	sig, aerr := h.verify(r)
+	if aerr != nil {
+		writeError(w, r, aerr)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	if bucket == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, r, errMethodNotAllowed)
+			return
+		}
+
+		h.listBuckets(w)
+		return
+	}
+
+	storage, err := h.manager.StorageE(bucket)
+	if err != nil {
+		writeError(w, r, errNoSuchBucket)
+		return
+	}
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodHead && !hasSubresource(query):
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && query.Has("location") && !hasSubresource(query, "location"):
+			writeXML(w, http.StatusOK, locationConstraint{Xmlns: xmlNamespace})
+		case r.Method == http.MethodGet && query.Get("list-type") == "2" && !hasSubresource(query, listV2Params...):
+			h.listObjectsV2(w, r, storage, bucket)
+		case r.Method == http.MethodGet && !hasSubresource(query, listV1Params...):
+			h.listObjectsV1(w, r, storage, bucket)
+		default:
+			writeError(w, r, errNotImplemented)
+		}
+		return
+	}
+
+	if gostorage.ValidateKey(key) != nil {
+		writeError(w, r, errInvalidKey)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if hasSubresource(query, responseOverrideParams...) {
+			writeError(w, r, errNotImplemented)
+			return
+		}
+		h.getObject(w, r, storage, bucket, key)
+
+	case http.MethodPut:
+		if hasSubresource(query) {
+			writeError(w, r, errNotImplemented)
+			return
+		}
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			h.copyObject(w, r, storage, bucket, key)
+			return
+		}
+		h.putObject(w, r, sig, storage, bucket, key)
+
+	case http.MethodDelete:
+		if hasSubresource(query) {
+			writeError(w, r, errNotImplemented)
+			return
+		}
+		h.deleteObject(w, r, storage, bucket, key)
+
+	case http.MethodPost:
+		writeError(w, r, errNotImplemented)
+
+	default:
+		writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// hasSubresource reports whether query selects something other than the plain resource,
+// e.g. "?acl" or "?uploads", ignoring allowed parameters, presigned URL parameters and
+// the "x-id" operation name added by the AWS SDKs.
+func hasSubresource(query url.Values, allowed ...string) bool {
+	for name := range query {
+		if strings.HasPrefix(name, "X-Amz-") || name == "x-id" {
+			continue
+		}
+
+		known := false
+		for _, a := range allowed {
+			if name == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newRequestID returns a random ID for the X-Amz-Request-Id header.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
@@ -0,0 +1,406 @@
+package s3gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAccessKeyID = "AKIAGATEWAYTEST"
+	testSecretKey   = "gateway-secret"
+)
+
+// newTestGateway serves a gateway over two local disks, "media" and "backups".
+func newTestGateway(t *testing.T) (*Handler, gostorage.StorageManager, *httptest.Server) {
+	t.Helper()
+
+	drivers := map[string]gostorage.StorageDriver{}
+	for _, alias := range []string{"media", "backups"} {
+		driver, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir()})
+		require.NoError(t, err, "expected no error creating disk storage")
+		drivers[alias] = driver
+	}
+
+	manager, err := gostorage.NewStorageManager("media", drivers)
+	require.NoError(t, err, "expected no error creating manager")
+
+	h := New(manager, WithCredentials(testAccessKeyID, testSecretKey))
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	return h, manager, server
+}
+
+// newClient returns an S3 client for server signing with the given secret.
+func newClient(server *httptest.Server, accessKeyID, secret string) *s3.Client {
+	return s3.New(s3.Options{
+		Region:       "eu-central-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider(accessKeyID, secret, ""),
+	})
+}
+
+// errorCode returns the S3 error code of err.
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func TestHandler_ListBuckets(t *testing.T) {
+	_, _, server := newTestGateway(t)
+	client := newClient(server, testAccessKeyID, testSecretKey)
+
+	out, err := client.ListBuckets(context.Background(), &s3.ListBucketsInput{})
+	require.NoError(t, err, "expected no error listing buckets")
+
+	var names []string
+	for _, b := range out.Buckets {
+		names = append(names, aws.ToString(b.Name))
+	}
+	assert.Equal(t, []string{"backups", "media"}, names, "expected every alias as a bucket")
+
+	_, err = client.HeadBucket(context.Background(), &s3.HeadBucketInput{Bucket: aws.String("media")})
+	assert.NoError(t, err, "expected existing bucket")
+
+	_, err = client.HeadBucket(context.Background(), &s3.HeadBucketInput{Bucket: aws.String("missing")})
+	assert.Error(t, err, "expected unknown bucket to fail")
+}
+
+func TestHandler_Objects(t *testing.T) {
+	_, manager, server := newTestGateway(t)
+	client := newClient(server, testAccessKeyID, testSecretKey)
+	ctx := context.Background()
+
+	content := []byte("hello from the gateway")
+	sum := md5.Sum(content)
+
+	put, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("media"),
+		Key:    aws.String("docs/hello_world.txt"),
+		Body:   bytes.NewReader(content),
+	})
+	require.NoError(t, err, "expected no error putting object")
+	assert.Equal(t, `"`+hex.EncodeToString(sum[:])+`"`, aws.ToString(put.ETag), "expected MD5 ETag")
+
+	exists, err := manager.Exists(ctx, "docs/hello_world.txt")
+	require.NoError(t, err, "expected no error checking storage")
+	assert.True(t, exists, "expected object to be stored in the media storage")
+
+	get, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("media"), Key: aws.String("docs/hello_world.txt")})
+	require.NoError(t, err, "expected no error getting object")
+	body, _ := io.ReadAll(get.Body)
+	get.Body.Close()
+	assert.Equal(t, content, body, "expected object content")
+	assert.Equal(t, "text/plain; charset=utf-8", aws.ToString(get.ContentType), "expected content type from extension")
+
+	ranged, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("media"), Key: aws.String("docs/hello_world.txt"), Range: aws.String("bytes=6-9")})
+	require.NoError(t, err, "expected no error getting range")
+	body, _ = io.ReadAll(ranged.Body)
+	ranged.Body.Close()
+	assert.Equal(t, "from", string(body), "expected requested range")
+
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("media"), Key: aws.String("docs/hello_world.txt")})
+	require.NoError(t, err, "expected no error heading object")
+	assert.Equal(t, int64(len(content)), aws.ToInt64(head.ContentLength), "expected object size")
+
+	copied, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String("backups"),
+		Key:        aws.String("copy.txt"),
+		CopySource: aws.String("media/docs%2Fhello_world.txt"),
+	})
+	require.NoError(t, err, "expected no error copying object")
+	assert.Equal(t, aws.ToString(put.ETag), aws.ToString(copied.CopyObjectResult.ETag), "expected copy to have the same ETag")
+
+	data := readObject(t, manager.Storage("backups"), "copy.txt")
+	assert.Equal(t, content, data, "expected copy in the backups storage")
+
+	_, err = client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String("media"), Key: aws.String("docs/hello_world.txt")})
+	require.NoError(t, err, "expected no error deleting object")
+
+	_, err = client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String("media"), Key: aws.String("docs/hello_world.txt")})
+	assert.NoError(t, err, "expected deleting a missing object to succeed")
+
+	_, err = client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("media"), Key: aws.String("docs/hello_world.txt")})
+	var noSuchKey *types.NoSuchKey
+	assert.ErrorAs(t, err, &noSuchKey, "expected NoSuchKey after delete")
+
+	_, err = client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("missing"), Key: aws.String("a.txt")})
+	assert.Equal(t, "NoSuchBucket", errorCode(err), "expected NoSuchBucket for unknown alias")
+
+	_, err = client.CopyObject(ctx, &s3.CopyObjectInput{Bucket: aws.String("media"), Key: aws.String("b.txt"), CopySource: aws.String("media/missing.txt")})
+	assert.Equal(t, "NoSuchKey", errorCode(err), "expected NoSuchKey for missing copy source")
+}
+
+func TestHandler_ListObjectsV2(t *testing.T) {
+	_, manager, server := newTestGateway(t)
+	client := newClient(server, testAccessKeyID, testSecretKey)
+	ctx := context.Background()
+
+	for _, key := range []string{"a.txt", "photos/2023/x.jpg", "photos/2024/y.jpg", "photos/cover.jpg", "photos/z.jpg", "z.txt"} {
+		_, err := manager.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err, "expected no error seeding %s", key)
+	}
+
+	tests := []struct {
+		name             string
+		input            s3.ListObjectsV2Input
+		expectedKeys     []string
+		expectedPrefixes []string
+	}{
+		{
+			name:         "should list every key recursively",
+			input:        s3.ListObjectsV2Input{},
+			expectedKeys: []string{"a.txt", "photos/2023/x.jpg", "photos/2024/y.jpg", "photos/cover.jpg", "photos/z.jpg", "z.txt"},
+		},
+		{
+			name:             "should group keys by slash delimiter",
+			input:            s3.ListObjectsV2Input{Delimiter: aws.String("/")},
+			expectedKeys:     []string{"a.txt", "z.txt"},
+			expectedPrefixes: []string{"photos/"},
+		},
+		{
+			name:             "should list a directory",
+			input:            s3.ListObjectsV2Input{Prefix: aws.String("photos/"), Delimiter: aws.String("/")},
+			expectedKeys:     []string{"photos/cover.jpg", "photos/z.jpg"},
+			expectedPrefixes: []string{"photos/2023/", "photos/2024/"},
+		},
+		{
+			name:             "should group keys by other delimiters",
+			input:            s3.ListObjectsV2Input{Prefix: aws.String("photos/"), Delimiter: aws.String("2")},
+			expectedKeys:     []string{"photos/cover.jpg", "photos/z.jpg"},
+			expectedPrefixes: []string{"photos/2"},
+		},
+		{
+			name:         "should start after a key",
+			input:        s3.ListObjectsV2Input{StartAfter: aws.String("photos/cover.jpg")},
+			expectedKeys: []string{"photos/z.jpg", "z.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			input.Bucket = aws.String("media")
+			input.MaxKeys = aws.Int32(2)
+
+			var keys, prefixes []string
+			pages := 0
+			paginator := s3.NewListObjectsV2Paginator(client, &input)
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(ctx)
+				require.NoError(t, err, "expected no error listing page")
+				pages++
+
+				for _, obj := range page.Contents {
+					keys = append(keys, aws.ToString(obj.Key))
+				}
+				for _, p := range page.CommonPrefixes {
+					prefixes = append(prefixes, aws.ToString(p.Prefix))
+				}
+			}
+
+			assert.Equal(t, tt.expectedKeys, keys, "expected keys to match")
+			assert.Equal(t, tt.expectedPrefixes, prefixes, "expected common prefixes to match")
+			assert.Equal(t, (len(keys)+len(prefixes)+1)/2, pages, "expected pages of two entries")
+		})
+	}
+}
+
+func TestHandler_ListObjectsV1(t *testing.T) {
+	_, manager, server := newTestGateway(t)
+	client := newClient(server, testAccessKeyID, testSecretKey)
+	ctx := context.Background()
+
+	for _, key := range []string{"a.txt", "b.txt", "c/d.txt"} {
+		_, err := manager.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err, "expected no error seeding %s", key)
+	}
+
+	out, err := client.ListObjects(ctx, &s3.ListObjectsInput{Bucket: aws.String("media"), Delimiter: aws.String("/"), MaxKeys: aws.Int32(2)})
+	require.NoError(t, err, "expected no error listing objects")
+	assert.True(t, aws.ToBool(out.IsTruncated), "expected truncated listing")
+	assert.Equal(t, "b.txt", aws.ToString(out.NextMarker), "expected next marker")
+	require.Len(t, out.Contents, 2, "expected two keys")
+
+	out, err = client.ListObjects(ctx, &s3.ListObjectsInput{Bucket: aws.String("media"), Delimiter: aws.String("/"), Marker: out.NextMarker})
+	require.NoError(t, err, "expected no error listing next page")
+	assert.False(t, aws.ToBool(out.IsTruncated), "expected last page")
+	require.Len(t, out.CommonPrefixes, 1, "expected one common prefix")
+	assert.Equal(t, "c/", aws.ToString(out.CommonPrefixes[0].Prefix), "expected directory as common prefix")
+}
+
+func TestHandler_Authentication(t *testing.T) {
+	h, manager, server := newTestGateway(t)
+	ctx := context.Background()
+
+	_, err := manager.Put(ctx, "secret.txt", strings.NewReader("classified"))
+	require.NoError(t, err, "expected no error seeding file")
+
+	t.Run("should reject wrong secret", func(t *testing.T) {
+		_, err := newClient(server, testAccessKeyID, "wrong").ListBuckets(ctx, &s3.ListBucketsInput{})
+		assert.Equal(t, "SignatureDoesNotMatch", errorCode(err), "expected signature mismatch")
+	})
+
+	t.Run("should reject unknown access key", func(t *testing.T) {
+		_, err := newClient(server, "AKIAUNKNOWN", testSecretKey).ListBuckets(ctx, &s3.ListBucketsInput{})
+		assert.Equal(t, "InvalidAccessKeyId", errorCode(err), "expected unknown access key")
+	})
+
+	t.Run("should reject anonymous requests", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/media/secret.txt")
+		require.NoError(t, err, "expected no transport error")
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "expected 403 for anonymous request")
+		assert.Contains(t, string(body), "<Code>AccessDenied</Code>", "expected S3 XML error")
+	})
+
+	t.Run("should reject tampered body", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/media/tampered.txt", strings.NewReader("evil"))
+		require.NoError(t, err, "expected no error building request")
+		signRequest(t, h, req, hashHex("good"))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "expected no transport error")
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected 400 for payload hash mismatch")
+		exists, _ := manager.Exists(ctx, "tampered.txt")
+		assert.False(t, exists, "expected tampered body not to be stored")
+	})
+
+	for _, signed := range [][]string{{"x-amz-date"}, {"x-amz-content-sha256", "x-amz-date"}, {"host", "x-amz-date"}} {
+		t.Run("should reject requests signing only "+strings.Join(signed, ";"), func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/media/secret.txt", nil)
+			require.NoError(t, err, "expected no error building request")
+			signRequestHeaders(t, h, req, emptySHA256, signed...)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, "expected no transport error")
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode, "expected 403 for unsigned host or payload hash")
+			assert.Contains(t, string(body), "were not signed", "expected unsigned headers error")
+		})
+	}
+
+	t.Run("should reject skewed clock", func(t *testing.T) {
+		h.now = func() time.Time { return time.Now().Add(time.Hour) }
+		defer func() { h.now = time.Now }()
+
+		_, err := newClient(server, testAccessKeyID, testSecretKey).ListBuckets(ctx, &s3.ListBucketsInput{})
+		assert.Equal(t, "RequestTimeTooSkewed", errorCode(err), "expected skewed clock to be rejected")
+	})
+}
+
+func TestHandler_PresignedURL(t *testing.T) {
+	h, manager, server := newTestGateway(t)
+	ctx := context.Background()
+
+	_, err := manager.Put(ctx, "report.pdf", strings.NewReader("pdf content"))
+	require.NoError(t, err, "expected no error seeding file")
+
+	presigner := s3.NewPresignClient(newClient(server, testAccessKeyID, testSecretKey))
+	signed, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String("media"),
+		Key:                        aws.String("report.pdf"),
+		ResponseContentDisposition: aws.String("attachment"),
+	}, s3.WithPresignExpires(time.Minute))
+	require.NoError(t, err, "expected no error presigning")
+
+	get := func(url string) (*http.Response, string) {
+		resp, err := http.Get(url)
+		require.NoError(t, err, "expected no transport error")
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get(signed.URL)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "expected presigned URL to work")
+	assert.Equal(t, "pdf content", body, "expected file content")
+	assert.Equal(t, "attachment", resp.Header.Get("Content-Disposition"), "expected response header override")
+
+	resp, _ = get(strings.Replace(signed.URL, "report.pdf", "other.pdf", 1))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "expected tampered URL to be rejected")
+
+	h.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	resp, body = get(signed.URL)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "expected expired URL to be rejected")
+	assert.Contains(t, body, "Request has expired", "expected expiry message")
+}
+
+func TestHandler_NotImplemented(t *testing.T) {
+	_, _, server := newTestGateway(t)
+	client := newClient(server, testAccessKeyID, testSecretKey)
+
+	_, err := client.CreateMultipartUpload(context.Background(), &s3.CreateMultipartUploadInput{Bucket: aws.String("media"), Key: aws.String("big.bin")})
+	assert.Equal(t, "NotImplemented", errorCode(err), "expected multipart uploads to be unsupported")
+
+	_, err = client.GetBucketVersioning(context.Background(), &s3.GetBucketVersioningInput{Bucket: aws.String("media")})
+	assert.Equal(t, "NotImplemented", errorCode(err), "expected bucket subresources to be unsupported")
+}
+
+// signRequest signs req for h with a precomputed payload hash, like a client would.
+func signRequest(t *testing.T, h *Handler, req *http.Request, payloadHash string) {
+	t.Helper()
+
+	signRequestHeaders(t, h, req, payloadHash, "host", "x-amz-content-sha256", "x-amz-date")
+}
+
+// signRequestHeaders signs req for h, covering only the given headers.
+func signRequestHeaders(t *testing.T, h *Handler, req *http.Request, payloadHash string, signedHeaders ...string) {
+	t.Helper()
+
+	now := h.now().UTC()
+	scope := now.Format("20060102") + "/us-east-1/s3/aws4_request"
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	key := signingKey(testSecretKey, now.Format("20060102"), "us-east-1", "s3")
+	stringToSign := signingAlgorithm + "\n" + now.Format(amzDateFormat) + "\n" + scope + "\n" + hashHex(canonicalRequest(req, signedHeaders, payloadHash))
+
+	req.Header.Set("Authorization", signingAlgorithm+" Credential="+testAccessKeyID+"/"+scope+
+		", SignedHeaders="+strings.Join(signedHeaders, ";")+
+		", Signature="+hex.EncodeToString(hmacSHA256(key, stringToSign)))
+}
+
+// readObject returns the content of key.
+func readObject(t *testing.T, storage gostorage.StorageManager, key string) []byte {
+	t.Helper()
+
+	body, err := storage.GetRange(context.Background(), key, 0, -1)
+	require.NoError(t, err, "expected no error reading %s", key)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err, "expected no error reading body")
+
+	return data
+}
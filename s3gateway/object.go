@@ -0,0 +1,138 @@
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// responseOverrideParams are the GetObject query parameters that override response headers.
+var responseOverrideParams = []string{
+	"response-cache-control",
+	"response-content-disposition",
+	"response-content-encoding",
+	"response-content-language",
+	"response-content-type",
+	"response-expires",
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	LastModified string   `xml:"LastModified"`
+	ETag         string   `xml:"ETag"`
+}
+
+// getObject answers GetObject and HeadObject. Range and conditional requests are
+// handled by http.ServeContent, so the storage must implement gostorage.RangeReader.
+func (h *Handler) getObject(w http.ResponseWriter, r *http.Request, storage gostorage.StorageManager, bucket, key string) {
+	reader, err := storage.OpenReaderAt(r.Context(), key)
+	if err != nil {
+		writeError(w, r, storageError(err, bucket, key))
+		return
+	}
+
+	info := reader.Info()
+	header := w.Header()
+
+	if info.ETag != "" {
+		header.Set("ETag", info.ETag)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+
+	query := r.URL.Query()
+	for _, param := range responseOverrideParams {
+		if value := query.Get(param); value != "" {
+			header.Set(strings.TrimPrefix(param, "response-"), value)
+		}
+	}
+
+	http.ServeContent(w, r, "", info.LastModified, io.NewSectionReader(reader, 0, reader.Size()))
+}
+
+// putObject answers PutObject, streaming the verified body into the storage.
+func (h *Handler) putObject(w http.ResponseWriter, r *http.Request, sig *signature, storage gostorage.StorageManager, bucket, key string) {
+	body, aerr := newPayload(r, sig)
+	if aerr != nil {
+		writeError(w, r, aerr)
+		return
+	}
+
+	if _, err := storage.Put(r.Context(), key, body); err != nil {
+		if body.err != nil {
+			writeError(w, r, body.err)
+			return
+		}
+
+		writeError(w, r, storageError(err, bucket, key))
+		return
+	}
+
+	w.Header().Set("ETag", body.etag())
+	w.WriteHeader(http.StatusOK)
+}
+
+// copyObject answers CopyObject by streaming the source, which may live in another bucket.
+func (h *Handler) copyObject(w http.ResponseWriter, r *http.Request, storage gostorage.StorageManager, bucket, key string) {
+	source, _, _ := strings.Cut(r.Header.Get("X-Amz-Copy-Source"), "?") // drop ?versionId=
+	source, err := url.PathUnescape(source)
+	if err != nil {
+		writeError(w, r, errInvalidCopySource)
+		return
+	}
+
+	sourceBucket, sourceKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	if sourceBucket == "" || sourceKey == "" {
+		writeError(w, r, errInvalidCopySource)
+		return
+	}
+
+	sourceStorage, err := h.manager.StorageE(sourceBucket)
+	if err != nil {
+		writeError(w, r, errNoSuchBucket)
+		return
+	}
+
+	file, err := sourceStorage.GetRange(r.Context(), sourceKey, 0, -1)
+	if err != nil {
+		writeError(w, r, storageError(err, sourceBucket, sourceKey))
+		return
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := storage.Put(r.Context(), key, io.TeeReader(file, hash)); err != nil {
+		writeError(w, r, storageError(err, bucket, key))
+		return
+	}
+
+	writeXML(w, http.StatusOK, copyObjectResult{
+		Xmlns:        xmlNamespace,
+		LastModified: formatTime(h.now()),
+		ETag:         `"` + hex.EncodeToString(hash.Sum(nil)) + `"`,
+	})
+}
+
+// deleteObject answers DeleteObject. Like S3, deleting a missing key succeeds.
+func (h *Handler) deleteObject(w http.ResponseWriter, r *http.Request, storage gostorage.StorageManager, bucket, key string) {
+	if err := storage.Delete(r.Context(), key); err != nil && !errors.Is(err, gostorage.ErrNotFound) {
+		writeError(w, r, storageError(err, bucket, key))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,236 @@
+package s3gateway
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxChunkSize bounds a single aws-chunked chunk, which is buffered until its signature is checked.
+const maxChunkSize = 16 << 20
+
+// payload is a request body decoded and verified according to X-Amz-Content-Sha256.
+// Read fails once the body turns out not to match its signature or digest; err then
+// holds the S3 error to report instead of whatever the storage made of the failed read.
+type payload struct {
+	r           io.Reader
+	md5         hash.Hash
+	expectedMD5 []byte
+	err         *apiError
+}
+
+// newPayload wraps the body of r according to the verified signature.
+func newPayload(r *http.Request, sig *signature) (*payload, *apiError) {
+	p := &payload{md5: md5.New()}
+
+	switch sig.payload {
+	case unsignedPayload:
+		p.r = r.Body
+	case streamingPayload, streamingPayloadTrailer:
+		p.r = &chunkedReader{r: bufio.NewReader(r.Body), sig: sig, previous: sig.signature}
+	case streamingUnsignedTrailer:
+		p.r = &chunkedReader{r: bufio.NewReader(r.Body)}
+	default:
+		expected, err := hex.DecodeString(sig.payload)
+		if err != nil || len(expected) != sha256.Size {
+			return nil, errInvalidArgument
+		}
+		p.r = &digestReader{r: r.Body, hash: sha256.New(), expected: expected, mismatch: errContentSHA256Mismatch}
+	}
+
+	if contentMD5 := r.Header.Get("Content-Md5"); contentMD5 != "" {
+		expected, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil || len(expected) != md5.Size {
+			return nil, errInvalidDigest
+		}
+		p.expectedMD5 = expected
+	}
+
+	return p, nil
+}
+
+func (p *payload) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.md5.Write(b[:n])
+
+	if errors.Is(err, io.EOF) && p.expectedMD5 != nil && !bytes.Equal(p.md5.Sum(nil), p.expectedMD5) {
+		err = errBadDigest
+	}
+
+	var aerr *apiError
+	if errors.As(err, &aerr) {
+		p.err = aerr
+	}
+
+	return n, err
+}
+
+// etag returns the S3 ETag of a fully read payload: its quoted MD5.
+func (p *payload) etag() string {
+	return `"` + hex.EncodeToString(p.md5.Sum(nil)) + `"`
+}
+
+// digestReader fails at EOF if the body does not hash to the expected value.
+type digestReader struct {
+	r        io.Reader
+	hash     hash.Hash
+	expected []byte
+	mismatch *apiError
+}
+
+func (d *digestReader) Read(b []byte) (int, error) {
+	n, err := d.r.Read(b)
+	d.hash.Write(b[:n])
+
+	if errors.Is(err, io.EOF) && !bytes.Equal(d.hash.Sum(nil), d.expected) {
+		return n, d.mismatch
+	}
+
+	return n, err
+}
+
+// chunkedReader decodes an aws-chunked body: "<hex size>[;chunk-signature=<sig>]\r\n<data>\r\n"
+// repeated until a zero-sized chunk, followed by optional trailers and an empty line.
+// With sig set, every chunk is buffered and checked against the signature chain before
+// any of it is returned. Trailing checksums are skipped; the payload is verified by its signatures.
+type chunkedReader struct {
+	r        *bufio.Reader
+	sig      *signature // nil for STREAMING-UNSIGNED-PAYLOAD-TRAILER
+	previous string     // signature of the previous chunk
+
+	chunk     []byte // verified data not yet returned
+	remaining int64  // unread bytes of the current unsigned chunk
+	done      bool
+}
+
+func (c *chunkedReader) Read(b []byte) (int, error) {
+	for {
+		switch {
+		case len(c.chunk) > 0:
+			n := copy(b, c.chunk)
+			c.chunk = c.chunk[n:]
+			return n, nil
+
+		case c.remaining > 0:
+			if int64(len(b)) > c.remaining {
+				b = b[:c.remaining]
+			}
+
+			n, err := c.r.Read(b)
+			c.remaining -= int64(n)
+			if c.remaining == 0 && err == nil {
+				err = c.readCRLF()
+			}
+			if errors.Is(err, io.EOF) {
+				err = errIncompleteBody
+			}
+
+			return n, err
+
+		case c.done:
+			return 0, io.EOF
+		}
+
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// next reads the header of the next chunk, and its data when the chunk is signed.
+func (c *chunkedReader) next() error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+
+	sizeHex, extension, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(sizeHex, 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return errInvalidChunk
+	}
+
+	if c.sig == nil {
+		if size == 0 {
+			c.done = true
+			return c.skipTrailers()
+		}
+
+		c.remaining = size
+		return nil
+	}
+
+	chunkSig, ok := strings.CutPrefix(extension, "chunk-signature=")
+	if !ok {
+		return errInvalidChunk
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return errIncompleteBody
+	}
+
+	sum := sha256.Sum256(data)
+	expected := c.sig.chunkSignature(c.previous, hex.EncodeToString(sum[:]))
+	if chunkSig != expected {
+		return errSignatureDoesNotMatch
+	}
+	c.previous = expected
+
+	if size == 0 {
+		c.done = true
+		return c.skipTrailers()
+	}
+
+	c.chunk = data
+	return c.readCRLF()
+}
+
+// skipTrailers consumes the trailer lines after the last chunk.
+func (c *chunkedReader) skipTrailers() error {
+	for {
+		line, err := c.readLine()
+		if line == "" || err != nil {
+			// some clients end the body right after the last chunk
+			if errors.Is(err, errIncompleteBody) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// readLine reads a CRLF-terminated line without its terminator.
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", errInvalidChunk
+	}
+	if err != nil {
+		return "", errIncompleteBody
+	}
+
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// readCRLF consumes the line break after the data of a chunk.
+func (c *chunkedReader) readCRLF() error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if line != "" {
+		return errInvalidChunk
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package s3gateway
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exampleSignature is the seed signature of the chunked upload example in the S3 documentation.
+func exampleSignature() *signature {
+	return &signature{
+		key:       signingKey("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "20130524", "us-east-1", "s3"),
+		date:      "20130524T000000Z",
+		scope:     "20130524/us-east-1/s3/aws4_request",
+		signature: "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9",
+	}
+}
+
+func TestChunkedReader_Signed(t *testing.T) {
+	first := strings.Repeat("a", 65536)
+	second := strings.Repeat("a", 1024)
+
+	valid := fmt.Sprintf("10000;chunk-signature=ad80c730a21e5b8d04586a2213dd63b9a0e99e0e2307b0ade35a65485a288648\r\n%s\r\n", first) +
+		fmt.Sprintf("400;chunk-signature=0055627c9e194cb4542bae2aa5492e3c1575bbb81b612b7d234b86a503ef5497\r\n%s\r\n", second) +
+		"0;chunk-signature=b6c6ea8a5354eaf15b3cb7646744f4275b71ea724fed81ceb9323e279d449df9\r\n\r\n"
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedBody  string
+		expectedError error
+	}{
+		{
+			name:         "should decode chunks with valid signatures",
+			body:         valid,
+			expectedBody: first + second,
+		},
+		{
+			name:          "should reject modified chunk",
+			body:          strings.Replace(valid, "aaaa\r\n400", "aaab\r\n400", 1),
+			expectedError: errSignatureDoesNotMatch,
+		},
+		{
+			name:          "should reject wrong final signature",
+			body:          strings.Replace(valid, "b6c6ea8a", "00000000", 1),
+			expectedError: errSignatureDoesNotMatch,
+		},
+		{
+			name:          "should reject missing signature",
+			body:          "3\r\nabc\r\n0\r\n\r\n",
+			expectedError: errInvalidChunk,
+		},
+		{
+			name:          "should reject truncated body",
+			body:          valid[:1000],
+			expectedError: errIncompleteBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := exampleSignature()
+			reader := &chunkedReader{r: bufio.NewReader(strings.NewReader(tt.body)), sig: sig, previous: sig.signature}
+
+			data, err := io.ReadAll(reader)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError, "expected error to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expectedBody, string(data), "expected decoded body")
+		})
+	}
+}
+
+func TestChunkedReader_UnsignedTrailer(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedBody  string
+		expectedError error
+	}{
+		{
+			name:         "should decode chunks and skip trailers",
+			body:         "5\r\nhello\r\n6\r\n world\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n",
+			expectedBody: "hello world",
+		},
+		{
+			name:         "should accept body ending after last chunk",
+			body:         "5\r\nhello\r\n0\r\n",
+			expectedBody: "hello",
+		},
+		{
+			name:          "should reject invalid chunk size",
+			body:          "zz\r\nhello\r\n0\r\n\r\n",
+			expectedError: errInvalidChunk,
+		},
+		{
+			name:          "should reject chunk longer than its size",
+			body:          "3\r\nhello\r\n0\r\n\r\n",
+			expectedError: errInvalidChunk,
+		},
+		{
+			name:          "should reject truncated chunk",
+			body:          "5\r\nhel",
+			expectedError: errIncompleteBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &chunkedReader{r: bufio.NewReader(strings.NewReader(tt.body))}
+
+			data, err := io.ReadAll(reader)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError, "expected error to match")
+				return
+			}
+
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expectedBody, string(data), "expected decoded body")
+		})
+	}
+}
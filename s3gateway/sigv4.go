@@ -0,0 +1,287 @@
+package s3gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+
+	// maxClockSkew is how far the request time of header-signed requests may be off.
+	maxClockSkew = 15 * time.Minute
+
+	// maxPresignExpiry is the longest validity S3 accepts for presigned URLs.
+	maxPresignExpiry = 7 * 24 * time.Hour
+
+	unsignedPayload          = "UNSIGNED-PAYLOAD"
+	streamingPayload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingPayloadTrailer  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+)
+
+// emptySHA256 is the hex SHA-256 of an empty string, part of every chunk string to sign.
+var emptySHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+
+// signature is a verified SigV4 request signature. Streaming payloads continue the
+// signature chain with it, one chunk at a time.
+type signature struct {
+	key       []byte // derived signing key
+	date      string // request time in amzDateFormat
+	scope     string // "<date>/<region>/<service>/aws4_request"
+	signature string // hex request signature, the seed of the chunk chain
+	payload   string // value of X-Amz-Content-Sha256, UNSIGNED-PAYLOAD for presigned URLs
+}
+
+// authorization holds the parts of a SigV4 Authorization header or presigned query.
+type authorization struct {
+	accessKeyID   string
+	scope         string
+	signedHeaders []string
+	signature     string
+	date          time.Time
+	expires       time.Duration // zero for header-signed requests
+}
+
+// verify checks the SigV4 signature of r against the configured credentials.
+func (h *Handler) verify(r *http.Request) (*signature, *apiError) {
+	auth, aerr := parseAuthorization(r)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	secret, ok := h.credentials[auth.accessKeyID]
+	if !ok {
+		return nil, errInvalidAccessKeyID
+	}
+
+	now := h.now()
+	if auth.expires > 0 {
+		if now.Before(auth.date.Add(-maxClockSkew)) || now.After(auth.date.Add(auth.expires)) {
+			return nil, errExpiredRequest
+		}
+	} else if d := now.Sub(auth.date); d > maxClockSkew || d < -maxClockSkew {
+		return nil, errRequestTimeTooSkewed
+	}
+
+	scope := strings.Split(auth.scope, "/")
+	if len(scope) != 4 || scope[0] != auth.date.Format("20060102") || scope[2] != "s3" || scope[3] != "aws4_request" {
+		return nil, errAuthorizationMalformed
+	}
+
+	payload := unsignedPayload
+	if auth.expires == 0 {
+		payload = r.Header.Get("X-Amz-Content-Sha256")
+		if payload == "" {
+			return nil, errMissingContentSHA256
+		}
+	}
+
+	sig := &signature{
+		key:     signingKey(secret, scope[0], scope[1], scope[2]),
+		date:    auth.date.Format(amzDateFormat),
+		scope:   auth.scope,
+		payload: payload,
+	}
+
+	stringToSign := signingAlgorithm + "\n" + sig.date + "\n" + sig.scope + "\n" + hashHex(canonicalRequest(r, auth.signedHeaders, payload))
+	sig.signature = hex.EncodeToString(hmacSHA256(sig.key, stringToSign))
+
+	if !hmac.Equal([]byte(sig.signature), []byte(auth.signature)) {
+		return nil, errSignatureDoesNotMatch
+	}
+
+	return sig, nil
+}
+
+// parseAuthorization reads the SigV4 parameters from the Authorization header or,
+// for presigned URLs, from the query string.
+func parseAuthorization(r *http.Request) (*authorization, *apiError) {
+	auth := &authorization{}
+	query := r.URL.Query()
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		fields, ok := strings.CutPrefix(header, signingAlgorithm+" ")
+		if !ok {
+			return nil, errAuthorizationMalformed
+		}
+
+		for _, field := range strings.Split(fields, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch name {
+			case "Credential":
+				auth.accessKeyID, auth.scope, _ = strings.Cut(value, "/")
+			case "SignedHeaders":
+				auth.signedHeaders = strings.Split(value, ";")
+			case "Signature":
+				auth.signature = value
+			}
+		}
+
+		date := r.Header.Get("X-Amz-Date")
+		if date == "" {
+			date = r.Header.Get("Date")
+		}
+
+		t, err := time.Parse(amzDateFormat, date)
+		if err != nil {
+			if t, err = http.ParseTime(date); err != nil {
+				return nil, errAccessDenied
+			}
+		}
+		auth.date = t.UTC()
+	} else if query.Get("X-Amz-Algorithm") == signingAlgorithm {
+		auth.accessKeyID, auth.scope, _ = strings.Cut(query.Get("X-Amz-Credential"), "/")
+		auth.signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		auth.signature = query.Get("X-Amz-Signature")
+
+		t, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+		if err != nil {
+			return nil, errAuthorizationMalformed
+		}
+		auth.date = t
+
+		seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > maxPresignExpiry {
+			return nil, errAuthorizationMalformed
+		}
+		auth.expires = time.Duration(seconds) * time.Second
+	} else {
+		return nil, errAccessDenied
+	}
+
+	if auth.accessKeyID == "" || auth.scope == "" || auth.signature == "" || len(auth.signedHeaders) == 0 {
+		return nil, errAuthorizationMalformed
+	}
+
+	// Like S3, require the host to be signed so signatures cannot be replayed against other
+	// hosts, and the payload hash of header-signed requests so the body cannot be swapped.
+	if !slices.Contains(auth.signedHeaders, "host") ||
+		(auth.expires == 0 && !slices.Contains(auth.signedHeaders, "x-amz-content-sha256")) {
+		return nil, errUnsignedHeaders
+	}
+
+	return auth, nil
+}
+
+// canonicalRequest builds the SigV4 canonical form of r.
+func canonicalRequest(r *http.Request, signedHeaders []string, payload string) string {
+	var b strings.Builder
+
+	b.WriteString(r.Method)
+	b.WriteByte('\n')
+	b.WriteString(uriEncode(r.URL.Path, false))
+	b.WriteByte('\n')
+	b.WriteString(canonicalQuery(r.URL.Query()))
+	b.WriteByte('\n')
+
+	for _, name := range signedHeaders {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(headerValue(r, name))
+		b.WriteByte('\n')
+	}
+
+	b.WriteByte('\n')
+	b.WriteString(strings.Join(signedHeaders, ";"))
+	b.WriteByte('\n')
+	b.WriteString(payload)
+
+	return b.String()
+}
+
+// canonicalQuery encodes the query parameters sorted by name, without the signature itself.
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		if name == "X-Amz-Signature" {
+			continue
+		}
+
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// headerValue returns the canonical value of a signed header: all values trimmed,
+// inner whitespace collapsed, joined by commas. net/http moves Host and Content-Length
+// out of r.Header, so they are read from the request itself.
+func headerValue(r *http.Request, name string) string {
+	switch name {
+	case "host":
+		return r.Host
+	case "content-length":
+		if r.Header.Get("Content-Length") == "" && r.ContentLength >= 0 {
+			return strconv.FormatInt(r.ContentLength, 10)
+		}
+	}
+
+	values := r.Header.Values(name)
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.Join(strings.Fields(value), " ")
+	}
+
+	return strings.Join(trimmed, ",")
+}
+
+// uriEncode escapes every byte except the unreserved characters of RFC 3986,
+// keeping "/" unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		}
+	}
+
+	return b.String()
+}
+
+// signingKey derives the SigV4 signing key for a day, region and service.
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// chunkSignature returns the signature of the next chunk of a streaming payload.
+func (s *signature) chunkSignature(previous string, chunkHash string) string {
+	stringToSign := signingAlgorithm + "-PAYLOAD\n" + s.date + "\n" + s.scope + "\n" + previous + "\n" + emptySHA256 + "\n" + chunkHash
+	return hex.EncodeToString(hmacSHA256(s.key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
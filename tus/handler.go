@@ -0,0 +1,606 @@
+// Package tus implements a tus 1.0 resumable upload server (https://tus.io) on top of a StorageManager.
+//
+//	http.Handle("/files/", tus.New(manager,
+//		tus.WithBasePath("/files/"),
+//		tus.WithKeyFunc(func(ctx context.Context, u tus.Upload) (string, error) {
+//			return "videos/" + u.ID + ".mp4", nil
+//		}),
+//	))
+//
+// The core protocol and the creation, termination, expiration and checksum extensions are supported.
+// Upload state and chunks are kept in the storage itself below a staging prefix, so any instance
+// behind a load balancer can resume an upload. On storages implementing gostorage.MultipartUploader
+// (e.g. S3) chunks become parts of a multipart upload to the final key; on other storages they are
+// staged as separate objects and concatenated into the final key when the upload completes.
+package tus
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+const (
+	// Version is the tus protocol version implemented by Handler.
+	Version = "1.0.0"
+
+	// Extensions lists the tus extensions implemented by Handler.
+	Extensions = "creation,termination,expiration,checksum"
+
+	// DefaultPartSize is the size of the chunks Handler writes to the storage.
+	DefaultPartSize = 8 << 20
+
+	// MinMultipartPartSize is the smallest part written to storages supporting multipart uploads,
+	// S3's minimum for every part but the last.
+	MinMultipartPartSize = 5 << 20
+
+	// DefaultExpiration is how long an upload may stay incomplete before it expires.
+	DefaultExpiration = 24 * time.Hour
+
+	// DefaultStagingPrefix is the key prefix under which upload state and chunks are stored.
+	DefaultStagingPrefix = ".tus/"
+
+	// StatusChecksumMismatch is the tus status code for a chunk that failed checksum verification.
+	StatusChecksumMismatch = 460
+)
+
+// checksumAlgorithms are the algorithms accepted in the Upload-Checksum header.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// idPattern matches the upload IDs generated by newID.
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// errChecksumMismatch is returned when a chunk does not match its Upload-Checksum header.
+var errChecksumMismatch = errors.New("tus: checksum mismatch")
+
+// Upload describes a resumable upload.
+type Upload struct {
+	ID        string            `json:"id"`                 // identifier in the upload URL
+	Key       string            `json:"key"`                // storage key the file is written to
+	Size      int64             `json:"size"`               // total size announced by the client
+	Offset    int64             `json:"offset"`             // number of bytes received so far
+	Metadata  map[string]string `json:"metadata,omitempty"` // decoded Upload-Metadata
+	ExpiresAt time.Time         `json:"expires_at"`         // when an incomplete upload is discarded
+	Completed bool              `json:"completed"`          // true once the file is available under Key
+}
+
+// KeyFunc chooses the storage key of a new upload, e.g. from its metadata.
+// Returning an error wrapping gostorage.ErrInvalidKey rejects the upload with 400 Bad Request.
+type KeyFunc func(ctx context.Context, upload Upload) (string, error)
+
+// Handler is an http.Handler implementing the tus protocol.
+// Concurrent PATCH requests for the same upload are rejected with 423 Locked;
+// the lock is held in memory, so requests for one upload should reach the same instance.
+type Handler struct {
+	manager     gostorage.StorageManager
+	basePath    string
+	maxSize     int64
+	partSize    int64
+	minPartSize int64 // lower bound of partSize for multipart uploads
+	expiration  time.Duration
+	prefix      string
+	keyFunc     KeyFunc
+	onComplete  func(ctx context.Context, upload Upload)
+	now         func() time.Time
+
+	mu     sync.Mutex
+	locked map[string]struct{} // IDs of uploads a request is working on
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithAlias stores uploads in the storage registered under alias instead of the default storage.
+func WithAlias(alias string) Option {
+	return func(h *Handler) {
+		h.manager = h.manager.Storage(alias)
+	}
+}
+
+// WithBasePath sets the URL path the handler is mounted at, used for routing and Location headers.
+// Defaults to "/files/".
+func WithBasePath(path string) Option {
+	return func(h *Handler) {
+		h.basePath = "/" + strings.Trim(path, "/") + "/"
+	}
+}
+
+// WithCompleteHook calls fn once an upload is complete and its file is available under Upload.Key.
+func WithCompleteHook(fn func(ctx context.Context, upload Upload)) Option {
+	return func(h *Handler) {
+		h.onComplete = fn
+	}
+}
+
+// WithExpiration sets how long an upload may stay incomplete, see CleanupExpired.
+func WithExpiration(d time.Duration) Option {
+	return func(h *Handler) {
+		h.expiration = d
+	}
+}
+
+// WithKeyFunc chooses the storage key of new uploads. By default the upload ID is used.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(h *Handler) {
+		h.keyFunc = fn
+	}
+}
+
+// WithMaxSize rejects uploads larger than size bytes with 413 Request Entity Too Large.
+func WithMaxSize(size int64) Option {
+	return func(h *Handler) {
+		h.maxSize = size
+	}
+}
+
+// WithPartSize sets the size of the chunks written to the storage and the memory used per PATCH request.
+// Values lower than 1 are ignored and DefaultPartSize is used instead. Uploads to storages with
+// multipart support use parts of at least MinMultipartPartSize, since S3 rejects smaller ones.
+func WithPartSize(size int64) Option {
+	return func(h *Handler) {
+		if size > 0 {
+			h.partSize = size
+		}
+	}
+}
+
+// WithStagingPrefix sets the key prefix for upload state and staged chunks. Defaults to ".tus/".
+func WithStagingPrefix(prefix string) Option {
+	return func(h *Handler) {
+		h.prefix = prefix
+	}
+}
+
+// New returns a tus Handler storing uploads in manager's default storage.
+// The storage must implement gostorage.RangeReader so upload state can be read back.
+func New(manager gostorage.StorageManager, opts ...Option) *Handler {
+	h := &Handler{
+		manager:     manager,
+		basePath:    "/files/",
+		partSize:    DefaultPartSize,
+		minPartSize: MinMultipartPartSize,
+		expiration:  DefaultExpiration,
+		prefix:      DefaultStagingPrefix,
+		keyFunc:     func(ctx context.Context, upload Upload) (string, error) { return upload.ID, nil },
+		now:         time.Now,
+		locked:      make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ServeHTTP routes tus requests: POST to the base path creates an upload,
+// HEAD, PATCH and DELETE on the base path followed by an upload ID operate on it.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", Version)
+
+	if override := r.Header.Get("X-HTTP-Method-Override"); override != "" {
+		r.Method = override
+	}
+
+	if r.Method == http.MethodOptions {
+		h.serveOptions(w)
+		return
+	}
+
+	if r.Header.Get("Tus-Resumable") != Version {
+		w.Header().Set("Tus-Version", Version)
+		http.Error(w, "unsupported tus version", http.StatusPreconditionFailed)
+		return
+	}
+
+	path := r.URL.Path
+	if path+"/" == h.basePath {
+		path = h.basePath
+	}
+
+	id, ok := strings.CutPrefix(path, h.basePath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		h.create(w, r)
+	case id == "":
+		methodNotAllowed(w, "POST, OPTIONS")
+	case !idPattern.MatchString(id):
+		http.NotFound(w, r)
+	case r.Method == http.MethodHead:
+		h.head(w, r, id)
+	case r.Method == http.MethodPatch:
+		h.patch(w, r, id)
+	case r.Method == http.MethodDelete:
+		h.terminate(w, r, id)
+	default:
+		methodNotAllowed(w, "HEAD, PATCH, DELETE, OPTIONS")
+	}
+}
+
+// serveOptions advertises the protocol version, extensions and limits.
+func (h *Handler) serveOptions(w http.ResponseWriter) {
+	algorithms := make([]string, 0, len(checksumAlgorithms))
+	for name := range checksumAlgorithms {
+		algorithms = append(algorithms, name)
+	}
+	sort.Strings(algorithms)
+
+	header := w.Header()
+	header.Set("Tus-Version", Version)
+	header.Set("Tus-Extension", Extensions)
+	header.Set("Tus-Checksum-Algorithm", strings.Join(algorithms, ","))
+	if h.maxSize > 0 {
+		header.Set("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// create handles the creation extension: POST with Upload-Length and optional Upload-Metadata.
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+
+	if h.maxSize > 0 && size > h.maxSize {
+		http.Error(w, "upload exceeds Tus-Max-Size", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	metadata, err := parseMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, "invalid Upload-Metadata", http.StatusBadRequest)
+		return
+	}
+
+	info := &uploadInfo{Upload: Upload{
+		ID:        newID(),
+		Size:      size,
+		Metadata:  metadata,
+		ExpiresAt: h.now().Add(h.expiration),
+	}}
+
+	info.Key, err = h.keyFunc(ctx, info.Upload)
+	if err == nil {
+		err = gostorage.ValidateKey(info.Key)
+	}
+	if err != nil {
+		h.serveError(w, info.ID, err)
+		return
+	}
+
+	if err := h.start(ctx, info); err != nil {
+		h.serveError(w, info.ID, err)
+		return
+	}
+
+	if err := h.saveInfo(ctx, info); err != nil {
+		h.serveError(w, info.ID, err)
+		return
+	}
+
+	if info.Completed {
+		h.complete(ctx, info)
+	}
+
+	w.Header().Set("Location", h.basePath+info.ID)
+	h.setExpires(w, info)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// head reports the offset of an upload so the client can resume it.
+func (h *Handler) head(w http.ResponseWriter, r *http.Request, id string) {
+	info, err := h.loadActive(r.Context(), id)
+	if err != nil {
+		h.serveError(w, id, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(info.Size, 10))
+	if len(info.Metadata) > 0 {
+		header.Set("Upload-Metadata", encodeMetadata(info.Metadata))
+	}
+	h.setExpires(w, info)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// patch appends the request body to an upload at Upload-Offset.
+func (h *Handler) patch(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "expected Content-Type application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	sum, err := parseChecksum(r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	unlock, ok := h.lock(id)
+	if !ok {
+		http.Error(w, "upload is locked by another request", http.StatusLocked)
+		return
+	}
+	defer unlock()
+
+	info, err := h.loadActive(ctx, id)
+	if err != nil {
+		h.serveError(w, id, err)
+		return
+	}
+
+	if offset != info.Offset {
+		http.Error(w, "Upload-Offset does not match upload", http.StatusConflict)
+		return
+	}
+
+	if !info.Completed {
+		if err := h.write(ctx, info, r.Body, sum); err != nil {
+			h.serveError(w, id, err)
+			return
+		}
+
+		if info.Completed {
+			h.complete(ctx, info)
+		}
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	h.setExpires(w, info)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// terminate handles the termination extension: DELETE discards an upload and its chunks.
+func (h *Handler) terminate(w http.ResponseWriter, r *http.Request, id string) {
+	unlock, ok := h.lock(id)
+	if !ok {
+		http.Error(w, "upload is locked by another request", http.StatusLocked)
+		return
+	}
+	defer unlock()
+
+	info, err := h.loadInfo(r.Context(), id)
+	if err != nil {
+		h.serveError(w, id, err)
+		return
+	}
+
+	if err := h.discard(r.Context(), info); err != nil {
+		h.serveError(w, id, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CleanupExpired discards uploads past their expiration and returns how many were removed.
+// Run it periodically; it requires a storage implementing gostorage.Lister.
+func (h *Handler) CleanupExpired(ctx context.Context) (int, error) {
+	var ids []string
+	err := h.manager.List(ctx, gostorage.ListOptions{Prefix: h.prefix, Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		if id, ok := strings.CutSuffix(strings.TrimPrefix(obj.Key, h.prefix), "/info"); ok {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, id := range ids {
+		info, err := h.loadInfo(ctx, id)
+		if err != nil {
+			return removed, err
+		}
+
+		if !h.expired(info) {
+			continue
+		}
+
+		if err := h.discard(ctx, info); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// loadActive loads an upload, reporting errExpired once it is past its expiration.
+func (h *Handler) loadActive(ctx context.Context, id string) (*uploadInfo, error) {
+	info, err := h.loadInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.expired(info) {
+		return nil, errExpired
+	}
+
+	return info, nil
+}
+
+// errExpired is reported for uploads past their expiration.
+var errExpired = errors.New("tus: upload expired")
+
+// expired reports whether an upload is past its expiration.
+func (h *Handler) expired(info *uploadInfo) bool {
+	return h.now().After(info.ExpiresAt)
+}
+
+// complete runs the completion hook of a finished upload.
+func (h *Handler) complete(ctx context.Context, info *uploadInfo) {
+	if h.onComplete != nil {
+		h.onComplete(ctx, info.Upload)
+	}
+}
+
+// lock acquires the in-memory lock of an upload without waiting.
+// Only uploads with a request in flight are tracked, so finished and expired uploads leave nothing behind.
+func (h *Handler) lock(id string) (func(), bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, busy := h.locked[id]; busy {
+		return nil, false
+	}
+	h.locked[id] = struct{}{}
+
+	return func() {
+		h.mu.Lock()
+		delete(h.locked, id)
+		h.mu.Unlock()
+	}, true
+}
+
+// setExpires sets Upload-Expires for uploads that are still incomplete.
+func (h *Handler) setExpires(w http.ResponseWriter, info *uploadInfo) {
+	if !info.Completed {
+		w.Header().Set("Upload-Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// serveError maps storage and protocol errors to HTTP status codes.
+func (h *Handler) serveError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, gostorage.ErrNotFound):
+		http.Error(w, "upload not found", http.StatusNotFound)
+	case errors.Is(err, errExpired):
+		http.Error(w, "upload expired", http.StatusGone)
+	case errors.Is(err, errChecksumMismatch):
+		http.Error(w, "checksum mismatch", StatusChecksumMismatch)
+	case errors.Is(err, gostorage.ErrInvalidKey):
+		http.Error(w, "invalid upload key", http.StatusBadRequest)
+	default:
+		log.Error().Err(err).Str("upload", id).Msg("failed to handle tus request")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// methodNotAllowed responds with 405 and the allowed methods.
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// newID returns a random upload ID of 32 hex characters.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// parseMetadata decodes an Upload-Metadata header: comma-separated "key base64(value)" pairs.
+func parseMetadata(header string) (map[string]string, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, fmt.Errorf("empty metadata key")
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata[key] = string(decoded)
+	}
+
+	return metadata, nil
+}
+
+// encodeMetadata is the inverse of parseMetadata, with keys in sorted order.
+func encodeMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + " " + base64.StdEncoding.EncodeToString([]byte(metadata[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// checksum verifies the body of a PATCH request against its Upload-Checksum header.
+type checksum struct {
+	hash     hash.Hash
+	expected []byte
+}
+
+// parseChecksum parses an Upload-Checksum header of the form "<algorithm> <base64 digest>".
+// It returns nil if the header is empty.
+func parseChecksum(header string) (*checksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	algorithm, encoded, _ := strings.Cut(header, " ")
+	newHash, ok := checksumAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Upload-Checksum")
+	}
+
+	return &checksum{hash: newHash(), expected: expected}, nil
+}
+
+// matches reports whether the data written to the hash matches the expected digest.
+func (c *checksum) matches() bool {
+	return string(c.hash.Sum(nil)) == string(c.expected)
+}
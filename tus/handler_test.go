@@ -0,0 +1,488 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multipartDisk adds in-memory multipart uploads with a minimum part size to the local disk driver,
+// behaving like S3: parts are invisible until completed and all but the last must be large enough.
+type multipartDisk struct {
+	*localdriver.DiskStorage
+	minPartSize int64
+
+	mu      sync.Mutex
+	uploads map[string]map[int][]byte
+	aborted []string
+}
+
+func (d *multipartDisk) Capabilities() gostorage.Capabilities {
+	caps := d.DiskStorage.Capabilities()
+	caps.Multipart = true
+	return caps
+}
+
+func (d *multipartDisk) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := fmt.Sprintf("mp-%d", len(d.uploads)+1)
+	d.uploads[id] = make(map[int][]byte)
+	return id, nil
+}
+
+func (d *multipartDisk) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (gostorage.Part, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return gostorage.Part{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	parts, ok := d.uploads[uploadID]
+	if !ok {
+		return gostorage.Part{}, gostorage.ErrNotFound
+	}
+	parts[number] = data
+
+	return gostorage.Part{Number: number, ETag: fmt.Sprintf("etag-%d", number), Size: int64(len(data))}, nil
+}
+
+func (d *multipartDisk) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []gostorage.Part) error {
+	d.mu.Lock()
+	uploaded := d.uploads[uploadID]
+	delete(d.uploads, uploadID)
+	d.mu.Unlock()
+
+	var content bytes.Buffer
+	for i, part := range parts {
+		data := uploaded[part.Number]
+		if i < len(parts)-1 && int64(len(data)) < d.minPartSize {
+			return fmt.Errorf("part %d is smaller than %d bytes", part.Number, d.minPartSize)
+		}
+		content.Write(data)
+	}
+
+	_, err := d.Put(ctx, key, &content)
+	return err
+}
+
+func (d *multipartDisk) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.uploads, uploadID)
+	d.aborted = append(d.aborted, uploadID)
+	return nil
+}
+
+// newDisk returns a local disk driver in a temporary directory.
+func newDisk(t *testing.T) *localdriver.DiskStorage {
+	t.Helper()
+
+	driver, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir()})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	return driver.(*localdriver.DiskStorage)
+}
+
+// newTestHandler returns a Handler with 4 byte parts, also for multipart uploads, over driver
+// and a controllable clock.
+func newTestHandler(t *testing.T, driver gostorage.StorageDriver, opts ...Option) (*Handler, gostorage.StorageManager, *time.Time) {
+	t.Helper()
+
+	manager, err := gostorage.NewStorageManager("uploads", map[string]gostorage.StorageDriver{"uploads": driver})
+	require.NoError(t, err, "expected no error creating manager")
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := New(manager, append([]Option{WithPartSize(4)}, opts...)...)
+	h.now = func() time.Time { return now }
+	h.minPartSize = 1
+
+	return h, manager, &now
+}
+
+// do sends a tus request to h.
+func do(h http.Handler, method, target string, header map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", Version)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// create starts an upload of size bytes and returns its URL.
+func create(t *testing.T, h http.Handler, size int, metadata string) string {
+	t.Helper()
+
+	rec := do(h, http.MethodPost, "/files/", map[string]string{
+		"Upload-Length":   fmt.Sprint(size),
+		"Upload-Metadata": metadata,
+	}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, "expected upload to be created")
+
+	return rec.Header().Get("Location")
+}
+
+// patch sends a chunk at offset.
+func patch(h http.Handler, location string, offset int, chunk string, header map[string]string) *httptest.ResponseRecorder {
+	headers := map[string]string{
+		"Content-Type":  "application/offset+octet-stream",
+		"Upload-Offset": fmt.Sprint(offset),
+	}
+	for key, value := range header {
+		headers[key] = value
+	}
+
+	return do(h, http.MethodPatch, location, headers, chunk)
+}
+
+// readFile returns the content stored under key.
+func readFile(t *testing.T, manager gostorage.StorageManager, key string) string {
+	t.Helper()
+
+	body, err := manager.GetRange(context.Background(), key, 0, -1)
+	require.NoError(t, err, "expected file %s to exist", key)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err, "expected no error reading file")
+
+	return string(data)
+}
+
+// stagedKeys lists the keys below the staging prefix.
+func stagedKeys(t *testing.T, manager gostorage.StorageManager) []string {
+	t.Helper()
+
+	var keys []string
+	err := manager.List(context.Background(), gostorage.ListOptions{Prefix: DefaultStagingPrefix, Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	require.NoError(t, err, "expected no error listing staging prefix")
+
+	return keys
+}
+
+func TestHandler_Options(t *testing.T) {
+	h, _, _ := newTestHandler(t, newDisk(t), WithMaxSize(1024))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/files/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for OPTIONS")
+	assert.Equal(t, Version, rec.Header().Get("Tus-Version"), "expected supported version")
+	assert.Equal(t, Extensions, rec.Header().Get("Tus-Extension"), "expected supported extensions")
+	assert.Equal(t, "md5,sha1,sha256", rec.Header().Get("Tus-Checksum-Algorithm"), "expected checksum algorithms")
+	assert.Equal(t, "1024", rec.Header().Get("Tus-Max-Size"), "expected max size")
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "should create upload",
+			header:         map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename bW92aWUubXA0,private"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "should reject missing Upload-Length",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "should reject negative Upload-Length",
+			header:         map[string]string{"Upload-Length": "-1"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "should reject upload larger than max size",
+			header:         map[string]string{"Upload-Length": "2048"},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "should reject invalid metadata",
+			header:         map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename !!!"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "should reject unsupported protocol version",
+			header:         map[string]string{"Upload-Length": "10", "Tus-Resumable": "0.2.2"},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newTestHandler(t, newDisk(t), WithMaxSize(1024))
+
+			rec := do(h, http.MethodPost, "/files", tt.header, "")
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, "expected status code to match")
+			assert.Equal(t, Version, rec.Header().Get("Tus-Resumable"), "expected Tus-Resumable on every response")
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Regexp(t, `^/files/[0-9a-f]{32}$`, rec.Header().Get("Location"), "expected upload URL")
+				assert.Equal(t, "Tue, 02 Jan 2024 12:00:00 GMT", rec.Header().Get("Upload-Expires"), "expected expiration")
+			}
+		})
+	}
+}
+
+func TestHandler_StagedUpload(t *testing.T) {
+	var completed []Upload
+	h, manager, _ := newTestHandler(t, newDisk(t),
+		WithKeyFunc(func(ctx context.Context, u Upload) (string, error) { return "videos/" + u.Metadata["filename"], nil }),
+		WithCompleteHook(func(ctx context.Context, u Upload) { completed = append(completed, u) }),
+	)
+
+	location := create(t, h, 10, "filename bW92aWUubXA0") // movie.mp4
+
+	rec := do(h, http.MethodHead, location, nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, "expected 200 for HEAD")
+	assert.Equal(t, "0", rec.Header().Get("Upload-Offset"), "expected empty upload")
+	assert.Equal(t, "10", rec.Header().Get("Upload-Length"), "expected upload length")
+	assert.Equal(t, "filename bW92aWUubXA0", rec.Header().Get("Upload-Metadata"), "expected metadata")
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"), "expected HEAD not to be cached")
+
+	rec = patch(h, location, 0, "hello", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for PATCH")
+	assert.Equal(t, "5", rec.Header().Get("Upload-Offset"), "expected offset after first chunk")
+
+	rec = patch(h, location, 3, "lo world", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code, "expected 409 for wrong offset")
+
+	rec = patch(h, location, 5, " world", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for last chunk")
+	assert.Equal(t, "10", rec.Header().Get("Upload-Offset"), "expected offset to stop at upload length")
+	assert.Empty(t, rec.Header().Get("Upload-Expires"), "expected completed upload not to expire")
+
+	assert.Equal(t, "hello worl", readFile(t, manager, "videos/movie.mp4"), "expected chunks to be concatenated into the final key")
+	require.Len(t, completed, 1, "expected completion hook to run once")
+	assert.Equal(t, "videos/movie.mp4", completed[0].Key, "expected hook to receive final key")
+
+	id := strings.TrimPrefix(location, "/files/")
+	assert.Equal(t, []string{DefaultStagingPrefix + id + "/info"}, stagedKeys(t, manager), "expected staged chunks to be removed")
+
+	rec = do(h, http.MethodHead, location, nil, "")
+	assert.Equal(t, "10", rec.Header().Get("Upload-Offset"), "expected completed upload to report full offset")
+	assert.Empty(t, h.locked, "expected no lock to be kept for the completed upload")
+}
+
+func TestHandler_MultipartUpload(t *testing.T) {
+	disk := &multipartDisk{DiskStorage: newDisk(t), minPartSize: 4, uploads: make(map[string]map[int][]byte)}
+	h, manager, _ := newTestHandler(t, disk, WithKeyFunc(func(ctx context.Context, u Upload) (string, error) { return "big.bin", nil }))
+
+	location := create(t, h, 11, "")
+
+	offset := 0
+	for _, chunk := range []string{"ab", "cde", "f", "ghijk"} {
+		rec := patch(h, location, offset, chunk, nil)
+		require.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for chunk %q", chunk)
+		offset += len(chunk)
+		assert.Equal(t, fmt.Sprint(offset), rec.Header().Get("Upload-Offset"), "expected offset after chunk %q", chunk)
+	}
+
+	assert.Equal(t, "abcdefghijk", readFile(t, manager, "big.bin"), "expected multipart upload to be completed at the final key")
+	assert.Empty(t, disk.uploads, "expected no multipart upload left open")
+
+	id := strings.TrimPrefix(location, "/files/")
+	assert.Equal(t, []string{DefaultStagingPrefix + id + "/info"}, stagedKeys(t, manager), "expected pending bytes to be removed")
+}
+
+func TestWithPartSize(t *testing.T) {
+	manager, err := gostorage.NewStorageManager("uploads", map[string]gostorage.StorageDriver{"uploads": newDisk(t)})
+	require.NoError(t, err, "expected no error creating manager")
+
+	tests := []struct {
+		name     string
+		size     int64
+		expected int64
+	}{
+		{name: "should use the given part size", size: 1 << 20, expected: 1 << 20},
+		{name: "should ignore a zero part size", size: 0, expected: DefaultPartSize},
+		{name: "should ignore a negative part size", size: -1, expected: DefaultPartSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(manager, WithPartSize(tt.size))
+
+			assert.Equal(t, tt.expected, h.partSize, "expected part size to match")
+		})
+	}
+}
+
+func TestHandler_MultipartUploadUsesMinimumPartSize(t *testing.T) {
+	disk := &multipartDisk{DiskStorage: newDisk(t), minPartSize: 4, uploads: make(map[string]map[int][]byte)}
+	h, manager, _ := newTestHandler(t, disk, WithKeyFunc(func(ctx context.Context, u Upload) (string, error) { return "big.bin", nil }))
+	h.partSize, h.minPartSize = 2, 4
+
+	location := create(t, h, 10, "")
+	rec := patch(h, location, 0, "abcdefghij", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for PATCH")
+
+	assert.Equal(t, "abcdefghij", readFile(t, manager, "big.bin"), "expected parts below the minimum to be merged")
+}
+
+func TestHandler_PendingBytesExceedPartSize(t *testing.T) {
+	h, _, _ := newTestHandler(t, newDisk(t))
+
+	location := create(t, h, 10, "")
+	rec := patch(h, location, 0, "abc", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for PATCH")
+
+	h.partSize = 2 // restarted with a smaller part size
+	rec = patch(h, location, 3, "defg", nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "expected an error instead of a panic")
+
+	rec = do(h, http.MethodHead, location, nil, "")
+	assert.Equal(t, "3", rec.Header().Get("Upload-Offset"), "expected the upload to keep its offset")
+}
+
+func TestHandler_Checksum(t *testing.T) {
+	h, manager, _ := newTestHandler(t, newDisk(t), WithKeyFunc(func(ctx context.Context, u Upload) (string, error) { return "file.txt", nil }))
+	location := create(t, h, 6, "")
+
+	sha := func(s string) string {
+		sum := sha1.Sum([]byte(s))
+		return "sha1 " + base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	rec := patch(h, location, 0, "abc", map[string]string{"Upload-Checksum": sha("abc")})
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected matching checksum to be accepted")
+
+	rec = patch(h, location, 3, "dXf", map[string]string{"Upload-Checksum": sha("def")})
+	assert.Equal(t, StatusChecksumMismatch, rec.Code, "expected 460 for checksum mismatch")
+
+	rec = do(h, http.MethodHead, location, nil, "")
+	assert.Equal(t, "3", rec.Header().Get("Upload-Offset"), "expected rejected chunk not to advance the offset")
+
+	rec = patch(h, location, 3, "def", map[string]string{"Upload-Checksum": "crc32 AAAA"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "expected 400 for unsupported algorithm")
+
+	rec = patch(h, location, 3, "def", map[string]string{"Upload-Checksum": sha("def")})
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected retried chunk to be accepted")
+
+	assert.Equal(t, "abcdef", readFile(t, manager, "file.txt"), "expected only verified chunks in the file")
+}
+
+func TestHandler_Terminate(t *testing.T) {
+	disk := &multipartDisk{DiskStorage: newDisk(t), minPartSize: 4, uploads: make(map[string]map[int][]byte)}
+	h, manager, _ := newTestHandler(t, disk)
+
+	location := create(t, h, 10, "")
+	require.Equal(t, http.StatusNoContent, patch(h, location, 0, "abcdef", nil).Code, "expected chunk to be accepted")
+
+	rec := do(h, http.MethodDelete, location, nil, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "expected 204 for DELETE")
+	assert.Equal(t, []string{"mp-1"}, disk.aborted, "expected multipart upload to be aborted")
+	assert.Empty(t, stagedKeys(t, manager), "expected upload state to be removed")
+
+	rec = do(h, http.MethodHead, location, nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code, "expected terminated upload to be gone")
+}
+
+func TestHandler_Expiration(t *testing.T) {
+	h, manager, now := newTestHandler(t, newDisk(t), WithExpiration(time.Hour))
+
+	expiring := create(t, h, 10, "")
+	require.Equal(t, http.StatusNoContent, patch(h, expiring, 0, "abcdef", nil).Code, "expected chunk to be accepted")
+
+	*now = now.Add(30 * time.Minute)
+	fresh := create(t, h, 10, "")
+
+	*now = now.Add(31 * time.Minute)
+
+	rec := patch(h, expiring, 6, "ghij", nil)
+	assert.Equal(t, http.StatusGone, rec.Code, "expected 410 for expired upload")
+
+	removed, err := h.CleanupExpired(context.Background())
+	assert.NoError(t, err, "expected no error cleaning up")
+	assert.Equal(t, 1, removed, "expected only the expired upload to be removed")
+
+	freshID := strings.TrimPrefix(fresh, "/files/")
+	assert.Equal(t, []string{DefaultStagingPrefix + freshID + "/info"}, stagedKeys(t, manager), "expected fresh upload to be kept")
+	assert.Empty(t, h.locked, "expected no lock to be kept for the expired upload")
+}
+
+func TestHandler_Lock(t *testing.T) {
+	h, _, _ := newTestHandler(t, newDisk(t))
+
+	unlock, ok := h.lock("a")
+	require.True(t, ok, "expected first lock to succeed")
+
+	_, ok = h.lock("a")
+	assert.False(t, ok, "expected second lock of the same upload to fail")
+
+	unlockB, ok := h.lock("b")
+	require.True(t, ok, "expected other uploads not to be blocked")
+	unlockB()
+
+	unlock()
+	assert.Empty(t, h.locked, "expected released locks to be forgotten")
+
+	unlock, ok = h.lock("a")
+	assert.True(t, ok, "expected lock to be acquired again once released")
+	unlock()
+}
+
+func TestHandler_EmptyUpload(t *testing.T) {
+	var completed int
+	h, manager, _ := newTestHandler(t, newDisk(t),
+		WithKeyFunc(func(ctx context.Context, u Upload) (string, error) { return "empty.txt", nil }),
+		WithCompleteHook(func(ctx context.Context, u Upload) { completed++ }),
+	)
+
+	create(t, h, 0, "")
+
+	assert.Equal(t, "", readFile(t, manager, "empty.txt"), "expected empty file to be stored right away")
+	assert.Equal(t, 1, completed, "expected completion hook to run")
+}
+
+func TestHandler_Routing(t *testing.T) {
+	h, _, _ := newTestHandler(t, newDisk(t))
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		expectedStatus int
+	}{
+		{name: "should reject paths outside base path", method: http.MethodHead, target: "/other/abc", expectedStatus: http.StatusNotFound},
+		{name: "should reject malformed upload IDs", method: http.MethodHead, target: "/files/../info", expectedStatus: http.StatusNotFound},
+		{name: "should reject unknown uploads", method: http.MethodHead, target: "/files/" + strings.Repeat("a", 32), expectedStatus: http.StatusNotFound},
+		{name: "should reject GET on base path", method: http.MethodGet, target: "/files/", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "should reject GET on upload", method: http.MethodGet, target: "/files/" + strings.Repeat("a", 32), expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(h, tt.method, tt.target, nil, "")
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, "expected status code to match")
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog/log"
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// uploadInfo is the state of an upload, stored as JSON under "<prefix><id>/info".
+// Saving it commits everything written before; chunks written after the last save are
+// overwritten when the client resumes from the saved offset.
+type uploadInfo struct {
+	Upload
+
+	// MultipartID is the storage's multipart upload ID. Without one, chunks are staged
+	// as separate objects under "<prefix><id>/part-NNNNNN".
+	MultipartID string           `json:"multipart_id,omitempty"`
+	Parts       []gostorage.Part `json:"parts,omitempty"`
+
+	// PendingKey holds received bytes too few to form a part yet (the storage may require
+	// a minimum part size). It is named after the offset it ends at, so a rejected chunk
+	// never overwrites the pending bytes the saved state refers to.
+	PendingKey  string `json:"pending_key,omitempty"`
+	PendingSize int64  `json:"pending_size,omitempty"`
+}
+
+func (h *Handler) infoKey(id string) string {
+	return h.prefix + id + "/info"
+}
+
+func (h *Handler) partKey(id string, number int) string {
+	return fmt.Sprintf("%s%s/part-%06d", h.prefix, id, number)
+}
+
+func (h *Handler) pendingKey(id string, offset int64) string {
+	return fmt.Sprintf("%s%s/pending-%d", h.prefix, id, offset)
+}
+
+// loadInfo reads the state of an upload. Returns gostorage.ErrNotFound for unknown uploads.
+func (h *Handler) loadInfo(ctx context.Context, id string) (*uploadInfo, error) {
+	body, err := h.manager.GetRange(ctx, h.infoKey(id), 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	info := &uploadInfo{}
+	if err := json.NewDecoder(body).Decode(info); err != nil {
+		return nil, fmt.Errorf("tus: decode upload %s: %w", id, err)
+	}
+
+	return info, nil
+}
+
+// saveInfo commits the state of an upload.
+func (h *Handler) saveInfo(ctx context.Context, info *uploadInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.manager.Put(ctx, h.infoKey(info.ID), bytes.NewReader(data))
+	return err
+}
+
+// start prepares the storage for a new upload: a multipart upload to the final key if the storage
+// supports it, or nothing for staged chunks. Empty uploads are stored right away.
+func (h *Handler) start(ctx context.Context, info *uploadInfo) error {
+	if info.Size == 0 {
+		if _, err := h.manager.Put(ctx, info.Key, bytes.NewReader(nil)); err != nil {
+			return err
+		}
+
+		info.Completed = true
+		return nil
+	}
+
+	caps, err := h.manager.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+
+	if caps.Multipart {
+		info.MultipartID, err = h.manager.CreateMultipartUpload(ctx, info.Key)
+	}
+
+	return err
+}
+
+// write reads body into parts of partSize bytes, at least minPartSize for multipart uploads,
+// keeping a shorter remainder as pending bytes.
+// Without a checksum, progress is committed after every part so an interrupted request keeps
+// what was received. With a checksum, nothing is committed unless the whole body matches it.
+func (h *Handler) write(ctx context.Context, info *uploadInfo, body io.Reader, sum *checksum) error {
+	size := h.partSize
+	if info.MultipartID != "" {
+		size = max(size, h.minPartSize)
+	}
+
+	buf := make([]byte, size)
+	fill, err := h.readPending(ctx, info, buf)
+	if err != nil {
+		return err
+	}
+	previousPending := info.PendingKey
+
+	body = io.LimitReader(body, info.Size-info.Offset)
+	if sum != nil {
+		body = io.TeeReader(body, sum.hash)
+	}
+
+	var readErr error
+	for {
+		n, err := io.ReadFull(body, buf[fill:])
+		fill += n
+		info.Offset += int64(n)
+
+		if fill == len(buf) || (info.Offset == info.Size && fill > 0) {
+			if err := h.writePart(ctx, info, buf[:fill]); err != nil {
+				return err
+			}
+			fill = 0
+			info.PendingKey, info.PendingSize = "", 0
+
+			if sum == nil {
+				if err := h.saveInfo(ctx, info); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				readErr = err
+			}
+			break
+		}
+	}
+
+	if readErr != nil && sum != nil {
+		return readErr
+	}
+
+	if fill > 0 {
+		info.PendingKey, info.PendingSize = h.pendingKey(info.ID, info.Offset), int64(fill)
+		if _, err := h.manager.Put(ctx, info.PendingKey, bytes.NewReader(buf[:fill])); err != nil {
+			return err
+		}
+	}
+
+	if sum != nil && !sum.matches() {
+		return errChecksumMismatch
+	}
+
+	if info.Offset == info.Size {
+		if err := h.finish(ctx, info); err != nil {
+			return err
+		}
+	}
+
+	if err := h.saveInfo(ctx, info); err != nil {
+		return err
+	}
+
+	if previousPending != "" && previousPending != info.PendingKey {
+		h.deleteStaged(ctx, previousPending)
+	}
+
+	return nil
+}
+
+// readPending copies the pending bytes of an upload into buf and returns their count.
+// It fails if they do not fit, which happens when the part size shrank since they were written.
+func (h *Handler) readPending(ctx context.Context, info *uploadInfo, buf []byte) (int, error) {
+	if info.PendingSize == 0 {
+		return 0, nil
+	}
+
+	if info.PendingSize > int64(len(buf)) {
+		return 0, fmt.Errorf("tus: %d pending bytes exceed the part size of %d bytes", info.PendingSize, len(buf))
+	}
+
+	body, err := h.manager.GetRange(ctx, info.PendingKey, 0, info.PendingSize)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	return io.ReadFull(body, buf[:info.PendingSize])
+}
+
+// writePart stores data as the next part of an upload.
+func (h *Handler) writePart(ctx context.Context, info *uploadInfo, data []byte) error {
+	number := len(info.Parts) + 1
+
+	if info.MultipartID != "" {
+		part, err := h.manager.UploadPart(ctx, info.Key, info.MultipartID, number, bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+
+		info.Parts = append(info.Parts, part)
+		return nil
+	}
+
+	if _, err := h.manager.Put(ctx, h.partKey(info.ID, number), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	info.Parts = append(info.Parts, gostorage.Part{Number: number, Size: int64(len(data))})
+	return nil
+}
+
+// finish assembles the parts into the final key and removes staged chunks.
+func (h *Handler) finish(ctx context.Context, info *uploadInfo) error {
+	if info.MultipartID != "" {
+		if err := h.manager.CompleteMultipartUpload(ctx, info.Key, info.MultipartID, info.Parts); err != nil {
+			return err
+		}
+	} else {
+		keys := h.stagedPartKeys(info)
+		if _, err := h.manager.Put(ctx, info.Key, &concatReader{ctx: ctx, manager: h.manager, keys: keys}); err != nil {
+			return err
+		}
+
+		h.deleteStaged(ctx, keys...)
+	}
+
+	info.Completed = true
+	return nil
+}
+
+// discard aborts an upload and deletes its state and chunks.
+func (h *Handler) discard(ctx context.Context, info *uploadInfo) error {
+	if info.MultipartID != "" && !info.Completed {
+		if err := h.manager.AbortMultipartUpload(ctx, info.Key, info.MultipartID); err != nil && !errors.Is(err, gostorage.ErrNotFound) {
+			return err
+		}
+	}
+
+	// listing also removes chunks of requests that failed before their state was saved
+	_, err := h.manager.DeletePrefix(ctx, h.prefix+info.ID+"/", gostorage.DeletePrefixOptions{})
+	if !errors.Is(err, gostorage.ErrNotSupported) {
+		return err
+	}
+
+	keys := append(h.stagedPartKeys(info), h.infoKey(info.ID))
+	if info.PendingKey != "" {
+		keys = append(keys, info.PendingKey)
+	}
+
+	return h.manager.DeleteMany(ctx, keys...)
+}
+
+// stagedPartKeys returns the keys of the parts staged as separate objects.
+func (h *Handler) stagedPartKeys(info *uploadInfo) []string {
+	if info.MultipartID != "" {
+		return nil
+	}
+
+	keys := make([]string, len(info.Parts))
+	for i, part := range info.Parts {
+		keys[i] = h.partKey(info.ID, part.Number)
+	}
+
+	return keys
+}
+
+// deleteStaged removes staged objects that are no longer needed, logging failures.
+func (h *Handler) deleteStaged(ctx context.Context, keys ...string) {
+	if err := h.manager.DeleteMany(ctx, keys...); err != nil {
+		log.Warn().Err(err).Strs("keys", keys).Msg("failed to delete staged tus chunks")
+	}
+}
+
+// concatReader reads the staged parts one after another.
+type concatReader struct {
+	ctx     context.Context
+	manager gostorage.StorageManager
+	keys    []string
+	current io.ReadCloser
+}
+
+func (c *concatReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.keys) == 0 {
+				return 0, io.EOF
+			}
+
+			body, err := c.manager.GetRange(c.ctx, c.keys[0], 0, -1)
+			if err != nil {
+				return 0, err
+			}
+			c.current, c.keys = body, c.keys[1:]
+		}
+
+		n, err := c.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+
+		return n, err
+	}
+}
@@ -0,0 +1,52 @@
+package gostorage
+
+import "time"
+
+// DefaultSignedURLExpiry is the lifetime of URLs signed by StorageManager.URL when neither
+// the options nor the storage provide an expiry.
+const DefaultSignedURLExpiry = 15 * time.Minute
+
+// Visibility describes who may access a file.
+type Visibility string
+
+const (
+	VisibilityPrivate Visibility = "private" // Files are private, need signed URL to access
+	VisibilityPublic  Visibility = "public"  // Files are publicly accessible via direct URL
+)
+
+// URLOptions controls how StorageManager.URL builds a URL.
+type URLOptions struct {
+	// Expiry is the lifetime of a signed URL. Defaults to the storage's default expiry,
+	// then to DefaultSignedURLExpiry.
+	Expiry time.Duration
+
+	// Visibility skips the visibility lookup and forces a public (direct) or private (signed) URL.
+	Visibility Visibility
+}
+
+// defaultVisibility returns the visibility of files on driver that have none of their own.
+// Drivers that do not report one are considered public if they serve direct URLs.
+func defaultVisibility(driver StorageDriver) Visibility {
+	if defaults, ok := driver.(DefaultsReporter); ok && defaults.DefaultVisibility() != "" {
+		return defaults.DefaultVisibility()
+	}
+
+	if CapabilitiesOf(driver).PublicURL {
+		return VisibilityPublic
+	}
+
+	return VisibilityPrivate
+}
+
+// signedURLExpiry returns expiry, or the driver's default expiry, or DefaultSignedURLExpiry.
+func signedURLExpiry(driver StorageDriver, expiry time.Duration) time.Duration {
+	if expiry > 0 {
+		return expiry
+	}
+
+	if defaults, ok := driver.(DefaultsReporter); ok && defaults.DefaultExpiry() > 0 {
+		return defaults.DefaultExpiry()
+	}
+
+	return DefaultSignedURLExpiry
+}
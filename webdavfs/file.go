@@ -0,0 +1,208 @@
+package webdavfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"sync"
+	"time"
+
+	gostorage "github.com/shoraid/go-storage"
+	"golang.org/x/net/webdav"
+)
+
+// fileInfo describes a file or an emulated directory. It also reports the storage's
+// ETag and an extension-based content type, so PROPFIND does not have to open files.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	etag    string
+	dir     bool
+}
+
+func newFileInfo(obj gostorage.ObjectInfo) *fileInfo {
+	return &fileInfo{name: nameOf(obj.Key), size: obj.Size, modTime: obj.LastModified, etag: obj.ETag}
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+
+	return 0o644
+}
+
+// ETag implements webdav.ETager.
+func (i *fileInfo) ETag(ctx context.Context) (string, error) {
+	if i.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+
+	return i.etag, nil
+}
+
+// ContentType implements webdav.ContentTyper.
+func (i *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if contentType := mime.TypeByExtension(path.Ext(i.name)); contentType != "" {
+		return contentType, nil
+	}
+
+	return "", webdav.ErrNotImplemented
+}
+
+// readFile is a file opened for reading, served by range requests.
+type readFile struct {
+	*io.SectionReader
+	info *fileInfo
+}
+
+func (f *readFile) Close() error                { return nil }
+func (f *readFile) Stat() (fs.FileInfo, error)  { return f.info, nil }
+func (f *readFile) Write(p []byte) (int, error) { return 0, fs.ErrPermission }
+func (f *readFile) Readdir(int) ([]fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: f.info.name, Err: fs.ErrInvalid}
+}
+
+// dirFile is an emulated directory. Its entries are listed on the first Readdir call.
+type dirFile struct {
+	fs      *FileSystem
+	ctx     context.Context
+	key     string
+	info    *fileInfo
+	entries []fs.FileInfo
+	loaded  bool
+}
+
+func (d *dirFile) Close() error                                 { return nil }
+func (d *dirFile) Stat() (fs.FileInfo, error)                   { return d.info, nil }
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d *dirFile) Write(p []byte) (int, error)                  { return 0, fs.ErrPermission }
+
+func (d *dirFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// Readdir returns the files and directories directly below d, without directory markers.
+func (d *dirFile) Readdir(count int) ([]fs.FileInfo, error) {
+	if !d.loaded {
+		prefix := ""
+		if d.key != "" {
+			prefix = d.key + "/"
+		}
+
+		err := d.fs.manager.List(d.ctx, gostorage.ListOptions{Prefix: prefix}, func(obj gostorage.ObjectInfo) error {
+			switch {
+			case obj.IsDir:
+				d.entries = append(d.entries, &fileInfo{name: nameOf(obj.Key), dir: true})
+			case path.Base(obj.Key) != dirMarker:
+				d.entries = append(d.entries, newFileInfo(obj))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, toFSError(err)
+		}
+
+		d.loaded = true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n := min(count, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// writeFile streams everything written to it into the storage. The upload runs while the
+// client writes and is committed by Close, which reports its outcome.
+type writeFile struct {
+	info *fileInfo
+	pipe *io.PipeWriter
+	done chan error
+
+	mu     sync.Mutex
+	closed bool
+	err    error
+}
+
+func newWriteFile(ctx context.Context, manager gostorage.StorageManager, key string) *writeFile {
+	reader, writer := io.Pipe()
+	f := &writeFile{info: &fileInfo{name: nameOf(key), modTime: time.Now()}, pipe: writer, done: make(chan error, 1)}
+
+	go func() {
+		_, err := manager.Put(ctx, key, reader)
+		reader.CloseWithError(err)
+		f.done <- err
+	}()
+
+	return f
+}
+
+func (f *writeFile) Write(p []byte) (int, error) {
+	n, err := f.pipe.Write(p)
+
+	f.mu.Lock()
+	f.info.size += int64(n)
+	f.mu.Unlock()
+
+	return n, err
+}
+
+// Close finishes the upload and returns its error, if any.
+func (f *writeFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.closed {
+		f.closed = true
+		f.pipe.Close()
+		f.err = toFSError(<-f.done)
+	}
+
+	return f.err
+}
+
+func (f *writeFile) Stat() (fs.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info := *f.info
+	return &info, nil
+}
+
+func (f *writeFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrInvalid}
+}
+
+func (f *writeFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if offset == 0 && (whence == io.SeekCurrent || whence == io.SeekEnd) {
+		return f.info.size, nil
+	}
+
+	return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+}
+
+func (f *writeFile) Readdir(int) ([]fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: f.info.name, Err: fs.ErrInvalid}
+}
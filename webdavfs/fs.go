@@ -0,0 +1,288 @@
+// Package webdavfs adapts a StorageManager to golang.org/x/net/webdav, so a storage can be
+// mounted as a network drive in Finder, Explorer or any other WebDAV client:
+//
+//	manager, _ := gostorage.NewFromConfig(cfg)
+//	http.Handle("/dav/", &webdav.Handler{
+//		Prefix:     "/dav",
+//		FileSystem: webdavfs.New(manager, webdavfs.WithAlias("media")),
+//		LockSystem: webdav.NewMemLS(),
+//	})
+//
+// Keys map to paths and directories are emulated over key prefixes: a directory exists as
+// long as a key lives below it. Empty directories created with MKCOL are kept by a ".keep"
+// marker file, which is hidden from listings. Names with spaces or other characters keys do not
+// allow are escaped, e.g. "untitled folder" is stored as "untitled_20folder". The storage must
+// implement gostorage.Lister and gostorage.RangeReader. Writes stream into the storage and always
+// replace the whole file.
+package webdavfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	gostorage "github.com/shoraid/go-storage"
+	"golang.org/x/net/webdav"
+)
+
+// dirMarker is the file that keeps an otherwise empty directory alive.
+const dirMarker = ".keep"
+
+// errFound stops a listing at its first entry.
+var errFound = errors.New("webdavfs: found")
+
+// FileSystem implements webdav.FileSystem on top of a StorageManager.
+type FileSystem struct {
+	manager  gostorage.StorageManager
+	readOnly bool
+}
+
+// Option configures a FileSystem.
+type Option func(*FileSystem)
+
+// WithAlias exposes the storage registered under alias instead of the manager's default storage.
+func WithAlias(alias string) Option {
+	return func(f *FileSystem) {
+		f.manager = f.manager.Storage(alias)
+	}
+}
+
+// WithReadOnly rejects every modification with fs.ErrPermission.
+func WithReadOnly() Option {
+	return func(f *FileSystem) {
+		f.readOnly = true
+	}
+}
+
+// New returns a FileSystem exposing manager's default storage.
+func New(manager gostorage.StorageManager, opts ...Option) *FileSystem {
+	f := &FileSystem{manager: manager}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+var _ webdav.FileSystem = (*FileSystem)(nil)
+
+// Mkdir creates an empty directory by storing its marker file.
+// Like os.Mkdir, it fails if name exists or its parent directory does not.
+func (f *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	key := toKey(name)
+	if f.readOnly {
+		return pathError("mkdir", name, fs.ErrPermission)
+	}
+	if key == "" {
+		return pathError("mkdir", name, fs.ErrExist)
+	}
+
+	if _, err := f.stat(ctx, key); err == nil {
+		return pathError("mkdir", name, fs.ErrExist)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return pathError("mkdir", name, err)
+	}
+
+	if parent := path.Dir(key); parent != "." {
+		info, err := f.stat(ctx, parent)
+		if err != nil {
+			return pathError("mkdir", name, err)
+		}
+		if !info.IsDir() {
+			return pathError("mkdir", name, fs.ErrNotExist)
+		}
+	}
+
+	if _, err := f.manager.Put(ctx, key+"/"+dirMarker, bytes.NewReader(nil)); err != nil {
+		return pathError("mkdir", name, toFSError(err))
+	}
+
+	return nil
+}
+
+// OpenFile opens a file or directory for reading, or a file for writing when flag contains
+// os.O_WRONLY or os.O_RDWR. Written content replaces the file when it is closed.
+func (f *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	key := toKey(name)
+
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		info, err := f.stat(ctx, key)
+		if err != nil {
+			return nil, pathError("open", name, err)
+		}
+
+		if info.IsDir() {
+			return &dirFile{fs: f, ctx: ctx, key: key, info: info}, nil
+		}
+
+		reader, err := f.manager.OpenReaderAt(ctx, key)
+		if err != nil {
+			return nil, pathError("open", name, toFSError(err))
+		}
+
+		return &readFile{info: info, SectionReader: io.NewSectionReader(reader, 0, reader.Size())}, nil
+	}
+
+	if f.readOnly {
+		return nil, pathError("open", name, fs.ErrPermission)
+	}
+	if key == "" || flag&os.O_APPEND != 0 || gostorage.ValidateKey(key) != nil {
+		return nil, pathError("open", name, fs.ErrInvalid)
+	}
+
+	if flag&os.O_EXCL != 0 {
+		if _, err := f.stat(ctx, key); err == nil {
+			return nil, pathError("open", name, fs.ErrExist)
+		}
+	}
+
+	return newWriteFile(ctx, f.manager, key), nil
+}
+
+// RemoveAll deletes a file or a directory with everything below it.
+func (f *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	key := toKey(name)
+	if f.readOnly || key == "" {
+		return pathError("removeall", name, fs.ErrPermission)
+	}
+
+	info, err := f.stat(ctx, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return pathError("removeall", name, err)
+	}
+
+	if !info.IsDir() {
+		if err := f.manager.Delete(ctx, key); err != nil && !errors.Is(err, gostorage.ErrNotFound) {
+			return pathError("removeall", name, toFSError(err))
+		}
+	}
+
+	if _, err := f.manager.DeletePrefix(ctx, key+"/", gostorage.DeletePrefixOptions{}); err != nil {
+		return pathError("removeall", name, toFSError(err))
+	}
+
+	return nil
+}
+
+// Rename moves a file, or every key below a directory, by copying and deleting.
+func (f *FileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldKey, newKey := toKey(oldName), toKey(newName)
+	if f.readOnly || oldKey == "" || newKey == "" {
+		return pathError("rename", oldName, fs.ErrPermission)
+	}
+	if newKey == oldKey || strings.HasPrefix(newKey, oldKey+"/") {
+		return pathError("rename", oldName, fs.ErrInvalid)
+	}
+
+	info, err := f.stat(ctx, oldKey)
+	if err != nil {
+		return pathError("rename", oldName, err)
+	}
+
+	if !info.IsDir() {
+		return pathError("rename", oldName, f.move(ctx, oldKey, newKey))
+	}
+
+	var keys []string
+	err = f.manager.List(ctx, gostorage.ListOptions{Prefix: oldKey + "/", Recursive: true}, func(obj gostorage.ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		return pathError("rename", oldName, toFSError(err))
+	}
+
+	for _, key := range keys {
+		if err := f.move(ctx, key, newKey+strings.TrimPrefix(key, oldKey)); err != nil {
+			return pathError("rename", oldName, err)
+		}
+	}
+
+	return nil
+}
+
+// Stat describes a file or directory.
+func (f *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	info, err := f.stat(ctx, toKey(name))
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+
+	return info, nil
+}
+
+// stat describes key, preferring a file over a directory of the same name.
+// Returns fs.ErrNotExist if neither exists.
+func (f *FileSystem) stat(ctx context.Context, key string) (*fileInfo, error) {
+	if key == "" {
+		return &fileInfo{name: "/", dir: true}, nil
+	}
+
+	obj, err := f.manager.Stat(ctx, key)
+	switch {
+	case err == nil:
+		return newFileInfo(obj), nil
+	case errors.Is(err, gostorage.ErrInvalidKey):
+		return nil, fs.ErrNotExist
+	case !errors.Is(err, gostorage.ErrNotFound):
+		return nil, toFSError(err)
+	}
+
+	err = f.manager.List(ctx, gostorage.ListOptions{Prefix: key + "/"}, func(gostorage.ObjectInfo) error {
+		return errFound
+	})
+	switch {
+	case errors.Is(err, errFound):
+		return &fileInfo{name: nameOf(key), dir: true}, nil
+	case err == nil, errors.Is(err, gostorage.ErrInvalidKey):
+		return nil, fs.ErrNotExist
+	default:
+		return nil, toFSError(err)
+	}
+}
+
+// move copies src to dst and deletes src.
+func (f *FileSystem) move(ctx context.Context, src, dst string) error {
+	body, err := f.manager.GetRange(ctx, src, 0, -1)
+	if err != nil {
+		return toFSError(err)
+	}
+	defer body.Close()
+
+	if _, err := f.manager.Put(ctx, dst, body); err != nil {
+		return toFSError(err)
+	}
+
+	return toFSError(f.manager.Delete(ctx, src))
+}
+
+// toFSError maps storage errors to their io/fs equivalents, which webdav.Handler
+// turns into status codes.
+func toFSError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gostorage.ErrNotFound):
+		return fs.ErrNotExist
+	case errors.Is(err, gostorage.ErrInvalidKey):
+		return fs.ErrInvalid
+	default:
+		return err
+	}
+}
+
+func pathError(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
@@ -0,0 +1,280 @@
+package webdavfs_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	gostorage "github.com/shoraid/go-storage"
+	localdriver "github.com/shoraid/go-storage/drivers/local"
+	"github.com/shoraid/go-storage/webdavfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+// newTestManager returns a manager over a local disk seeded with a few files.
+func newTestManager(t *testing.T) gostorage.StorageManager {
+	t.Helper()
+
+	driver, err := localdriver.NewDiskStorage(localdriver.DiskStorageConfig{Root: t.TempDir()})
+	require.NoError(t, err, "expected no error creating disk storage")
+
+	manager, err := gostorage.NewStorageManager("media", map[string]gostorage.StorageDriver{"media": driver})
+	require.NoError(t, err, "expected no error creating manager")
+
+	for key, content := range map[string]string{
+		"readme.txt":            "hello",
+		"photos/2024/beach.jpg": "jpeg",
+		"photos/cover.png":      "png",
+	} {
+		_, err := manager.Put(context.Background(), key, strings.NewReader(content))
+		require.NoError(t, err, "expected no error seeding %s", key)
+	}
+
+	return manager
+}
+
+// newTestServer serves fs through webdav.Handler.
+func newTestServer(t *testing.T, fileSystem webdav.FileSystem) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(&webdav.Handler{FileSystem: fileSystem, LockSystem: webdav.NewMemLS()})
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// do sends a WebDAV request and returns the status code and body.
+func do(t *testing.T, server *httptest.Server, method, target string, header map[string]string, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, server.URL+target, strings.NewReader(body))
+	require.NoError(t, err, "expected no error building request")
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "expected no transport error")
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "expected no error reading body")
+
+	return resp.StatusCode, string(data)
+}
+
+func TestFileSystem_Read(t *testing.T) {
+	server := newTestServer(t, webdavfs.New(newTestManager(t)))
+
+	status, body := do(t, server, http.MethodGet, "/readme.txt", nil, "")
+	assert.Equal(t, http.StatusOK, status, "expected file to be served")
+	assert.Equal(t, "hello", body, "expected file content")
+
+	status, body = do(t, server, http.MethodGet, "/readme.txt", map[string]string{"Range": "bytes=1-3"}, "")
+	assert.Equal(t, http.StatusPartialContent, status, "expected range to be served")
+	assert.Equal(t, "ell", body, "expected requested range")
+
+	status, _ = do(t, server, http.MethodGet, "/missing.txt", nil, "")
+	assert.Equal(t, http.StatusNotFound, status, "expected 404 for missing file")
+
+	status, body = do(t, server, "PROPFIND", "/photos/", map[string]string{"Depth": "1"}, "")
+	assert.Equal(t, http.StatusMultiStatus, status, "expected directory listing")
+	assert.Contains(t, body, "<D:href>/photos/</D:href>", "expected the directory itself")
+	assert.Contains(t, body, "<D:href>/photos/2024/</D:href>", "expected emulated subdirectory")
+	assert.Contains(t, body, "<D:href>/photos/cover.png</D:href>", "expected file in directory")
+	assert.Contains(t, body, "<D:getcontenttype>image/png</D:getcontenttype>", "expected content type from extension")
+	assert.NotContains(t, body, "beach.jpg", "expected depth 1 not to list nested files")
+
+	status, _ = do(t, server, "PROPFIND", "/nothing/", map[string]string{"Depth": "1"}, "")
+	assert.Equal(t, http.StatusNotFound, status, "expected 404 for missing directory")
+}
+
+func TestFileSystem_Write(t *testing.T) {
+	manager := newTestManager(t)
+	server := newTestServer(t, webdavfs.New(manager))
+	ctx := context.Background()
+
+	status, _ := do(t, server, http.MethodPut, "/docs/new.txt", nil, "fresh content")
+	assert.Equal(t, http.StatusCreated, status, "expected file to be created")
+	assert.Equal(t, "fresh content", readFile(t, manager, "docs/new.txt"), "expected content in storage")
+
+	status, _ = do(t, server, "MKCOL", "/empty", nil, "")
+	assert.Equal(t, http.StatusCreated, status, "expected directory to be created")
+
+	status, _ = do(t, server, "MKCOL", "/empty", nil, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, status, "expected existing directory to be rejected")
+
+	status, _ = do(t, server, "MKCOL", "/missing/child", nil, "")
+	assert.Equal(t, http.StatusConflict, status, "expected missing parent to be rejected")
+
+	status, body := do(t, server, "PROPFIND", "/empty/", map[string]string{"Depth": "1"}, "")
+	assert.Equal(t, http.StatusMultiStatus, status, "expected empty directory to exist")
+	assert.NotContains(t, body, ".keep", "expected directory marker to be hidden")
+
+	status, _ = do(t, server, "MOVE", "/photos", map[string]string{"Destination": server.URL + "/archive/photos"}, "")
+	assert.Equal(t, http.StatusCreated, status, "expected directory to be moved")
+	assert.Equal(t, "jpeg", readFile(t, manager, "archive/photos/2024/beach.jpg"), "expected nested file to be moved")
+
+	exists, err := manager.Exists(ctx, "photos/cover.png")
+	require.NoError(t, err, "expected no error checking storage")
+	assert.False(t, exists, "expected source to be removed after move")
+
+	status, _ = do(t, server, "COPY", "/readme.txt", map[string]string{"Destination": server.URL + "/docs/readme-copy.txt"}, "")
+	assert.Equal(t, http.StatusCreated, status, "expected file to be copied")
+	assert.Equal(t, "hello", readFile(t, manager, "docs/readme-copy.txt"), "expected copied content")
+
+	status, _ = do(t, server, http.MethodDelete, "/archive", nil, "")
+	assert.Equal(t, http.StatusNoContent, status, "expected directory to be deleted")
+
+	exists, err = manager.Exists(ctx, "archive/photos/2024/beach.jpg")
+	require.NoError(t, err, "expected no error checking storage")
+	assert.False(t, exists, "expected files below deleted directory to be removed")
+}
+
+func TestFileSystem_EscapedNames(t *testing.T) {
+	manager := newTestManager(t)
+	server := newTestServer(t, webdavfs.New(manager))
+
+	status, _ := do(t, server, "MKCOL", "/untitled%20folder", nil, "")
+	assert.Equal(t, http.StatusCreated, status, "expected directory with a space to be created")
+
+	status, _ = do(t, server, http.MethodPut, "/untitled%20folder/%C3%9Cberblick%20(1).pdf", nil, "pdf")
+	assert.Equal(t, http.StatusCreated, status, "expected file with unicode name to be created")
+	assert.Equal(t, "pdf", readFile(t, manager, "untitled_20folder/_C3_9Cberblick_20_281_29.pdf"), "expected escaped key in storage")
+
+	status, body := do(t, server, http.MethodGet, "/untitled%20folder/%C3%9Cberblick%20(1).pdf", nil, "")
+	assert.Equal(t, http.StatusOK, status, "expected file to be served by its name")
+	assert.Equal(t, "pdf", body, "expected file content")
+
+	status, body = do(t, server, "PROPFIND", "/untitled%20folder/", map[string]string{"Depth": "1"}, "")
+	assert.Equal(t, http.StatusMultiStatus, status, "expected directory to be listed")
+	assert.Contains(t, body, "<D:displayname>Überblick (1).pdf</D:displayname>", "expected unescaped name in listing")
+
+	status, _ = do(t, server, "MOVE", "/untitled%20folder", map[string]string{"Destination": server.URL + "/Neuer%20Ordner"}, "")
+	assert.Equal(t, http.StatusCreated, status, "expected directory to be renamed")
+	assert.Equal(t, "pdf", readFile(t, manager, "Neuer_20Ordner/_C3_9Cberblick_20_281_29.pdf"), "expected file below renamed directory")
+}
+
+func TestFileSystem_NameMapping(t *testing.T) {
+	manager := newTestManager(t)
+	davFS := webdavfs.New(manager)
+	ctx := context.Background()
+
+	for _, key := range []string{"snake_case.txt", "odd_BC.txt", "file_20.txt"} {
+		_, err := manager.Put(ctx, key, strings.NewReader(key))
+		require.NoError(t, err, "expected no error seeding %s", key)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{name: "should keep valid names", key: "snake_case.txt", expected: "snake_case.txt"},
+		{name: "should keep keys that only look escaped", key: "odd_BC.txt", expected: "odd_BC.txt"},
+		{name: "should show escaped keys by their name", key: "file_20.txt", expected: "file .txt"},
+		{name: "should escape a name that reads as an escape", key: "file_5F20.txt", expected: "file_20.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := davFS.OpenFile(ctx, "/"+tt.expected, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+			require.NoError(t, err, "expected no error opening %q for writing", tt.expected)
+			_, err = io.WriteString(f, tt.expected)
+			require.NoError(t, err, "expected no error writing")
+			require.NoError(t, f.Close(), "expected no error closing")
+
+			assert.Equal(t, tt.expected, readFile(t, manager, tt.key), "expected name to be stored under the key")
+
+			info, err := davFS.Stat(ctx, "/"+tt.expected)
+			require.NoError(t, err, "expected name to be found")
+			assert.Equal(t, tt.expected, info.Name(), "expected name to round-trip")
+		})
+	}
+}
+
+func TestFileSystem_ReadOnly(t *testing.T) {
+	manager := newTestManager(t)
+	server := newTestServer(t, webdavfs.New(manager, webdavfs.WithReadOnly()))
+
+	status, body := do(t, server, http.MethodGet, "/readme.txt", nil, "")
+	assert.Equal(t, http.StatusOK, status, "expected reads to be allowed")
+	assert.Equal(t, "hello", body, "expected file content")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header map[string]string
+	}{
+		{name: "should reject PUT", method: http.MethodPut, target: "/readme.txt"},
+		{name: "should reject MKCOL", method: "MKCOL", target: "/new"},
+		{name: "should reject DELETE", method: http.MethodDelete, target: "/readme.txt"},
+		{name: "should reject MOVE", method: "MOVE", target: "/readme.txt", header: map[string]string{"Destination": server.URL + "/moved.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _ := do(t, server, tt.method, tt.target, tt.header, "changed")
+
+			assert.GreaterOrEqual(t, status, 400, "expected modification to fail")
+		})
+	}
+
+	assert.Equal(t, "hello", readFile(t, manager, "readme.txt"), "expected file to be unchanged")
+}
+
+func TestFileSystem_Stat(t *testing.T) {
+	davFS := webdavfs.New(newTestManager(t))
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedDir   bool
+		expectedSize  int64
+		expectedError bool
+	}{
+		{name: "should stat root", path: "/", expectedDir: true},
+		{name: "should stat file", path: "/readme.txt", expectedSize: 5},
+		{name: "should stat emulated directory", path: "/photos/2024", expectedDir: true},
+		{name: "should not find missing path", path: "/photos/2025", expectedError: true},
+		{name: "should not find missing name with spaces", path: "/my file.txt", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := davFS.Stat(ctx, tt.path)
+
+			if tt.expectedError {
+				assert.ErrorIs(t, err, fs.ErrNotExist, "expected not exist error")
+				return
+			}
+
+			require.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.expectedDir, info.IsDir(), "expected directory flag to match")
+			assert.Equal(t, tt.expectedSize, info.Size(), "expected size to match")
+		})
+	}
+}
+
+// readFile returns the content stored under key.
+func readFile(t *testing.T, manager gostorage.StorageManager, key string) string {
+	t.Helper()
+
+	body, err := manager.GetRange(context.Background(), key, 0, -1)
+	require.NoError(t, err, "expected file %s to exist", key)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err, "expected no error reading file")
+
+	return string(data)
+}
@@ -0,0 +1,116 @@
+package webdavfs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	gostorage "github.com/shoraid/go-storage"
+)
+
+// WebDAV clients create names such as "untitled folder" or "Überblick.pdf", while keys only
+// allow letters, digits, ".", "_" and "-" per segment. Names are therefore mapped to keys
+// segment by segment: a name that already is a valid segment is used as is, any other name is
+// escaped by writing each disallowed byte as "_" followed by two upper-case hex digits, so
+// "untitled folder" is stored as "untitled_20folder". A "_" that would read as such an escape
+// is escaped itself. Keys written by other code are shown unchanged unless they are exactly
+// the escaped form of a name, which keeps the mapping reversible in both directions.
+
+// toKey converts a WebDAV path to a storage key, "" for the root.
+func toKey(name string) string {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return ""
+	}
+
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = escapeName(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// nameOf returns the WebDAV name of the last segment of key.
+func nameOf(key string) string {
+	return unescapeName(path.Base(key))
+}
+
+// escapeName converts a single WebDAV name to a key segment.
+func escapeName(name string) string {
+	if _, escaped := decodeName(name); !escaped && gostorage.ValidateKey(name) == nil {
+		return name
+	}
+
+	return encodeName(name)
+}
+
+// unescapeName converts a key segment back to the WebDAV name it was escaped from.
+func unescapeName(segment string) string {
+	if name, escaped := decodeName(segment); escaped {
+		return name
+	}
+
+	return segment
+}
+
+// encodeName escapes every byte that is not allowed in a key segment, and every "_" that
+// is followed by two upper-case hex digits.
+func encodeName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case isKeyByte(c):
+			b.WriteByte(c)
+		case c == '_' && !(i+2 < len(name) && isUpperHex(name[i+1]) && isUpperHex(name[i+2])):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "_%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// decodeName reverses encodeName. It reports false unless segment contains an escape and is
+// exactly the encoding of a valid UTF-8 name, so keys that merely look escaped stay literal.
+func decodeName(segment string) (string, bool) {
+	var b strings.Builder
+	escaped := false
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if c == '_' && i+2 < len(segment) && isUpperHex(segment[i+1]) && isUpperHex(segment[i+2]) {
+			b.WriteByte(unhex(segment[i+1])<<4 | unhex(segment[i+2]))
+			escaped = true
+			i += 2
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	name := b.String()
+	if !escaped || !utf8.ValidString(name) || strings.ContainsAny(name, "/\x00") || encodeName(name) != segment {
+		return "", false
+	}
+
+	return name, true
+}
+
+// isKeyByte reports whether c may appear unescaped in a key segment, other than "_".
+func isKeyByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '-'
+}
+
+func isUpperHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	if c <= '9' {
+		return c - '0'
+	}
+
+	return c - 'A' + 10
+}